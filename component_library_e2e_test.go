@@ -229,6 +229,14 @@ func TestAutoTableGeneration(t *testing.T) {
 				"lvt:datatable",
 			},
 		},
+		{
+			name:  "simple mode shows ref labels",
+			input: `<table lvt-source="users" lvt-columns="owner_id:Owner"></table>`,
+			contains: []string{
+				"<th>Owner</th>",
+				"{{with .Owner_id_label}}{{.}}{{else}}{{.Owner_id}}{{end}}",
+			},
+		},
 		{
 			name:  "simple mode with actions",
 			input: `<table lvt-source="users" lvt-columns="name:Name" lvt-actions="delete:Delete"></table>`,
@@ -484,6 +492,80 @@ func TestAutoListGeneration(t *testing.T) {
 	}
 }
 
+// TestAutoFormGeneration tests the form auto-generation function
+func TestAutoFormGeneration(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:  "empty form",
+			input: `<form lvt-source="tasks"></form>`,
+			contains: []string{
+				`lvt-submit="add"`,
+				"{{range .Fields}}",
+				`name="{{.Name}}"`,
+				`<option value="{{.Value}}"`,
+				"{{with index . $name}}",
+			},
+		},
+		{
+			name:  "with submit and fields",
+			input: `<form lvt-source="tasks" lvt-submit="update" lvt-fields="title,due"></form>`,
+			contains: []string{
+				`lvt-submit="update"`,
+				"{{range .Fields}}",
+			},
+			notContains: []string{
+				`lvt-submit="add"`,
+				"lvt-fields",
+			},
+		},
+		{
+			name:  "form with inputs is kept",
+			input: `<form lvt-source="tasks" lvt-submit="add"><input name="title"></form>`,
+			contains: []string{
+				`<input name="title">`,
+			},
+			notContains: []string{
+				"{{range .Fields}}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Parse the input as a page to trigger autoGenerateFormTemplate
+			page, err := tinkerdown.ParseString(fmt.Sprintf("---\ntitle: test\nsources:\n  tasks:\n    type: json\n    file: test.json\n---\n```lvt\n%s\n```", tt.input))
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+
+			// Get the generated content from the interactive block
+			var generatedContent string
+			for _, block := range page.InteractiveBlocks {
+				generatedContent = block.Content
+				break
+			}
+
+			t.Logf("Generated content:\n%s", generatedContent)
+
+			for _, want := range tt.contains {
+				if !strings.Contains(generatedContent, want) {
+					t.Errorf("Expected generated content to contain %q", want)
+				}
+			}
+			for _, unwanted := range tt.notContains {
+				if strings.Contains(generatedContent, unwanted) {
+					t.Errorf("Expected generated content not to contain %q", unwanted)
+				}
+			}
+		})
+	}
+}
+
 // TestXSSPrevention verifies that user-provided strings are HTML-escaped
 func TestXSSPrevention(t *testing.T) {
 	tests := []struct {
@@ -6,11 +6,25 @@ import (
 	"time"
 )
 
+// validatorRetention is how long an expired entry with HTTP validators is kept,
+// so it can be revalidated with a conditional request instead of refetched
+const validatorRetention = time.Hour
+
 // Entry represents a cached data entry
 type Entry struct {
 	Data      []map[string]interface{}
 	ExpiresAt time.Time
 	StaleAt   time.Time // For stale-while-revalidate: when data becomes stale (but still usable)
+	FetchedAt time.Time // When the data was fetched (zero if unknown)
+
+	// HTTP validators of the response the data came from (for conditional requests)
+	ETag         string
+	LastModified string
+}
+
+// HasValidators returns true if the entry can be revalidated with a conditional request
+func (e *Entry) HasValidators() bool {
+	return e.ETag != "" || e.LastModified != ""
 }
 
 // IsExpired returns true if the entry has expired
@@ -39,6 +53,13 @@ type Cache interface {
 	// expireAfter: duration until data expires completely
 	SetWithStale(key string, data []map[string]interface{}, staleAfter, expireAfter time.Duration)
 
+	// SetEntry stores an entry as is (including HTTP validators)
+	SetEntry(key string, entry *Entry)
+
+	// Peek returns the entry for a key, even if it has expired. Expired entries
+	// with validators are retained for a while so they can be revalidated.
+	Peek(key string) (*Entry, bool)
+
 	// Invalidate removes an entry from the cache
 	Invalidate(key string)
 
@@ -80,8 +101,10 @@ func (c *MemoryCache) Get(key string) ([]map[string]interface{}, bool, bool) {
 	}
 
 	if entry.IsExpired() {
-		// Entry has completely expired, remove it
-		c.Invalidate(key)
+		// Entry has completely expired, remove it (unless it can still be revalidated)
+		if !entry.HasValidators() {
+			c.Invalidate(key)
+		}
 		return nil, false, false
 	}
 
@@ -100,6 +123,7 @@ func (c *MemoryCache) SetWithStale(key string, data []map[string]interface{}, st
 		Data:      data,
 		StaleAt:   now.Add(staleAfter),
 		ExpiresAt: now.Add(expireAfter),
+		FetchedAt: now,
 	}
 
 	c.mu.Lock()
@@ -107,6 +131,21 @@ func (c *MemoryCache) SetWithStale(key string, data []map[string]interface{}, st
 	c.mu.Unlock()
 }
 
+// SetEntry stores an entry as is (including HTTP validators)
+func (c *MemoryCache) SetEntry(key string, entry *Entry) {
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+}
+
+// Peek returns the entry for a key, even if it has expired
+func (c *MemoryCache) Peek(key string) (*Entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, exists := c.entries[key]
+	return entry, exists
+}
+
 // Invalidate removes an entry from the cache
 func (c *MemoryCache) Invalidate(key string) {
 	c.mu.Lock()
@@ -143,7 +182,11 @@ func (c *MemoryCache) cleanup() {
 
 	now := time.Now()
 	for key, entry := range c.entries {
-		if now.After(entry.ExpiresAt) {
+		expiresAt := entry.ExpiresAt
+		if entry.HasValidators() {
+			expiresAt = expiresAt.Add(validatorRetention)
+		}
+		if now.After(expiresAt) {
 			delete(c.entries, key)
 		}
 	}
@@ -213,3 +213,38 @@ func TestMemoryCacheStopIdempotent(t *testing.T) {
 	c.Stop()
 	c.Stop()
 }
+
+func TestMemoryCacheRetainsExpiredEntriesWithValidators(t *testing.T) {
+	c := NewMemoryCache()
+	defer c.Stop()
+
+	past := time.Now().Add(-time.Second)
+	data := []map[string]interface{}{{"id": 1}}
+	c.SetEntry("validated", &Entry{Data: data, ExpiresAt: past, StaleAt: past, ETag: `"v1"`})
+	c.SetEntry("plain", &Entry{Data: data, ExpiresAt: past, StaleAt: past})
+
+	for _, key := range []string{"validated", "plain"} {
+		if _, found, _ := c.Get(key); found {
+			t.Errorf("expected cache miss for expired entry %q", key)
+		}
+	}
+
+	// The expired entry with validators can still be revalidated
+	entry, found := c.Peek("validated")
+	if !found || entry.ETag != `"v1"` || len(entry.Data) != 1 {
+		t.Errorf("expected expired entry with validators to be retained, got %v, %v", entry, found)
+	}
+	if _, found := c.Peek("plain"); found {
+		t.Error("expected expired entry without validators to be removed")
+	}
+
+	// Retention ends eventually
+	c.SetEntry("old", &Entry{Data: data, ExpiresAt: time.Now().Add(-2 * validatorRetention), ETag: `"v0"`})
+	c.cleanup()
+	if _, found := c.Peek("old"); found {
+		t.Error("expected entry past retention to be cleaned up")
+	}
+	if _, found := c.Peek("validated"); !found {
+		t.Error("expected entry within retention to survive cleanup")
+	}
+}
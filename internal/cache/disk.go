@@ -0,0 +1,352 @@
+package cache
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go SQLite driver
+)
+
+// DiskOptions configures the limits of a DiskCache
+type DiskOptions struct {
+	MaxSize    int64         // Total bytes of cached data; 0 for unlimited
+	MaxEntries int           // Most entries kept; 0 for unlimited
+	MaxStale   time.Duration // How long expired entries are kept; 0 keeps them until evicted
+	Namespace  string        // Prefix of the stored keys, so several sites can share a database file
+}
+
+// DiskCache is a cache stored in a SQLite database, so cached data survives
+// restarts. Expired entries are not returned by Get but are kept for MaxStale,
+// so a source can serve them while it fails to fetch (see Peek). When the cache
+// exceeds its limits, the least recently used entries are evicted.
+//
+// Rows are stored as JSON: whole numbers come back as int, other numbers as
+// float64 and times as strings.
+type DiskCache struct {
+	db   *sql.DB
+	opts DiskOptions
+	mu   sync.Mutex // Serializes writes and eviction
+
+	// Reads are recorded in memory and written in batches (see flushUsed)
+	usedMu    sync.Mutex
+	used      map[string]int64 // When entries were last read, by stored key
+	flushedAt time.Time
+}
+
+// usedFlushInterval is how often reads write the times entries were used.
+// Eviction and Close write them too, so the LRU order stays exact.
+const usedFlushInterval = time.Minute
+
+// NewDiskCache opens (or creates) the cache database at path
+func NewDiskCache(path string, opts DiskOptions) (*DiskCache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("disk cache: %w", err)
+	}
+	// Several servers may share the file; wait for their writes instead of failing
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("disk cache: failed to open %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS entries (
+		key TEXT PRIMARY KEY,
+		data BLOB NOT NULL,
+		size INTEGER NOT NULL,
+		stale_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL,
+		etag TEXT NOT NULL DEFAULT '',
+		last_modified TEXT NOT NULL DEFAULT '',
+		used_at INTEGER NOT NULL,
+		fetched_at INTEGER NOT NULL DEFAULT 0
+	)`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("disk cache: failed to create table: %w", err)
+	}
+	// Caches created before fetch times were stored lack the column (another
+	// server sharing the file may have just added it)
+	if _, err := db.Exec(`ALTER TABLE entries ADD COLUMN fetched_at INTEGER NOT NULL DEFAULT 0`); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+		db.Close()
+		return nil, fmt.Errorf("disk cache: failed to add fetched_at: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS entries_used_at ON entries (used_at)`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("disk cache: failed to create index: %w", err)
+	}
+
+	c := &DiskCache{db: db, opts: opts, used: make(map[string]int64), flushedAt: time.Now()}
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	return c, nil
+}
+
+// Get retrieves data from the cache
+// Returns (data, found, stale) where stale indicates data is stale but usable
+func (c *DiskCache) Get(key string) ([]map[string]interface{}, bool, bool) {
+	entry, found := c.Peek(key)
+	if !found || entry.IsExpired() {
+		return nil, false, false
+	}
+	return entry.Data, true, entry.IsStale()
+}
+
+// Set stores data in the cache with the given TTL
+func (c *DiskCache) Set(key string, data []map[string]interface{}, ttl time.Duration) {
+	c.SetWithStale(key, data, ttl, ttl)
+}
+
+// SetWithStale stores data with separate stale and expire times
+func (c *DiskCache) SetWithStale(key string, data []map[string]interface{}, staleAfter, expireAfter time.Duration) {
+	now := time.Now()
+	c.SetEntry(key, &Entry{
+		Data:      data,
+		StaleAt:   now.Add(staleAfter),
+		ExpiresAt: now.Add(expireAfter),
+		FetchedAt: now,
+	})
+}
+
+// SetEntry stores an entry as is (including HTTP validators)
+func (c *DiskCache) SetEntry(key string, entry *Entry) {
+	key = c.opts.Namespace + key
+	data, err := json.Marshal(entry.Data)
+	if err != nil {
+		log.Printf("[cache] Failed to encode %s: %v", key, err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.usedMu.Lock()
+	delete(c.used, key)
+	c.usedMu.Unlock()
+
+	if c.opts.MaxSize > 0 && int64(len(data)) > c.opts.MaxSize {
+		// Would evict everything, itself included
+		c.delete(key)
+		return
+	}
+	var fetchedAt int64
+	if !entry.FetchedAt.IsZero() {
+		fetchedAt = entry.FetchedAt.UnixNano()
+	}
+	_, err = c.db.Exec(`INSERT OR REPLACE INTO entries (key, data, size, stale_at, expires_at, etag, last_modified, used_at, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key, data, len(data), entry.StaleAt.UnixNano(), entry.ExpiresAt.UnixNano(), entry.ETag, entry.LastModified, time.Now().UnixNano(), fetchedAt)
+	if err != nil {
+		log.Printf("[cache] Failed to store %s: %v", key, err)
+		return
+	}
+	c.evict()
+}
+
+// Peek returns the entry for a key, even if it has expired
+func (c *DiskCache) Peek(key string) (*Entry, bool) {
+	key = c.opts.Namespace + key
+	var data []byte
+	var staleAt, expiresAt, fetchedAt int64
+	entry := &Entry{}
+	err := c.db.QueryRow(`SELECT data, stale_at, expires_at, etag, last_modified, fetched_at FROM entries WHERE key = ?`, key).
+		Scan(&data, &staleAt, &expiresAt, &entry.ETag, &entry.LastModified, &fetchedAt)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("[cache] Failed to read %s: %v", key, err)
+		}
+		return nil, false
+	}
+	if entry.Data, err = decodeRows(data); err != nil {
+		log.Printf("[cache] Failed to decode %s: %v", key, err)
+		return nil, false
+	}
+	entry.StaleAt = time.Unix(0, staleAt)
+	entry.ExpiresAt = time.Unix(0, expiresAt)
+	if fetchedAt != 0 {
+		entry.FetchedAt = time.Unix(0, fetchedAt)
+	}
+
+	// Reads don't write to the database, except for a periodic flush
+	now := time.Now()
+	c.usedMu.Lock()
+	c.used[key] = now.UnixNano()
+	flush := now.Sub(c.flushedAt) >= usedFlushInterval
+	c.usedMu.Unlock()
+	if flush {
+		c.mu.Lock()
+		c.flushUsed()
+		c.mu.Unlock()
+	}
+	return entry, true
+}
+
+// flushUsed writes the times entries were read since the last flush. Must be
+// called with c.mu held.
+func (c *DiskCache) flushUsed() {
+	c.usedMu.Lock()
+	used := c.used
+	c.used = make(map[string]int64)
+	c.flushedAt = time.Now()
+	c.usedMu.Unlock()
+	if len(used) == 0 {
+		return
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		log.Printf("[cache] Failed to update used times: %v", err)
+		return
+	}
+	defer tx.Rollback()
+	for key, usedAt := range used {
+		if _, err := tx.Exec(`UPDATE entries SET used_at = ? WHERE key = ?`, usedAt, key); err != nil {
+			log.Printf("[cache] Failed to update %s: %v", key, err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("[cache] Failed to update used times: %v", err)
+	}
+}
+
+// decodeRows decodes rows stored as JSON. Whole numbers are restored to int,
+// as the schemas of sources coerce them before they are cached.
+func decodeRows(data []byte) ([]map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var rows []map[string]interface{}
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		for k, v := range row {
+			row[k] = restoreNumbers(v)
+		}
+	}
+	return rows, nil
+}
+
+// restoreNumbers replaces the json.Numbers in a decoded value with an int if
+// they are whole and fit, or a float64
+func restoreNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := strconv.ParseInt(string(v), 10, 0); err == nil {
+			return int(n)
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = restoreNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = restoreNumbers(item)
+		}
+	}
+	return v
+}
+
+// Invalidate removes an entry from the cache
+func (c *DiskCache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delete(c.opts.Namespace + key)
+}
+
+// InvalidateAll removes all entries of the cache's namespace
+func (c *DiskCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.db.Exec(`DELETE FROM entries WHERE substr(key, 1, length(?1)) = ?1`, c.opts.Namespace); err != nil {
+		log.Printf("[cache] Failed to clear: %v", err)
+	}
+}
+
+// delete removes an entry by its stored key. Must be called with c.mu held.
+func (c *DiskCache) delete(key string) {
+	if _, err := c.db.Exec(`DELETE FROM entries WHERE key = ?`, key); err != nil {
+		log.Printf("[cache] Failed to remove %s: %v", key, err)
+	}
+}
+
+// evict removes the entries expired for longer than MaxStale, then the least
+// recently used entries until the cache is within its limits. Only the entries
+// of the cache's namespace count and are removed. Must be called with c.mu held.
+func (c *DiskCache) evict() {
+	c.flushUsed()
+	if c.opts.MaxStale > 0 {
+		cutoff := time.Now().Add(-c.opts.MaxStale).UnixNano()
+		if _, err := c.db.Exec(`DELETE FROM entries WHERE substr(key, 1, length(?1)) = ?1 AND expires_at < ?2`, c.opts.Namespace, cutoff); err != nil {
+			log.Printf("[cache] Failed to remove expired entries: %v", err)
+		}
+	}
+	if c.opts.MaxSize <= 0 && c.opts.MaxEntries <= 0 {
+		return
+	}
+
+	var count, size int64
+	if err := c.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(size), 0) FROM entries WHERE substr(key, 1, length(?1)) = ?1`, c.opts.Namespace).Scan(&count, &size); err != nil {
+		log.Printf("[cache] Failed to read cache size: %v", err)
+		return
+	}
+	if !c.overLimits(count, size) {
+		return
+	}
+
+	rows, err := c.db.Query(`SELECT key, size FROM entries WHERE substr(key, 1, length(?1)) = ?1 ORDER BY used_at`, c.opts.Namespace)
+	if err != nil {
+		log.Printf("[cache] Failed to evict: %v", err)
+		return
+	}
+	var evicted []string
+	for rows.Next() && c.overLimits(count, size) {
+		var key string
+		var entrySize int64
+		if err := rows.Scan(&key, &entrySize); err != nil {
+			break
+		}
+		evicted = append(evicted, key)
+		count--
+		size -= entrySize
+	}
+	rows.Close()
+
+	for _, key := range evicted {
+		c.delete(key)
+	}
+}
+
+// overLimits reports whether a cache of count entries and size bytes exceeds the limits
+func (c *DiskCache) overLimits(count, size int64) bool {
+	return (c.opts.MaxEntries > 0 && count > int64(c.opts.MaxEntries)) ||
+		(c.opts.MaxSize > 0 && size > c.opts.MaxSize)
+}
+
+// Close writes the pending used times and closes the database
+func (c *DiskCache) Close() error {
+	c.mu.Lock()
+	c.flushUsed()
+	c.mu.Unlock()
+	return c.db.Close()
+}
+
+// Len returns the number of entries in the cache's namespace (for testing)
+func (c *DiskCache) Len() int {
+	var count int
+	if err := c.db.QueryRow(`SELECT COUNT(*) FROM entries WHERE substr(key, 1, length(?1)) = ?1`, c.opts.Namespace).Scan(&count); err != nil {
+		return 0
+	}
+	return count
+}
@@ -0,0 +1,294 @@
+package cache
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiskCacheBasic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "cache.db")
+	c, err := NewDiskCache(path, DiskOptions{})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+
+	if _, found, _ := c.Get("test"); found {
+		t.Error("expected cache miss for non-existent key")
+	}
+
+	fetchedAt := time.Now().Add(-time.Second)
+	c.SetEntry("test", &Entry{
+		Data:      []map[string]interface{}{{"id": 1, "name": "test"}},
+		StaleAt:   time.Now().Add(time.Minute),
+		ExpiresAt: time.Now().Add(time.Hour),
+		FetchedAt: fetchedAt,
+		ETag:      `"v1"`,
+	})
+	c.Close()
+
+	// Entries survive reopening the cache
+	c, err = NewDiskCache(path, DiskOptions{})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer c.Close()
+
+	data, found, stale := c.Get("test")
+	if !found || stale {
+		t.Fatalf("expected fresh hit, got found=%v stale=%v", found, stale)
+	}
+	// Rows are stored as JSON; whole numbers come back as int
+	if len(data) != 1 || data[0]["id"] != 1 || data[0]["name"] != "test" {
+		t.Errorf("unexpected data: %v", data)
+	}
+	if entry, _ := c.Peek("test"); entry.ETag != `"v1"` || !entry.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("expected validators and fetch time to be kept, got %+v", entry)
+	}
+
+	c.Invalidate("test")
+	if _, found, _ := c.Get("test"); found {
+		t.Error("expected cache miss after invalidate")
+	}
+}
+
+func TestDiskCacheAddsFetchedAt(t *testing.T) {
+	// A cache created before fetch times were stored
+	path := filepath.Join(t.TempDir(), "cache.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE entries (
+		key TEXT PRIMARY KEY,
+		data BLOB NOT NULL,
+		size INTEGER NOT NULL,
+		stale_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL,
+		etag TEXT NOT NULL DEFAULT '',
+		last_modified TEXT NOT NULL DEFAULT '',
+		used_at INTEGER NOT NULL
+	)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	expiresAt := time.Now().Add(time.Hour).UnixNano()
+	if _, err := db.Exec(`INSERT INTO entries (key, data, size, stale_at, expires_at, used_at) VALUES ('old', '[{"id": 1}]', 11, ?, ?, 0)`, expiresAt, expiresAt); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	db.Close()
+
+	c, err := NewDiskCache(path, DiskOptions{})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer c.Close()
+
+	if entry, ok := c.Peek("old"); !ok || !entry.FetchedAt.IsZero() {
+		t.Errorf("expected the old entry with an unknown fetch time, got %+v", entry)
+	}
+	c.Set("new", []map[string]interface{}{{"id": 2}}, time.Hour)
+	if entry, ok := c.Peek("new"); !ok || entry.FetchedAt.IsZero() {
+		t.Errorf("expected the fetch time to be stored, got %+v", entry)
+	}
+}
+
+func TestDiskCacheNumbers(t *testing.T) {
+	c, err := NewDiskCache(filepath.Join(t.TempDir(), "cache.db"), DiskOptions{})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer c.Close()
+
+	c.Set("test", []map[string]interface{}{{
+		"count": 1500000,
+		"price": 9.5,
+		"tags":  []interface{}{1, 2.25},
+		"meta":  map[string]interface{}{"rank": -3},
+	}}, time.Hour)
+	data, found, _ := c.Get("test")
+	if !found {
+		t.Fatal("expected cache hit")
+	}
+	want := map[string]interface{}{
+		"count": 1500000,
+		"price": 9.5,
+		"tags":  []interface{}{1, 2.25},
+		"meta":  map[string]interface{}{"rank": -3},
+	}
+	if !reflect.DeepEqual(data[0], want) {
+		t.Errorf("got %#v, want %#v", data[0], want)
+	}
+}
+
+func TestDiskCacheExpired(t *testing.T) {
+	c, err := NewDiskCache(filepath.Join(t.TempDir(), "cache.db"), DiskOptions{MaxStale: time.Hour})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer c.Close()
+
+	c.SetWithStale("swr", []map[string]interface{}{{"id": 1}}, 0, time.Minute)
+	if _, found, stale := c.Get("swr"); !found || !stale {
+		t.Errorf("expected stale hit, got found=%v stale=%v", found, stale)
+	}
+
+	// Expired entries are kept for Peek until MaxStale has passed
+	c.SetEntry("expired", &Entry{Data: []map[string]interface{}{{"id": 2}}, ExpiresAt: time.Now().Add(-time.Minute)})
+	c.SetEntry("old", &Entry{Data: []map[string]interface{}{{"id": 3}}, ExpiresAt: time.Now().Add(-2 * time.Hour)})
+	if _, found, _ := c.Get("expired"); found {
+		t.Error("expected cache miss for expired entry")
+	}
+	if entry, ok := c.Peek("expired"); !ok || len(entry.Data) != 1 {
+		t.Errorf("expected expired entry to be kept, got %v", entry)
+	}
+	if _, ok := c.Peek("old"); ok {
+		t.Error("expected entry expired for longer than MaxStale to be removed")
+	}
+}
+
+func TestDiskCacheEviction(t *testing.T) {
+	c, err := NewDiskCache(filepath.Join(t.TempDir(), "cache.db"), DiskOptions{MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer c.Close()
+
+	data := []map[string]interface{}{{"id": 1}}
+	c.Set("a", data, time.Minute)
+	c.Set("b", data, time.Minute)
+	c.Get("a") // b is now the least recently used
+	c.Set("c", data, time.Minute)
+
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+	if _, found, _ := c.Get("b"); found {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, found, _ := c.Get(key); !found {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+
+	// Size limit: each entry is `[{"id":1}]` (10 bytes)
+	sized, err := NewDiskCache(filepath.Join(t.TempDir(), "sized.db"), DiskOptions{MaxSize: 25})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer sized.Close()
+	for _, key := range []string{"a", "b", "c"} {
+		sized.Set(key, data, time.Minute)
+	}
+	if sized.Len() != 2 {
+		t.Errorf("expected 2 entries within the size limit, got %d", sized.Len())
+	}
+	sized.Set("big", []map[string]interface{}{{"text": "more than twenty-five bytes"}}, time.Minute)
+	if _, ok := sized.Peek("big"); ok || sized.Len() != 2 {
+		t.Error("expected entry larger than the cache not to be stored")
+	}
+
+	sized.InvalidateAll()
+	if sized.Len() != 0 {
+		t.Errorf("expected empty cache, got %d entries", sized.Len())
+	}
+}
+
+func TestDiskCacheBatchesUsedTimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	c, err := NewDiskCache(path, DiskOptions{})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	c.Set("a", []map[string]interface{}{{"id": 1}}, time.Minute)
+	usedAt := func() int64 {
+		t.Helper()
+		var v int64
+		if err := c.db.QueryRow(`SELECT used_at FROM entries WHERE key = 'a'`).Scan(&v); err != nil {
+			t.Fatalf("failed to read used_at: %v", err)
+		}
+		return v
+	}
+	stored := usedAt()
+
+	// Reads are kept in memory
+	for i := 0; i < 10; i++ {
+		c.Get("a")
+	}
+	if got := usedAt(); got != stored {
+		t.Errorf("expected reads not to write, used_at changed from %d to %d", stored, got)
+	}
+
+	// A periodic flush writes them
+	c.flushedAt = time.Now().Add(-usedFlushInterval)
+	c.Get("a")
+	if got := usedAt(); got <= stored {
+		t.Errorf("expected the flush to write used_at, got %d (stored %d)", got, stored)
+	}
+	c.Close()
+}
+
+func TestDiskCacheNamespace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	site1, err := NewDiskCache(path, DiskOptions{Namespace: "/sites/one|"})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer site1.Close()
+	site2, err := NewDiskCache(path, DiskOptions{Namespace: "/sites/twö|"})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer site2.Close()
+
+	site1.Set("source:tasks", []map[string]interface{}{{"id": 1}}, time.Hour)
+	site2.Set("source:tasks", []map[string]interface{}{{"id": 2}}, time.Hour)
+	if data, _, _ := site1.Get("source:tasks"); len(data) != 1 || data[0]["id"] != 1 {
+		t.Errorf("site1 got %v", data)
+	}
+
+	// Clearing one site keeps the other's entries
+	site2.InvalidateAll()
+	if _, found, _ := site2.Get("source:tasks"); found {
+		t.Error("expected site2 to be cleared")
+	}
+	if _, found, _ := site1.Get("source:tasks"); !found {
+		t.Error("expected site1 to keep its entry")
+	}
+}
+
+func TestDiskCacheNamespaceEviction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	site1, err := NewDiskCache(path, DiskOptions{Namespace: "one|", MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer site1.Close()
+	site2, err := NewDiskCache(path, DiskOptions{Namespace: "two|", MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer site2.Close()
+
+	data := []map[string]interface{}{{"id": 1}}
+	site1.Set("a", data, time.Minute)
+	site1.Set("b", data, time.Minute)
+	// Entries of the other site neither count nor get evicted
+	site2.Set("a", data, time.Minute)
+	site2.Set("b", data, time.Minute)
+	site2.Set("c", data, time.Minute)
+
+	if site1.Len() != 2 || site2.Len() != 2 {
+		t.Errorf("expected 2 entries per site, got %d and %d", site1.Len(), site2.Len())
+	}
+	for _, key := range []string{"a", "b"} {
+		if _, found, _ := site1.Get(key); !found {
+			t.Errorf("expected site1 to keep %s", key)
+		}
+	}
+	if _, found, _ := site2.Get("a"); found {
+		t.Error("expected site2's least recently used entry to be evicted")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -23,33 +25,90 @@ type Config struct {
 	Ignore      []string                `yaml:"ignore"`
 	Sources     map[string]SourceConfig `yaml:"sources,omitempty"`
 	Actions     map[string]*Action      `yaml:"actions,omitempty"`
+	Cache       *CacheBackendConfig     `yaml:"cache,omitempty"` // Where source caches are stored (default: memory)
 }
 
 // SourceConfig defines a data source for lvt-source blocks
 type SourceConfig struct {
-	Type        string                 `yaml:"type"`                   // "exec", "pg", "rest", "csv", "json", "markdown", "sqlite", "wasm", "graphql"
-	Cmd         string                 `yaml:"cmd,omitempty"`          // For exec: command to run
-	Query       string                 `yaml:"query,omitempty"`        // For pg: SQL query
-	From        string                 `yaml:"from,omitempty"`         // For rest/graphql: API endpoint URL
-	File        string                 `yaml:"file,omitempty"`         // For csv/json/markdown: file path
-	Anchor      string                 `yaml:"anchor,omitempty"`       // For markdown: section anchor (e.g., "#todos")
-	DB          string                 `yaml:"db,omitempty"`           // For sqlite: database file path (default: ./tinkerdown.db)
-	Table       string                 `yaml:"table,omitempty"`        // For sqlite: table name
-	Path        string                 `yaml:"path,omitempty"`         // For wasm: path to .wasm file
-	QueryFile   string                 `yaml:"query_file,omitempty"`   // For graphql: path to .graphql file
-	Variables   map[string]interface{} `yaml:"variables,omitempty"`    // For graphql: query variables
-	Headers     map[string]string      `yaml:"headers,omitempty"`      // For rest/graphql: HTTP headers (env vars expanded)
-	QueryParams map[string]string      `yaml:"query_params,omitempty"` // For rest: URL query parameters (env vars expanded)
-	ResultPath  string                 `yaml:"result_path,omitempty"`  // For rest/graphql: dot-path to extract array (e.g., "data.items")
-	Readonly    *bool                  `yaml:"readonly,omitempty"`     // For markdown/sqlite: read-only mode (default: true, set to false for writes)
-	Options     map[string]string      `yaml:"options,omitempty"`      // Type-specific options (also used for wasm init config)
-	Manual      bool                   `yaml:"manual,omitempty"`       // For exec: require Run button click
-	Format      string                 `yaml:"format,omitempty"`       // For exec: output format (json, lines, csv). Default: json
-	Delimiter   string                 `yaml:"delimiter,omitempty"`    // For exec CSV: field delimiter. Default: ","
-	Env         map[string]string      `yaml:"env,omitempty"`          // For exec: environment variables (env vars expanded)
-	Timeout     string                 `yaml:"timeout,omitempty"`      // Request timeout (e.g., "30s", "1m"). Default: 10s
-	Retry       *RetryConfig           `yaml:"retry,omitempty"`        // Retry configuration
-	Cache       *CacheConfig           `yaml:"cache,omitempty"`        // Cache configuration
+	Type        string                     `yaml:"type"`                      // "exec", "pg", "rest", "csv", "json", "yaml", "toml", "xlsx", "parquet", "arrow", "git", "derived", "query", "markdown", "sqlite", "wasm", "graphql", "collection", "sse", "websocket", "tail"
+	Cmd         string                     `yaml:"cmd,omitempty"`             // For exec: command to run
+	Query       string                     `yaml:"query,omitempty"`           // For pg: SQL query. For query: SQL statement run over the tables
+	From        string                     `yaml:"from,omitempty"`            // For rest/graphql/sse/websocket: endpoint URL. For derived: input source whose rows are transformed
+	File        string                     `yaml:"file,omitempty"`            // For csv/json/yaml/toml/xlsx/parquet/arrow/markdown/tail/git: file path
+	Anchor      string                     `yaml:"anchor,omitempty"`          // For markdown: section anchor (e.g., "#todos")
+	Glob        string                     `yaml:"glob,omitempty"`            // For collection: markdown files to include (e.g., "posts/*.md")
+	DB          string                     `yaml:"db,omitempty"`              // For sqlite: database file path (default: ./tinkerdown.db)
+	Table       string                     `yaml:"table,omitempty"`           // For sqlite: table name
+	Path        string                     `yaml:"path,omitempty"`            // For wasm: path to .wasm file. For git: repository directory (default: site directory)
+	QueryFile   string                     `yaml:"query_file,omitempty"`      // For graphql: path to .graphql file
+	Variables   map[string]interface{}     `yaml:"variables,omitempty"`       // For graphql: query variables
+	Headers     map[string]string          `yaml:"headers,omitempty"`         // For rest/graphql: HTTP headers (env vars expanded)
+	QueryParams map[string]string          `yaml:"query_params,omitempty"`    // For rest: URL query parameters (env vars expanded)
+	ResultPath  string                     `yaml:"result_path,omitempty"`     // For rest/graphql/yaml/toml: dot-path to extract array (e.g., "data.items")
+	Readonly    *bool                      `yaml:"readonly,omitempty"`        // For markdown/sqlite/collection/json/csv: read-only mode (default: true, set to false for writes)
+	Options     map[string]string          `yaml:"options,omitempty"`         // Type-specific options (also used for wasm init config)
+	Manual      bool                       `yaml:"manual,omitempty"`          // For exec: require Run button click
+	Format      string                     `yaml:"format,omitempty"`          // For exec: json, lines, csv (default: json). For rest: json, ndjson, csv, tsv, xml, yaml (default: from Content-Type). For tail: text, json, logfmt, regex (default: text)
+	Delimiter   string                     `yaml:"delimiter,omitempty"`       // For exec/rest CSV: field delimiter. Default: ","
+	Env         map[string]string          `yaml:"env,omitempty"`             // For exec: environment variables (env vars expanded)
+	Timeout     string                     `yaml:"timeout,omitempty"`         // Request timeout (e.g., "30s", "1m"). Default: 10s
+	Retry       *RetryConfig               `yaml:"retry,omitempty"`           // Retry configuration
+	RateLimit   *RateLimitConfig           `yaml:"rate_limit,omitempty"`      // For rest/graphql/exec: limit the request rate (shared per host, or per command for exec)
+	Circuit     *CircuitBreakerConfig      `yaml:"circuit_breaker,omitempty"` // For rest/graphql/pg/exec/wasm: when to stop calling a failing source
+	Cache       *CacheConfig               `yaml:"cache,omitempty"`           // Cache configuration
+	Pagination  *PaginationConfig          `yaml:"pagination,omitempty"`      // For rest/graphql: follow paged responses
+	Auth        *AuthConfig                `yaml:"auth,omitempty"`            // For rest/graphql: authentication and TLS settings
+	Endpoints   map[string]RestEndpoint    `yaml:"endpoints,omitempty"`       // For rest: HTTP request per write action (add, update, delete, toggle)
+	Mutations   map[string]GraphQLMutation `yaml:"mutations,omitempty"`       // For graphql: mutation per write action (add, update, delete, toggle)
+	Stream      *StreamConfig              `yaml:"stream,omitempty"`          // For sse/websocket/tail and graphql subscriptions: how incoming events update the rows
+	Filter      []string                   `yaml:"filter,omitempty"`          // For tail/parquet/arrow/git/derived: row predicates that must all match (e.g., "level == error", "status >= 500")
+	Columns     []string                   `yaml:"columns,omitempty"`         // For parquet/arrow: columns to read (default: the lvt-columns of the table, else all)
+	Union       []string                   `yaml:"union,omitempty"`           // For derived: sources whose rows are appended to those of from
+	Join        []JoinConfig               `yaml:"join,omitempty"`            // For derived: sources joined to the rows, in order
+	Fields      []string                   `yaml:"fields,omitempty"`          // For derived: computed columns, in order (e.g., "load = points / capacity")
+	GroupBy     []string                   `yaml:"group_by,omitempty"`        // For derived: columns whose values form groups
+	Aggregate   []string                   `yaml:"aggregate,omitempty"`       // For derived: aggregate columns per group (e.g., "open = count", "points = sum(points)")
+	Tables      []string                   `yaml:"tables,omitempty"`          // For query: sources loaded as tables of the same name
+	Transform   []TransformStep            `yaml:"transform,omitempty"`       // Steps shaping the fetched rows, in order (applied before caching)
+	Schema      map[string]FieldSchema     `yaml:"schema,omitempty"`          // Field types and rules: rows are coerced on read, adds and updates are validated
+}
+
+// FieldSchema declares the type and validation rules of a field
+type FieldSchema struct {
+	Type     string      `yaml:"type,omitempty"`      // string (default), int, float, bool, date, datetime, enum, json
+	Label    string      `yaml:"label,omitempty"`     // Label for forms, table headers and messages. Default: the field name, title-cased
+	Required bool        `yaml:"required,omitempty"`  // Adds (and updates setting the field) need a non-empty value
+	Unique   bool        `yaml:"unique,omitempty"`    // No two rows may have the same value
+	Min      *float64    `yaml:"min,omitempty"`       // Smallest number, or fewest characters of text
+	Max      *float64    `yaml:"max,omitempty"`       // Largest number, or most characters of text
+	Pattern  string      `yaml:"pattern,omitempty"`   // Regular expression the whole value must match
+	Values   []string    `yaml:"values,omitempty"`    // For enum: the allowed values
+	Default  interface{} `yaml:"default,omitempty"`   // Value for adds without one (template expressions like "{{today}}" are resolved)
+	Ref      string      `yaml:"ref,omitempty"`       // Source whose row ids the field holds
+	Display  string      `yaml:"display,omitempty"`   // For ref: column of the referenced rows shown for an id. Default: name
+	OnDelete string      `yaml:"on_delete,omitempty"` // For ref: restrict (default), cascade or nullify when the referenced row is deleted
+}
+
+// TransformStep is one step of a source's transform pipeline. Exactly one
+// field is set.
+type TransformStep struct {
+	Filter    []string          `yaml:"filter,omitempty"`     // Keep rows matching all predicates (e.g., "status == open")
+	Select    []string          `yaml:"select,omitempty"`     // Keep only these columns
+	Rename    map[string]string `yaml:"rename,omitempty"`     // Rename columns (old: new)
+	Sort      []string          `yaml:"sort,omitempty"`       // Sort by columns; "-column" or "column desc" sorts descending
+	Limit     *int              `yaml:"limit,omitempty"`      // Keep the first n rows
+	Dedupe    []string          `yaml:"dedupe,omitempty"`     // Keep the first row per distinct value of these columns ("*" for whole rows)
+	Flatten   []string          `yaml:"flatten,omitempty"`    // Replace nested objects with columns (address.city -> address_city); "*" for all
+	ParseDate map[string]string `yaml:"parse_date,omitempty"` // Parse columns as dates (column: layout, e.g., "2006-01-02", or "" to detect)
+	Cast      map[string]string `yaml:"cast,omitempty"`       // Convert columns (column: string, int, float or bool)
+}
+
+// JoinConfig joins the rows of a derived source with those of another source
+type JoinConfig struct {
+	Source string `yaml:"source"`           // Source to join
+	On     string `yaml:"on"`               // Key columns: "owner = login" (row column = joined column), or "id" if both are named the same
+	Type   string `yaml:"type,omitempty"`   // "inner" (default; rows without a match are dropped) or "left" (they are kept)
+	Prefix string `yaml:"prefix,omitempty"` // Prefix for the joined columns. Default: none, and columns the row already has are kept
 }
 
 // RetryConfig configures retry behavior for a source
@@ -59,10 +118,228 @@ type RetryConfig struct {
 	MaxDelay   string `yaml:"max_delay,omitempty"`   // Maximum delay (e.g., "5s"). Default: 5s
 }
 
+// RateLimitConfig limits the requests of a source with a token bucket. Sources
+// with the same host (or command, for exec) and limits share a bucket.
+type RateLimitConfig struct {
+	Requests float64 `yaml:"requests"`        // Requests allowed per interval (required)
+	Per      string  `yaml:"per,omitempty"`   // Interval (e.g., "1s", "1m"). Default: 1s
+	Burst    int     `yaml:"burst,omitempty"` // Requests allowed at once. Default: 1
+}
+
+// CircuitBreakerConfig configures when the circuit breaker of a source opens
+// (requests fail fast) and closes again
+type CircuitBreakerConfig struct {
+	FailureThreshold int    `yaml:"failure_threshold,omitempty"` // Failures within failure_window that open the circuit. Default: 5
+	SuccessThreshold int    `yaml:"success_threshold,omitempty"` // Successes in half-open state that close the circuit. Default: 2
+	Timeout          string `yaml:"timeout,omitempty"`           // How long the circuit stays open before a test request (e.g., "30s"). Default: 30s
+	FailureWindow    string `yaml:"failure_window,omitempty"`    // Window in which failures are counted (e.g., "1m"). Default: 1m
+}
+
 // CacheConfig configures caching behavior for a source
 type CacheConfig struct {
-	TTL      string `yaml:"ttl,omitempty"`      // Cache TTL (e.g., "5m", "1h"). Default: disabled (empty)
-	Strategy string `yaml:"strategy,omitempty"` // Cache strategy: "simple" or "stale-while-revalidate". Default: "simple"
+	TTL                 string `yaml:"ttl,omitempty"`                   // Cache TTL (e.g., "5m", "1h"). Default: disabled (empty)
+	Strategy            string `yaml:"strategy,omitempty"`              // Cache strategy: "simple" or "stale-while-revalidate". Default: "simple"
+	RespectCacheControl bool   `yaml:"respect_cache_control,omitempty"` // For rest/graphql: take the TTL from Cache-Control max-age (and honor no-store)
+}
+
+// CacheBackendConfig selects the store of the site's source caches
+type CacheBackendConfig struct {
+	Backend    string `yaml:"backend,omitempty"`     // "memory" (default) or "disk"
+	Path       string `yaml:"path,omitempty"`        // For disk: SQLite file, relative to the site directory. Default: .tinkerdown/cache.db
+	MaxSize    string `yaml:"max_size,omitempty"`    // For disk: total size of the cached data (e.g., "100MB"). Default: 100MB
+	MaxEntries int    `yaml:"max_entries,omitempty"` // For disk: most entries kept. Default: unlimited
+	MaxStale   string `yaml:"max_stale,omitempty"`   // For disk: how long expired data is kept, to serve while a source fails (e.g., "24h"). Default: 7 days
+}
+
+// GetPath returns the path of the disk cache (default: .tinkerdown/cache.db)
+func (c *CacheBackendConfig) GetPath() string {
+	if c == nil || c.Path == "" {
+		return filepath.Join(".tinkerdown", "cache.db")
+	}
+	return c.Path
+}
+
+// GetMaxSize returns the size limit of the disk cache in bytes (default: 100MB)
+func (c *CacheBackendConfig) GetMaxSize() int64 {
+	const defaultSize = 100 << 20
+	if c == nil || c.MaxSize == "" {
+		return defaultSize
+	}
+	size, err := ParseSize(c.MaxSize)
+	if err != nil {
+		return defaultSize
+	}
+	return size
+}
+
+// GetMaxStale returns how long expired entries are kept (default: 7 days)
+func (c *CacheBackendConfig) GetMaxStale() time.Duration {
+	const defaultStale = 7 * 24 * time.Hour
+	if c == nil || c.MaxStale == "" {
+		return defaultStale
+	}
+	d, err := time.ParseDuration(c.MaxStale)
+	if err != nil {
+		return defaultStale
+	}
+	return d
+}
+
+// ParseSize parses a size in bytes with an optional unit: KB, MB or GB
+// (powers of 1024), e.g., "512KB" or "1.5GB"
+func ParseSize(s string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := 1.0
+	for _, unit := range []struct {
+		suffix string
+		size   float64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(text, unit.suffix) {
+			text, multiplier = strings.TrimSpace(strings.TrimSuffix(text, unit.suffix)), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * multiplier), nil
+}
+
+// AuthConfig configures authentication and TLS for HTTP sources (rest, graphql).
+// String values have env vars expanded; file paths are relative to the site directory.
+type AuthConfig struct {
+	Type         string            `yaml:"type,omitempty"`          // "basic", "bearer", or "oauth2" (client credentials). Empty for TLS-only settings
+	Username     string            `yaml:"username,omitempty"`      // For basic: user name
+	Password     string            `yaml:"password,omitempty"`      // For basic: password
+	Token        string            `yaml:"token,omitempty"`         // For bearer: token value
+	TokenFile    string            `yaml:"token_file,omitempty"`    // For bearer: file containing the token (re-read when it changes)
+	TokenURL     string            `yaml:"token_url,omitempty"`     // For oauth2: token endpoint
+	ClientID     string            `yaml:"client_id,omitempty"`     // For oauth2: client ID
+	ClientSecret string            `yaml:"client_secret,omitempty"` // For oauth2: client secret
+	Scopes       []string          `yaml:"scopes,omitempty"`        // For oauth2: requested scopes
+	Params       map[string]string `yaml:"params,omitempty"`        // For oauth2: extra token request parameters (e.g., audience)
+	ClientAuth   string            `yaml:"client_auth,omitempty"`   // For oauth2: "header" (HTTP basic) or "params" (form body). Default: header
+	CACert       string            `yaml:"ca_cert,omitempty"`       // PEM bundle of additional CAs to trust
+	ClientCert   string            `yaml:"client_cert,omitempty"`   // PEM client certificate for mTLS
+	ClientKey    string            `yaml:"client_key,omitempty"`    // PEM client private key for mTLS
+}
+
+// RestEndpoint configures the HTTP request a writable REST source makes for an action
+type RestEndpoint struct {
+	Method string `yaml:"method,omitempty"` // HTTP method. Default: POST for add, PATCH for update/toggle, DELETE for delete
+	URL    string `yaml:"url,omitempty"`    // Request URL; {field} is replaced from form data. Relative URLs resolve against from
+	Body   string `yaml:"body,omitempty"`   // Go template for the request body. Default: form data as JSON
+}
+
+// GraphQLMutation configures the mutation a writable GraphQL source runs for an action
+type GraphQLMutation struct {
+	File      string                 `yaml:"file,omitempty"`      // .graphql file with the mutation. Default: query_file
+	Operation string                 `yaml:"operation,omitempty"` // Operation name; required if the file has several mutations
+	Variables map[string]interface{} `yaml:"variables,omitempty"` // Variable values; "{field}" is replaced from form data. Default: form fields named like the declared variables
+}
+
+// StreamConfig configures how a streaming source applies incoming events to its rows
+type StreamConfig struct {
+	URL     string `yaml:"url,omitempty"`      // For graphql: subscription endpoint. Default: from, with http(s) replaced by ws(s)
+	Mode    string `yaml:"mode,omitempty"`     // "append" adds each event's rows (default); "replace" replaces all rows
+	MaxRows int    `yaml:"max_rows,omitempty"` // Rows kept in append mode, oldest dropped first. Default: 1000
+	Replay  *int   `yaml:"replay,omitempty"`   // Recent events replayed to a page when it connects. Default: 100
+	Buffer  int    `yaml:"buffer,omitempty"`   // Events queued per page before new events are dropped. Default: 256
+	Send    string `yaml:"send,omitempty"`     // For websocket: message sent after connecting (e.g., a subscribe request)
+}
+
+// GetMode returns the stream mode (default: "append")
+func (s StreamConfig) GetMode() string {
+	if s.Mode == "" {
+		return "append"
+	}
+	return s.Mode
+}
+
+// GetMaxRows returns the number of rows kept in append mode (default: 1000)
+func (s StreamConfig) GetMaxRows() int {
+	if s.MaxRows <= 0 {
+		return 1000
+	}
+	return s.MaxRows
+}
+
+// GetReplay returns the number of events replayed on connect (default: 100)
+func (s StreamConfig) GetReplay() int {
+	if s.Replay == nil {
+		return 100
+	}
+	return max(*s.Replay, 0)
+}
+
+// GetBuffer returns the per-page event queue size (default: 256)
+func (s StreamConfig) GetBuffer() int {
+	if s.Buffer <= 0 {
+		return 256
+	}
+	return s.Buffer
+}
+
+// PaginationConfig configures how a REST or GraphQL source follows paged responses
+type PaginationConfig struct {
+	Type         string `yaml:"type"`                     // Strategy: "link" (Link header rel=next), "cursor", or "page" for rest; "relay" for graphql
+	CursorPath   string `yaml:"cursor_path,omitempty"`    // For cursor: dot-path to the next cursor in the response (e.g., "meta.next_cursor"). For relay: dot-path to pageInfo (default: derived from result_path)
+	CursorParam  string `yaml:"cursor_param,omitempty"`   // For cursor: query parameter carrying the cursor. Default: "cursor". For relay: cursor variable. Default: "after"
+	PageParam    string `yaml:"page_param,omitempty"`     // For page: page number query parameter. Default: "page"
+	PerPageParam string `yaml:"per_page_param,omitempty"` // For page: page size query parameter. Default: "per_page". For relay: page size variable. Default: "first"
+	PerPage      int    `yaml:"per_page,omitempty"`       // For page/relay: page size to request (omitted if 0)
+	StartPage    *int   `yaml:"start_page,omitempty"`     // For page: first page number. Default: 1
+	MaxPages     int    `yaml:"max_pages,omitempty"`      // Maximum pages to fetch. Default: 100
+	MaxRows      int    `yaml:"max_rows,omitempty"`       // Maximum rows to return. Default: 10000
+}
+
+// GetCursorParam returns the cursor query parameter (default: "cursor")
+func (p PaginationConfig) GetCursorParam() string {
+	if p.CursorParam == "" {
+		return "cursor"
+	}
+	return p.CursorParam
+}
+
+// GetPageParam returns the page number query parameter (default: "page")
+func (p PaginationConfig) GetPageParam() string {
+	if p.PageParam == "" {
+		return "page"
+	}
+	return p.PageParam
+}
+
+// GetPerPageParam returns the page size query parameter (default: "per_page")
+func (p PaginationConfig) GetPerPageParam() string {
+	if p.PerPageParam == "" {
+		return "per_page"
+	}
+	return p.PerPageParam
+}
+
+// GetStartPage returns the first page number (default: 1)
+func (p PaginationConfig) GetStartPage() int {
+	if p.StartPage == nil {
+		return 1
+	}
+	return *p.StartPage
+}
+
+// GetMaxPages returns the maximum number of pages to fetch (default: 100)
+func (p PaginationConfig) GetMaxPages() int {
+	if p.MaxPages <= 0 {
+		return 100
+	}
+	return p.MaxPages
+}
+
+// GetMaxRows returns the maximum number of rows to return (default: 10000)
+func (p PaginationConfig) GetMaxRows() int {
+	if p.MaxRows <= 0 {
+		return 10000
+	}
+	return p.MaxRows
 }
 
 // IsReadonly returns true if the source is read-only (default: true for markdown sources)
@@ -85,6 +362,66 @@ func (c SourceConfig) GetTimeout() time.Duration {
 	return d
 }
 
+// GetRateLimitPer returns the interval of the rate limit (default: 1s)
+func (c SourceConfig) GetRateLimitPer() time.Duration {
+	if c.RateLimit == nil || c.RateLimit.Per == "" {
+		return time.Second
+	}
+	d, err := time.ParseDuration(c.RateLimit.Per)
+	if err != nil || d <= 0 {
+		return time.Second
+	}
+	return d
+}
+
+// GetRateLimitBurst returns the requests allowed at once (default: 1)
+func (c SourceConfig) GetRateLimitBurst() int {
+	if c.RateLimit == nil || c.RateLimit.Burst < 1 {
+		return 1
+	}
+	return c.RateLimit.Burst
+}
+
+// GetCircuitFailureThreshold returns the failures that open the circuit (default: 5)
+func (c SourceConfig) GetCircuitFailureThreshold() int {
+	if c.Circuit == nil || c.Circuit.FailureThreshold < 1 {
+		return 5
+	}
+	return c.Circuit.FailureThreshold
+}
+
+// GetCircuitSuccessThreshold returns the successes that close the circuit (default: 2)
+func (c SourceConfig) GetCircuitSuccessThreshold() int {
+	if c.Circuit == nil || c.Circuit.SuccessThreshold < 1 {
+		return 2
+	}
+	return c.Circuit.SuccessThreshold
+}
+
+// GetCircuitTimeout returns how long the circuit stays open (default: 30s)
+func (c SourceConfig) GetCircuitTimeout() time.Duration {
+	if c.Circuit == nil || c.Circuit.Timeout == "" {
+		return 30 * time.Second
+	}
+	d, err := time.ParseDuration(c.Circuit.Timeout)
+	if err != nil || d <= 0 {
+		return 30 * time.Second
+	}
+	return d
+}
+
+// GetCircuitFailureWindow returns the window in which failures are counted (default: 1m)
+func (c SourceConfig) GetCircuitFailureWindow() time.Duration {
+	if c.Circuit == nil || c.Circuit.FailureWindow == "" {
+		return time.Minute
+	}
+	d, err := time.ParseDuration(c.Circuit.FailureWindow)
+	if err != nil || d <= 0 {
+		return time.Minute
+	}
+	return d
+}
+
 // GetRetryMaxRetries returns the max retries (default: 3, set to 0 to disable retries)
 func (c SourceConfig) GetRetryMaxRetries() int {
 	if c.Retry == nil {
@@ -122,7 +459,7 @@ func (c SourceConfig) GetRetryMaxDelay() time.Duration {
 
 // IsCacheEnabled returns true if caching is enabled for this source
 func (c SourceConfig) IsCacheEnabled() bool {
-	return c.Cache != nil && c.Cache.TTL != ""
+	return c.Cache != nil && (c.Cache.TTL != "" || c.Cache.RespectCacheControl)
 }
 
 // GetCacheTTL returns the cache TTL (0 if caching is disabled)
@@ -191,6 +528,36 @@ type NavPage struct {
 	Path  string `yaml:"path"`  // Page path (e.g., "getting-started/installation.md")
 }
 
+// PageURL converts a markdown file path, relative to the site directory, to
+// the URL path the site serves it at
+// Examples:
+//   - "index.md" → "/"
+//   - "getting-started.md" → "/getting-started"
+//   - "guides/intro.md" → "/guides/intro"
+//   - "guides/index.md" → "/guides/"
+func PageURL(relPath string) string {
+	// Remove .md extension
+	path := strings.TrimSuffix(relPath, ".md")
+
+	// Convert to URL path
+	path = filepath.ToSlash(path)
+
+	// Handle index files
+	if path == "index" {
+		return "/"
+	}
+	if strings.HasSuffix(path, "/index") {
+		return "/" + strings.TrimSuffix(path, "index")
+	}
+
+	// Add leading slash
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return path
+}
+
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
 	Port  int    `yaml:"port"`
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -14,6 +15,7 @@ func TestSourceConfigIsCacheEnabled(t *testing.T) {
 		{"nil cache", nil, false},
 		{"empty TTL", &CacheConfig{TTL: ""}, false},
 		{"with TTL", &CacheConfig{TTL: "5m"}, true},
+		{"cache control only", &CacheConfig{RespectCacheControl: true}, true},
 	}
 
 	for _, tt := range tests {
@@ -50,6 +52,73 @@ func TestSourceConfigGetCacheTTL(t *testing.T) {
 	}
 }
 
+func TestCacheBackendConfigDefaults(t *testing.T) {
+	var nilCfg *CacheBackendConfig
+	if got := nilCfg.GetPath(); got != filepath.Join(".tinkerdown", "cache.db") {
+		t.Errorf("GetPath() = %q", got)
+	}
+	if got := nilCfg.GetMaxSize(); got != 100<<20 {
+		t.Errorf("GetMaxSize() = %d, want 100MB", got)
+	}
+	if got := nilCfg.GetMaxStale(); got != 7*24*time.Hour {
+		t.Errorf("GetMaxStale() = %v, want 7 days", got)
+	}
+
+	cfg := &CacheBackendConfig{Path: "data/cache.db", MaxSize: "2GB", MaxStale: "24h"}
+	if got := cfg.GetPath(); got != "data/cache.db" {
+		t.Errorf("GetPath() = %q", got)
+	}
+	if got := cfg.GetMaxSize(); got != 2<<30 {
+		t.Errorf("GetMaxSize() = %d, want 2GB", got)
+	}
+	if got := cfg.GetMaxStale(); got != 24*time.Hour {
+		t.Errorf("GetMaxStale() = %v, want 24h", got)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"1024", 1024, false},
+		{"512B", 512, false},
+		{"512KB", 512 << 10, false},
+		{"1.5 MB", 3 << 19, false},
+		{"2gb", 2 << 30, false},
+		{"", 0, true},
+		{"big", 0, true},
+		{"-1MB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageURL(t *testing.T) {
+	tests := map[string]string{
+		"index.md":           "/",
+		"getting-started.md": "/getting-started",
+		"guides/intro.md":    "/guides/intro",
+		"guides/index.md":    "/guides/",
+	}
+	for relPath, want := range tests {
+		if got := PageURL(relPath); got != want {
+			t.Errorf("PageURL(%q) = %q, want %q", relPath, got, want)
+		}
+	}
+}
+
 func TestSourceConfigGetCacheStrategy(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -93,3 +162,58 @@ func TestSourceConfigIsStaleWhileRevalidate(t *testing.T) {
 		})
 	}
 }
+
+func TestPaginationConfigDefaults(t *testing.T) {
+	var p PaginationConfig
+	if got := p.GetCursorParam(); got != "cursor" {
+		t.Errorf("GetCursorParam() = %q, want %q", got, "cursor")
+	}
+	if got := p.GetPageParam(); got != "page" {
+		t.Errorf("GetPageParam() = %q, want %q", got, "page")
+	}
+	if got := p.GetPerPageParam(); got != "per_page" {
+		t.Errorf("GetPerPageParam() = %q, want %q", got, "per_page")
+	}
+	if got := p.GetStartPage(); got != 1 {
+		t.Errorf("GetStartPage() = %d, want 1", got)
+	}
+	if got := p.GetMaxPages(); got != 100 {
+		t.Errorf("GetMaxPages() = %d, want 100", got)
+	}
+	if got := p.GetMaxRows(); got != 10000 {
+		t.Errorf("GetMaxRows() = %d, want 10000", got)
+	}
+
+	zero := 0
+	p = PaginationConfig{StartPage: &zero, MaxPages: 5, MaxRows: 50}
+	if got := p.GetStartPage(); got != 0 {
+		t.Errorf("GetStartPage() = %d, want 0", got)
+	}
+	if got := p.GetMaxPages(); got != 5 {
+		t.Errorf("GetMaxPages() = %d, want 5", got)
+	}
+	if got := p.GetMaxRows(); got != 50 {
+		t.Errorf("GetMaxRows() = %d, want 50", got)
+	}
+}
+
+func TestSourceConfigCircuitBreaker(t *testing.T) {
+	var defaults SourceConfig
+	if defaults.GetCircuitFailureThreshold() != 5 || defaults.GetCircuitSuccessThreshold() != 2 {
+		t.Errorf("unexpected default thresholds: %d, %d", defaults.GetCircuitFailureThreshold(), defaults.GetCircuitSuccessThreshold())
+	}
+	if defaults.GetCircuitTimeout() != 30*time.Second || defaults.GetCircuitFailureWindow() != time.Minute {
+		t.Errorf("unexpected default durations: %v, %v", defaults.GetCircuitTimeout(), defaults.GetCircuitFailureWindow())
+	}
+
+	cfg := SourceConfig{Circuit: &CircuitBreakerConfig{FailureThreshold: 3, SuccessThreshold: 1, Timeout: "2m", FailureWindow: "invalid"}}
+	if cfg.GetCircuitFailureThreshold() != 3 || cfg.GetCircuitSuccessThreshold() != 1 {
+		t.Errorf("unexpected thresholds: %d, %d", cfg.GetCircuitFailureThreshold(), cfg.GetCircuitSuccessThreshold())
+	}
+	if cfg.GetCircuitTimeout() != 2*time.Minute {
+		t.Errorf("GetCircuitTimeout() = %v, want 2m", cfg.GetCircuitTimeout())
+	}
+	if cfg.GetCircuitFailureWindow() != time.Minute {
+		t.Errorf("GetCircuitFailureWindow() = %v, want the default for an invalid value", cfg.GetCircuitFailureWindow())
+	}
+}
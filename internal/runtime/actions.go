@@ -3,6 +3,7 @@ package runtime
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -41,7 +42,12 @@ func (s *GenericState) runExec(data map[string]interface{}) error {
 		return fmt.Errorf("Run action only valid for exec sources")
 	}
 
-	execSrc, ok := s.source.(*source.ExecSource)
+	// The exec source may be wrapped by its schema and transform (see
+	// source.WithTransform), which then shape the rows of each run
+	execSrc, ok := s.source.(interface {
+		source.Source
+		FetchWithArgs(ctx context.Context, args map[string]string) ([]map[string]interface{}, error)
+	})
 	if !ok {
 		return fmt.Errorf("invalid exec source")
 	}
@@ -81,6 +87,7 @@ func (s *GenericState) runExec(data map[string]interface{}) error {
 		// No form data - use default command
 		result, err = execSrc.Fetch(ctx)
 	}
+	s.fetched(time.Time{}, err)
 
 	if err != nil {
 		s.Status = "error"
@@ -88,13 +95,13 @@ func (s *GenericState) runExec(data map[string]interface{}) error {
 		return err
 	}
 
-	s.Data = result
+	s.Data = s.withRefLabels(result)
 	s.Status = "success"
 	s.Error = ""
 	return nil
 }
 
-// handleWriteAction handles Add, Toggle, Delete, Update actions for writable sources
+// handleWriteAction handles Add, Toggle, Delete, Update (and Move, Indent, Outdent) actions for writable sources
 func (s *GenericState) handleWriteAction(action string, data map[string]interface{}) error {
 	writable, ok := s.source.(source.WritableSource)
 	if !ok {
@@ -105,6 +112,12 @@ func (s *GenericState) handleWriteAction(action string, data map[string]interfac
 		return fmt.Errorf("source %q is read-only", s.sourceName)
 	}
 
+	// Fill in schema defaults before resolving, so they may use template expressions
+	actionLower := strings.ToLower(action)
+	if actionLower == "add" {
+		data = s.schema.ApplyDefaults(data)
+	}
+
 	// Resolve template expressions in action data (e.g., {{timestamp}}, {{today}}, {{.operator}})
 	// This enables auto-filling timestamps and operator identity on form submission
 	resolver := NewDefaultResolver(s.getOperator())
@@ -114,9 +127,51 @@ func (s *GenericState) handleWriteAction(action string, data map[string]interfac
 		return fmt.Errorf("failed to resolve template expressions: %w", err)
 	}
 
+	// Validate against the schema; invalid fields are shown with the form
+	if actionLower == "add" || actionLower == "update" {
+		validated, errs := s.schema.Validate(actionLower, resolvedData, s.Data)
+		for field, msg := range s.checkRefs(validated) {
+			if errs == nil {
+				errs = make(map[string]string)
+			}
+			if _, ok := errs[field]; !ok {
+				errs[field] = msg
+			}
+		}
+		if errs != nil {
+			s.Errors = errs
+			return nil
+		}
+		resolvedData = validated
+	}
+
+	// Deletes also apply the on_delete rules of rows referencing the row
+	if actionLower == "delete" {
+		written, err := s.deleteWithReferences(writable, resolvedData)
+		s.refWritten = written
+		if err != nil {
+			var restricted *restrictedError
+			var partial *partialDeleteError
+			switch {
+			case errors.As(err, &restricted):
+				// Shown with the page, like validation errors
+				s.Error = err.Error()
+				return nil
+			case errors.As(err, &partial):
+				// The row is gone; show the rows as they are now, with the error
+				s.refresh()
+				s.Error = err.Error()
+				return nil
+			}
+			s.Error = err.Error()
+			return err
+		}
+		return s.refresh()
+	}
+
 	// Delegate to the source's WriteItem
 	ctx := context.Background()
-	if err := writable.WriteItem(ctx, strings.ToLower(action), resolvedData); err != nil {
+	if err := writable.WriteItem(ctx, actionLower, resolvedData); err != nil {
 		s.Error = err.Error()
 		return err
 	}
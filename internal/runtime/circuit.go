@@ -0,0 +1,79 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/source"
+)
+
+// fetched records the outcome of a fetch of the source: when the rows shown
+// were fetched (fetchedAt, zero for now) and the state of the source's
+// circuit breaker. Cached rows keep the time they were fetched upstream.
+func (s *GenericState) fetched(fetchedAt time.Time, err error) {
+	var stale *source.StaleError
+	switch {
+	case err == nil:
+		if fetchedAt.IsZero() {
+			fetchedAt = time.Now()
+		}
+		s.fetchedAt = fetchedAt
+		s.UpdatedAt = s.fetchedAt.UTC().Format(time.RFC3339)
+		s.DataAge = ""
+	case errors.As(err, &stale) && !stale.FetchedAt.IsZero():
+		// Rows the cache kept are shown instead
+		s.fetchedAt = stale.FetchedAt
+		s.UpdatedAt = s.fetchedAt.UTC().Format(time.RFC3339)
+		s.DataAge = formatAge(time.Since(s.fetchedAt))
+	case !s.fetchedAt.IsZero():
+		// The previous rows are still shown
+		s.DataAge = formatAge(time.Since(s.fetchedAt))
+	}
+	s.updateCircuit()
+}
+
+// updateCircuit copies the state and last error of the source's circuit breaker
+func (s *GenericState) updateCircuit() {
+	if s.breaker == nil {
+		return
+	}
+	s.CircuitState = s.breaker.State().String()
+	s.CircuitError = ""
+	if lastErr := s.breaker.LastError(); lastErr != nil {
+		s.CircuitError = lastErr.Error()
+	}
+}
+
+// resetCircuit closes the circuit of the source, so that it is called again,
+// and fetches the rows. Exec sources run on the next Run instead.
+func (s *GenericState) resetCircuit() error {
+	if s.breaker == nil {
+		return fmt.Errorf("source %q has no circuit breaker", s.sourceName)
+	}
+	s.breaker.Reset()
+	if s.sourceType == "exec" {
+		s.updateCircuit()
+		return nil
+	}
+
+	// Show the outcome, failed or not
+	if err := s.refresh(); err != nil {
+		s.Error = err.Error()
+	}
+	return nil
+}
+
+// formatAge formats a duration in its largest whole unit (e.g., "45s", "5m", "2h", "3d")
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,125 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+	"github.com/livetemplate/tinkerdown/internal/source"
+)
+
+// Field describes an input of a form generated from a source's schema
+type Field struct {
+	Name     string   `json:"name"`
+	Label    string   `json:"label"`
+	Input    string   `json:"input"` // text, number, checkbox, date, datetime-local, select, or textarea
+	Required bool     `json:"required,omitempty"`
+	Min      string   `json:"min,omitempty"`     // min of numbers
+	Max      string   `json:"max,omitempty"`     // max of numbers
+	MinLen   string   `json:"minLen,omitempty"`  // minlength of text
+	MaxLen   string   `json:"maxLen,omitempty"`  // maxlength of text
+	Step     string   `json:"step,omitempty"`    // "1" for int, "any" for float
+	Pattern  string   `json:"pattern,omitempty"` // Regular expression the text must match
+	Options  []Option `json:"options,omitempty"` // Choices of select inputs
+	Default  string   `json:"default"`           // Initial value (dynamic defaults are resolved on submit)
+}
+
+// Option is a choice of a select input
+type Option struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// buildFields returns the form fields of a schema. order lists the fields to
+// show first (from lvt-fields); the others follow by name.
+func buildFields(schema map[string]config.FieldSchema, order []string) []Field {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(schema))
+	seen := make(map[string]bool)
+	for _, name := range order {
+		if _, ok := schema[name]; ok && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	var rest []string
+	for name := range schema {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	names = append(names, rest...)
+
+	fields := make([]Field, 0, len(names))
+	for _, name := range names {
+		fs := schema[name]
+		f := Field{
+			Name:     name,
+			Label:    schemaFieldLabel(name, fs),
+			Input:    "text",
+			Required: fs.Required,
+			Pattern:  fs.Pattern,
+		}
+
+		switch fs.Type {
+		case "int", "float":
+			f.Input = "number"
+			f.Step = "any"
+			if fs.Type == "int" {
+				f.Step = "1"
+			}
+			f.Min, f.Max = formatBound(fs.Min), formatBound(fs.Max)
+		case "bool":
+			f.Input = "checkbox"
+			f.Required = false // An unchecked box is false
+		case "date":
+			f.Input = "date"
+		case "datetime":
+			f.Input = "datetime-local"
+		case "enum":
+			f.Input = "select"
+			for _, v := range fs.Values {
+				f.Options = append(f.Options, Option{Value: v, Label: v})
+			}
+		case "json":
+			f.Input = "textarea"
+		default:
+			f.MinLen, f.MaxLen = formatBound(fs.Min), formatBound(fs.Max)
+		}
+		if fs.Ref != "" {
+			// The options are the referenced rows (see loadRefs)
+			f.Input = "select"
+			f.Min, f.Max, f.MinLen, f.MaxLen, f.Step, f.Pattern = "", "", "", "", "", ""
+		}
+
+		if fs.Default != nil {
+			if def := fmt.Sprint(fs.Default); !strings.Contains(def, "{{") {
+				f.Default = def
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// schemaFieldLabel returns the label of a schema field
+func schemaFieldLabel(name string, fs config.FieldSchema) string {
+	if fs.Label != "" {
+		return fs.Label
+	}
+	return source.SchemaLabel(name)
+}
+
+// formatBound formats a min or max for an input attribute
+func formatBound(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
@@ -0,0 +1,140 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/livetemplate/tinkerdown/internal/cache"
+	"github.com/livetemplate/tinkerdown/internal/config"
+	"github.com/livetemplate/tinkerdown/internal/source"
+)
+
+// SourceConfigLookup finds the config of a source by name (page sources first,
+// then site sources)
+type SourceConfigLookup func(name string) (config.SourceConfig, bool)
+
+// inputsSource is a derived or query source with the input sources created for it
+type inputsSource struct {
+	source.Source
+	inputs *inputSources
+}
+
+// newInputsSource creates a derived or query source whose inputs are created
+// from their configs on first use. Inputs whose configs enable caching read
+// through c (nil disables caching).
+func newInputsSource(name string, cfg config.SourceConfig, siteDir, currentFile string, sources SourceConfigLookup, c cache.Cache) (source.Source, error) {
+	if sources == nil {
+		return nil, fmt.Errorf("%s source %q: input sources are not available here", cfg.Type, name)
+	}
+	inputs := &inputSources{configs: sources, siteDir: siteDir, currentFile: currentFile, cache: c, sources: make(map[string]source.Source)}
+	var src source.Source
+	var err error
+	if cfg.Type == "query" {
+		src, err = source.NewQuerySource(name, cfg, inputs.get)
+	} else {
+		src, err = source.NewDerivedSource(name, cfg, inputs.get)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inputsSource{Source: src, inputs: inputs}, nil
+}
+
+// Close closes the input sources
+func (s *inputsSource) Close() error {
+	return s.inputs.Close()
+}
+
+// Invalidate drops the cached rows of the inputs created so far
+func (s *inputsSource) Invalidate() {
+	s.inputs.mu.Lock()
+	defer s.inputs.mu.Unlock()
+	for _, src := range s.inputs.sources {
+		invalidate(src)
+	}
+}
+
+// invalidate drops the cached rows of src, of the sources it wraps and of
+// their inputs
+func invalidate(src source.Source) {
+	for src != nil {
+		if cached, ok := src.(interface{ Invalidate() }); ok {
+			cached.Invalidate()
+		}
+		wrapper, ok := src.(interface{ GetInner() source.Source })
+		if !ok {
+			return
+		}
+		src = wrapper.GetInner()
+	}
+}
+
+// inputSources creates and keeps the inputs of a derived or query source
+type inputSources struct {
+	configs     SourceConfigLookup
+	siteDir     string
+	currentFile string
+	cache       cache.Cache // The site's cache (see source.SharedCache); nil disables caching
+
+	mu      sync.Mutex
+	sources map[string]source.Source
+}
+
+// get returns the input source with the given name, creating it if needed
+func (in *inputSources) get(name string) (source.Source, bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if src, ok := in.sources[name]; ok {
+		return src, true
+	}
+	cfg, ok := in.configs(name)
+	if !ok {
+		return nil, false
+	}
+	src, err := createSource(name, cfg, in.siteDir, in.currentFile, in.configs, in.cache)
+	if err == nil {
+		src, err = source.WithTransform(src, cfg)
+	}
+	if err != nil {
+		// Report the error when the input is fetched
+		return failedSource{name: name, err: err}, true
+	}
+	// Inputs share the cache entries of the pages showing them, which also
+	// version their rows for query sources (see source.CachedSource.FetchVersion)
+	if cfg.Type != "exec" {
+		src = source.WithCache(src, in.cache, cfg)
+	}
+	in.sources[name] = src
+	return src, true
+}
+
+// Close closes the inputs created so far
+func (in *inputSources) Close() error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	var firstErr error
+	for name, src := range in.sources {
+		if err := src.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(in.sources, name)
+	}
+	return firstErr
+}
+
+// failedSource is an input source that could not be created
+type failedSource struct {
+	name string
+	err  error
+}
+
+func (s failedSource) Name() string { return s.name }
+
+func (s failedSource) Fetch(context.Context) ([]map[string]interface{}, error) {
+	return nil, s.err
+}
+
+func (s failedSource) Close() error { return nil }
@@ -0,0 +1,379 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+	"github.com/livetemplate/tinkerdown/internal/source"
+)
+
+// refLabelSuffix is appended to the name of a ref field for the column holding
+// the label of the referenced row (e.g., owner_id_label)
+const refLabelSuffix = "_label"
+
+// refField is a schema field holding the ids of another source's rows
+type refField struct {
+	name     string
+	ref      string // Referenced source
+	display  string // Column of the referenced rows shown for an id
+	onDelete string // restrict, cascade or nullify
+}
+
+// schemaRefs returns the ref fields of a schema, by name
+func schemaRefs(schema map[string]config.FieldSchema) []refField {
+	var refs []refField
+	for name, fs := range schema {
+		if fs.Ref == "" {
+			continue
+		}
+		ref := refField{name: name, ref: fs.Ref, display: fs.Display, onDelete: fs.OnDelete}
+		if ref.display == "" {
+			ref.display = "name"
+		}
+		if ref.onDelete == "" {
+			ref.onDelete = "restrict"
+		}
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].name < refs[j].name })
+	return refs
+}
+
+// rowID returns the id of a row or of action data
+func rowID(data map[string]interface{}) (interface{}, bool) {
+	for _, key := range []string{"id", "Id", "ID"} {
+		if id, ok := data[key]; ok && id != nil {
+			return id, true
+		}
+	}
+	return nil, false
+}
+
+// loadRefs reads the rows of the referenced sources into the options of the
+// ref fields. The options of a source that fails to fetch are kept.
+func (s *GenericState) loadRefs() {
+	if len(s.refs) == 0 || s.related == nil {
+		return
+	}
+	if s.refLabels == nil {
+		s.refLabels = make(map[string]map[string]string)
+	}
+
+	ctx := context.Background()
+	for _, ref := range s.refs {
+		src, ok := s.related.get(ref.ref)
+		if !ok {
+			continue
+		}
+		rows, err := src.Fetch(ctx)
+		if err != nil && !source.IsStale(err) {
+			continue
+		}
+
+		labels := make(map[string]string, len(rows))
+		options := make([]Option, 0, len(rows))
+		for _, row := range rows {
+			id, ok := rowID(row)
+			if !ok {
+				continue
+			}
+			value := fmt.Sprint(id)
+			label := value
+			if display, ok := row[ref.display]; ok && display != nil {
+				label = fmt.Sprint(display)
+			}
+			labels[value] = label
+			options = append(options, Option{Value: value, Label: label})
+		}
+		s.refLabels[ref.name] = labels
+
+		for i := range s.Fields {
+			if s.Fields[i].Name == ref.name {
+				s.Fields[i].Options = options
+			}
+		}
+	}
+}
+
+// addRefLabels adds the label of the referenced row to each ref field of the
+// rows, as <field>_label. The rows may be shared (e.g., cached), so a new
+// slice is returned in which labeled rows are copies.
+func (s *GenericState) addRefLabels(rows []map[string]interface{}) []map[string]interface{} {
+	labeled := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		copied := false
+		for _, ref := range s.refs {
+			v, ok := row[ref.name]
+			if !ok || v == nil {
+				continue
+			}
+			key := ref.name + refLabelSuffix
+			if _, exists := row[key]; exists {
+				continue
+			}
+			label, ok := s.refLabels[ref.name][fmt.Sprint(v)]
+			if !ok {
+				continue
+			}
+			if !copied {
+				row = copyRow(row)
+				copied = true
+			}
+			row[key] = label
+		}
+		labeled[i] = row
+	}
+	return labeled
+}
+
+// copyRow returns a shallow copy of row
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(row)+1)
+	for k, v := range row {
+		c[k] = v
+	}
+	return c
+}
+
+// isRefLabel reports whether a column holds the labels of a ref field
+func (s *GenericState) isRefLabel(col string) bool {
+	for _, ref := range s.refs {
+		if col == ref.name+refLabelSuffix {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRefs returns an error message for each ref field of the data whose
+// value is not the id of a referenced row
+func (s *GenericState) checkRefs(data map[string]interface{}) map[string]string {
+	if len(s.refs) == 0 {
+		return nil
+	}
+	s.loadRefs()
+
+	errs := make(map[string]string)
+	for _, ref := range s.refs {
+		v, ok := data[ref.name]
+		if !ok || v == nil || v == "" {
+			continue
+		}
+		labels, loaded := s.refLabels[ref.name]
+		if !loaded {
+			// The referenced source couldn't be read
+			continue
+		}
+		if _, ok := labels[fmt.Sprint(v)]; !ok {
+			errs[ref.name] = fmt.Sprintf("%s must refer to a row of %s", schemaFieldLabel(ref.name, s.sourceCfg.Schema[ref.name]), ref.ref)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// restrictedError reports a delete blocked by rows referencing the row
+type restrictedError struct {
+	source string
+	count  int
+}
+
+func (e *restrictedError) Error() string {
+	if e.count == 1 {
+		return fmt.Sprintf("cannot delete: a row of %s refers to it", e.source)
+	}
+	return fmt.Sprintf("cannot delete: %d rows of %s refer to it", e.count, e.source)
+}
+
+// refWrite is a write to a referencing source planned for a delete
+type refWrite struct {
+	source string
+	action string
+	data   map[string]interface{}
+}
+
+// deletePlan collects the writes a delete causes in referencing sources
+type deletePlan struct {
+	writes  []refWrite
+	seen    map[string]bool
+	sources map[string]source.Source
+}
+
+// partialDeleteError reports a delete whose on_delete writes stopped partway:
+// the row is deleted, and so are the writes listed in changed
+type partialDeleteError struct {
+	source  string   // Source whose write failed
+	changed []string // e.g., "deleted 2 rows of tasks"
+	err     error
+}
+
+func (e *partialDeleteError) Error() string {
+	changed := "no referencing rows"
+	if len(e.changed) > 0 {
+		changed = strings.Join(e.changed, ", ")
+	}
+	return fmt.Sprintf("deleted the row, but failed to update %q: %v (already changed: %s)", e.source, e.err, changed)
+}
+
+func (e *partialDeleteError) Unwrap() error {
+	return e.err
+}
+
+// deleteWithReferences deletes a row and applies the on_delete rules of the
+// sources referencing it: cascade deletes the referencing rows, nullify clears
+// their field, and restrict (the default) blocks the delete with a
+// *restrictedError. Nothing is written if the delete is blocked or a
+// referencing source is not writable. The row is deleted first, then the
+// referencing rows top down; if one of their writes fails, the error is a
+// *partialDeleteError listing what was already changed. Returns the
+// referencing sources written to, whose cached rows are invalidated.
+func (s *GenericState) deleteWithReferences(writable source.WritableSource, data map[string]interface{}) ([]string, error) {
+	ctx := context.Background()
+	id, ok := rowID(data)
+	if s.sourceNames == nil || s.related == nil || !ok {
+		return nil, writable.WriteItem(ctx, "delete", data)
+	}
+
+	plan := &deletePlan{seen: make(map[string]bool), sources: make(map[string]source.Source)}
+	defer func() {
+		for _, src := range plan.sources {
+			src.Close()
+		}
+	}()
+	if err := s.planDelete(plan, s.sourceName, fmt.Sprint(id)); err != nil {
+		return nil, err
+	}
+	for _, w := range plan.writes {
+		if ws, ok := plan.sources[w.source].(source.WritableSource); !ok || ws.IsReadonly() {
+			return nil, fmt.Errorf("cannot delete: source %q referring to it is not writable", w.source)
+		}
+	}
+
+	if err := writable.WriteItem(ctx, "delete", data); err != nil {
+		return nil, err
+	}
+
+	// The writes go to uncached sources, so drop the rows the site's cache
+	// holds for them, also after a failed write
+	var written []string
+	defer func() {
+		for _, name := range written {
+			if src, ok := s.related.get(name); ok {
+				invalidate(src)
+			}
+		}
+	}()
+
+	// The plan lists rows after the rows referring to them; write it in reverse
+	type writeCount struct {
+		action, source string
+		rows           int
+	}
+	var done []writeCount
+	for i := len(plan.writes) - 1; i >= 0; i-- {
+		w := plan.writes[i]
+		if !slices.Contains(written, w.source) {
+			written = append(written, w.source)
+		}
+		if err := plan.sources[w.source].(source.WritableSource).WriteItem(ctx, w.action, w.data); err != nil {
+			changed := make([]string, len(done))
+			for j, c := range done {
+				verb := "updated"
+				if c.action == "delete" {
+					verb = "deleted"
+				}
+				rows := fmt.Sprintf("%d rows", c.rows)
+				if c.rows == 1 {
+					rows = "1 row"
+				}
+				changed[j] = fmt.Sprintf("%s %s of %s", verb, rows, c.source)
+			}
+			return written, &partialDeleteError{source: w.source, changed: changed, err: err}
+		}
+		if n := len(done); n > 0 && done[n-1].action == w.action && done[n-1].source == w.source {
+			done[n-1].rows++
+		} else {
+			done = append(done, writeCount{action: w.action, source: w.source, rows: 1})
+		}
+	}
+	return written, nil
+}
+
+// planDelete adds the writes deleting the row of a source causes, depth first:
+// the writes of a row come after those of the rows referring to it
+func (s *GenericState) planDelete(plan *deletePlan, target, id string) error {
+	key := target + "\x00" + id
+	if plan.seen[key] {
+		return nil
+	}
+	plan.seen[key] = true
+
+	for _, name := range s.sourceNames() {
+		cfg, ok := s.related.configs(name)
+		if !ok {
+			continue
+		}
+		for _, ref := range schemaRefs(cfg.Schema) {
+			if ref.ref != target {
+				continue
+			}
+			rows, err := plan.fetch(s.related, name, cfg)
+			if err != nil {
+				return fmt.Errorf("cannot delete: failed to read %q: %w", name, err)
+			}
+
+			var matches []map[string]interface{}
+			for _, row := range rows {
+				if v, ok := row[ref.name]; ok && v != nil && fmt.Sprint(v) == id {
+					matches = append(matches, row)
+				}
+			}
+			if len(matches) == 0 {
+				continue
+			}
+
+			switch ref.onDelete {
+			case "cascade":
+				for _, row := range matches {
+					rid, ok := rowID(row)
+					if !ok {
+						continue
+					}
+					if err := s.planDelete(plan, name, fmt.Sprint(rid)); err != nil {
+						return err
+					}
+					plan.writes = append(plan.writes, refWrite{source: name, action: "delete", data: map[string]interface{}{"id": rid}})
+				}
+			case "nullify":
+				for _, row := range matches {
+					if rid, ok := rowID(row); ok {
+						plan.writes = append(plan.writes, refWrite{source: name, action: "update", data: map[string]interface{}{"id": rid, ref.name: ""}})
+					}
+				}
+			default:
+				return &restrictedError{source: name, count: len(matches)}
+			}
+		}
+	}
+	return nil
+}
+
+// fetch reads the rows of a referencing source as stored, without its transform
+func (p *deletePlan) fetch(in *inputSources, name string, cfg config.SourceConfig) ([]map[string]interface{}, error) {
+	src, ok := p.sources[name]
+	if !ok {
+		var err error
+		src, err = createSource(name, cfg, in.siteDir, in.currentFile, in.configs, in.cache)
+		if err != nil {
+			return nil, err
+		}
+		p.sources[name] = src
+	}
+	return src.Fetch(context.Background())
+}
@@ -8,9 +8,11 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/livetemplate/components/datatable"
 
+	"github.com/livetemplate/tinkerdown/internal/cache"
 	"github.com/livetemplate/tinkerdown/internal/config"
 	"github.com/livetemplate/tinkerdown/internal/source"
 	"github.com/livetemplate/tinkerdown/internal/wasm"
@@ -29,8 +31,16 @@ type Store interface {
 type GenericState struct {
 	// Common fields (JSON-serializable for templates)
 	Data   []map[string]interface{} `json:"data"`
+	Tree   []map[string]interface{} `json:"tree,omitempty"` // Top-level rows of hierarchical data (rows with parent_id)
 	Error  string                   `json:"error,omitempty"`
 	Errors map[string]string        `json:"errors,omitempty"`
+	Fields []Field                  `json:"fields,omitempty"` // Form inputs of the schema's fields
+
+	// Health of the source (see circuit.go)
+	CircuitState string `json:"circuitState,omitempty"` // closed, open or half-open, for sources with a circuit breaker
+	CircuitError string `json:"circuitError,omitempty"` // Error of the source's last failed fetch, until one succeeds
+	UpdatedAt    string `json:"updatedAt,omitempty"`    // When the rows were last fetched (RFC 3339)
+	DataAge      string `json:"dataAge,omitempty"`      // How long ago the rows shown were fetched, while fetching fails (e.g., "5m")
 
 	// Datatable field - used when source is rendered in a table element
 	Table *datatable.DataTable `json:"table,omitempty"`
@@ -46,6 +56,9 @@ type GenericState struct {
 
 	// Private runtime fields (not serialized)
 	source       source.Source
+	breaker      *source.CircuitBreaker // Circuit breaker of the source (nil if none)
+	fetchedAt    time.Time              // When the rows were last fetched
+	schema       *source.Schema         // Coerces rows and validates writes (nil if none)
 	sourceCfg    config.SourceConfig
 	sourceType   string
 	sourceName   string
@@ -61,6 +74,20 @@ type GenericState struct {
 	// concurrently with action handling.
 	actions  map[string]*config.Action          // Custom actions declared in frontmatter
 	registry func(string) (source.Source, bool) // Lookup function for sources (for SQL actions)
+
+	// Streaming sources (see source.StreamingSource)
+	streaming     bool               // Rows arrive as stream events instead of being fetched
+	streamMode    string             // "append" or "replace"
+	streamMaxRows int                // Rows kept in append mode
+	onUpdate      func(func())       // Applies stream events and renders; set via SetOnUpdate
+	cancelStream  context.CancelFunc // Unsubscribes from the stream
+
+	// Relations between sources (see refs.go)
+	refs        []refField                   // Schema fields referencing other sources
+	refLabels   map[string]map[string]string // Label of each referenced id, by ref field
+	related     *inputSources                // Referenced sources (nil without source configs)
+	sourceNames func() []string              // Names of all sources, to find the rows referencing a deleted row; set via SetSourceNames
+	refWritten  []string                     // Referencing sources the last action's on_delete rules wrote to
 }
 
 // Arg represents an exec source argument
@@ -83,33 +110,80 @@ func NewGenericState(name string, cfg config.SourceConfig, siteDir, currentFile
 // NewGenericStateWithMetadata creates a new state with block metadata for datatable support.
 // Metadata should include "lvt-element" ("table", "select", or "div") and "lvt-columns" for tables.
 func NewGenericStateWithMetadata(name string, cfg config.SourceConfig, siteDir, currentFile string, metadata map[string]string) (*GenericState, error) {
-	// Create the underlying source using the existing factory
-	src, err := createSource(name, cfg, siteDir, currentFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create source %q: %w", name, err)
-	}
+	return NewGenericStateWithSources(name, cfg, siteDir, currentFile, metadata, nil)
+}
 
-	s := &GenericState{
-		source:     src,
-		sourceCfg:  cfg,
-		sourceType: cfg.Type,
-		sourceName: name,
-		siteDir:    siteDir,
-		Errors:     make(map[string]string),
-	}
+// NewGenericStateWithSources creates a new state with block metadata and a lookup for
+// the configs of other sources, which derived and query sources read as inputs.
+func NewGenericStateWithSources(name string, cfg config.SourceConfig, siteDir, currentFile string, metadata map[string]string, sources SourceConfigLookup) (*GenericState, error) {
+	return NewGenericStateWithCache(name, cfg, siteDir, currentFile, metadata, sources, nil)
+}
 
+// NewGenericStateWithCache creates a new state whose source, if its config enables
+// caching, reads through the site's cache c (see source.SharedCache). A nil c
+// disables caching.
+func NewGenericStateWithCache(name string, cfg config.SourceConfig, siteDir, currentFile string, metadata map[string]string, sources SourceConfigLookup, c cache.Cache) (*GenericState, error) {
 	// Parse metadata for element type and columns
+	var elementType string
+	var tableColumns []string
+	var fieldOrder []string
 	if metadata != nil {
-		s.elementType = metadata["lvt-element"]
+		elementType = metadata["lvt-element"]
 		if columns := metadata["lvt-columns"]; columns != "" {
 			// Parse "name:Name,email:Email" format
 			for _, pair := range strings.Split(columns, ",") {
 				parts := strings.SplitN(pair, ":", 2)
 				if len(parts) > 0 {
-					s.tableColumns = append(s.tableColumns, parts[0])
+					tableColumns = append(tableColumns, strings.TrimSpace(parts[0]))
 				}
 			}
 		}
+		if fields := metadata["lvt-fields"]; fields != "" {
+			for _, field := range strings.Split(fields, ",") {
+				fieldOrder = append(fieldOrder, strings.TrimSpace(field))
+			}
+		}
+	}
+
+	// Columnar sources only read the columns the table shows. A transform may
+	// rename or compute those columns, so then all are read.
+	if (cfg.Type == "parquet" || cfg.Type == "arrow") && len(cfg.Columns) == 0 && len(cfg.Transform) == 0 {
+		cfg.Columns = tableColumns
+	}
+
+	// Create the underlying source using the existing factory
+	src, err := createSource(name, cfg, siteDir, currentFile, sources, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create source %q: %w", name, err)
+	}
+	// The schema and transform shape the rows before they are cached
+	src, err = source.WithTransform(src, cfg)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("failed to create source %q: %w", name, err)
+	}
+	schema, err := source.NewSchema(name, cfg.Schema)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("failed to create source %q: %w", name, err)
+	}
+
+	s := &GenericState{
+		source:       src,
+		breaker:      source.SourceCircuitBreaker(src),
+		schema:       schema,
+		sourceCfg:    cfg,
+		sourceType:   cfg.Type,
+		sourceName:   name,
+		siteDir:      siteDir,
+		elementType:  elementType,
+		tableColumns: tableColumns,
+		Errors:       make(map[string]string),
+		Fields:       buildFields(cfg.Schema, fieldOrder),
+		refs:         schemaRefs(cfg.Schema),
+	}
+	if sources != nil {
+		s.related = &inputSources{configs: sources, siteDir: siteDir, currentFile: currentFile, cache: c, sources: make(map[string]source.Source)}
 	}
 
 	// Set exec-specific fields if applicable
@@ -124,6 +198,23 @@ func NewGenericStateWithMetadata(name string, cfg config.SourceConfig, siteDir,
 		}
 	}
 
+	// Streaming sources push rows instead of being fetched
+	if _, ok := src.(source.StreamingSource); ok {
+		streaming, err := s.subscribe(name, cfg, siteDir, currentFile)
+		if err != nil {
+			s.Error = err.Error()
+			return s, nil
+		}
+		if streaming {
+			return s, nil
+		}
+	}
+
+	// Fetched rows are cached for all pages; exec sources run on demand
+	if cfg.Type != "exec" {
+		s.source = source.WithCache(src, c, cfg)
+	}
+
 	// Initial data fetch
 	if err := s.refresh(); err != nil {
 		s.Error = err.Error()
@@ -132,6 +223,116 @@ func NewGenericStateWithMetadata(name string, cfg config.SourceConfig, siteDir,
 	return s, nil
 }
 
+// subscribe starts receiving the rows of a streaming source. Pages showing the
+// same source share one upstream stream (see source.SharedStreamHub), which
+// replays recent events to each new page. Returns false if the source has
+// nothing to stream.
+func (s *GenericState) subscribe(name string, cfg config.SourceConfig, siteDir, currentFile string) (bool, error) {
+	var stream config.StreamConfig
+	if cfg.Stream != nil {
+		stream = *cfg.Stream
+	}
+
+	cfgKey, err := json.Marshal(cfg)
+	if err != nil {
+		return false, err
+	}
+	hub := source.SharedStreamHub(siteDir+"|"+name+"|"+string(cfgKey), name, func() (source.StreamingSource, error) {
+		src, err := createSource(name, cfg, siteDir, currentFile, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if src, err = source.WithTransform(src, cfg); err != nil {
+			return nil, err
+		}
+		streamingSrc, ok := src.(source.StreamingSource)
+		if !ok {
+			return nil, fmt.Errorf("source %q does not support streaming", name)
+		}
+		return streamingSrc, nil
+	}, stream.GetReplay(), stream.GetBuffer())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := hub.Subscribe(ctx)
+	if err != nil || events == nil {
+		cancel()
+		return false, err
+	}
+
+	s.streaming = true
+	s.streamMode = stream.GetMode()
+	s.streamMaxRows = stream.GetMaxRows()
+	s.cancelStream = cancel
+	s.setData([]map[string]interface{}{})
+
+	// Apply the replayed events now, so the first render includes them
+	for drained := false; !drained; {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return true, nil
+			}
+			s.applyEvent(ev)
+		default:
+			drained = true
+		}
+	}
+
+	go s.consumeStream(events)
+	return true, nil
+}
+
+// consumeStream applies stream events until the stream is closed. Once an
+// update callback is set, events are applied through it (see SetOnUpdate).
+func (s *GenericState) consumeStream(events <-chan source.Event) {
+	for ev := range events {
+		s.mu.Lock()
+		onUpdate := s.onUpdate
+		if onUpdate == nil {
+			s.applyEvent(ev)
+		}
+		s.mu.Unlock()
+
+		if onUpdate != nil {
+			onUpdate(func() {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				s.applyEvent(ev)
+			})
+		}
+	}
+}
+
+// applyEvent updates the rows with a stream event. Must be called with s.mu held.
+func (s *GenericState) applyEvent(ev source.Event) {
+	if ev.Err != nil {
+		s.Error = ev.Err.Error()
+		return
+	}
+
+	// Event rows are shared with other pages, so they are copied before appending
+	rows := s.withRefLabels(append([]map[string]interface{}{}, ev.Rows...))
+	if ev.Replace || s.streamMode == "replace" {
+		s.setData(rows)
+		return
+	}
+	data := append(s.Data, rows...)
+	if len(data) > s.streamMaxRows {
+		data = data[len(data)-s.streamMaxRows:]
+	}
+	s.setData(data)
+}
+
+// SetOnUpdate registers a callback for changes outside of actions, e.g., when
+// a streaming source delivered new rows. The callback must call apply to change
+// the state, holding the lock that serializes the caller's actions and renders
+// with it, then render the state.
+func (s *GenericState) SetOnUpdate(fn func(apply func())) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onUpdate = fn
+}
+
 // SetPageConfig configures page-level settings for custom actions.
 // actions is the map of custom actions declared in frontmatter.
 // registry is a lookup function to find sources by name (for SQL actions).
@@ -142,8 +343,28 @@ func (s *GenericState) SetPageConfig(actions map[string]*config.Action, registry
 	s.registry = registry
 }
 
-// createSource creates a source from config (mirrors source.createSource)
-func createSource(name string, cfg config.SourceConfig, siteDir, currentFile string) (source.Source, error) {
+// SetSourceNames sets the function listing the names of all sources. Deletes
+// look through them for rows referencing the deleted row (see schema ref and
+// on_delete).
+func (s *GenericState) SetSourceNames(names func() []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sourceNames = names
+}
+
+// RefWrittenSources returns the sources the last action wrote to besides the
+// state's own: those whose rows a delete removed or cleared through their
+// on_delete rules
+func (s *GenericState) RefWrittenSources() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.refWritten
+}
+
+// createSource creates a source from config (mirrors source.createSource).
+// sources finds the configs of the inputs of derived and query sources, which
+// read through the cache c.
+func createSource(name string, cfg config.SourceConfig, siteDir, currentFile string, sources SourceConfigLookup, c cache.Cache) (source.Source, error) {
 	switch cfg.Type {
 	case "exec":
 		if !config.IsExecAllowed() {
@@ -155,21 +376,45 @@ func createSource(name string, cfg config.SourceConfig, siteDir, currentFile str
 		}
 		return source.NewExecSourceWithConfig(name, cfg, siteDir)
 	case "pg":
-		return source.NewPostgresSource(name, cfg.Query, cfg.Options)
+		return source.NewPostgresSourceWithConfig(name, cfg.Query, cfg.Options, cfg)
 	case "rest":
-		return source.NewRestSourceWithConfig(name, cfg)
+		return source.NewRestSourceWithConfig(name, cfg, siteDir)
 	case "json":
-		return source.NewJSONFileSource(name, cfg.File, siteDir)
+		return source.NewJSONFileSourceWithConfig(name, cfg, siteDir)
 	case "csv":
-		return source.NewCSVFileSource(name, cfg.File, siteDir, cfg.Options)
+		return source.NewCSVFileSourceWithConfig(name, cfg, siteDir)
+	case "yaml", "toml":
+		return source.NewDocumentFileSource(name, cfg, siteDir)
+	case "xlsx":
+		return source.NewXLSXFileSource(name, cfg, siteDir)
+	case "parquet":
+		return source.NewParquetSource(name, cfg, siteDir)
+	case "arrow":
+		return source.NewArrowSource(name, cfg, siteDir)
+	case "git":
+		return source.NewGitSource(name, cfg, siteDir, currentFile)
+	case "derived", "query":
+		return newInputsSource(name, cfg, siteDir, currentFile, sources, c)
 	case "markdown":
-		return source.NewMarkdownSource(name, cfg.File, cfg.Anchor, siteDir, currentFile, cfg.IsReadonly())
+		return source.NewMarkdownSourceWithConfig(name, cfg, siteDir, currentFile)
 	case "sqlite":
 		return source.NewSQLiteSource(name, cfg.DB, cfg.Table, siteDir, cfg.IsReadonly())
 	case "wasm":
-		return wasm.NewWasmSource(name, cfg.Path, siteDir, cfg.Options)
+		src, err := wasm.NewWasmSource(name, cfg.Path, siteDir, cfg.Options)
+		if err != nil {
+			return nil, err
+		}
+		return source.WithCircuitBreaker(src, cfg), nil
 	case "graphql":
 		return source.NewGraphQLSource(name, cfg, siteDir)
+	case "collection":
+		return source.NewCollectionSource(name, cfg, siteDir)
+	case "sse":
+		return source.NewSSESource(name, cfg, siteDir)
+	case "websocket":
+		return source.NewWebSocketSource(name, cfg, siteDir)
+	case "tail":
+		return source.NewTailSource(name, cfg, siteDir)
 	default:
 		return nil, fmt.Errorf("unsupported source type: %s", cfg.Type)
 	}
@@ -184,16 +429,25 @@ func (s *GenericState) HandleAction(action string, data map[string]interface{})
 	// Clear previous errors
 	s.Errors = make(map[string]string)
 	s.Error = ""
+	s.refWritten = nil
 
 	// Normalize action to lowercase for matching
 	actionLower := strings.ToLower(action)
 
 	switch actionLower {
 	case "refresh":
+		// Refreshes follow changes the cache doesn't know about (e.g., edited
+		// files), also in the inputs of derived and query sources
+		invalidate(s.source)
 		return s.refresh()
+	case "reset":
+		if customAction, ok := s.actions[action]; ok {
+			return s.executeCustomAction(customAction, data)
+		}
+		return s.resetCircuit()
 	case "run":
 		return s.runExec(data)
-	case "add", "toggle", "delete", "update":
+	case "add", "toggle", "delete", "update", "move", "indent", "outdent":
 		return s.handleWriteAction(action, data)
 	default:
 		// Check for datatable actions (Sort_X, NextPage_X, PrevPage_X)
@@ -336,6 +590,12 @@ func convertNumber(v interface{}) interface{} {
 
 // Close releases any resources held by the source.
 func (s *GenericState) Close() error {
+	if s.cancelStream != nil {
+		s.cancelStream()
+	}
+	if s.related != nil {
+		s.related.Close()
+	}
 	if s.source != nil {
 		return s.source.Close()
 	}
@@ -348,23 +608,99 @@ func (s *GenericState) refresh() error {
 		// No source to refresh - this is valid for actions without a source binding
 		return nil
 	}
+	if s.streaming {
+		// Rows arrive through the stream
+		return nil
+	}
 
 	ctx := context.Background()
-	data, err := s.source.Fetch(ctx)
-	if err != nil {
+	data, fetchedAt, err := source.FetchTime(ctx, s.source)
+	s.fetched(fetchedAt, err)
+	if err != nil && !source.IsStale(err) {
 		s.Error = err.Error()
 		return err
 	}
 
+	s.setData(s.withRefLabels(data))
+	if err != nil {
+		// The rows are stale (e.g., expired cached rows); show why
+		s.Error = err.Error()
+	}
+	return nil
+}
+
+// withRefLabels adds the labels of referenced rows to rows, which come
+// coerced and transformed from the source
+func (s *GenericState) withRefLabels(rows []map[string]interface{}) []map[string]interface{} {
+	if len(s.refs) == 0 {
+		return rows
+	}
+	s.loadRefs()
+	return s.addRefLabels(rows)
+}
+
+// setData replaces the rows and the views derived from them
+func (s *GenericState) setData(data []map[string]interface{}) {
 	s.Data = data
+	s.Tree = buildTree(data)
 	s.Error = ""
 
 	// Build DataTable if this is a table element
 	if s.elementType == "table" {
 		s.Table = s.buildDataTable()
 	}
+}
 
-	return nil
+// buildTree returns the top-level rows of hierarchical data, with "children"
+// holding their nested rows. The tree is built from the final rows, matching
+// parent_id to id, so nested rows are coerced and transformed like top-level
+// ones; rows whose parent was filtered out become top-level rows. The tree
+// rows are copies, so rows shared with a cache are not modified.
+// Returns nil for flat data (rows without parent_id).
+func buildTree(data []map[string]interface{}) []map[string]interface{} {
+	ids := make(map[string]bool, len(data))
+	for _, row := range data {
+		if _, ok := row["parent_id"]; !ok {
+			return nil
+		}
+		ids[fmt.Sprint(row["id"])] = true
+	}
+
+	var roots []map[string]interface{}
+	children := make(map[string][]map[string]interface{})
+	for _, row := range data {
+		parentID := fmt.Sprint(row["parent_id"])
+		if parentID == "" || !ids[parentID] {
+			roots = append(roots, row)
+		} else {
+			children[parentID] = append(children[parentID], row)
+		}
+	}
+
+	var node func(row map[string]interface{}, seen map[string]bool) map[string]interface{}
+	node = func(row map[string]interface{}, seen map[string]bool) map[string]interface{} {
+		id := fmt.Sprint(row["id"])
+		copied := make(map[string]interface{}, len(row)+1)
+		for k, v := range row {
+			copied[k] = v
+		}
+		kids := []map[string]interface{}{}
+		if !seen[id] {
+			seen[id] = true
+			for _, child := range children[id] {
+				kids = append(kids, node(child, seen))
+			}
+		}
+		copied["children"] = kids
+		return copied
+	}
+
+	seen := make(map[string]bool)
+	tree := make([]map[string]interface{}, len(roots))
+	for i, row := range roots {
+		tree[i] = node(row, seen)
+	}
+	return tree
 }
 
 // buildDataTable creates a datatable.DataTable from the current Data
@@ -377,13 +713,9 @@ func (s *GenericState) buildDataTable() *datatable.DataTable {
 	var columns []datatable.Column
 	if len(s.tableColumns) > 0 {
 		for _, col := range s.tableColumns {
-			label := col
-			if len(label) > 0 {
-				label = strings.ToUpper(label[:1]) + label[1:]
-			}
 			columns = append(columns, datatable.Column{
 				ID:       col,
-				Label:    label,
+				Label:    s.columnLabel(col),
 				Sortable: true,
 			})
 		}
@@ -394,13 +726,13 @@ func (s *GenericState) buildDataTable() *datatable.DataTable {
 			if len(key) > 0 && key[0] >= 'A' && key[0] <= 'Z' {
 				continue
 			}
-			label := key
-			if len(label) > 0 {
-				label = strings.ToUpper(label[:1]) + label[1:]
+			// Ref fields show their labels in their own column
+			if s.isRefLabel(key) {
+				continue
 			}
 			columns = append(columns, datatable.Column{
 				ID:       key,
-				Label:    label,
+				Label:    s.columnLabel(key),
 				Sortable: true,
 			})
 		}
@@ -411,7 +743,10 @@ func (s *GenericState) buildDataTable() *datatable.DataTable {
 	for i, item := range s.Data {
 		data := make(map[string]any)
 		for _, col := range columns {
-			if val, ok := item[col.ID]; ok {
+			if label, ok := item[col.ID+refLabelSuffix]; ok && s.isRefLabel(col.ID+refLabelSuffix) {
+				// Show the referenced row's label instead of its id
+				data[col.ID] = label
+			} else if val, ok := item[col.ID]; ok {
 				data[col.ID] = val
 			} else {
 				// Try titlecase key
@@ -437,6 +772,18 @@ func (s *GenericState) buildDataTable() *datatable.DataTable {
 	return datatable.New(s.sourceName, datatable.WithColumns(columns), datatable.WithRows(rows))
 }
 
+// columnLabel returns the header of a table column: the label of the schema
+// field, or the column name with its first letter in upper case
+func (s *GenericState) columnLabel(col string) string {
+	if fs, ok := s.sourceCfg.Schema[col]; ok {
+		return schemaFieldLabel(col, fs)
+	}
+	if len(col) > 0 {
+		return strings.ToUpper(col[:1]) + col[1:]
+	}
+	return col
+}
+
 // parseExecArgs parses command-line arguments from a command string.
 // It extracts --flag value pairs and infers types from values.
 // Example: "./script.sh --name World --count 3 --verbose true"
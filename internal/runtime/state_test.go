@@ -0,0 +1,706 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+	"github.com/livetemplate/tinkerdown/internal/source"
+)
+
+// waitForLines waits until the state holds the given lines
+func waitForLines(t *testing.T, state *GenericState, updates <-chan struct{}, want ...string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		state.mu.RLock()
+		var got []string
+		for _, row := range state.Data {
+			got = append(got, row["line"].(string))
+		}
+		state.mu.RUnlock()
+
+		if len(got) == len(want) {
+			match := true
+			for i := range want {
+				match = match && got[i] == want[i]
+			}
+			if match {
+				return
+			}
+		}
+		select {
+		case <-updates:
+		case <-time.After(50 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("timed out waiting for %v, have %v", want, got)
+		}
+	}
+}
+
+func TestGenericState_StreamingSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+	os.WriteFile(logPath, []byte("one\ntwo\n"), 0644)
+
+	cfg := config.SourceConfig{Type: "tail", File: "app.log", Stream: &config.StreamConfig{MaxRows: 3}}
+	state, err := NewGenericState("log", cfg, tmpDir, "")
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	defer state.Close()
+
+	updates := make(chan struct{}, 100)
+	state.SetOnUpdate(func(apply func()) {
+		apply()
+		// The update callback renders the state, so the lock must be released
+		if _, err := state.GetStateAsInterface(); err != nil {
+			t.Errorf("GetStateAsInterface failed: %v", err)
+		}
+		select {
+		case updates <- struct{}{}:
+		default:
+		}
+	})
+	waitForLines(t, state, updates, "one", "two")
+
+	f, _ := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("three\nfour\n")
+	f.Close()
+	// max_rows drops the oldest line
+	waitForLines(t, state, updates, "two", "three", "four")
+
+	// Refreshing does not replace streamed rows
+	if err := state.HandleAction("refresh", nil); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	waitForLines(t, state, updates, "two", "three", "four")
+
+	// A second page shares the stream and starts with the replayed events
+	other, err := NewGenericState("log", cfg, tmpDir, "")
+	if err != nil {
+		t.Fatalf("failed to create second state: %v", err)
+	}
+	defer other.Close()
+	waitForLines(t, other, nil, "two", "three", "four")
+}
+
+// TestGenericState_StreamDuringActions runs actions while stream events arrive,
+// with the server's locking: events are applied under the lock that serializes
+// actions and renders. Run with -race.
+func TestGenericState_StreamDuringActions(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "app.log")
+	os.WriteFile(logPath, []byte("one\n"), 0644)
+
+	cfg := config.SourceConfig{Type: "tail", File: "app.log", Stream: &config.StreamConfig{MaxRows: 50}}
+	state, err := NewGenericStateWithMetadata("log", cfg, tmpDir, "", map[string]string{"lvt-element": "table"})
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	defer state.Close()
+
+	var mu sync.Mutex // The block instance's lock in the server
+	var applied atomic.Int32
+	state.SetOnUpdate(func(apply func()) {
+		mu.Lock()
+		apply()
+		mu.Unlock()
+		applied.Add(1)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f, _ := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+		defer f.Close()
+		for i := 0; i < 50; i++ {
+			fmt.Fprintf(f, "line %d\n", i)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	timeout := time.After(5 * time.Second)
+	for running := true; running || applied.Load() == 0; {
+		select {
+		case <-done:
+			running = false
+		case <-timeout:
+			t.Fatal("timed out waiting for stream events")
+		default:
+		}
+		mu.Lock()
+		state.HandleAction("Sort_line", nil)
+		// Renders read the fields directly
+		_ = len(state.Data) + len(state.Tree)
+		if state.Table != nil {
+			_ = state.Table.Rows
+		}
+		mu.Unlock()
+	}
+}
+
+func TestGenericState_TransformAndDerived(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "tickets.json"), []byte(`[
+		{"id": "1", "owner": "ada", "points": "3"},
+		{"id": "2", "owner": "grace", "points": "5"},
+		{"id": "3", "owner": "ada", "points": "8"}
+	]`), 0644)
+
+	limit := 1
+	sources := map[string]config.SourceConfig{
+		"tickets": {Type: "json", File: "tickets.json", Transform: []config.TransformStep{
+			{Cast: map[string]string{"points": "int"}},
+			{Rename: map[string]string{"owner": "login"}},
+		}},
+	}
+	lookup := func(name string) (config.SourceConfig, bool) {
+		cfg, ok := sources[name]
+		return cfg, ok
+	}
+
+	// The derived source reads the transformed tickets; its own transform runs last
+	cfg := config.SourceConfig{
+		Type:      "derived",
+		From:      "tickets",
+		GroupBy:   []string{"login"},
+		Aggregate: []string{"points = sum(points)"},
+		Transform: []config.TransformStep{{Sort: []string{"-points"}}, {Limit: &limit}},
+	}
+	state, err := NewGenericStateWithSources("totals", cfg, tmpDir, "", nil, lookup)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	defer state.Close()
+
+	if len(state.Data) != 1 || state.Data[0]["login"] != "ada" || state.Data[0]["points"] != 11.0 {
+		t.Errorf("unexpected data: %v (error %q)", state.Data, state.Error)
+	}
+
+	// Derived sources need the other sources' configs
+	if _, err := NewGenericState("totals", cfg, tmpDir, ""); err == nil {
+		t.Error("expected error without source configs")
+	}
+}
+
+func TestGenericState_Schema(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "tasks.csv"), []byte("id,title,points,status\n1,Ship,10,open\n2,Plan,9,done\n"), 0644)
+
+	readonly := false
+	maxPoints := 13.0
+	cfg := config.SourceConfig{
+		Type:     "csv",
+		File:     "tasks.csv",
+		Readonly: &readonly,
+		Schema: map[string]config.FieldSchema{
+			"title":  {Required: true, Unique: true},
+			"points": {Type: "int", Max: &maxPoints},
+			"status": {Type: "enum", Values: []string{"open", "done"}, Default: "open"},
+		},
+		Transform: []config.TransformStep{{Sort: []string{"points"}}},
+	}
+	state, err := NewGenericStateWithMetadata("tasks", cfg, tmpDir, "", map[string]string{"lvt-fields": "title,status"})
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	defer state.Close()
+
+	// CSV values are coerced before the transform sorts them
+	if len(state.Data) != 2 || state.Data[0]["points"] != 9 || state.Data[1]["points"] != 10 {
+		t.Errorf("unexpected data: %v (error %q)", state.Data, state.Error)
+	}
+
+	var names []string
+	for _, f := range state.Fields {
+		names = append(names, f.Name)
+	}
+	if len(names) != 3 || names[0] != "title" || names[1] != "status" || names[2] != "points" {
+		t.Errorf("unexpected field order: %v", names)
+	}
+	if f := state.Fields[2]; f.Input != "number" || f.Step != "1" || f.Max != "13" {
+		t.Errorf("unexpected points field: %+v", f)
+	}
+
+	// Invalid data is not written; the errors are shown with the form
+	err = state.HandleAction("add", map[string]interface{}{"title": "Ship", "points": "20"})
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if state.Errors["title"] != "Title must be unique" || state.Errors["points"] != "Points must be at most 13" {
+		t.Errorf("unexpected errors: %v", state.Errors)
+	}
+	if len(state.Data) != 2 {
+		t.Errorf("invalid row was written: %v", state.Data)
+	}
+
+	if err := state.HandleAction("add", map[string]interface{}{"title": "Test", "points": "1"}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if len(state.Errors) != 0 || len(state.Data) != 3 {
+		t.Fatalf("unexpected state: %v (errors %v)", state.Data, state.Errors)
+	}
+	if row := state.Data[0]; row["title"] != "Test" || row["points"] != 1 || row["status"] != "open" {
+		t.Errorf("unexpected row: %v", row)
+	}
+}
+
+func TestGenericState_TreeWithSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "runbook.md"), []byte(`# Runbook {#steps}
+
+- [ ] Prepare points:3 <!-- id:p1 -->
+  - [x] Drain node points:1 <!-- id:c1 -->
+  - [ ] Snapshot disk points:2 <!-- id:c2 -->
+    - [ ] Verify snapshot points:5 <!-- id:g1 -->
+- [ ] Deploy points:8 <!-- id:p2 -->
+`), 0644)
+
+	cfg := config.SourceConfig{
+		Type:      "markdown",
+		File:      "runbook.md",
+		Anchor:    "#steps",
+		Options:   map[string]string{"inline_metadata": "true"},
+		Schema:    map[string]config.FieldSchema{"points": {Type: "int"}},
+		Transform: []config.TransformStep{{Filter: []string{"done == false"}}},
+	}
+	state, err := NewGenericState("steps", cfg, tmpDir, "")
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	defer state.Close()
+
+	if len(state.Tree) != 2 {
+		t.Fatalf("expected 2 top-level rows, got %v (error %q)", state.Tree, state.Error)
+	}
+	// Nested rows are the coerced rows; the filtered-out child is gone
+	children := state.Tree[0]["children"].([]map[string]interface{})
+	if len(children) != 1 || children[0]["id"] != "c2" || children[0]["points"] != 2 {
+		t.Fatalf("unexpected children: %v", children)
+	}
+	grandchildren := children[0]["children"].([]map[string]interface{})
+	if len(grandchildren) != 1 || grandchildren[0]["points"] != 5 {
+		t.Errorf("unexpected grandchildren: %v", grandchildren)
+	}
+
+	// The flat rows are not modified by the tree
+	for _, row := range state.Data {
+		if _, ok := row["children"]; ok {
+			t.Errorf("row %v should not have children", row["id"])
+		}
+	}
+}
+
+func TestGenericState_Refs(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "users.csv"), []byte("id,name\n1,Ada\n2,Grace\n3,Alan\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "tasks.csv"), []byte("id,title,owner_id,reviewer_id\n1,Ship,1,2\n2,Plan,2,\n3,Test,1,\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "notes.csv"), []byte("id,text,task_id\n1,Soon,1\n"), 0644)
+
+	readonly := false
+	sources := map[string]config.SourceConfig{
+		"users": {Type: "csv", File: "users.csv", Readonly: &readonly},
+		"tasks": {Type: "csv", File: "tasks.csv", Readonly: &readonly, Schema: map[string]config.FieldSchema{
+			"title":       {Required: true},
+			"owner_id":    {Ref: "users", Label: "Owner", OnDelete: "cascade"},
+			"reviewer_id": {Ref: "users", OnDelete: "nullify"},
+		}},
+		"notes": {Type: "csv", File: "notes.csv", Readonly: &readonly, Schema: map[string]config.FieldSchema{
+			"task_id": {Ref: "tasks", Display: "title"},
+		}},
+	}
+	lookup := func(name string) (config.SourceConfig, bool) {
+		cfg, ok := sources[name]
+		return cfg, ok
+	}
+	names := func() []string { return []string{"notes", "tasks", "users"} }
+	newState := func(name string) *GenericState {
+		t.Helper()
+		state, err := NewGenericStateWithSources(name, sources[name], tmpDir, "", nil, lookup)
+		if err != nil {
+			t.Fatalf("failed to create state: %v", err)
+		}
+		state.SetSourceNames(names)
+		t.Cleanup(func() { state.Close() })
+		return state
+	}
+
+	tasks := newState("tasks")
+	if row := tasks.Data[0]; row["owner_id_label"] != "Ada" || row["reviewer_id_label"] != "Grace" {
+		t.Errorf("unexpected labels: %v", row)
+	}
+	if _, ok := tasks.Data[1]["reviewer_id_label"]; ok {
+		t.Errorf("empty ref has a label: %v", tasks.Data[1])
+	}
+	var owner Field
+	for _, f := range tasks.Fields {
+		if f.Name == "owner_id" {
+			owner = f
+		}
+	}
+	if owner.Input != "select" || len(owner.Options) != 3 || owner.Options[1] != (Option{Value: "2", Label: "Grace"}) {
+		t.Errorf("unexpected owner field: %+v", owner)
+	}
+
+	// Refs must point to existing rows
+	if err := tasks.HandleAction("add", map[string]interface{}{"title": "Docs", "owner_id": "9"}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if tasks.Errors["owner_id"] != "Owner must refer to a row of users" || len(tasks.Data) != 3 {
+		t.Errorf("unexpected errors: %v", tasks.Errors)
+	}
+
+	// Notes restrict deleting their task
+	if err := tasks.HandleAction("delete", map[string]interface{}{"id": "1"}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if tasks.Error != "cannot delete: a row of notes refers to it" || len(tasks.Data) != 3 {
+		t.Errorf("delete was not restricted: %q, %v", tasks.Error, tasks.Data)
+	}
+
+	// Deleting Grace clears her reviews and deletes her tasks
+	users := newState("users")
+	if err := users.HandleAction("delete", map[string]interface{}{"id": "2"}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if users.Error != "" || len(users.Data) != 2 {
+		t.Fatalf("unexpected users: %v (error %q)", users.Data, users.Error)
+	}
+	content, _ := os.ReadFile(filepath.Join(tmpDir, "tasks.csv"))
+	if got, want := string(content), "id,title,owner_id,reviewer_id\n1,Ship,1,\n3,Test,1,\n"; got != want {
+		t.Errorf("tasks.csv = %q, want %q", got, want)
+	}
+
+	// Deleting Ada would cascade to task 1, which notes restrict
+	if err := users.HandleAction("delete", map[string]interface{}{"id": "1"}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if users.Error != "cannot delete: a row of notes refers to it" || len(users.Data) != 2 {
+		t.Errorf("delete was not restricted: %q", users.Error)
+	}
+}
+
+func TestGenericState_RefsNotWritable(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "users.csv"), []byte("id,name\n1,Ada\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "tasks.csv"), []byte("id,title,owner_id\n1,Ship,1\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "views.csv"), []byte("id,user_id\n1,1\n"), 0644)
+
+	readonly := false
+	sources := map[string]config.SourceConfig{
+		"users": {Type: "csv", File: "users.csv", Readonly: &readonly},
+		"tasks": {Type: "csv", File: "tasks.csv", Readonly: &readonly, Schema: map[string]config.FieldSchema{
+			"owner_id": {Ref: "users", OnDelete: "cascade"},
+		}},
+		"views": {Type: "csv", File: "views.csv", Schema: map[string]config.FieldSchema{
+			"user_id": {Ref: "users", OnDelete: "nullify"},
+		}},
+	}
+	state, err := NewGenericStateWithSources("users", sources["users"], tmpDir, "", nil, func(name string) (config.SourceConfig, bool) {
+		cfg, ok := sources[name]
+		return cfg, ok
+	})
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	defer state.Close()
+	state.SetSourceNames(func() []string { return []string{"tasks", "users", "views"} })
+
+	// views is read-only, so nothing is written, not even the cascade to tasks
+	if err := state.HandleAction("delete", map[string]interface{}{"id": "1"}); err == nil {
+		t.Fatal("expected delete to fail")
+	}
+	if !strings.Contains(state.Error, `source "views" referring to it is not writable`) {
+		t.Errorf("unexpected error: %q", state.Error)
+	}
+	for file, want := range map[string]string{"users.csv": "id,name\n1,Ada\n", "tasks.csv": "id,title,owner_id\n1,Ship,1\n"} {
+		if content, _ := os.ReadFile(filepath.Join(tmpDir, file)); string(content) != want {
+			t.Errorf("%s = %q, want %q", file, content, want)
+		}
+	}
+}
+
+func TestGenericState_RefsCascadeCached(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "users.csv"), []byte("id,name\n1,Ada\n2,Grace\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "tasks.csv"), []byte("id,title,owner_id\n1,Ship,1\n2,Plan,2\n"), 0644)
+
+	siteCache, err := source.SharedCache(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("SharedCache failed: %v", err)
+	}
+	readonly := false
+	sources := map[string]config.SourceConfig{
+		"users": {Type: "csv", File: "users.csv", Readonly: &readonly},
+		"tasks": {Type: "csv", File: "tasks.csv", Readonly: &readonly, Cache: &config.CacheConfig{TTL: "1h"}, Schema: map[string]config.FieldSchema{
+			"owner_id": {Ref: "users", OnDelete: "cascade"},
+		}},
+	}
+	lookup := func(name string) (config.SourceConfig, bool) {
+		cfg, ok := sources[name]
+		return cfg, ok
+	}
+	newState := func(name string) *GenericState {
+		t.Helper()
+		state, err := NewGenericStateWithCache(name, sources[name], tmpDir, "", nil, lookup, siteCache)
+		if err != nil {
+			t.Fatalf("failed to create state: %v", err)
+		}
+		state.SetSourceNames(func() []string { return []string{"tasks", "users"} })
+		t.Cleanup(func() { state.Close() })
+		return state
+	}
+
+	// A block shows the cached tasks while another deletes Grace
+	newState("tasks")
+	users := newState("users")
+	if err := users.HandleAction("delete", map[string]interface{}{"id": "2"}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if got := users.RefWrittenSources(); len(got) != 1 || got[0] != "tasks" {
+		t.Errorf("RefWrittenSources() = %v, want [tasks]", got)
+	}
+
+	// The cascade dropped the cached tasks, so her task is gone everywhere
+	if tasks := newState("tasks"); len(tasks.Data) != 1 || tasks.Data[0]["title"] != "Ship" {
+		t.Errorf("expected the cascaded task gone from the cache, got %v", tasks.Data)
+	}
+
+	// Other actions write no other sources
+	if err := users.HandleAction("add", map[string]interface{}{"id": "3", "name": "Alan"}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if got := users.RefWrittenSources(); got != nil {
+		t.Errorf("RefWrittenSources() after add = %v, want none", got)
+	}
+}
+
+func TestPartialDeleteError(t *testing.T) {
+	err := &partialDeleteError{source: "notes", changed: []string{"deleted 2 rows of tasks"}, err: errors.New("disk full")}
+	if got, want := err.Error(), `deleted the row, but failed to update "notes": disk full (already changed: deleted 2 rows of tasks)`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestGenericState_Cache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer server.Close()
+
+	siteDir := t.TempDir()
+	siteCache, err := source.SharedCache(siteDir, &config.CacheBackendConfig{Backend: "disk"})
+	if err != nil {
+		t.Fatalf("SharedCache failed: %v", err)
+	}
+	cfg := config.SourceConfig{Type: "rest", From: server.URL, Cache: &config.CacheConfig{TTL: "1h"}}
+	newState := func(cfg config.SourceConfig) *GenericState {
+		t.Helper()
+		state, err := NewGenericStateWithCache("api", cfg, siteDir, "", nil, nil, siteCache)
+		if err != nil {
+			t.Fatalf("failed to create state: %v", err)
+		}
+		t.Cleanup(func() { state.Close() })
+		return state
+	}
+
+	// Pages share the cached rows
+	newState(cfg)
+	second := newState(cfg)
+	if n := requests.Load(); n != 1 || len(second.Data) != 1 {
+		t.Errorf("expected 1 request and cached rows, got %d requests and %v", n, second.Data)
+	}
+
+	// Refresh bypasses the cache
+	if err := second.HandleAction("Refresh", nil); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected refresh to fetch, got %d requests", n)
+	}
+
+	// A changed config doesn't read the rows cached with the old one
+	cfg.QueryParams = map[string]string{"page": "2"}
+	newState(cfg)
+	if n := requests.Load(); n != 3 {
+		t.Errorf("expected a changed config to fetch, got %d requests", n)
+	}
+}
+
+func TestGenericState_CacheServesStale(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer server.Close()
+
+	siteDir := t.TempDir()
+	siteCache, err := source.SharedCache(siteDir, &config.CacheBackendConfig{Backend: "disk"})
+	if err != nil {
+		t.Fatalf("SharedCache failed: %v", err)
+	}
+	cfg := config.SourceConfig{Type: "rest", From: server.URL, Retry: &config.RetryConfig{MaxRetries: 0}, Cache: &config.CacheConfig{TTL: "200ms"}}
+	newState := func() *GenericState {
+		t.Helper()
+		state, err := NewGenericStateWithCache("api", cfg, siteDir, "", nil, nil, siteCache)
+		if err != nil {
+			t.Fatalf("failed to create state: %v", err)
+		}
+		t.Cleanup(func() { state.Close() })
+		return state
+	}
+
+	first := newState()
+
+	// Cached rows keep the time they were fetched
+	hit := newState()
+	if !hit.fetchedAt.Equal(first.fetchedAt) || hit.UpdatedAt != first.UpdatedAt {
+		t.Errorf("expected the fetch time of the cached rows %v, got %v", first.fetchedAt, hit.fetchedAt)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	failing.Store(true)
+
+	// The expired rows are shown with the failure and their age
+	state := newState()
+	if len(state.Data) != 1 {
+		t.Errorf("expected the expired rows, got %v", state.Data)
+	}
+	if !strings.Contains(state.Error, "showing cached data") || !strings.Contains(state.Error, "503") {
+		t.Errorf("expected the failure to be shown, got %q", state.Error)
+	}
+	if !state.fetchedAt.Equal(first.fetchedAt) || state.UpdatedAt != first.UpdatedAt {
+		t.Errorf("expected the fetch time of the cached rows %v, got %v", first.fetchedAt, state.fetchedAt)
+	}
+	if state.DataAge == "" {
+		t.Error("expected DataAge to be set while the cached rows are shown")
+	}
+}
+
+func TestGenericState_CachedInputs(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`[{"id": 1}, {"id": 2}]`))
+	}))
+	defer server.Close()
+
+	siteDir := t.TempDir()
+	siteCache, err := source.SharedCache(siteDir, nil)
+	if err != nil {
+		t.Fatalf("SharedCache failed: %v", err)
+	}
+	sources := map[string]config.SourceConfig{
+		"api": {Type: "rest", From: server.URL, Cache: &config.CacheConfig{TTL: "1h"}},
+	}
+	lookup := func(name string) (config.SourceConfig, bool) {
+		cfg, ok := sources[name]
+		return cfg, ok
+	}
+	cfg := config.SourceConfig{Type: "query", Tables: []string{"api"}, Query: "SELECT count(*) AS n FROM api"}
+	newState := func() *GenericState {
+		t.Helper()
+		state, err := NewGenericStateWithCache("counts", cfg, siteDir, "", nil, lookup, siteCache)
+		if err != nil {
+			t.Fatalf("failed to create state: %v", err)
+		}
+		t.Cleanup(func() { state.Close() })
+		return state
+	}
+
+	// Pages share the cached rows of the query's inputs
+	newState()
+	second := newState()
+	if n := requests.Load(); n != 1 || len(second.Data) != 1 || second.Data[0]["n"] != int64(2) {
+		t.Errorf("expected 1 request and the counted rows, got %d requests and %v (error %q)", n, second.Data, second.Error)
+	}
+
+	// Refreshing the query refetches its inputs
+	if err := second.HandleAction("Refresh", nil); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected refresh to fetch the input, got %d requests", n)
+	}
+}
+
+func TestGenericState_Circuit(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer server.Close()
+
+	cfg := config.SourceConfig{
+		Type:    "rest",
+		From:    server.URL,
+		Retry:   &config.RetryConfig{MaxRetries: 0},
+		Circuit: &config.CircuitBreakerConfig{FailureThreshold: 1, Timeout: "1h"},
+	}
+	state, err := NewGenericState("api", cfg, t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	defer state.Close()
+	if state.CircuitState != "closed" || state.UpdatedAt == "" || state.DataAge != "" {
+		t.Errorf("unexpected initial state: %q, %q, %q", state.CircuitState, state.UpdatedAt, state.DataAge)
+	}
+
+	// The rows are kept while the source fails
+	failing.Store(true)
+	if err := state.HandleAction("Refresh", nil); err == nil {
+		t.Fatal("expected refresh to fail")
+	}
+	if state.CircuitState != "open" || !strings.Contains(state.CircuitError, "503") || state.DataAge != "0s" {
+		t.Errorf("unexpected failed state: %q, %q, %q", state.CircuitState, state.CircuitError, state.DataAge)
+	}
+	if len(state.Data) != 1 {
+		t.Errorf("expected the previous rows, got %v", state.Data)
+	}
+
+	// The open circuit blocks refreshes until reset
+	failing.Store(false)
+	if err := state.HandleAction("Refresh", nil); err == nil {
+		t.Error("expected refresh to fail while the circuit is open")
+	}
+	if err := state.HandleAction("Reset", nil); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if state.CircuitState != "closed" || state.CircuitError != "" || state.DataAge != "" || state.Error != "" {
+		t.Errorf("unexpected state after reset: %q, %q, %q, %q", state.CircuitState, state.CircuitError, state.DataAge, state.Error)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		45 * time.Second:               "45s",
+		5*time.Minute + 10*time.Second: "5m",
+		2 * time.Hour:                  "2h",
+		75 * time.Hour:                 "3d",
+	}
+	for d, want := range tests {
+		if got := formatAge(d); got != want {
+			t.Errorf("formatAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
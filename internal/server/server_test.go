@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/livetemplate/tinkerdown"
 )
 
 func TestMdToPattern(t *testing.T) {
@@ -466,3 +468,134 @@ func TestServeWebSocketEmptyRoutes(t *testing.T) {
 		t.Errorf("Expected 'No pages available' error, got: %s", body)
 	}
 }
+
+func TestWebSocketHandlerPageSourceSettings(t *testing.T) {
+	h := &WebSocketHandler{
+		page: &tinkerdown.Page{Config: tinkerdown.PageConfig{
+			Sources: map[string]tinkerdown.SourceConfig{
+				"api": {
+					Type:       "rest",
+					From:       "https://api.example.com/items",
+					Pagination: &tinkerdown.PaginationConfig{Type: "cursor", CursorPath: "meta.next"},
+					Auth:       &tinkerdown.AuthConfig{Type: "bearer", Token: "secret"},
+					Cache:      &tinkerdown.CacheConfig{TTL: "5m"},
+					RateLimit:  &tinkerdown.RateLimitConfig{Requests: 10},
+					Circuit:    &tinkerdown.CircuitBreakerConfig{FailureThreshold: 3},
+					Endpoints:  map[string]tinkerdown.RestEndpoint{"delete": {URL: "/items/{id}"}},
+					Timeout:    "5s",
+					Retry:      &tinkerdown.RetryConfig{MaxRetries: 1},
+				},
+				"events": {
+					Type:   "sse",
+					From:   "https://api.example.com/events",
+					Stream: &tinkerdown.StreamConfig{Mode: "replace", MaxRows: 50, Send: "subscribe"},
+				},
+				"report": {
+					Type: "exec",
+					Cmd:  "./report.sh",
+					Env:  map[string]string{"REGION": "eu"},
+				},
+				"issues": {
+					Type:      "graphql",
+					From:      "https://api.example.com/graphql",
+					QueryFile: "issues.graphql",
+					Variables: map[string]interface{}{"owner": "livetemplate"},
+					Mutations: map[string]tinkerdown.GraphQLMutation{"add": {File: "mutations.graphql", Operation: "CreateIssue"}},
+				},
+			},
+		}},
+	}
+
+	// Page-level sources keep the settings of site-level ones
+	cfg, ok := h.getEffectiveSource("api")
+	if !ok {
+		t.Fatal("source not found")
+	}
+	if cfg.Pagination == nil || cfg.Pagination.CursorPath != "meta.next" || cfg.Auth == nil || cfg.Auth.Token != "secret" ||
+		cfg.Cache == nil || cfg.Cache.TTL != "5m" || cfg.RateLimit == nil || cfg.RateLimit.Requests != 10 ||
+		cfg.Circuit == nil || cfg.Circuit.FailureThreshold != 3 || cfg.Endpoints["delete"].URL != "/items/{id}" {
+		t.Errorf("settings not copied: %+v", cfg)
+	}
+	if cfg.Timeout != "5s" || cfg.Retry == nil || cfg.Retry.MaxRetries != 1 {
+		t.Errorf("timeout and retry not copied: %+v", cfg)
+	}
+
+	// Page-defined stream sources keep their stream settings
+	cfg, _ = h.getEffectiveSource("events")
+	if cfg.Stream == nil || cfg.Stream.GetMode() != "replace" || cfg.Stream.GetMaxRows() != 50 || cfg.Stream.Send != "subscribe" {
+		t.Errorf("stream settings not copied: %+v", cfg.Stream)
+	}
+	cfg, _ = h.getEffectiveSource("report")
+	if cfg.Env["REGION"] != "eu" {
+		t.Errorf("env not copied: %+v", cfg.Env)
+	}
+	cfg, _ = h.getEffectiveSource("issues")
+	if cfg.QueryFile != "issues.graphql" || cfg.Variables["owner"] != "livetemplate" ||
+		cfg.Mutations["add"].File != "mutations.graphql" || cfg.Mutations["add"].Operation != "CreateIssue" {
+		t.Errorf("graphql settings not copied: %+v", cfg)
+	}
+}
+
+func TestWebSocketHandlerSourceDependencies(t *testing.T) {
+	rootDir := t.TempDir()
+	h := &WebSocketHandler{
+		page: &tinkerdown.Page{Config: tinkerdown.PageConfig{
+			Sources: map[string]tinkerdown.SourceConfig{
+				"tasks":   {Type: "markdown", File: "tasks.md"},
+				"notes":   {Type: "markdown", File: "notes.md"},
+				"open":    {Type: "derived", From: "tasks"},
+				"summary": {Type: "query", Tables: []string{"open", "notes"}, Query: "SELECT * FROM open"},
+			},
+			Actions: map[string]tinkerdown.Action{"archive": {Kind: "sql", Source: "notes"}},
+		}},
+		sourceFiles: make(map[string][]string),
+		rootDir:     rootDir,
+	}
+
+	cfg, _ := h.getEffectiveSource("summary")
+	h.trackSourceFiles("block", cfg, filepath.Join(rootDir, "index.md"), map[string]bool{"summary": true})
+	if got := strings.Join(h.sourceFiles["block"], ","); got != "tasks.md,notes.md" {
+		t.Errorf("tracked files = %q, want tasks.md,notes.md", got)
+	}
+
+	for _, tt := range []struct {
+		name, target string
+		want         bool
+	}{
+		{"summary", "tasks", true},
+		{"open", "tasks", true},
+		{"open", "notes", false},
+		{"tasks", "tasks", true},
+		{"notes", "tasks", false},
+	} {
+		if got := h.readsSource(tt.name, tt.target, make(map[string]bool)); got != tt.want {
+			t.Errorf("readsSource(%q, %q) = %v, want %v", tt.name, tt.target, got, tt.want)
+		}
+	}
+
+	instance := &BlockInstance{source: "tasks"}
+	if got := h.writtenSources(instance, "Toggle"); len(got) != 1 || got[0] != "tasks" {
+		t.Errorf("writtenSources(Toggle) = %v", got)
+	}
+	if got := h.writtenSources(instance, "archive"); len(got) != 1 || got[0] != "notes" {
+		t.Errorf("writtenSources(archive) = %v", got)
+	}
+	if got := h.writtenSources(instance, "Sort_title"); got != nil {
+		t.Errorf("writtenSources(Sort_title) = %v", got)
+	}
+
+	// Deletes also wrote the sources their on_delete rules cascaded into
+	instance = &BlockInstance{source: "users", state: &refWritingState{written: []string{"tasks"}}}
+	if got := strings.Join(h.writtenSources(instance, "Delete"), ","); got != "users,tasks" {
+		t.Errorf("writtenSources(Delete) = %q, want users,tasks", got)
+	}
+}
+
+// refWritingState is a state whose last delete cascaded into other sources
+type refWritingState struct {
+	written []string
+}
+
+func (s *refWritingState) HandleAction(string, map[string]interface{}) error { return nil }
+func (s *refWritingState) Close() error                                      { return nil }
+func (s *refWritingState) RefWrittenSources() []string                       { return s.written }
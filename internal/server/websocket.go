@@ -9,6 +9,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/gorilla/websocket"
@@ -56,12 +59,14 @@ type WebSocketHandler struct {
 	rootDir        string                          // Site root directory for database path
 	config         *config.Config                  // Site configuration with sources
 	conn           *websocket.Conn                 // Current connection for this handler
+	writeMu        sync.Mutex                      // Serializes writes to the connection
 	actionSources  map[string]source.Source       // Cached sources for custom actions
 }
 
 // BlockInstance represents a running LiveTemplate instance for an interactive block.
 type BlockInstance struct {
 	blockID  string
+	source   string // Name of the block's lvt-source
 	state    runtime.Store
 	template *livetemplate.Template
 	conn     *websocket.Conn
@@ -136,6 +141,16 @@ func (h *WebSocketHandler) initializeSourceBlocks() {
 		log.Printf("[WS] All server block IDs: %v", blockIDs)
 	}
 
+	// Sources with cache settings share the site's cache across pages
+	var cacheCfg *config.CacheBackendConfig
+	if h.config != nil {
+		cacheCfg = h.config.Cache
+	}
+	siteCache, err := source.SharedCache(h.rootDir, cacheCfg)
+	if err != nil {
+		log.Printf("[WS] Failed to open the source cache, caching is disabled: %v", err)
+	}
+
 	for blockID, block := range h.page.ServerBlocks {
 		if h.debug {
 			log.Printf("[WS] Processing server block: %s (metadata: %v)", blockID, block.Metadata)
@@ -179,7 +194,7 @@ func (h *WebSocketHandler) initializeSourceBlocks() {
 		pageActions := h.getPageActions()
 
 		factory := func() runtime.Store {
-			state, err := runtime.NewGenericStateWithMetadata(srcName, srcCfg, rootDir, curFile, blockMeta)
+			state, err := runtime.NewGenericStateWithCache(srcName, srcCfg, rootDir, curFile, blockMeta, h.getEffectiveSource, siteCache)
 			if err != nil {
 				log.Printf("[WS] Failed to create runtime state for %s: %v", srcName, err)
 				return nil
@@ -189,6 +204,7 @@ func (h *WebSocketHandler) initializeSourceBlocks() {
 			if len(pageActions) > 0 {
 				state.SetPageConfig(pageActions, h.lookupSource)
 			}
+			state.SetSourceNames(h.sourceNames)
 
 			return state
 		}
@@ -196,35 +212,7 @@ func (h *WebSocketHandler) initializeSourceBlocks() {
 		h.stateFactories[blockID] = factory
 
 		// Track source files for markdown sources (for live refresh)
-		if sourceCfg.Type == "markdown" {
-			var sourceFilePath string
-			if sourceCfg.File != "" {
-				// External file - resolve relative to root or current file
-				if filepath.IsAbs(sourceCfg.File) {
-					sourceFilePath = sourceCfg.File
-				} else {
-					// Try relative to current file first, then root
-					if currentFile != "" {
-						sourceFilePath = filepath.Join(filepath.Dir(currentFile), sourceCfg.File)
-					} else {
-						sourceFilePath = filepath.Join(h.rootDir, sourceCfg.File)
-					}
-				}
-			} else {
-				// Same-file source
-				sourceFilePath = currentFile
-			}
-			if sourceFilePath != "" {
-				// Make path relative to rootDir for consistent matching with watcher events
-				if relPath, err := filepath.Rel(h.rootDir, sourceFilePath); err == nil {
-					sourceFilePath = relPath
-				}
-				h.sourceFiles[blockID] = append(h.sourceFiles[blockID], sourceFilePath)
-				if h.debug {
-					log.Printf("[WS] Block %s tracks source file: %s", blockID, sourceFilePath)
-				}
-			}
-		}
+		h.trackSourceFiles(blockID, sourceCfg, currentFile, map[string]bool{sourceName: true})
 
 		if h.debug {
 			log.Printf("[WS] Successfully initialized block: %s", blockID)
@@ -241,12 +229,182 @@ func (h *WebSocketHandler) initializeSourceBlocks() {
 	}
 }
 
+// trackSourceFiles records the markdown files a block's source reads, so the
+// block is refreshed when they change. Derived and query sources read the files
+// of their inputs.
+func (h *WebSocketHandler) trackSourceFiles(blockID string, sourceCfg config.SourceConfig, currentFile string, visited map[string]bool) {
+	if sourceCfg.Type == "markdown" {
+		var sourceFilePath string
+		if sourceCfg.File != "" {
+			// External file - resolve relative to root or current file
+			if filepath.IsAbs(sourceCfg.File) {
+				sourceFilePath = sourceCfg.File
+			} else {
+				// Try relative to current file first, then root
+				if currentFile != "" {
+					sourceFilePath = filepath.Join(filepath.Dir(currentFile), sourceCfg.File)
+				} else {
+					sourceFilePath = filepath.Join(h.rootDir, sourceCfg.File)
+				}
+			}
+		} else {
+			// Same-file source
+			sourceFilePath = currentFile
+		}
+		if sourceFilePath != "" {
+			// Make path relative to rootDir for consistent matching with watcher events
+			if relPath, err := filepath.Rel(h.rootDir, sourceFilePath); err == nil {
+				sourceFilePath = relPath
+			}
+			if !slices.Contains(h.sourceFiles[blockID], sourceFilePath) {
+				h.sourceFiles[blockID] = append(h.sourceFiles[blockID], sourceFilePath)
+			}
+			if h.debug {
+				log.Printf("[WS] Block %s tracks source file: %s", blockID, sourceFilePath)
+			}
+		}
+	}
+
+	for _, input := range source.SourceInputs(sourceCfg) {
+		if visited[input] {
+			continue
+		}
+		visited[input] = true
+		if inputCfg, ok := h.getEffectiveSource(input); ok {
+			h.trackSourceFiles(blockID, inputCfg, currentFile, visited)
+		}
+	}
+}
+
+// readsSource reports whether a source is, or (through derived and query
+// sources) reads, the target source
+func (h *WebSocketHandler) readsSource(name, target string, visited map[string]bool) bool {
+	if name == target {
+		return true
+	}
+	if visited[name] {
+		return false
+	}
+	visited[name] = true
+	cfg, ok := h.getEffectiveSource(name)
+	if !ok {
+		return false
+	}
+	for _, input := range source.SourceInputs(cfg) {
+		if h.readsSource(input, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// writtenSources returns the sources an action may have written to: the block's
+// own source for write actions (plus the sources a delete cascaded into), and
+// the source of a custom action
+func (h *WebSocketHandler) writtenSources(instance *BlockInstance, action string) []string {
+	switch strings.ToLower(action) {
+	case "add", "toggle", "delete", "update", "move", "indent", "outdent":
+		written := []string{instance.source}
+		if refs, ok := instance.state.(interface{ RefWrittenSources() []string }); ok {
+			written = append(written, refs.RefWrittenSources()...)
+		}
+		return written
+	}
+	if h.page == nil {
+		return nil
+	}
+	if custom, ok := h.page.Config.Actions[action]; ok {
+		if custom.Source != "" {
+			return []string{custom.Source}
+		}
+		return []string{instance.source}
+	}
+	return nil
+}
+
+// refreshDependents refreshes the other blocks whose source is, or reads, one
+// of the written sources, so derived and query blocks follow writes to their inputs
+func (h *WebSocketHandler) refreshDependents(written *BlockInstance, sources []string) {
+	h.mu.RLock()
+	var dependents []*BlockInstance
+	for _, instance := range h.instances {
+		if instance == written || instance.source == "" {
+			continue
+		}
+		for _, name := range sources {
+			if name != "" && h.readsSource(instance.source, name, make(map[string]bool)) {
+				dependents = append(dependents, instance)
+				break
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, instance := range dependents {
+		if err := h.handleAction(instance, "Refresh", nil); err != nil {
+			log.Printf("[WS] Failed to refresh block %s: %v", instance.blockID, err)
+			continue
+		}
+		h.sendUpdate(instance)
+	}
+}
+
+// sourceNames returns the names of the page-level and site-level sources, sorted
+func (h *WebSocketHandler) sourceNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	if h.page != nil {
+		for name := range h.page.Config.Sources {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if h.config != nil {
+		for name := range h.config.Sources {
+			if !seen[name] {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // getEffectiveSource looks up a source by name, checking page-level sources first
 // (from frontmatter), then falling back to site-level sources (from tinkerdown.yaml).
 func (h *WebSocketHandler) getEffectiveSource(name string) (config.SourceConfig, bool) {
 	// Check page-level sources first (from frontmatter)
 	if h.page != nil && h.page.Config.Sources != nil {
 		if src, ok := h.page.Config.Sources[name]; ok {
+			var joins []config.JoinConfig
+			for _, j := range src.Join {
+				joins = append(joins, config.JoinConfig{Source: j.Source, On: j.On, Type: j.Type, Prefix: j.Prefix})
+			}
+			var transform []config.TransformStep
+			for _, step := range src.Transform {
+				transform = append(transform, config.TransformStep(step))
+			}
+			var schema map[string]config.FieldSchema
+			if src.Schema != nil {
+				schema = make(map[string]config.FieldSchema, len(src.Schema))
+				for field, fs := range src.Schema {
+					schema[field] = config.FieldSchema(fs)
+				}
+			}
+			var endpoints map[string]config.RestEndpoint
+			if src.Endpoints != nil {
+				endpoints = make(map[string]config.RestEndpoint, len(src.Endpoints))
+				for action, ep := range src.Endpoints {
+					endpoints[action] = config.RestEndpoint(ep)
+				}
+			}
+			var mutations map[string]config.GraphQLMutation
+			if src.Mutations != nil {
+				mutations = make(map[string]config.GraphQLMutation, len(src.Mutations))
+				for action, m := range src.Mutations {
+					mutations[action] = config.GraphQLMutation(m)
+				}
+			}
 			// Convert tinkerdown.SourceConfig to config.SourceConfig
 			return config.SourceConfig{
 				Type:        src.Type,
@@ -255,15 +413,41 @@ func (h *WebSocketHandler) getEffectiveSource(name string) (config.SourceConfig,
 				From:        src.From,
 				File:        src.File,
 				Anchor:      src.Anchor,
+				Glob:        src.Glob,
 				DB:          src.DB,
 				Table:       src.Table,
 				Path:        src.Path,
 				Headers:     src.Headers,
 				QueryParams: src.QueryParams,
+				QueryFile:   src.QueryFile,
+				Variables:   src.Variables,
 				ResultPath:  src.ResultPath,
+				Format:      src.Format,
+				Delimiter:   src.Delimiter,
 				Readonly:    src.Readonly,
 				Options:     src.Options,
 				Manual:      src.Manual,
+				Env:         src.Env,
+				Timeout:     src.Timeout,
+				Retry:       (*config.RetryConfig)(src.Retry),
+				Stream:      (*config.StreamConfig)(src.Stream),
+				Filter:      src.Filter,
+				Columns:     src.Columns,
+				Union:       src.Union,
+				Join:        joins,
+				Fields:      src.Fields,
+				GroupBy:     src.GroupBy,
+				Aggregate:   src.Aggregate,
+				Tables:      src.Tables,
+				Transform:   transform,
+				Schema:      schema,
+				RateLimit:   (*config.RateLimitConfig)(src.RateLimit),
+				Circuit:     (*config.CircuitBreakerConfig)(src.Circuit),
+				Cache:       (*config.CacheConfig)(src.Cache),
+				Pagination:  (*config.PaginationConfig)(src.Pagination),
+				Auth:        (*config.AuthConfig)(src.Auth),
+				Endpoints:   endpoints,
+				Mutations:   mutations,
 			}, true
 		}
 	}
@@ -296,6 +480,8 @@ func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			h.server.UnregisterConnection(conn)
 		}
 		conn.Close()
+		// The handler belongs to this connection: stop streams and release sources
+		h.Close()
 	}()
 
 	// Register connection for reload broadcasts (with handler for source refresh)
@@ -382,6 +568,7 @@ func (h *WebSocketHandler) initializeInstances(conn *websocket.Conn) {
 
 		instance := &BlockInstance{
 			blockID:  blockID,
+			source:   stateBlock.Metadata["lvt-source"],
 			state:    state,
 			template: tmpl,
 			conn:     conn,
@@ -389,6 +576,18 @@ func (h *WebSocketHandler) initializeInstances(conn *websocket.Conn) {
 
 		h.instances[blockID] = instance
 
+		// Push rows of streaming sources (e.g., SSE, tailed files) as they arrive.
+		// They are applied under instance.mu, like actions, so renders never see
+		// a half-applied event.
+		if notifier, ok := state.(interface{ SetOnUpdate(func(func())) }); ok {
+			notifier.SetOnUpdate(func(apply func()) {
+				instance.mu.Lock()
+				apply()
+				instance.mu.Unlock()
+				h.sendUpdate(instance)
+			})
+		}
+
 		// Send initial state
 		h.sendInitialState(instance)
 
@@ -477,6 +676,11 @@ func (h *WebSocketHandler) handleMessage(conn *websocket.Conn, message []byte) {
 
 	// Re-render and send update
 	h.sendUpdate(instance)
+
+	// Blocks reading what the action wrote show the change too
+	if written := h.writtenSources(instance, envelope.Action); len(written) > 0 {
+		h.refreshDependents(instance, written)
+	}
 }
 
 // handleAction executes an action on the state.
@@ -561,7 +765,11 @@ func (h *WebSocketHandler) sendMessage(conn *websocket.Conn, envelope MessageEnv
 		return
 	}
 
-	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	// Live updates are sent from source goroutines, concurrently with responses
+	h.writeMu.Lock()
+	err = conn.WriteMessage(websocket.TextMessage, data)
+	h.writeMu.Unlock()
+	if err != nil {
 		log.Printf("[WS] Failed to send message: %v", err)
 		return
 	}
@@ -76,7 +76,7 @@ func (m *Manager) discoverFromConfig() error {
 			}
 
 			// Generate URL path
-			urlPath := mdToURLPath(filePath)
+			urlPath := config.PageURL(filePath)
 
 			pageNode := &PageNode{
 				Title:    page.Title,
@@ -108,7 +108,7 @@ func (m *Manager) discoverFromConfig() error {
 			return fmt.Errorf("failed to parse home page %s: %w", homePath, err)
 		}
 
-		urlPath := mdToURLPath(homePath)
+		urlPath := config.PageURL(homePath)
 		m.home = &PageNode{
 			Title:    m.config.Title,
 			Path:     urlPath,
@@ -169,7 +169,7 @@ func (m *Manager) discoverFromFiles() error {
 		}
 
 		// Generate URL path
-		urlPath := mdToURLPath(relPath)
+		urlPath := config.PageURL(relPath)
 
 		// Determine title (from frontmatter or filename)
 		title := parsed.Title
@@ -379,7 +379,7 @@ func (m *Manager) Reload(filePath string) error {
 	}
 
 	// Generate URL path
-	urlPath := mdToURLPath(relPath)
+	urlPath := config.PageURL(relPath)
 
 	// Check if this page exists
 	pageNode, exists := m.pages[urlPath]
@@ -527,32 +527,3 @@ func stripHTMLTags(html string) string {
 
 	return result.String()
 }
-
-// mdToURLPath converts a markdown file path to a URL path
-// Examples:
-//   - "index.md" → "/"
-//   - "getting-started.md" → "/getting-started"
-//   - "guides/intro.md" → "/guides/intro"
-//   - "guides/index.md" → "/guides/"
-func mdToURLPath(relPath string) string {
-	// Remove .md extension
-	path := strings.TrimSuffix(relPath, ".md")
-
-	// Convert to URL path
-	path = filepath.ToSlash(path)
-
-	// Handle index files
-	if path == "index" {
-		return "/"
-	}
-	if strings.HasSuffix(path, "/index") {
-		return "/" + strings.TrimSuffix(path, "index")
-	}
-
-	// Add leading slash
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
-	}
-
-	return path
-}
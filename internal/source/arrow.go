@@ -0,0 +1,327 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// arrowFileMagic starts (and ends) Arrow IPC files, including Feather v2 files
+var arrowFileMagic = []byte("ARROW1")
+
+// columnarSettings holds the options shared by the parquet and arrow sources
+type columnarSettings struct {
+	columns []string          // Columns to read; empty for all
+	filters []filterPredicate // Rows must match all
+	limit   int               // Maximum rows; 0 for no limit
+}
+
+// newColumnarSettings reads columns, filter and options.limit
+func newColumnarSettings(name string, cfg config.SourceConfig) (columnarSettings, error) {
+	filters, err := parseFilters(name, cfg.Filter)
+	if err != nil {
+		return columnarSettings{}, err
+	}
+	settings := columnarSettings{columns: cfg.Columns, filters: filters}
+	if v := cfg.Options["limit"]; v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return columnarSettings{}, &ValidationError{Source: name, Field: "options.limit", Reason: fmt.Sprintf("must be a positive number, got %q", v)}
+		}
+		settings.limit = limit
+	}
+	return settings, nil
+}
+
+// project returns the indices of the top-level fields to read, in file order:
+// the configured columns, the fields used by filters, and "id" if present
+// (actions address rows by id). Returns nil to read all fields.
+func (c columnarSettings) project(name string, fields []string) ([]int, error) {
+	if len(c.columns) == 0 {
+		return nil, nil
+	}
+
+	wanted := slices.Clone(c.columns)
+	for _, p := range c.filters {
+		top, _, _ := strings.Cut(p.field, ".")
+		wanted = append(wanted, top)
+	}
+
+	var indices []int
+	for _, column := range wanted {
+		i := slices.Index(fields, column)
+		if i < 0 {
+			return nil, &ValidationError{Source: name, Field: "columns", Reason: fmt.Sprintf("column %q not found (available: %s)", column, strings.Join(fields, ", "))}
+		}
+		indices = append(indices, i)
+	}
+	if i := slices.Index(fields, "id"); i >= 0 {
+		indices = append(indices, i)
+	}
+	slices.Sort(indices)
+	return slices.Compact(indices), nil
+}
+
+// full reports whether the row limit has been reached
+func (c columnarSettings) full(rows []map[string]interface{}) bool {
+	return c.limit > 0 && len(rows) >= c.limit
+}
+
+// appendRecordRows converts the rows of a record batch, keeping those that match
+// the filters, until the limit is reached. fields selects the columns (nil for all).
+func (c columnarSettings) appendRecordRows(rows []map[string]interface{}, rec arrow.RecordBatch, fields []int) []map[string]interface{} {
+	if fields == nil {
+		fields = make([]int, rec.NumCols())
+		for i := range fields {
+			fields[i] = i
+		}
+	}
+
+	for i := 0; i < int(rec.NumRows()) && !c.full(rows); i++ {
+		row := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			row[rec.ColumnName(f)] = arrowValue(rec.Column(f), i)
+		}
+		if matchFilters(row, c.filters) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// arrowValue converts an element of an Arrow array to a Go value: integers are
+// int64 (uint64 if unsigned), floats and decimals are float64, dates and
+// timestamps are time.Time, lists are []interface{}, and structs and maps are
+// map[string]interface{}. Other types use their string form.
+func arrowValue(arr arrow.Array, i int) interface{} {
+	if arr.IsNull(i) {
+		return nil
+	}
+
+	switch a := arr.(type) {
+	case *array.Boolean:
+		return a.Value(i)
+	case *array.Int8:
+		return int64(a.Value(i))
+	case *array.Int16:
+		return int64(a.Value(i))
+	case *array.Int32:
+		return int64(a.Value(i))
+	case *array.Int64:
+		return a.Value(i)
+	case *array.Uint8:
+		return uint64(a.Value(i))
+	case *array.Uint16:
+		return uint64(a.Value(i))
+	case *array.Uint32:
+		return uint64(a.Value(i))
+	case *array.Uint64:
+		return a.Value(i)
+	case *array.Float16:
+		return float64(a.Value(i).Float32())
+	case *array.Float32:
+		return float64(a.Value(i))
+	case *array.Float64:
+		return a.Value(i)
+	case *array.Decimal128, *array.Decimal256:
+		if f, err := strconv.ParseFloat(arr.ValueStr(i), 64); err == nil {
+			return f
+		}
+	case *array.String:
+		return a.Value(i)
+	case *array.LargeString:
+		return a.Value(i)
+	case *array.StringView:
+		return a.Value(i)
+	case *array.Binary:
+		return string(a.Value(i))
+	case *array.LargeBinary:
+		return string(a.Value(i))
+	case *array.Date32:
+		return a.Value(i).ToTime()
+	case *array.Date64:
+		return a.Value(i).ToTime()
+	case *array.Timestamp:
+		typ := a.DataType().(*arrow.TimestampType)
+		t := a.Value(i).ToTime(typ.Unit)
+		if loc, err := time.LoadLocation(typ.TimeZone); err == nil && typ.TimeZone != "" {
+			t = t.In(loc)
+		}
+		return t
+	case *array.Duration:
+		return time.Duration(a.Value(i)) * a.DataType().(*arrow.DurationType).Unit.Multiplier()
+	case *array.Dictionary:
+		return arrowValue(a.Dictionary(), a.GetValueIndex(i))
+	case *array.Map:
+		start, end := a.ValueOffsets(i)
+		m := make(map[string]interface{}, end-start)
+		for j := int(start); j < int(end); j++ {
+			m[fmt.Sprint(arrowValue(a.Keys(), j))] = arrowValue(a.Items(), j)
+		}
+		return m
+	case array.ListLike:
+		start, end := a.ValueOffsets(i)
+		list := make([]interface{}, 0, end-start)
+		for j := int(start); j < int(end); j++ {
+			list = append(list, arrowValue(a.ListValues(), j))
+		}
+		return list
+	case *array.Struct:
+		typ := a.DataType().(*arrow.StructType)
+		m := make(map[string]interface{}, a.NumField())
+		for f := 0; f < a.NumField(); f++ {
+			m[typ.Field(f).Name] = arrowValue(a.Field(f), i)
+		}
+		return m
+	}
+	return arr.ValueStr(i)
+}
+
+// ArrowSource reads rows from an Arrow IPC file: the file format (also used
+// by Feather v2 .feather files) or the stream format (.arrows). Record batches
+// are read one at a time, so reading stops early once options.limit rows match.
+type ArrowSource struct {
+	name     string
+	filePath string
+	siteDir  string
+	settings columnarSettings
+}
+
+// NewArrowSource creates a new Arrow IPC file source
+func NewArrowSource(name string, cfg config.SourceConfig, siteDir string) (*ArrowSource, error) {
+	if cfg.File == "" {
+		return nil, fmt.Errorf("arrow source %q: file is required", name)
+	}
+	settings, err := newColumnarSettings(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &ArrowSource{
+		name:     name,
+		filePath: cfg.File,
+		siteDir:  siteDir,
+		settings: settings,
+	}, nil
+}
+
+// Name returns the source identifier
+func (s *ArrowSource) Name() string {
+	return s.name
+}
+
+// Fetch reads the record batches and converts their rows
+func (s *ArrowSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	path := s.resolvePath(s.filePath)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("arrow source %q: failed to open file: %w", s.name, err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(arrowFileMagic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return nil, fmt.Errorf("arrow source %q: failed to read file: %w", s.name, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("arrow source %q: failed to read file: %w", s.name, err)
+	}
+
+	var rows []map[string]interface{}
+	if bytes.Equal(magic, arrowFileMagic) {
+		rows, err = s.readFile(ctx, f)
+	} else {
+		rows, err = s.readStream(ctx, f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// readFile reads the IPC file format
+func (s *ArrowSource) readFile(ctx context.Context, f *os.File) ([]map[string]interface{}, error) {
+	r, err := ipc.NewFileReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("arrow source %q: invalid Arrow file: %w", s.name, err)
+	}
+	defer r.Close()
+
+	fields, err := s.settings.project(s.name, schemaFieldNames(r.Schema()))
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []map[string]interface{}{}
+	for i := 0; i < r.NumRecords() && !s.settings.full(rows); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rec, err := r.RecordBatch(i)
+		if err != nil {
+			return nil, fmt.Errorf("arrow source %q: failed to read record batch %d: %w", s.name, i, err)
+		}
+		rows = s.settings.appendRecordRows(rows, rec, fields)
+	}
+	return rows, nil
+}
+
+// readStream reads the IPC stream format
+func (s *ArrowSource) readStream(ctx context.Context, f *os.File) ([]map[string]interface{}, error) {
+	r, err := ipc.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("arrow source %q: invalid Arrow stream: %w", s.name, err)
+	}
+	defer r.Release()
+
+	fields, err := s.settings.project(s.name, schemaFieldNames(r.Schema()))
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []map[string]interface{}{}
+	for !s.settings.full(rows) && r.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rows = s.settings.appendRecordRows(rows, r.RecordBatch(), fields)
+	}
+	if err := r.Err(); err != nil {
+		return nil, fmt.Errorf("arrow source %q: failed to read stream: %w", s.name, err)
+	}
+	return rows, nil
+}
+
+// schemaFieldNames returns the names of a schema's top-level fields
+func schemaFieldNames(schema *arrow.Schema) []string {
+	names := make([]string, schema.NumFields())
+	for i, field := range schema.Fields() {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// Close is a no-op for file sources
+func (s *ArrowSource) Close() error {
+	return nil
+}
+
+// resolvePath makes a path absolute relative to siteDir
+func (s *ArrowSource) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.siteDir, path)
+}
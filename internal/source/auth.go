@@ -0,0 +1,388 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// tokenExpiryDelta is how long before expiry an OAuth2 token is refreshed
+const tokenExpiryDelta = 30 * time.Second
+
+// authenticator adds credentials to outgoing requests
+type authenticator interface {
+	authorize(req *http.Request) error
+}
+
+// newHTTPClient creates the HTTP client for an HTTP source, applying the TLS and
+// authentication settings of the auth block (if any)
+func newHTTPClient(name string, auth *config.AuthConfig, siteDir string, timeout time.Duration) (*http.Client, error) {
+	if auth == nil {
+		return &http.Client{Timeout: timeout}, nil
+	}
+
+	base, err := newAuthTransport(name, auth, siteDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var authn authenticator
+	switch auth.Type {
+	case "":
+		// TLS settings only
+	case "basic":
+		if auth.Username == "" {
+			return nil, &ValidationError{Source: name, Field: "auth.username", Reason: "username is required for basic auth"}
+		}
+		authn = &basicAuth{username: os.ExpandEnv(auth.Username), password: os.ExpandEnv(auth.Password)}
+	case "bearer":
+		switch {
+		case auth.TokenFile != "":
+			authn = &fileTokenAuth{path: resolveSitePath(siteDir, os.ExpandEnv(auth.TokenFile))}
+		case auth.Token != "":
+			authn = &staticTokenAuth{token: os.ExpandEnv(auth.Token)}
+		default:
+			return nil, &ValidationError{Source: name, Field: "auth.token", Reason: "token or token_file is required for bearer auth"}
+		}
+	case "oauth2":
+		if auth.TokenURL == "" {
+			return nil, &ValidationError{Source: name, Field: "auth.token_url", Reason: "token_url is required for oauth2"}
+		}
+		if auth.ClientID == "" {
+			return nil, &ValidationError{Source: name, Field: "auth.client_id", Reason: "client_id is required for oauth2"}
+		}
+		if auth.ClientAuth != "" && auth.ClientAuth != "header" && auth.ClientAuth != "params" {
+			return nil, &ValidationError{Source: name, Field: "auth.client_auth", Reason: "client_auth must be \"header\" or \"params\""}
+		}
+		params := make(map[string]string, len(auth.Params))
+		for k, v := range auth.Params {
+			params[k] = os.ExpandEnv(v)
+		}
+		oauth := &clientCredentialsAuth{
+			tokenURL:     os.ExpandEnv(auth.TokenURL),
+			clientID:     os.ExpandEnv(auth.ClientID),
+			clientSecret: os.ExpandEnv(auth.ClientSecret),
+			scopes:       auth.Scopes,
+			params:       params,
+			paramsAuth:   auth.ClientAuth == "params",
+			// Token requests share the TLS settings but not the credentials
+			client: &http.Client{Timeout: timeout, Transport: base},
+		}
+		oauth.cached = sharedOAuthToken(oauth)
+		authn = oauth
+	default:
+		return nil, &ValidationError{Source: name, Field: "auth.type", Reason: fmt.Sprintf("unknown auth type %q (expected basic, bearer, or oauth2)", auth.Type)}
+	}
+
+	var transport http.RoundTripper = base
+	if authn != nil {
+		transport = &authRoundTripper{base: base, auth: authn}
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// newAuthTransport creates a transport trusting the configured CA bundle and
+// presenting the configured client certificate
+func newAuthTransport(name string, auth *config.AuthConfig, siteDir string) (http.RoundTripper, error) {
+	if auth.CACert == "" && auth.ClientCert == "" && auth.ClientKey == "" {
+		return http.DefaultTransport, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if auth.CACert != "" {
+		pem, err := os.ReadFile(resolveSitePath(siteDir, os.ExpandEnv(auth.CACert)))
+		if err != nil {
+			return nil, &ValidationError{Source: name, Field: "auth.ca_cert", Reason: err.Error()}
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, &ValidationError{Source: name, Field: "auth.ca_cert", Reason: "no certificates found in PEM file"}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if auth.ClientCert != "" || auth.ClientKey != "" {
+		if auth.ClientCert == "" || auth.ClientKey == "" {
+			return nil, &ValidationError{Source: name, Field: "auth.client_cert", Reason: "client_cert and client_key must be set together"}
+		}
+		cert, err := tls.LoadX509KeyPair(
+			resolveSitePath(siteDir, os.ExpandEnv(auth.ClientCert)),
+			resolveSitePath(siteDir, os.ExpandEnv(auth.ClientKey)),
+		)
+		if err != nil {
+			return nil, &ValidationError{Source: name, Field: "auth.client_cert", Reason: err.Error()}
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// resolveSitePath makes a path absolute relative to siteDir
+func resolveSitePath(siteDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(siteDir, path)
+}
+
+// authRoundTripper adds credentials to each request
+type authRoundTripper struct {
+	base http.RoundTripper
+	auth authenticator
+}
+
+// RoundTrip authorizes and sends a request. If an OAuth2 token is rejected, a new
+// token is requested and the request is sent once more. Redirects to another
+// origin are sent without credentials.
+func (t *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !sameOriginAsInitial(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	authReq := req.Clone(req.Context())
+	if err := t.auth.authorize(authReq); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(authReq)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	oauth, ok := t.auth.(*clientCredentialsAuth)
+	if !ok || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+	oauth.invalidate(strings.TrimPrefix(authReq.Header.Get("Authorization"), "Bearer "))
+
+	retryReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retryReq.Body = body
+	}
+	if err := oauth.authorize(retryReq); err != nil {
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return t.base.RoundTrip(retryReq)
+}
+
+// sameOriginAsInitial reports whether req has the scheme and host of the
+// request the client was asked to send, following the redirects leading to it
+func sameOriginAsInitial(req *http.Request) bool {
+	initial := req
+	for initial.Response != nil && initial.Response.Request != nil {
+		initial = initial.Response.Request
+	}
+	return strings.EqualFold(req.URL.Scheme, initial.URL.Scheme) && strings.EqualFold(req.URL.Host, initial.URL.Host)
+}
+
+// basicAuth sends HTTP basic credentials
+type basicAuth struct {
+	username, password string
+}
+
+func (a *basicAuth) authorize(req *http.Request) error {
+	req.SetBasicAuth(a.username, a.password)
+	return nil
+}
+
+// staticTokenAuth sends a fixed bearer token
+type staticTokenAuth struct {
+	token string
+}
+
+func (a *staticTokenAuth) authorize(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+// fileTokenAuth sends a bearer token read from a file, re-reading it when the
+// file changes (e.g., a token rotated by an external agent)
+type fileTokenAuth struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	token   string
+}
+
+func (a *fileTokenAuth) authorize(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return fmt.Errorf("bearer token file: %w", err)
+	}
+	if a.token == "" || !info.ModTime().Equal(a.modTime) {
+		data, err := os.ReadFile(a.path)
+		if err != nil {
+			return fmt.Errorf("bearer token file: %w", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return fmt.Errorf("bearer token file %s is empty", a.path)
+		}
+		a.token, a.modTime = token, info.ModTime()
+	}
+
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+// clientCredentialsAuth obtains tokens with the OAuth2 client credentials grant
+// and caches them until shortly before they expire
+type clientCredentialsAuth struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	params       map[string]string
+	paramsAuth   bool // Send client credentials in the form body instead of HTTP basic
+	client       *http.Client
+	cached       *oauthToken // Shared by the sources using the same client (see sharedOAuthToken)
+}
+
+// oauthToken is a cached OAuth2 access token
+type oauthToken struct {
+	mu     sync.Mutex
+	token  string
+	expiry time.Time // Zero if the token server did not report an expiry
+}
+
+// oauthTokens are the tokens shared by sources, by token URL, client
+// credentials, scopes and extra parameters
+var (
+	oauthTokensMu sync.Mutex
+	oauthTokens   = make(map[string]*oauthToken)
+)
+
+// sharedOAuthToken returns the cached token of the client of a, shared with
+// the sources requesting tokens for the same client, scopes and parameters, so
+// pages and sources don't each request their own. The key includes a hash of
+// the client secret: a source with another (e.g., rotated or wrong) secret must
+// not get a token it could not have requested.
+func sharedOAuthToken(a *clientCredentialsAuth) *oauthToken {
+	params, _ := json.Marshal(a.params) // Maps are encoded with sorted keys
+	secret := sha256.Sum256([]byte(a.clientSecret))
+	key := strings.Join([]string{a.tokenURL, a.clientID, hex.EncodeToString(secret[:]), strings.Join(a.scopes, " "), string(params)}, "|")
+
+	oauthTokensMu.Lock()
+	defer oauthTokensMu.Unlock()
+	t, ok := oauthTokens[key]
+	if !ok {
+		t = &oauthToken{}
+		oauthTokens[key] = t
+	}
+	return t
+}
+
+func (a *clientCredentialsAuth) authorize(req *http.Request) error {
+	token, err := a.accessToken(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// invalidate drops the cached token if it is the rejected one, so the next
+// request fetches a new one (another source may have done so already)
+func (a *clientCredentialsAuth) invalidate(rejected string) {
+	a.cached.mu.Lock()
+	defer a.cached.mu.Unlock()
+	if a.cached.token == rejected {
+		a.cached.token = ""
+	}
+}
+
+// accessToken returns the cached token, requesting a new one if it is missing or about to expire
+func (a *clientCredentialsAuth) accessToken(ctx context.Context) (string, error) {
+	c := a.cached
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && (c.expiry.IsZero() || time.Until(c.expiry) > tokenExpiryDelta) {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.scopes) > 0 {
+		form.Set("scope", strings.Join(a.scopes, " "))
+	}
+	for k, v := range a.params {
+		form.Set(k, v)
+	}
+	if a.paramsAuth {
+		form.Set("client_id", a.clientID)
+		form.Set("client_secret", a.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("oauth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !a.paramsAuth {
+		req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oauth2 token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return "", fmt.Errorf("oauth2 token request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("oauth2 token request failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var tokenResp struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("oauth2 token request: could not parse response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("oauth2 token request: response has no access_token")
+	}
+
+	c.token = tokenResp.AccessToken
+	c.expiry = time.Time{}
+	if seconds, err := tokenResp.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		c.expiry = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return c.token, nil
+}
@@ -0,0 +1,384 @@
+package source
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// authEchoServer returns the Authorization header it received as a single row
+func authEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]map[string]interface{}{{"authorization": r.Header.Get("Authorization")}})
+	}))
+}
+
+func fetchAuthorization(t *testing.T, src *RestSource) string {
+	t.Helper()
+	results, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	return results[0]["authorization"].(string)
+}
+
+func TestRestSource_AuthBasic(t *testing.T) {
+	server := authEchoServer()
+	defer server.Close()
+
+	os.Setenv("TEST_AUTH_PASSWORD", "s3cret")
+	defer os.Unsetenv("TEST_AUTH_PASSWORD")
+
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+		Type: "rest",
+		From: server.URL,
+		Auth: &config.AuthConfig{Type: "basic", Username: "alice", Password: "${TEST_AUTH_PASSWORD}"},
+	}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	// "alice:s3cret" base64-encoded
+	if got := fetchAuthorization(t, src); got != "Basic YWxpY2U6czNjcmV0" {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+func TestRestSource_AuthBearerFile(t *testing.T) {
+	server := authEchoServer()
+	defer server.Close()
+
+	siteDir := t.TempDir()
+	tokenPath := filepath.Join(siteDir, "token")
+	os.WriteFile(tokenPath, []byte("first-token\n"), 0600)
+
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+		Type: "rest",
+		From: server.URL,
+		Auth: &config.AuthConfig{Type: "bearer", TokenFile: "token"},
+	}, siteDir)
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	if got := fetchAuthorization(t, src); got != "Bearer first-token" {
+		t.Errorf("Authorization = %q", got)
+	}
+
+	// A rotated token is picked up without restarting
+	os.WriteFile(tokenPath, []byte("second-token"), 0600)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(tokenPath, future, future)
+	if got := fetchAuthorization(t, src); got != "Bearer second-token" {
+		t.Errorf("Authorization after rotation = %q", got)
+	}
+}
+
+func TestRestSource_AuthOAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokenRequests, 1)
+		r.ParseForm()
+		user, pass, _ := r.BasicAuth()
+		if r.Form.Get("grant_type") != "client_credentials" || user != "client" || pass != "secret" {
+			http.Error(w, "invalid_client", http.StatusUnauthorized)
+			return
+		}
+		if r.Form.Get("scope") != "read write" || r.Form.Get("audience") != "api" {
+			http.Error(w, "invalid_scope", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// The first token expires within the refresh window, so it is replaced on the next fetch
+		expiresIn := 3600
+		if n == 1 {
+			expiresIn = 10
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token-" + string(rune('0'+n)),
+			"token_type":   "bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	defer tokenServer.Close()
+
+	server := authEchoServer()
+	defer server.Close()
+
+	auth := &config.AuthConfig{
+		Type:         "oauth2",
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+		Params:       map[string]string{"audience": "api"},
+	}
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{Type: "rest", From: server.URL, Auth: auth}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	if got := fetchAuthorization(t, src); got != "Bearer token-1" {
+		t.Errorf("First Authorization = %q", got)
+	}
+	if got := fetchAuthorization(t, src); got != "Bearer token-2" {
+		t.Errorf("Expected refreshed token, got %q", got)
+	}
+	if got := fetchAuthorization(t, src); got != "Bearer token-2" {
+		t.Errorf("Expected cached token, got %q", got)
+	}
+
+	// Sources of the same client (e.g., on other pages) share the token
+	other, err := NewRestSourceWithConfig("other", config.SourceConfig{Type: "rest", From: server.URL, Auth: auth}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	if got := fetchAuthorization(t, other); got != "Bearer token-2" {
+		t.Errorf("Expected shared token, got %q", got)
+	}
+	if n := atomic.LoadInt32(&tokenRequests); n != 2 {
+		t.Errorf("Expected 2 token requests, got %d", n)
+	}
+}
+
+func TestSharedOAuthTokenBySecret(t *testing.T) {
+	a := &clientCredentialsAuth{tokenURL: "https://auth.example.com/token", clientID: "client", clientSecret: "secret"}
+	same := &clientCredentialsAuth{tokenURL: a.tokenURL, clientID: "client", clientSecret: "secret"}
+	rotated := &clientCredentialsAuth{tokenURL: a.tokenURL, clientID: "client", clientSecret: "rotated"}
+
+	if sharedOAuthToken(a) != sharedOAuthToken(same) {
+		t.Error("expected sources with the same credentials to share the token")
+	}
+	// A source with another secret must request its own token
+	if sharedOAuthToken(a) == sharedOAuthToken(rotated) {
+		t.Error("expected sources with different secrets not to share the token")
+	}
+}
+
+func TestRestSource_AuthOAuth2RetriesRejectedToken(t *testing.T) {
+	var tokenRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokenRequests, 1)
+		r.ParseForm()
+		if r.Form.Get("client_id") != "client" || r.Form.Get("client_secret") != "secret" {
+			http.Error(w, "invalid_client", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token-" + string(rune('0'+n))})
+	}))
+	defer tokenServer.Close()
+
+	// The API revokes the first token
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			http.Error(w, "token revoked", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]interface{}{{"ok": true}})
+	}))
+	defer server.Close()
+
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+		Type: "rest",
+		From: server.URL,
+		Auth: &config.AuthConfig{Type: "oauth2", TokenURL: tokenServer.URL, ClientID: "client", ClientSecret: "secret", ClientAuth: "params"},
+	}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	results, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(results) != 1 || results[0]["ok"] != true {
+		t.Errorf("Unexpected results: %v", results)
+	}
+}
+
+// writeTestCert creates a self-signed certificate and writes it and its key to
+// <name>.pem and <name>-key.pem in dir
+func writeTestCert(t *testing.T, dir, name string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(filepath.Join(dir, name+"-key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return cert
+}
+
+func TestRestSource_AuthTLS(t *testing.T) {
+	siteDir := t.TempDir()
+	clientX509 := writeTestCert(t, siteDir, "client")
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{{"client": r.TLS.PeerCertificates[0].Subject.CommonName}})
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientX509)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	caPath := filepath.Join(siteDir, "ca.pem")
+	os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+
+	noRetry := &config.RetryConfig{MaxRetries: 0}
+
+	// Without the CA the server certificate is rejected
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{Type: "rest", From: server.URL, Retry: noRetry}, siteDir)
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	if _, err := src.Fetch(context.Background()); err == nil {
+		t.Error("Expected certificate error without ca_cert")
+	}
+
+	src, err = NewRestSourceWithConfig("test", config.SourceConfig{
+		Type:  "rest",
+		From:  server.URL,
+		Retry: noRetry,
+		Auth:  &config.AuthConfig{CACert: "ca.pem", ClientCert: "client.pem", ClientKey: "client-key.pem"},
+	}, siteDir)
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	results, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if results[0]["client"] != "client" {
+		t.Errorf("Expected client certificate to be presented, got %v", results[0])
+	}
+}
+
+func TestRestSource_AuthNotSentOnCrossOriginRedirect(t *testing.T) {
+	other := authEchoServer()
+	defer other.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		case "/echo":
+			json.NewEncoder(w).Encode([]map[string]interface{}{{"authorization": r.Header.Get("Authorization")}})
+		default:
+			http.Redirect(w, r, other.URL, http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	newSource := func(path string) *RestSource {
+		src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+			Type: "rest",
+			From: server.URL + path,
+			Auth: &config.AuthConfig{Type: "bearer", Token: "secret-token"},
+		}, "")
+		if err != nil {
+			t.Fatalf("Failed to create source: %v", err)
+		}
+		return src
+	}
+
+	// The other host never sees the token
+	if got := fetchAuthorization(t, newSource("/elsewhere")); got != "" {
+		t.Errorf("cross-origin redirect target got Authorization %q", got)
+	}
+	// Redirects within the origin stay authorized
+	if got := fetchAuthorization(t, newSource("/moved")); got != "Bearer secret-token" {
+		t.Errorf("same-origin redirect target got Authorization %q", got)
+	}
+}
+
+func TestGraphQLSource_AuthBearer(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"items": []interface{}{}}})
+	}))
+	defer server.Close()
+
+	siteDir := t.TempDir()
+	os.WriteFile(filepath.Join(siteDir, "q.graphql"), []byte("{ items { id } }"), 0644)
+
+	src, err := NewGraphQLSource("test", config.SourceConfig{
+		Type:       "graphql",
+		From:       server.URL,
+		QueryFile:  "q.graphql",
+		ResultPath: "items",
+		Auth:       &config.AuthConfig{Type: "bearer", Token: "gql-token"},
+	}, siteDir)
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	if _, err := src.Fetch(context.Background()); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if received != "Bearer gql-token" {
+		t.Errorf("Authorization = %q", received)
+	}
+}
+
+func TestNewHTTPClientValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    config.AuthConfig
+		wantErr string
+	}{
+		{"unknown type", config.AuthConfig{Type: "digest"}, "unknown auth type"},
+		{"basic without username", config.AuthConfig{Type: "basic"}, "username is required"},
+		{"bearer without token", config.AuthConfig{Type: "bearer"}, "token or token_file is required"},
+		{"oauth2 without token_url", config.AuthConfig{Type: "oauth2", ClientID: "x"}, "token_url is required"},
+		{"oauth2 without client_id", config.AuthConfig{Type: "oauth2", TokenURL: "http://x"}, "client_id is required"},
+		{"cert without key", config.AuthConfig{ClientCert: "client.pem"}, "must be set together"},
+		{"missing ca file", config.AuthConfig{CACert: "missing.pem"}, "auth.ca_cert"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := tt.auth
+			_, err := newHTTPClient("test", &auth, t.TempDir(), time.Second)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
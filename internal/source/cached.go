@@ -3,6 +3,7 @@ package source
 import (
 	"context"
 	"log"
+	"strconv"
 	"sync"
 	"time"
 
@@ -10,14 +11,22 @@ import (
 	"github.com/livetemplate/tinkerdown/internal/config"
 )
 
+// cacheFlights coalesces concurrent cache fills by cache key, so the cached
+// sources of one configuration (e.g., one per connection) fetch once
+var cacheFlights flightGroup
+
 // CachedSource wraps a Source with caching behavior
 type CachedSource struct {
 	inner    Source
 	cache    cache.Cache
 	name     string
+	key      string // Cache key; changes with the source's config
 	ttl      time.Duration
 	strategy string // "simple" or "stale-while-revalidate"
 
+	// For HTTP sources: take the TTL from Cache-Control instead of ttl
+	respectCacheControl bool
+
 	// For stale-while-revalidate: track in-flight revalidations
 	mu           sync.Mutex
 	revalidating bool
@@ -34,10 +43,13 @@ func NewCachedSource(inner Source, c cache.Cache, cfg config.SourceConfig) *Cach
 		inner:      inner,
 		cache:      c,
 		name:       inner.Name(),
+		key:        "source:" + flightKey(inner.Name(), cfg),
 		ttl:        cfg.GetCacheTTL(),
 		strategy:   cfg.GetCacheStrategy(),
 		cancelCtx:  ctx,
 		cancelFunc: cancel,
+
+		respectCacheControl: cfg.Cache != nil && cfg.Cache.RespectCacheControl,
 	}
 }
 
@@ -48,43 +60,186 @@ func (s *CachedSource) Name() string {
 
 // Fetch retrieves data, using cache if available
 func (s *CachedSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	data, _, err := s.FetchTime(ctx)
+	return data, err
+}
+
+// FetchTime is like Fetch, but also returns when the rows were fetched from
+// the underlying source: the fetch time of the cache entry they were read
+// from, or now for fresh rows. It is zero if unknown (e.g., for entries
+// cached by older versions).
+func (s *CachedSource) FetchTime(ctx context.Context) ([]map[string]interface{}, time.Time, error) {
 	// Check if context is already cancelled
 	if err := ctx.Err(); err != nil {
-		return nil, err
+		return nil, time.Time{}, err
 	}
 
 	cacheKey := s.cacheKey()
 
 	// Try to get from cache
-	data, found, stale := s.cache.Get(cacheKey)
-	if found {
-		if stale && s.strategy == "stale-while-revalidate" {
+	if entry, found := s.cache.Peek(cacheKey); found && !entry.IsExpired() {
+		if entry.IsStale() && s.strategy == "stale-while-revalidate" {
 			// Return stale data immediately, revalidate in background
 			go s.revalidateInBackground()
 		}
-		return data, nil
+		return entry.Data, entry.FetchedAt, nil
 	}
 
 	// Cache miss - fetch fresh data
-	return s.fetchAndCache(ctx)
+	data, err := s.fetchAndCache(ctx)
+	if err != nil && ctx.Err() == nil {
+		// Serve expired data while the source fails, if the cache still has it,
+		// with the failure so callers can show the data is degraded
+		if entry, ok := s.cache.Peek(cacheKey); ok {
+			log.Printf("[cache/%s] Fetch failed, serving expired data: %v", s.name, err)
+			return entry.Data, entry.FetchedAt, &StaleError{Source: s.name, Err: err, FetchedAt: entry.FetchedAt}
+		}
+	}
+	if err != nil {
+		return data, time.Time{}, err
+	}
+	// Report the time stored with the rows, so later hits report the same
+	if entry, found := s.cache.Peek(cacheKey); found && !entry.IsExpired() && !entry.FetchedAt.IsZero() {
+		return data, entry.FetchedAt, nil
+	}
+	return data, time.Now(), nil
+}
+
+// FetchTime fetches the rows of src and when they were fetched, for sources
+// that know it (see CachedSource.FetchTime); others return now
+func FetchTime(ctx context.Context, src Source) ([]map[string]interface{}, time.Time, error) {
+	if timed, ok := src.(interface {
+		FetchTime(context.Context) ([]map[string]interface{}, time.Time, error)
+	}); ok {
+		return timed.FetchTime(ctx)
+	}
+	data, err := src.Fetch(ctx)
+	if err != nil {
+		return data, time.Time{}, err
+	}
+	return data, time.Now(), nil
+}
+
+// FetchVersion is like Fetch, but also returns the version of the rows: the
+// cache key and the expiry of the entry they were read from. The version is
+// "" if the rows did not come from a valid entry, e.g., if they could not be
+// cached.
+func (s *CachedSource) FetchVersion(ctx context.Context) ([]map[string]interface{}, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	cacheKey := s.cacheKey()
+	if entry, found := s.cache.Peek(cacheKey); found && !entry.IsExpired() {
+		if entry.IsStale() && s.strategy == "stale-while-revalidate" {
+			go s.revalidateInBackground()
+		}
+		return entry.Data, entryVersion(cacheKey, entry), nil
+	}
+
+	data, err := s.Fetch(ctx)
+	if err != nil {
+		// Stale rows are served unversioned
+		return data, "", err
+	}
+	// The rows are versioned if they are the ones now cached (a disk cache
+	// returns copies, so they are versioned from the next fetch on)
+	if entry, found := s.cache.Peek(cacheKey); found && !entry.IsExpired() && sameRows(entry.Data, data) {
+		return data, entryVersion(cacheKey, entry), nil
+	}
+	return data, "", nil
 }
 
-// fetchAndCache fetches from the underlying source and caches the result
+// entryVersion identifies the rows of a cache entry. Every store sets a new
+// expiry, so the version changes whenever the rows are refetched.
+func entryVersion(key string, entry *cache.Entry) string {
+	return key + "@" + strconv.FormatInt(entry.ExpiresAt.UnixNano(), 10)
+}
+
+// sameRows reports whether a and b are the same slice
+func sameRows(a, b []map[string]interface{}) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// fetchAndCache fetches from the underlying source and caches the result.
+// Concurrent calls for the cache key share a single fetch, across instances.
 func (s *CachedSource) fetchAndCache(ctx context.Context) ([]map[string]interface{}, error) {
-	data, err := s.inner.Fetch(ctx)
+	return cacheFlights.Do(ctx, s.cacheKey(), func() ([]map[string]interface{}, error) {
+		return s.fetchAndStore(ctx)
+	})
+}
+
+// fetchAndStore fetches from the underlying source and caches the result.
+// HTTP sources revalidate the previous response with a conditional request
+// when its validators are still cached, reusing the cached data on 304.
+func (s *CachedSource) fetchAndStore(ctx context.Context) ([]map[string]interface{}, error) {
+	conditional, ok := s.inner.(ConditionalSource)
+	if !ok {
+		data, err := s.inner.Fetch(ctx)
+		if err != nil {
+			// Stale rows (e.g., of a derived source's inputs) are not cached
+			return data, err
+		}
+		s.store(&cache.Entry{Data: data}, s.ttl)
+		return data, nil
+	}
+
+	var etag, lastModified string
+	previous, found := s.cache.Peek(s.cacheKey())
+	if found && previous.HasValidators() {
+		etag, lastModified = previous.ETag, previous.LastModified
+	}
+
+	data, info, notModified, err := conditional.FetchConditional(ctx, etag, lastModified)
 	if err != nil {
 		return nil, err
 	}
+	if notModified {
+		if !found {
+			// 304 without validators sent; nothing to reuse
+			if data, err = s.inner.Fetch(ctx); err != nil {
+				return nil, err
+			}
+		} else {
+			data = previous.Data
+			// A 304 may omit unchanged validators
+			if info.ETag == "" {
+				info.ETag = previous.ETag
+			}
+			if info.LastModified == "" {
+				info.LastModified = previous.LastModified
+			}
+		}
+	}
 
+	ttl := s.ttl
+	if s.respectCacheControl {
+		if info.NoStore {
+			s.cache.Invalidate(s.cacheKey())
+			return data, nil
+		}
+		if info.HasMaxAge {
+			ttl = info.MaxAge
+		}
+	}
+
+	s.store(&cache.Entry{Data: data, ETag: info.ETag, LastModified: info.LastModified}, ttl)
+	return data, nil
+}
+
+// store caches an entry for ttl
+func (s *CachedSource) store(entry *cache.Entry, ttl time.Duration) {
+	staleAfter := ttl
 	if s.strategy == "stale-while-revalidate" {
 		// For SWR: data is fresh for half the TTL, then stale for the other half
-		staleAfter := s.ttl / 2
-		s.cache.SetWithStale(s.cacheKey(), data, staleAfter, s.ttl)
-	} else {
-		s.cache.Set(s.cacheKey(), data, s.ttl)
+		staleAfter = ttl / 2
 	}
 
-	return data, nil
+	now := time.Now()
+	entry.StaleAt = now.Add(staleAfter)
+	entry.ExpiresAt = now.Add(ttl)
+	entry.FetchedAt = now
+	s.cache.SetEntry(s.cacheKey(), entry)
 }
 
 // revalidateInBackground fetches fresh data in the background
@@ -116,9 +271,10 @@ func (s *CachedSource) revalidateInBackground() {
 	}
 }
 
-// cacheKey returns the cache key for this source
+// cacheKey returns the cache key for this source. It includes a hash of the
+// source's config, so a persistent cache doesn't serve rows read with an old one.
 func (s *CachedSource) cacheKey() string {
-	return "source:" + s.name
+	return s.key
 }
 
 // Close closes the underlying source and cancels any background operations
@@ -2,6 +2,12 @@ package source
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -16,6 +22,7 @@ type mockSource struct {
 	data       []map[string]interface{}
 	fetchCount int32
 	fetchDelay time.Duration
+	fetchErr   error
 }
 
 func (s *mockSource) Name() string { return s.name }
@@ -25,6 +32,9 @@ func (s *mockSource) Fetch(ctx context.Context) ([]map[string]interface{}, error
 	if s.fetchDelay > 0 {
 		time.Sleep(s.fetchDelay)
 	}
+	if s.fetchErr != nil {
+		return nil, s.fetchErr
+	}
 	return s.data, nil
 }
 
@@ -98,6 +108,30 @@ func TestCachedSourceBasic(t *testing.T) {
 	}
 }
 
+func TestCachedSourceFetchVersion(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Stop()
+
+	inner := &mockSource{name: "test", data: []map[string]interface{}{{"id": 1}}}
+	cached := NewCachedSource(inner, c, config.SourceConfig{Cache: &config.CacheConfig{TTL: "1m"}})
+
+	ctx := context.Background()
+	_, first, err := cached.FetchVersion(ctx)
+	if err != nil || first == "" {
+		t.Fatalf("expected a version, got %q, %v", first, err)
+	}
+	if _, again, _ := cached.FetchVersion(ctx); again != first || inner.FetchCount() != 1 {
+		t.Errorf("expected the cached rows of version %q, got %q after %d fetches", first, again, inner.FetchCount())
+	}
+
+	// Refetched rows have a new version
+	cached.Invalidate()
+	time.Sleep(time.Millisecond)
+	if _, refetched, _ := cached.FetchVersion(ctx); refetched == "" || refetched == first {
+		t.Errorf("expected a new version, got %q", refetched)
+	}
+}
+
 func TestCachedSourceTTLExpiry(t *testing.T) {
 	c := cache.NewMemoryCache()
 	defer c.Stop()
@@ -271,6 +305,148 @@ func TestCachedSourceStaleWhileRevalidate(t *testing.T) {
 	}
 }
 
+func TestCachedSourceCoalescesFetches(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Stop()
+
+	inner := &mockSource{name: "test", data: []map[string]interface{}{{"id": 1}}, fetchDelay: 50 * time.Millisecond}
+	cached := NewCachedSource(inner, c, config.SourceConfig{Cache: &config.CacheConfig{TTL: "1m"}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cached.Fetch(context.Background()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if inner.FetchCount() != 1 {
+		t.Errorf("expected concurrent misses to share 1 fetch, got %d", inner.FetchCount())
+	}
+}
+
+func TestCachedSourceServesExpiredOnError(t *testing.T) {
+	c, err := cache.NewDiskCache(filepath.Join(t.TempDir(), "cache.db"), cache.DiskOptions{})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer c.Close()
+
+	inner := &mockSource{name: "test", data: []map[string]interface{}{{"id": 1}}}
+	cached := NewCachedSource(inner, c, config.SourceConfig{Cache: &config.CacheConfig{TTL: "50ms"}})
+	ctx := context.Background()
+	_, fetchedAt, err := cached.FetchTime(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	inner.fetchErr = errors.New("upstream down")
+
+	// The expired rows come with the failure and the time they were fetched
+	data, err := cached.Fetch(ctx)
+	var stale *StaleError
+	if !errors.As(err, &stale) || !errors.Is(err, inner.fetchErr) {
+		t.Fatalf("expected a stale error wrapping the failure, got %v", err)
+	}
+	if !stale.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("expected the rows' fetch time %v, got %v", fetchedAt, stale.FetchedAt)
+	}
+	if len(data) != 1 || data[0]["id"] != 1 {
+		t.Errorf("unexpected data: %v", data)
+	}
+	if inner.FetchCount() != 2 {
+		t.Errorf("expected a refetch of expired data, got %d fetches", inner.FetchCount())
+	}
+
+	// Without cached data the error is returned
+	c.InvalidateAll()
+	if _, err := cached.Fetch(ctx); err == nil || IsStale(err) {
+		t.Errorf("expected error with empty cache, got %v", err)
+	}
+}
+
+func TestCachedSourceDiskCacheWithSchema(t *testing.T) {
+	c, err := cache.NewDiskCache(filepath.Join(t.TempDir(), "cache.db"), cache.DiskOptions{})
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	defer c.Close()
+
+	cfg := config.SourceConfig{
+		Cache:  &config.CacheConfig{TTL: "1h"},
+		Schema: map[string]config.FieldSchema{"views": {Type: "int"}, "score": {Type: "float"}},
+	}
+	inner, err := WithTransform(&mockSource{name: "stats", data: []map[string]interface{}{{"views": "1500000", "score": "0.5"}}}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := NewCachedSource(inner, c, cfg)
+	defer cached.Close()
+
+	// Rows read back from disk keep the types the schema coerced them to
+	for i := 0; i < 2; i++ {
+		data, err := cached.Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("fetch %d: unexpected error: %v", i, err)
+		}
+		if data[0]["views"] != 1500000 || data[0]["score"] != 0.5 {
+			t.Errorf("fetch %d: unexpected rows %#v", i, data)
+		}
+	}
+	if n := inner.(*TransformSource).inner.(*mockSource).FetchCount(); n != 1 {
+		t.Errorf("expected the second fetch from the cache, got %d fetches", n)
+	}
+}
+
+func TestRegistryCacheBackend(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "items.json")
+	if err := os.WriteFile(path, []byte(`[{"id": 1}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Sources: map[string]config.SourceConfig{
+			"items": {Type: "json", File: "items.json", Cache: &config.CacheConfig{TTL: "1h"}},
+		},
+		Cache: &config.CacheBackendConfig{Backend: "disk"},
+	}
+
+	fetch := func() []map[string]interface{} {
+		t.Helper()
+		r, err := NewRegistry(cfg, dir)
+		if err != nil {
+			t.Fatalf("NewRegistry failed: %v", err)
+		}
+		defer r.Close()
+		src, _ := r.Get("items")
+		rows, err := src.Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("fetch failed: %v", err)
+		}
+		return rows
+	}
+	fetch()
+	if _, err := os.Stat(filepath.Join(dir, ".tinkerdown", "cache.db")); err != nil {
+		t.Fatalf("expected cache file: %v", err)
+	}
+
+	// A new registry reads the cached rows, not the changed file
+	os.WriteFile(path, []byte(`[{"id": 1}, {"id": 2}]`), 0644)
+	if rows := fetch(); len(rows) != 1 {
+		t.Errorf("expected cached rows after restart, got %v", rows)
+	}
+
+	cfg.Cache.Backend = "redis"
+	if _, err := NewRegistry(cfg, dir); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
 func TestCachedSourceName(t *testing.T) {
 	c := cache.NewMemoryCache()
 	defer c.Stop()
@@ -401,3 +577,171 @@ func TestCachedSourceCloseStopsRevalidation(t *testing.T) {
 	// Background revalidation should have been cancelled, not completed
 	// It may or may not have started, but it shouldn't complete after Close()
 }
+
+// etagServer serves rows with an ETag and answers conditional requests with 304
+func etagServer(cacheControl string, full, notModified *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(full, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 1}, {"id": 2}]`))
+	}))
+}
+
+func TestCachedSourceConditionalRevalidation(t *testing.T) {
+	var full, notModified int32
+	server := etagServer("max-age=0", &full, &notModified)
+	defer server.Close()
+
+	c := cache.NewMemoryCache()
+	defer c.Stop()
+
+	cfg := config.SourceConfig{
+		Type:  "rest",
+		From:  server.URL,
+		Cache: &config.CacheConfig{RespectCacheControl: true},
+	}
+	inner, err := NewRestSourceWithConfig("test", cfg, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	cached := NewCachedSource(inner, c, cfg)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		data, err := cached.Fetch(ctx)
+		if err != nil {
+			t.Fatalf("fetch %d: unexpected error: %v", i, err)
+		}
+		if len(data) != 2 {
+			t.Fatalf("fetch %d: expected 2 rows, got %d", i, len(data))
+		}
+	}
+
+	// max-age=0: every fetch revalidates, and only the first transfers data
+	if full != 1 || notModified != 2 {
+		t.Errorf("expected 1 full response and 2 revalidations, got %d and %d", full, notModified)
+	}
+}
+
+func TestCachedSourceConditionalRevalidationWithSchema(t *testing.T) {
+	var full, notModified int32
+	server := etagServer("max-age=0", &full, &notModified)
+	defer server.Close()
+
+	c := cache.NewMemoryCache()
+	defer c.Stop()
+
+	cfg := config.SourceConfig{
+		Type:   "rest",
+		From:   server.URL,
+		Cache:  &config.CacheConfig{RespectCacheControl: true},
+		Schema: map[string]config.FieldSchema{"id": {Type: "string"}},
+	}
+	rest, err := NewRestSourceWithConfig("test", cfg, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	inner, err := WithTransform(rest, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := NewCachedSource(inner, c, cfg)
+
+	for i := 0; i < 3; i++ {
+		data, err := cached.Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("fetch %d: unexpected error: %v", i, err)
+		}
+		// Rows reused on 304 keep the coercion of the full response
+		if len(data) != 2 || data[0]["id"] != "1" {
+			t.Fatalf("fetch %d: unexpected rows %v", i, data)
+		}
+	}
+
+	// The schema wrapper still revalidates with the cached ETag
+	if full != 1 || notModified != 2 {
+		t.Errorf("expected 1 full response and 2 revalidations, got %d and %d", full, notModified)
+	}
+}
+
+func TestCachedSourceCacheControl(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		ttl          string
+		wantRequests int32
+	}{
+		{"max-age keeps data fresh", "max-age=60", "", 1},
+		{"max-age overrides ttl", "max-age=0", "1h", 3},
+		{"no-store is not cached", "no-store", "1h", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var full, notModified int32
+			server := etagServer(tt.cacheControl, &full, &notModified)
+			defer server.Close()
+
+			c := cache.NewMemoryCache()
+			defer c.Stop()
+
+			cfg := config.SourceConfig{
+				Type:  "rest",
+				From:  server.URL,
+				Cache: &config.CacheConfig{TTL: tt.ttl, RespectCacheControl: true},
+			}
+			inner, err := NewRestSourceWithConfig("test", cfg, "")
+			if err != nil {
+				t.Fatalf("Failed to create source: %v", err)
+			}
+			cached := NewCachedSource(inner, c, cfg)
+
+			for i := 0; i < 3; i++ {
+				if _, err := cached.Fetch(context.Background()); err != nil {
+					t.Fatalf("fetch %d: unexpected error: %v", i, err)
+				}
+			}
+			if got := full + notModified; got != tt.wantRequests {
+				t.Errorf("expected %d requests, got %d", tt.wantRequests, got)
+			}
+		})
+	}
+}
+
+func TestParseHTTPCacheInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    HTTPCacheInfo
+	}{
+		{"none", nil, HTTPCacheInfo{}},
+		{"validators", map[string]string{"ETag": `W/"abc"`, "Last-Modified": "Mon, 05 Jan 2026 10:00:00 GMT"},
+			HTTPCacheInfo{ETag: `W/"abc"`, LastModified: "Mon, 05 Jan 2026 10:00:00 GMT"}},
+		{"max-age", map[string]string{"Cache-Control": "public, max-age=300"}, HTTPCacheInfo{MaxAge: 5 * time.Minute, HasMaxAge: true}},
+		{"s-maxage wins", map[string]string{"Cache-Control": "max-age=300, s-maxage=60"}, HTTPCacheInfo{MaxAge: time.Minute, HasMaxAge: true}},
+		{"age is subtracted", map[string]string{"Cache-Control": "max-age=300", "Age": "100"}, HTTPCacheInfo{MaxAge: 200 * time.Second, HasMaxAge: true}},
+		{"no-cache", map[string]string{"Cache-Control": "no-cache, max-age=300"}, HTTPCacheInfo{HasMaxAge: true}},
+		{"no-store", map[string]string{"Cache-Control": "no-store"}, HTTPCacheInfo{NoStore: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+			if got := parseHTTPCacheInfo(header); got != tt.want {
+				t.Errorf("parseHTTPCacheInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
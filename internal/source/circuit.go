@@ -2,9 +2,12 @@ package source
 
 import (
 	"context"
+	"errors"
 	"log"
 	"sync"
 	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
 )
 
 // CircuitState represents the state of a circuit breaker
@@ -36,6 +39,9 @@ type CircuitBreakerConfig struct {
 	Timeout          time.Duration // Time to wait before half-open (default: 30s)
 	FailureWindow    time.Duration // Window to count failures (default: 1 minute)
 	EnableLog        bool          // Whether to log state changes
+
+	// IsFailure reports whether an error counts as a failure (default: isServiceFailure)
+	IsFailure func(error) bool
 }
 
 // DefaultCircuitBreakerConfig returns the default circuit breaker configuration
@@ -49,6 +55,34 @@ func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
 	}
 }
 
+// sourceCircuitBreakerConfig returns the circuit breaker settings of a source:
+// the defaults, with those of its circuit_breaker config
+func sourceCircuitBreakerConfig(cfg config.SourceConfig) CircuitBreakerConfig {
+	cbConfig := DefaultCircuitBreakerConfig()
+	cbConfig.FailureThreshold = cfg.GetCircuitFailureThreshold()
+	cbConfig.SuccessThreshold = cfg.GetCircuitSuccessThreshold()
+	cbConfig.Timeout = cfg.GetCircuitTimeout()
+	cbConfig.FailureWindow = cfg.GetCircuitFailureWindow()
+	return cbConfig
+}
+
+// isServiceFailure reports whether an error shows the service failing: errors
+// worth retrying, including those that exhausted their retries (WithRetry
+// marks them as no longer retryable)
+func isServiceFailure(err error) bool {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return false
+	}
+	return shouldRetry(err) || isRetryableError(err)
+}
+
+// isCommandFailure counts every error of a command (exec, wasm) as a failure,
+// except cancellation: commands fail with their own errors, not retryable ones
+func isCommandFailure(err error) bool {
+	return !errors.Is(err, context.Canceled)
+}
+
 // CircuitBreaker implements the circuit breaker pattern
 type CircuitBreaker struct {
 	name   string
@@ -59,6 +93,7 @@ type CircuitBreaker struct {
 	failures        []time.Time // Recent failure timestamps
 	successes       int         // Consecutive successes in half-open state
 	lastStateChange time.Time
+	lastErr         error // Error of the last failed request since the last success
 }
 
 // NewCircuitBreaker creates a new circuit breaker
@@ -72,6 +107,28 @@ func NewCircuitBreaker(name string, config CircuitBreakerConfig) *CircuitBreaker
 	}
 }
 
+// circuitBreakers are the circuit breakers shared by sources, by name and config
+var (
+	circuitBreakersMu sync.Mutex
+	circuitBreakers   = make(map[string]*CircuitBreaker)
+)
+
+// sharedCircuitBreaker returns the circuit breaker of a source, shared with the
+// sources of the same name and config (e.g., the blocks of other pages), so
+// they see the same state and a Reset applies to all of them
+func sharedCircuitBreaker(name string, cfg config.SourceConfig, cbConfig CircuitBreakerConfig) *CircuitBreaker {
+	key := flightKey(name, cfg)
+
+	circuitBreakersMu.Lock()
+	defer circuitBreakersMu.Unlock()
+	cb, ok := circuitBreakers[key]
+	if !ok {
+		cb = NewCircuitBreaker(name, cbConfig)
+		circuitBreakers[key] = cb
+	}
+	return cb
+}
+
 // Execute runs a function through the circuit breaker
 func (cb *CircuitBreaker) Execute(ctx context.Context, fn RetryableFunc) ([]map[string]interface{}, error) {
 	if !cb.canExecute() {
@@ -121,9 +178,17 @@ func (cb *CircuitBreaker) recordResult(err error) {
 	now := time.Now()
 
 	if err == nil {
+		cb.lastErr = nil
 		cb.recordSuccess()
-	} else if shouldRetry(err) {
-		// Only count retryable errors as failures
+		return
+	}
+
+	cb.lastErr = err
+	isFailure := cb.config.IsFailure
+	if isFailure == nil {
+		isFailure = isServiceFailure
+	}
+	if isFailure(err) {
 		cb.recordFailure(now)
 	}
 }
@@ -193,13 +258,106 @@ func (cb *CircuitBreaker) State() CircuitState {
 	return cb.state
 }
 
+// LastError returns the error of the last failed request, or nil if the last
+// request succeeded
+func (cb *CircuitBreaker) LastError() error {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.lastErr
+}
+
 // Reset forces the circuit breaker to closed state
 func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
+	if cb.config.EnableLog && cb.state != CircuitClosed {
+		log.Printf("[circuit/%s] Reset: %s -> %s", cb.name, cb.state, CircuitClosed)
+	}
 	cb.state = CircuitClosed
 	cb.failures = cb.failures[:0]
 	cb.successes = 0
 	cb.lastStateChange = time.Now()
+	cb.lastErr = nil
+}
+
+// BreakerSource is implemented by sources guarded by a circuit breaker
+type BreakerSource interface {
+	CircuitBreaker() *CircuitBreaker
 }
 
+// SourceCircuitBreaker returns the circuit breaker of a source, looking through
+// wrapping sources (cache, transform). Returns nil if the source has none.
+func SourceCircuitBreaker(src Source) *CircuitBreaker {
+	for src != nil {
+		if bs, ok := src.(BreakerSource); ok {
+			return bs.CircuitBreaker()
+		}
+		wrapper, ok := src.(interface{ GetInner() Source })
+		if !ok {
+			return nil
+		}
+		src = wrapper.GetInner()
+	}
+	return nil
+}
+
+// CircuitSource guards the fetches of a source that has no circuit breaker of
+// its own (e.g., wasm) with one
+type CircuitSource struct {
+	inner   Source
+	breaker *CircuitBreaker
+}
+
+// WithCircuitBreaker wraps src with a circuit breaker configured by the
+// circuit_breaker settings of cfg. Writable sources stay writable; writes are
+// not guarded.
+func WithCircuitBreaker(src Source, cfg config.SourceConfig) Source {
+	cbConfig := sourceCircuitBreakerConfig(cfg)
+	cbConfig.IsFailure = isCommandFailure
+	cs := &CircuitSource{inner: src, breaker: sharedCircuitBreaker(src.Name(), cfg, cbConfig)}
+	if ws, ok := src.(WritableSource); ok {
+		return &CircuitWritableSource{CircuitSource: cs, writable: ws}
+	}
+	return cs
+}
+
+// Name returns the source name
+func (s *CircuitSource) Name() string {
+	return s.inner.Name()
+}
+
+// Fetch fetches the rows of the source through the circuit breaker
+func (s *CircuitSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	return s.breaker.Execute(ctx, s.inner.Fetch)
+}
+
+// Close closes the underlying source
+func (s *CircuitSource) Close() error {
+	return s.inner.Close()
+}
+
+// CircuitBreaker returns the circuit breaker of the source
+func (s *CircuitSource) CircuitBreaker() *CircuitBreaker {
+	return s.breaker
+}
+
+// GetInner returns the underlying source
+func (s *CircuitSource) GetInner() Source {
+	return s.inner
+}
+
+// CircuitWritableSource is a CircuitSource over a WritableSource
+type CircuitWritableSource struct {
+	*CircuitSource
+	writable WritableSource
+}
+
+// WriteItem performs a write on the underlying source
+func (s *CircuitWritableSource) WriteItem(ctx context.Context, action string, data map[string]interface{}) error {
+	return s.writable.WriteItem(ctx, action, data)
+}
+
+// IsReadonly returns whether the underlying source is read-only
+func (s *CircuitWritableSource) IsReadonly() bool {
+	return s.writable.IsReadonly()
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"testing"
 	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/cache"
+	"github.com/livetemplate/tinkerdown/internal/config"
 )
 
 func TestCircuitBreakerInitialState(t *testing.T) {
@@ -290,3 +293,162 @@ func TestDefaultCircuitBreakerConfig(t *testing.T) {
 		t.Errorf("expected FailureWindow=1m, got %v", cfg.FailureWindow)
 	}
 }
+
+func TestCircuitBreakerLastError(t *testing.T) {
+	cb := NewCircuitBreaker("test", DefaultCircuitBreakerConfig())
+	fail := errors.New("upstream down")
+
+	cb.Execute(context.Background(), func(ctx context.Context) ([]map[string]interface{}, error) {
+		return nil, fail
+	})
+	if cb.LastError() != fail {
+		t.Errorf("expected last error %v, got %v", fail, cb.LastError())
+	}
+
+	cb.Execute(context.Background(), func(ctx context.Context) ([]map[string]interface{}, error) {
+		return nil, nil
+	})
+	if cb.LastError() != nil {
+		t.Errorf("expected no error after a success, got %v", cb.LastError())
+	}
+
+	cb.Execute(context.Background(), func(ctx context.Context) ([]map[string]interface{}, error) {
+		return nil, fail
+	})
+	cb.Reset()
+	if cb.LastError() != nil {
+		t.Errorf("expected no error after reset, got %v", cb.LastError())
+	}
+}
+
+func TestCircuitBreakerIsFailure(t *testing.T) {
+	cfg := CircuitBreakerConfig{
+		FailureThreshold: 2,
+		SuccessThreshold: 1,
+		Timeout:          1 * time.Minute,
+		FailureWindow:    1 * time.Minute,
+		IsFailure:        isCommandFailure,
+	}
+	cb := NewCircuitBreaker("test", cfg)
+
+	// Cancellation doesn't count
+	cb.Execute(context.Background(), func(ctx context.Context) ([]map[string]interface{}, error) {
+		return nil, context.Canceled
+	})
+	if cb.State() != CircuitClosed {
+		t.Errorf("expected cancellation not to count, got %v", cb.State())
+	}
+
+	// Errors that are not retryable count
+	for i := 0; i < 2; i++ {
+		cb.Execute(context.Background(), func(ctx context.Context) ([]map[string]interface{}, error) {
+			return nil, errors.New("exit status 1")
+		})
+	}
+	if cb.State() != CircuitOpen {
+		t.Errorf("expected circuit to be Open, got %v", cb.State())
+	}
+}
+
+func TestSourceCircuitBreakerConfig(t *testing.T) {
+	cbConfig := sourceCircuitBreakerConfig(config.SourceConfig{
+		Circuit: &config.CircuitBreakerConfig{FailureThreshold: 3, Timeout: "5s"},
+	})
+	if cbConfig.FailureThreshold != 3 || cbConfig.Timeout != 5*time.Second {
+		t.Errorf("expected configured settings, got %+v", cbConfig)
+	}
+	if cbConfig.SuccessThreshold != 2 || cbConfig.FailureWindow != time.Minute || !cbConfig.EnableLog {
+		t.Errorf("expected defaults for the other settings, got %+v", cbConfig)
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	inner := &mockWritableSource{mockSource: mockSource{name: "plugin", fetchErr: errors.New("plugin crashed")}}
+	src := WithCircuitBreaker(inner, config.SourceConfig{
+		Circuit: &config.CircuitBreakerConfig{FailureThreshold: 2},
+	})
+	if _, ok := src.(WritableSource); !ok {
+		t.Fatal("expected writable source to stay writable")
+	}
+	// Sources of the same name and config share the breaker
+	t.Cleanup(SourceCircuitBreaker(src).Reset)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		src.Fetch(ctx)
+	}
+	_, err := src.Fetch(ctx)
+	var circuitErr *CircuitOpenError
+	if !errors.As(err, &circuitErr) {
+		t.Errorf("expected circuit open error, got %v", err)
+	}
+	if inner.FetchCount() != 2 {
+		t.Errorf("expected 2 fetches before the circuit opened, got %d", inner.FetchCount())
+	}
+
+	// The breaker is found through wrapping sources
+	c := cache.NewMemoryCache()
+	defer c.Stop()
+	cached := NewCachedSource(src, c, config.SourceConfig{})
+	breaker := SourceCircuitBreaker(cached)
+	if breaker == nil || breaker.State() != CircuitOpen {
+		t.Errorf("expected the open breaker of the wrapped source, got %v", breaker)
+	}
+	if SourceCircuitBreaker(inner) != nil {
+		t.Error("expected no breaker for a source without one")
+	}
+}
+
+func TestSharedCircuitBreaker(t *testing.T) {
+	cfg := config.SourceConfig{Type: "rest", From: "http://example.invalid/shared", Circuit: &config.CircuitBreakerConfig{FailureThreshold: 1}}
+	first, err := NewRestSourceWithConfig("api", cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewRestSourceWithConfig("api", cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.CircuitBreaker() != second.CircuitBreaker() {
+		t.Fatal("expected sources of the same name and config to share the breaker")
+	}
+
+	// A reset through one source closes the circuit of the other
+	first.CircuitBreaker().recordResult(errors.New("connection refused"))
+	if second.CircuitBreaker().State() != CircuitOpen {
+		t.Errorf("expected the shared circuit to be open, got %s", second.CircuitBreaker().State())
+	}
+	first.CircuitBreaker().Reset()
+	if second.CircuitBreaker().State() != CircuitClosed {
+		t.Errorf("expected the shared circuit to be closed, got %s", second.CircuitBreaker().State())
+	}
+
+	cfg.Circuit = &config.CircuitBreakerConfig{FailureThreshold: 3}
+	if other, _ := NewRestSourceWithConfig("api", cfg, ""); other.CircuitBreaker() == first.CircuitBreaker() {
+		t.Error("expected a changed config to get its own breaker")
+	}
+}
+
+func TestCircuitBreakerCountsExhaustedRetries(t *testing.T) {
+	cfg := CircuitBreakerConfig{
+		FailureThreshold: 2,
+		SuccessThreshold: 1,
+		Timeout:          1 * time.Minute,
+		FailureWindow:    1 * time.Minute,
+	}
+	cb := NewCircuitBreaker("test", cfg)
+	retry := RetryConfig{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 2}
+
+	// WithRetry marks the error as no longer retryable once its retries are used up
+	for i := 0; i < 2; i++ {
+		cb.Execute(context.Background(), func(ctx context.Context) ([]map[string]interface{}, error) {
+			return WithRetry(ctx, "test", retry, func(ctx context.Context) ([]map[string]interface{}, error) {
+				return nil, &HTTPError{Source: "test", StatusCode: 503, Status: "503 Service Unavailable"}
+			})
+		})
+	}
+
+	if cb.State() != CircuitOpen {
+		t.Errorf("expected failures after retries to open the circuit, got %v", cb.State())
+	}
+}
@@ -0,0 +1,116 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// flightGroup coalesces concurrent fetches of the same key: the first caller
+// fetches, and the others wait for its result instead of fetching again.
+// The zero value is ready to use.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall is a fetch in progress
+type flightCall struct {
+	done chan struct{}
+	data []map[string]interface{}
+	err  error
+
+	// For conditional fetches (see DoConditional)
+	info        HTTPCacheInfo
+	notModified bool
+
+	cancelled bool // The context of the caller running fn ended
+}
+
+// Do runs fn, or waits for the fn already running for key and returns its
+// result. Waiting stops when ctx is done. If the context of the caller running
+// fn ends, the waiters whose context is still active fetch again.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func() ([]map[string]interface{}, error)) ([]map[string]interface{}, error) {
+	data, _, _, err := g.DoConditional(ctx, key, func() ([]map[string]interface{}, HTTPCacheInfo, bool, error) {
+		data, err := fn()
+		return data, HTTPCacheInfo{}, false, err
+	})
+	return data, err
+}
+
+// DoConditional is like Do for conditional fetches (see ConditionalSource):
+// the waiters also share the cache info and whether the response was a 304.
+// The key must include the validators sent.
+func (g *flightGroup) DoConditional(ctx context.Context, key string, fn func() ([]map[string]interface{}, HTTPCacheInfo, bool, error)) ([]map[string]interface{}, HTTPCacheInfo, bool, error) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = make(map[string]*flightCall)
+		}
+		call, running := g.calls[key]
+		if !running {
+			call = &flightCall{done: make(chan struct{})}
+			g.calls[key] = call
+			g.mu.Unlock()
+
+			g.run(ctx, key, call, fn)
+			return call.data, call.info, call.notModified, call.err
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, HTTPCacheInfo{}, false, ctx.Err()
+		}
+		if call.cancelled && ctx.Err() == nil {
+			continue
+		}
+		return call.data, call.info, call.notModified, call.err
+	}
+}
+
+// run runs fn for a call and releases its waiters, even if fn panics: they get
+// an error, and the panic continues in the caller running fn.
+func (g *flightGroup) run(ctx context.Context, key string, call *flightCall, fn func() ([]map[string]interface{}, HTTPCacheInfo, bool, error)) {
+	completed := false
+	defer func() {
+		var recovered interface{}
+		if !completed {
+			recovered = recover()
+			call.data, call.err = nil, fmt.Errorf("fetch panicked: %v", recovered)
+		}
+		call.cancelled = ctx.Err() != nil
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+	call.data, call.info, call.notModified, call.err = fn()
+	completed = true
+}
+
+// flightKey identifies the fetches of a source configuration, so that sources
+// created from the same configuration (e.g., one per connection) share fetches
+func flightKey(name string, cfg config.SourceConfig) string {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return name
+	}
+	sum := sha256.Sum256(data)
+	return name + ":" + hex.EncodeToString(sum[:])
+}
+
+// conditionalFlightKey identifies the conditional fetches of a configuration
+// sending the same validators
+func conditionalFlightKey(key, etag, lastModified string) string {
+	return key + "|" + etag + "|" + lastModified
+}
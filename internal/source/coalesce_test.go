@@ -0,0 +1,248 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/cache"
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+func TestFlightGroupCoalesces(t *testing.T) {
+	var g flightGroup
+	var calls int32
+	release := make(chan struct{})
+	fn := func() ([]map[string]interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []map[string]interface{}{{"id": 1}}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]map[string]interface{}, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = g.Do(context.Background(), "key", fn)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected 1 call, got %d", got)
+	}
+	for i, rows := range results {
+		if len(rows) != 1 {
+			t.Errorf("caller %d got %v", i, rows)
+		}
+	}
+
+	// Later calls run again
+	g.Do(context.Background(), "key", func() ([]map[string]interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	})
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected a new call after the first finished, got %d calls", got)
+	}
+}
+
+func TestFlightGroupCancellation(t *testing.T) {
+	var g flightGroup
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	started := make(chan struct{})
+
+	leaderDone := make(chan error)
+	go func() {
+		_, err := g.Do(leaderCtx, "key", func() ([]map[string]interface{}, error) {
+			close(started)
+			<-leaderCtx.Done()
+			return nil, leaderCtx.Err()
+		})
+		leaderDone <- err
+	}()
+	<-started
+
+	// A waiter whose context ends stops waiting
+	waiterCtx, cancelWaiter := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelWaiter()
+	if _, err := g.Do(waiterCtx, "key", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiter error = %v, want deadline exceeded", err)
+	}
+
+	// A waiter outliving the cancelled caller fetches itself
+	waiterDone := make(chan []map[string]interface{})
+	go func() {
+		rows, _ := g.Do(context.Background(), "key", func() ([]map[string]interface{}, error) {
+			return []map[string]interface{}{{"id": 2}}, nil
+		})
+		waiterDone <- rows
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancelLeader()
+
+	if err := <-leaderDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader error = %v, want canceled", err)
+	}
+	if rows := <-waiterDone; len(rows) != 1 || rows[0]["id"] != 2 {
+		t.Errorf("waiter got %v, want its own fetch", rows)
+	}
+}
+
+func TestFlightGroupPanic(t *testing.T) {
+	var g flightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	leaderPanic := make(chan interface{})
+	go func() {
+		defer func() { leaderPanic <- recover() }()
+		g.Do(context.Background(), "key", func() ([]map[string]interface{}, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	waiterErr := make(chan error)
+	go func() {
+		_, err := g.Do(context.Background(), "key", nil)
+		waiterErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if r := <-leaderPanic; r != "boom" {
+		t.Errorf("leader recovered %v, want the panic", r)
+	}
+	select {
+	case err := <-waiterErr:
+		if err == nil || !strings.Contains(err.Error(), "fetch panicked: boom") {
+			t.Errorf("waiter error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter still blocked after the panic")
+	}
+
+	// The key is free again
+	rows, err := g.Do(context.Background(), "key", func() ([]map[string]interface{}, error) {
+		return []map[string]interface{}{{"id": 1}}, nil
+	})
+	if err != nil || len(rows) != 1 {
+		t.Errorf("Do after panic = %v, %v", rows, err)
+	}
+}
+
+func TestRestSource_CoalescesFetches(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer server.Close()
+
+	// Sources created from the same configuration, as by several connections
+	cfg := config.SourceConfig{From: server.URL}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		src, err := NewRestSourceWithConfig("shared", cfg, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rows, err := src.Fetch(context.Background()); err != nil || len(rows) != 1 {
+				t.Errorf("fetch = %v, %v", rows, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected 1 request, got %d", got)
+	}
+}
+
+func TestRestSource_CoalescesConditionalFetches(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("ETag", `"v2"`)
+		w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer server.Close()
+
+	cfg := config.SourceConfig{From: server.URL}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		src, err := NewRestSourceWithConfig("shared-conditional", cfg, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rows, info, notModified, err := src.FetchConditional(context.Background(), `"v1"`, "")
+			if err != nil || notModified || len(rows) != 1 || info.ETag != `"v2"` {
+				t.Errorf("fetch = %v, %+v, %v, %v", rows, info, notModified, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected 1 request, got %d", got)
+	}
+}
+
+func TestCachedSource_CoalescesAcrossInstances(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer server.Close()
+
+	c := cache.NewMemoryCache()
+	defer c.Stop()
+
+	// Cached sources of one configuration sharing a cache, as created by
+	// several connections to the same page
+	cfg := config.SourceConfig{From: server.URL, Cache: &config.CacheConfig{TTL: "1m"}}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		inner, err := NewRestSourceWithConfig("shared-cached", cfg, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cached := NewCachedSource(inner, c, cfg)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rows, err := cached.Fetch(context.Background()); err != nil || len(rows) != 1 {
+				t.Errorf("fetch = %v, %v", rows, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected 1 request, got %d", got)
+	}
+}
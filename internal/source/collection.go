@@ -0,0 +1,491 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// collectionBuiltinKeys are row columns computed from the file rather than read
+// from frontmatter. They are ignored when writing.
+var collectionBuiltinKeys = map[string]bool{
+	"id": true, "path": true, "url": true, "modified": true, "word_count": true, "content": true,
+}
+
+// CollectionSource treats every markdown file matching a glob as a row.
+// Frontmatter keys become columns, plus id, path, title, url, modified and word_count.
+// It implements WritableSource: add creates a new file (optionally from a template),
+// update rewrites frontmatter in place, and delete removes the file.
+type CollectionSource struct {
+	name         string
+	glob         string
+	siteDir      string
+	readonly     bool
+	templateFile string // For add: template for new files (Go text/template)
+	filename     string // For add: file name template (default "{{.slug}}.md")
+
+	pattern *regexp.Regexp // compiled glob, matched against slash paths relative to siteDir
+	baseDir string         // static directory prefix of the glob
+}
+
+// NewCollectionSource creates a new collection source.
+// Supported options: template (file used for new entries), filename (name template for new entries)
+func NewCollectionSource(name string, cfg config.SourceConfig, siteDir string) (*CollectionSource, error) {
+	if cfg.Glob == "" {
+		return nil, &ValidationError{Source: name, Field: "glob", Reason: "glob is required (e.g., \"posts/*.md\")"}
+	}
+
+	glob := filepath.ToSlash(cfg.Glob)
+	if filepath.IsAbs(cfg.Glob) || strings.HasPrefix(glob, "../") || strings.Contains(glob, "/../") {
+		return nil, &ValidationError{Source: name, Field: "glob", Reason: "glob must be relative to the site directory"}
+	}
+
+	pattern, err := globToRegexp(glob)
+	if err != nil {
+		return nil, &ValidationError{Source: name, Field: "glob", Reason: err.Error()}
+	}
+
+	filename := cfg.Options["filename"]
+	if filename == "" {
+		filename = "{{.slug}}.md"
+	}
+
+	return &CollectionSource{
+		name:         name,
+		glob:         glob,
+		siteDir:      siteDir,
+		readonly:     cfg.IsReadonly(),
+		templateFile: cfg.Options["template"],
+		filename:     filename,
+		pattern:      pattern,
+		baseDir:      globBaseDir(glob),
+	}, nil
+}
+
+// Name returns the source identifier
+func (s *CollectionSource) Name() string {
+	return s.name
+}
+
+// Fetch reads every matching file and returns one row per file, ordered by path
+func (s *CollectionSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	paths, err := s.matchingFiles()
+	if err != nil {
+		return nil, fmt.Errorf("collection source %q: %w", s.name, err)
+	}
+
+	results := make([]map[string]interface{}, 0, len(paths))
+	for _, relPath := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		row, err := s.readEntry(relPath)
+		if err != nil {
+			return nil, fmt.Errorf("collection source %q: %w", s.name, err)
+		}
+		results = append(results, row)
+	}
+
+	return results, nil
+}
+
+// Close is a no-op for file sources
+func (s *CollectionSource) Close() error {
+	return nil
+}
+
+// IsReadonly returns whether the source is read-only
+func (s *CollectionSource) IsReadonly() bool {
+	return s.readonly
+}
+
+// matchingFiles returns the slash-separated paths (relative to siteDir) of files matching the glob
+func (s *CollectionSource) matchingFiles() ([]string, error) {
+	root := filepath.Join(s.siteDir, filepath.FromSlash(s.baseDir))
+	var paths []string
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipAll // No directory yet means no entries
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(s.siteDir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if s.pattern.MatchString(relPath) {
+			paths = append(paths, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// readEntry reads a file and builds its row
+func (s *CollectionSource) readEntry(relPath string) (map[string]interface{}, error) {
+	fullPath := filepath.Join(s.siteDir, filepath.FromSlash(relPath))
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", relPath, err)
+	}
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+	}
+
+	frontmatter, body := splitFrontmatter(content)
+
+	row := make(map[string]interface{})
+	if len(frontmatter) > 0 {
+		if err := yaml.Unmarshal(frontmatter, &row); err != nil {
+			return nil, fmt.Errorf("failed to parse frontmatter in %s: %w", relPath, err)
+		}
+		if row == nil {
+			row = make(map[string]interface{})
+		}
+	}
+
+	if title, ok := row["title"].(string); !ok || title == "" {
+		row["title"] = collectionTitle(relPath, body)
+	}
+	row["id"] = relPath
+	row["path"] = relPath
+	row["url"] = config.PageURL(relPath)
+	row["modified"] = info.ModTime().UTC().Format(time.RFC3339)
+	row["word_count"] = len(strings.Fields(string(body)))
+
+	return row, nil
+}
+
+// WriteItem creates, updates or deletes collection entries.
+// Supported actions: add, update, delete
+func (s *CollectionSource) WriteItem(ctx context.Context, action string, data map[string]interface{}) error {
+	if s.readonly {
+		return fmt.Errorf("collection source %q is read-only", s.name)
+	}
+
+	switch action {
+	case "add":
+		return s.addEntry(data)
+	case "update":
+		return s.updateEntry(data)
+	case "delete":
+		path, err := s.entryPath(data)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("collection source %q: failed to delete entry: %w", s.name, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("collection source %q: unsupported action: %s", s.name, action)
+	}
+}
+
+// entryPath resolves the file for an existing entry by its id, rejecting paths outside the collection
+func (s *CollectionSource) entryPath(data map[string]interface{}) (string, error) {
+	id, _ := data["id"].(string)
+	if id == "" {
+		return "", fmt.Errorf("collection source %q: 'id' field is required", s.name)
+	}
+	if !s.pattern.MatchString(id) || strings.Contains(id, "..") {
+		return "", fmt.Errorf("collection source %q: %q is not an entry of this collection", s.name, id)
+	}
+	return filepath.Join(s.siteDir, filepath.FromSlash(id)), nil
+}
+
+// addEntry creates a new file in the collection's base directory
+func (s *CollectionSource) addEntry(data map[string]interface{}) error {
+	vars := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		vars[k] = v
+	}
+	title, _ := data["title"].(string)
+	if _, ok := vars["slug"]; !ok {
+		vars["slug"] = slugify(title)
+	}
+
+	filename, err := executeTextTemplate("filename", s.filename, vars)
+	if err != nil {
+		return fmt.Errorf("collection source %q: invalid filename template: %w", s.name, err)
+	}
+	filename = strings.TrimSpace(filename)
+	if filename == "" || filename == ".md" || strings.ContainsAny(filename, `/\`) || strings.HasPrefix(filename, ".") {
+		return fmt.Errorf("collection source %q: cannot derive a file name for the new entry (is 'title' set?)", s.name)
+	}
+
+	relPath := strings.TrimPrefix(s.baseDir+"/"+filename, "/")
+	if !s.pattern.MatchString(relPath) {
+		return fmt.Errorf("collection source %q: new entry %q does not match glob %q", s.name, relPath, s.glob)
+	}
+	fullPath := filepath.Join(s.siteDir, filepath.FromSlash(relPath))
+
+	// Start from the template, or from the submitted content
+	var content []byte
+	if s.templateFile != "" {
+		tmplPath := s.templateFile
+		if !filepath.IsAbs(tmplPath) {
+			tmplPath = filepath.Join(s.siteDir, tmplPath)
+		}
+		tmplContent, err := os.ReadFile(tmplPath)
+		if err != nil {
+			return fmt.Errorf("collection source %q: failed to read template: %w", s.name, err)
+		}
+		rendered, err := executeTextTemplate("template", string(tmplContent), vars)
+		if err != nil {
+			return fmt.Errorf("collection source %q: failed to render template: %w", s.name, err)
+		}
+		content = []byte(rendered)
+	} else if body, ok := data["content"].(string); ok {
+		content = []byte(body)
+	}
+
+	content, err = setFrontmatterFields(content, data)
+	if err != nil {
+		return fmt.Errorf("collection source %q: %w", s.name, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("collection source %q: failed to create directory: %w", s.name, err)
+	}
+	// Never overwrites an entry, also one added concurrently with the same slug
+	if err := createFileAtomic(fullPath, content, 0644); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("collection source %q: entry %q already exists", s.name, relPath)
+		}
+		return fmt.Errorf("collection source %q: failed to write entry: %w", s.name, err)
+	}
+	return nil
+}
+
+// updateEntry sets frontmatter fields of an existing entry, leaving the body untouched
+func (s *CollectionSource) updateEntry(data map[string]interface{}) error {
+	path, err := s.entryPath(data)
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockFile(path)
+	if err != nil {
+		return fmt.Errorf("collection source %q: %w", s.name, err)
+	}
+	defer lock.unlock()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("collection source %q: failed to read entry: %w", s.name, err)
+	}
+
+	content, err = setFrontmatterFields(content, data)
+	if err != nil {
+		return fmt.Errorf("collection source %q: %w", s.name, err)
+	}
+
+	if err := writeFileAtomic(path, content, 0644); err != nil {
+		return fmt.Errorf("collection source %q: failed to write entry: %w", s.name, err)
+	}
+	return nil
+}
+
+// splitFrontmatter splits a markdown file into its YAML frontmatter (without the
+// --- delimiters) and body. frontmatter is nil if the file has none.
+func splitFrontmatter(content []byte) (frontmatter, body []byte) {
+	if !bytes.HasPrefix(content, []byte("---\n")) {
+		return nil, content
+	}
+	rest := content[4:]
+	if bytes.HasPrefix(rest, []byte("---\n")) {
+		return []byte{}, rest[4:] // Empty frontmatter
+	}
+	end := bytes.Index(rest, []byte("\n---\n"))
+	if end == -1 {
+		if bytes.HasSuffix(rest, []byte("\n---")) {
+			return rest[:len(rest)-4], nil
+		}
+		return nil, content
+	}
+	return rest[:end+1], rest[end+5:]
+}
+
+// setFrontmatterFields sets frontmatter keys from data, keeping the order, comments
+// and value types of existing keys. Builtin row columns are skipped.
+func setFrontmatterFields(content []byte, data map[string]interface{}) ([]byte, error) {
+	frontmatter, body := splitFrontmatter(content)
+
+	var doc yaml.Node
+	if len(bytes.TrimSpace(frontmatter)) > 0 {
+		if err := yaml.Unmarshal(frontmatter, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("frontmatter is not a mapping")
+	}
+
+	for _, key := range sortedKeys(data) {
+		if collectionBuiltinKeys[key] || key == "slug" {
+			continue
+		}
+
+		var existing *yaml.Node
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == key {
+				existing = mapping.Content[i+1]
+				break
+			}
+		}
+
+		value, err := frontmatterValueNode(existing, data[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %w", key, err)
+		}
+		if existing != nil {
+			value.HeadComment, value.LineComment, value.FootComment = existing.HeadComment, existing.LineComment, existing.FootComment
+			*existing = *value
+		} else {
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to write frontmatter: %w", err)
+	}
+	enc.Close()
+	buf.WriteString("---\n")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// frontmatterValueNode builds the YAML node for a new value. Form values arrive as
+// strings, so a string that parses as the existing scalar's type keeps that type.
+func frontmatterValueNode(existing *yaml.Node, value interface{}) (*yaml.Node, error) {
+	if str, ok := value.(string); ok && existing != nil && existing.Kind == yaml.ScalarNode {
+		var err error
+		switch existing.Tag {
+		case "!!bool":
+			_, err = strconv.ParseBool(str)
+		case "!!int":
+			_, err = strconv.ParseInt(str, 10, 64)
+		case "!!float":
+			_, err = strconv.ParseFloat(str, 64)
+		case "!!timestamp":
+			_, err = time.Parse("2006-01-02", str)
+		default:
+			err = fmt.Errorf("not a typed scalar")
+		}
+		if err == nil {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: existing.Tag, Value: str}, nil
+		}
+	}
+
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// collectionTitle derives a title from the first level-1 heading, or the file name
+func collectionTitle(relPath string, body []byte) string {
+	if m := regexp.MustCompile(`(?m)^#\s+(.+?)\s*$`).FindSubmatch(body); m != nil {
+		return string(m[1])
+	}
+	title := strings.TrimSuffix(filepath.Base(relPath), filepath.Ext(relPath))
+	title = strings.ReplaceAll(title, "-", " ")
+	title = strings.ReplaceAll(title, "_", " ")
+	if len(title) > 0 {
+		title = strings.ToUpper(title[:1]) + title[1:]
+	}
+	return title
+}
+
+// globToRegexp compiles a slash-separated glob into a regexp.
+// Supports * (within a path segment), ? and ** (any number of directories).
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.Compile(re.String())
+}
+
+// globBaseDir returns the directory part of a glob before its first wildcard.
+// Like globToRegexp, it treats only *, ? and ** as wildcards.
+func globBaseDir(glob string) string {
+	dir := glob
+	if i := strings.IndexAny(glob, "*?"); i >= 0 {
+		dir = glob[:i]
+	}
+	if i := strings.LastIndex(dir, "/"); i >= 0 {
+		return dir[:i]
+	}
+	return ""
+}
+
+// executeTextTemplate renders a Go text/template with data
+func executeTextTemplate(name, text string, data map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,377 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+func writeCollectionFixture(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func newTestCollection(t *testing.T, dir, glob string, readonly bool, options map[string]string) *CollectionSource {
+	t.Helper()
+	src, err := NewCollectionSource("posts", config.SourceConfig{
+		Type:     "collection",
+		Glob:     glob,
+		Readonly: &readonly,
+		Options:  options,
+	}, dir)
+	if err != nil {
+		t.Fatalf("NewCollectionSource failed: %v", err)
+	}
+	return src
+}
+
+func TestCollectionSourceFetch(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeCollectionFixture(t, tmpDir, map[string]string{
+		"posts/hello-world.md":    "---\ntitle: Hello World\ntags: [go, web]\ndraft: false\n---\n\nSome words here.\n",
+		"posts/no-frontmatter.md": "# From Heading\n\nOne two three four.\n",
+		"posts/plain_name.md":     "Body only.\n",
+		"posts/notes.txt":         "not markdown",
+		"other/skip.md":           "# Skip\n",
+	})
+
+	src := newTestCollection(t, tmpDir, "posts/*.md", true, nil)
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows, got %d: %v", len(rows), rows)
+	}
+
+	first := rows[0]
+	if first["id"] != "posts/hello-world.md" || first["path"] != "posts/hello-world.md" {
+		t.Errorf("Unexpected id/path: %v / %v", first["id"], first["path"])
+	}
+	if first["title"] != "Hello World" {
+		t.Errorf("Expected frontmatter title, got %v", first["title"])
+	}
+	if first["url"] != "/posts/hello-world" {
+		t.Errorf("Expected url /posts/hello-world, got %v", first["url"])
+	}
+	if first["word_count"] != 3 {
+		t.Errorf("Expected word_count 3, got %v", first["word_count"])
+	}
+	if first["draft"] != false {
+		t.Errorf("Expected draft false, got %v", first["draft"])
+	}
+	if tags, ok := first["tags"].([]interface{}); !ok || len(tags) != 2 {
+		t.Errorf("Expected 2 tags, got %v", first["tags"])
+	}
+	if _, ok := first["modified"].(string); !ok {
+		t.Errorf("Expected modified timestamp, got %v", first["modified"])
+	}
+
+	if rows[1]["title"] != "From Heading" {
+		t.Errorf("Expected heading title, got %v", rows[1]["title"])
+	}
+	if rows[2]["title"] != "Plain name" {
+		t.Errorf("Expected title from file name, got %v", rows[2]["title"])
+	}
+}
+
+func TestCollectionSourceRecursiveGlob(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeCollectionFixture(t, tmpDir, map[string]string{
+		"docs/index.md":          "# Docs\n",
+		"docs/guide/index.md":    "# Guide\n",
+		"docs/guide/setup.md":    "# Setup\n",
+		"docs/.drafts/hidden.md": "# Hidden\n",
+	})
+
+	src := newTestCollection(t, tmpDir, "docs/**/*.md", true, nil)
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	var urls []string
+	for _, row := range rows {
+		urls = append(urls, row["url"].(string))
+	}
+	if got := strings.Join(urls, ","); got != "/docs/guide/,/docs/guide/setup,/docs/" {
+		t.Errorf("Unexpected urls: %s", got)
+	}
+}
+
+func TestCollectionSourceMissingDirectory(t *testing.T) {
+	src := newTestCollection(t, t.TempDir(), "posts/*.md", true, nil)
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("Expected no rows, got %v", rows)
+	}
+}
+
+func TestNewCollectionSourceValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		glob string
+	}{
+		{"missing glob", ""},
+		{"absolute glob", "/etc/*.md"},
+		{"parent directory", "../posts/*.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCollectionSource("posts", config.SourceConfig{Type: "collection", Glob: tt.glob}, t.TempDir())
+			if err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestCollectionSourceReadonly(t *testing.T) {
+	src, err := NewCollectionSource("posts", config.SourceConfig{Type: "collection", Glob: "posts/*.md"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCollectionSource failed: %v", err)
+	}
+	if !src.IsReadonly() {
+		t.Error("Expected collection source to be read-only by default")
+	}
+	if err := src.WriteItem(context.Background(), "add", map[string]interface{}{"title": "New"}); err == nil {
+		t.Error("Expected error writing to read-only source")
+	}
+}
+
+func TestCollectionSourceAdd(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeCollectionFixture(t, tmpDir, map[string]string{
+		"templates/post.md": "---\nauthor: {{.author}}\ndraft: true\n---\n\n# {{.title}}\n\nWrite here.\n",
+	})
+
+	src := newTestCollection(t, tmpDir, "posts/*.md", false, map[string]string{"template": "templates/post.md"})
+	ctx := context.Background()
+
+	if err := src.WriteItem(ctx, "add", map[string]interface{}{"title": "My First Post", "author": "sam"}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "posts", "my-first-post.md"))
+	if err != nil {
+		t.Fatalf("Expected new file: %v", err)
+	}
+	want := "---\nauthor: sam\ndraft: true\ntitle: My First Post\n---\n\n# My First Post\n\nWrite here.\n"
+	if string(content) != want {
+		t.Errorf("Unexpected content:\n%s\nwant:\n%s", content, want)
+	}
+
+	// Adding the same title again must not overwrite the entry
+	if err := src.WriteItem(ctx, "add", map[string]interface{}{"title": "My First Post"}); err == nil {
+		t.Error("Expected error adding an existing entry")
+	}
+}
+
+func TestCollectionSourceAddConcurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := newTestCollection(t, tmpDir, "posts/*.md", false, nil)
+	ctx := context.Background()
+
+	// Of concurrent adds with the same slug, exactly one creates the entry
+	const adds = 8
+	errs := make(chan error, adds)
+	var wg sync.WaitGroup
+	for i := 0; i < adds; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- src.WriteItem(ctx, "add", map[string]interface{}{"title": "Race", "content": fmt.Sprintf("writer %d\n", i)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+		} else if !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("expected 1 add to succeed, got %d", created)
+	}
+	entries, _ := os.ReadDir(filepath.Join(tmpDir, "posts"))
+	if len(entries) != 1 {
+		t.Errorf("expected only the entry to remain, got %v", entries)
+	}
+}
+
+func TestCollectionSourceAddWithoutTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := newTestCollection(t, tmpDir, "notes/*.md", false, map[string]string{"filename": "{{.date}}-{{.slug}}.md"})
+
+	err := src.WriteItem(context.Background(), "add", map[string]interface{}{
+		"title":   "Standup",
+		"date":    "2026-01-05",
+		"content": "# Standup\n\nNotes.\n",
+	})
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "notes", "2026-01-05-standup.md"))
+	if err != nil {
+		t.Fatalf("Expected new file: %v", err)
+	}
+	want := "---\ndate: \"2026-01-05\"\ntitle: Standup\n---\n# Standup\n\nNotes.\n"
+	if string(content) != want {
+		t.Errorf("Unexpected content:\n%s\nwant:\n%s", content, want)
+	}
+}
+
+func TestCollectionSourceUpdate(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeCollectionFixture(t, tmpDir, map[string]string{
+		"posts/a.md": "---\n# Post metadata\ntitle: A\ndraft: true\nviews: 10\n---\n\nBody stays.\n",
+	})
+
+	src := newTestCollection(t, tmpDir, "posts/*.md", false, nil)
+	ctx := context.Background()
+
+	err := src.WriteItem(ctx, "update", map[string]interface{}{
+		"id":         "posts/a.md",
+		"draft":      "false",
+		"views":      "11",
+		"category":   "news",
+		"word_count": 99, // builtin columns are never written
+	})
+	if err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(tmpDir, "posts", "a.md"))
+	want := "---\n# Post metadata\ntitle: A\ndraft: false\nviews: 11\ncategory: news\n---\n\nBody stays.\n"
+	if string(content) != want {
+		t.Errorf("Unexpected content:\n%s\nwant:\n%s", content, want)
+	}
+
+	rows, err := src.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if rows[0]["draft"] != false || rows[0]["views"] != 11 {
+		t.Errorf("Expected typed values after update, got draft=%v views=%v", rows[0]["draft"], rows[0]["views"])
+	}
+}
+
+func TestCollectionSourceDelete(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeCollectionFixture(t, tmpDir, map[string]string{
+		"posts/a.md": "# A\n",
+		"secret.md":  "# Secret\n",
+	})
+
+	src := newTestCollection(t, tmpDir, "posts/*.md", false, nil)
+	ctx := context.Background()
+
+	// Entries outside the glob cannot be touched
+	for _, id := range []string{"secret.md", "posts/../secret.md"} {
+		if err := src.WriteItem(ctx, "delete", map[string]interface{}{"id": id}); err == nil {
+			t.Errorf("Expected error deleting %q", id)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "secret.md")); err != nil {
+		t.Errorf("secret.md should not be deleted: %v", err)
+	}
+
+	if err := src.WriteItem(ctx, "delete", map[string]interface{}{"id": "posts/a.md"}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "posts", "a.md")); !os.IsNotExist(err) {
+		t.Errorf("Expected file to be removed, got %v", err)
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"posts/*.md", "posts/a.md", true},
+		{"posts/*.md", "posts/sub/a.md", false},
+		{"posts/**/*.md", "posts/a.md", true},
+		{"posts/**/*.md", "posts/sub/deep/a.md", true},
+		{"posts/?.md", "posts/ab.md", false},
+		{"*.md", "index.md", true},
+		{"posts/*.md", "postsXa.md", false},
+	}
+
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.glob)
+		if err != nil {
+			t.Fatalf("globToRegexp(%q) failed: %v", tt.glob, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.match {
+			t.Errorf("glob %q on %q: expected %v, got %v", tt.glob, tt.path, tt.match, got)
+		}
+	}
+}
+
+func TestGlobBaseDir(t *testing.T) {
+	tests := map[string]string{
+		"posts/*.md":         "posts",
+		"posts/**/*.md":      "posts",
+		"*.md":               "",
+		"docs/a?/*.md":       "docs",
+		"docs/[draft]/*.md":  "docs/[draft]", // [ is literal, as in globToRegexp
+		"notes/todo.md":      "notes",
+		"docs/[draft]/**.md": "docs/[draft]",
+	}
+	for glob, want := range tests {
+		if got := globBaseDir(glob); got != want {
+			t.Errorf("globBaseDir(%q) = %q, want %q", glob, got, want)
+		}
+	}
+}
+
+func TestCollectionSourceBracketDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeCollectionFixture(t, tmpDir, map[string]string{
+		"docs/[draft]/intro.md": "# Intro\n",
+		"docs/index.md":         "# Docs\n",
+	})
+
+	src := newTestCollection(t, tmpDir, "docs/[draft]/*.md", false, nil)
+	ctx := context.Background()
+
+	// New entries go to the directory of the glob, so they are listed
+	if err := src.WriteItem(ctx, "add", map[string]interface{}{"title": "Next Steps"}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	rows, err := src.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["path"] != "docs/[draft]/intro.md" || rows[1]["path"] != "docs/[draft]/next-steps.md" {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+	if rows[0]["url"] != "/docs/[draft]/intro" {
+		t.Errorf("url = %v, want /docs/[draft]/intro", rows[0]["url"])
+	}
+}
@@ -0,0 +1,457 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// derivedFieldPattern matches "name = expression" (but not "name == ...")
+var derivedFieldPattern = regexp.MustCompile(`(?s)^\s*([A-Za-z_]\w*)\s*=([^=].*)$`)
+
+// derivedAggregatePattern matches count, count(column), sum(column), ...
+var derivedAggregatePattern = regexp.MustCompile(`^(count|sum|avg|min|max)(?:\(\s*([\w.-]*)\s*\))?$`)
+
+// derivedChainKey is the context key for the derived sources being fetched,
+// used to detect inputs that (indirectly) depend on themselves
+type derivedChainKey struct{}
+
+// DerivedSource computes rows from other sources: the rows of from, followed by
+// those of the union sources, joined with the join sources, extended with
+// computed fields, grouped and aggregated, then filtered. Inputs are looked up
+// by name on each fetch, so they are read through their own caches.
+type DerivedSource struct {
+	name       string
+	from       string
+	union      []string
+	joins      []derivedJoin
+	fields     []derivedField
+	groupBy    []string
+	aggregates []derivedAggregate
+	filters    []filterPredicate
+	lookup     func(string) (Source, bool)
+}
+
+// derivedJoin joins the rows of a source on a key
+type derivedJoin struct {
+	source   string
+	leftKey  string // Column of the rows
+	rightKey string // Column of the joined source
+	left     bool   // Keep rows without a match
+	prefix   string // Prefix for the joined columns
+}
+
+// derivedField is a computed column
+type derivedField struct {
+	name string
+	expr expr
+}
+
+// derivedAggregate is an aggregate column of a group
+type derivedAggregate struct {
+	name   string
+	fn     string // count, sum, avg, min or max
+	column string // Empty for count of rows
+}
+
+// NewDerivedSource creates a derived source. lookup finds the input sources by
+// name (e.g., Registry.Get); the derived source does not close them.
+func NewDerivedSource(name string, cfg config.SourceConfig, lookup func(string) (Source, bool)) (*DerivedSource, error) {
+	if cfg.From == "" {
+		return nil, &ValidationError{Source: name, Field: "from", Reason: "from is required (the input source)"}
+	}
+
+	s := &DerivedSource{
+		name:    name,
+		from:    cfg.From,
+		union:   cfg.Union,
+		groupBy: cfg.GroupBy,
+		lookup:  lookup,
+	}
+
+	for i, j := range cfg.Join {
+		field := fmt.Sprintf("join[%d]", i)
+		if j.Source == "" {
+			return nil, &ValidationError{Source: name, Field: field + ".source", Reason: "source is required"}
+		}
+		leftKey, rightKey, ok := parseJoinKeys(j.On)
+		if !ok {
+			return nil, &ValidationError{Source: name, Field: field + ".on", Reason: fmt.Sprintf("invalid join keys %q (expected \"column\" or \"column = other_column\")", j.On)}
+		}
+		if j.Type != "" && j.Type != "inner" && j.Type != "left" {
+			return nil, &ValidationError{Source: name, Field: field + ".type", Reason: fmt.Sprintf("unknown join type %q (expected inner or left)", j.Type)}
+		}
+		s.joins = append(s.joins, derivedJoin{source: j.Source, leftKey: leftKey, rightKey: rightKey, left: j.Type == "left", prefix: j.Prefix})
+	}
+
+	for _, f := range cfg.Fields {
+		match := derivedFieldPattern.FindStringSubmatch(f)
+		if match == nil {
+			return nil, &ValidationError{Source: name, Field: "fields", Reason: fmt.Sprintf("invalid field %q (expected \"name = expression\")", f)}
+		}
+		e, err := parseExpr(match[2])
+		if err != nil {
+			return nil, &ValidationError{Source: name, Field: "fields", Reason: fmt.Sprintf("invalid expression for %s: %v", match[1], err)}
+		}
+		s.fields = append(s.fields, derivedField{name: match[1], expr: e})
+	}
+
+	for _, a := range cfg.Aggregate {
+		aggregate, err := parseAggregate(a)
+		if err != nil {
+			return nil, &ValidationError{Source: name, Field: "aggregate", Reason: err.Error()}
+		}
+		s.aggregates = append(s.aggregates, aggregate)
+	}
+
+	filters, err := parseFilters(name, cfg.Filter)
+	if err != nil {
+		return nil, err
+	}
+	s.filters = filters
+	return s, nil
+}
+
+// parseJoinKeys parses "column" (the same column on both sides) or "left = right"
+func parseJoinKeys(on string) (left, right string, ok bool) {
+	left, right, found := strings.Cut(strings.Replace(on, "==", "=", 1), "=")
+	left, right = strings.TrimSpace(left), strings.TrimSpace(right)
+	if !found {
+		right = left
+	}
+	return left, right, left != "" && right != ""
+}
+
+// parseAggregate parses "name = count", "name = sum(column)", ...
+func parseAggregate(a string) (derivedAggregate, error) {
+	match := derivedFieldPattern.FindStringSubmatch(a)
+	if match == nil {
+		return derivedAggregate{}, fmt.Errorf("invalid aggregate %q (expected \"name = function(column)\")", a)
+	}
+	fn := derivedAggregatePattern.FindStringSubmatch(strings.TrimSpace(match[2]))
+	if fn == nil {
+		return derivedAggregate{}, fmt.Errorf("invalid aggregate %q (expected count, count(column), sum, avg, min or max of a column)", a)
+	}
+	if fn[1] != "count" && fn[2] == "" {
+		return derivedAggregate{}, fmt.Errorf("invalid aggregate %q: %s needs a column", a, fn[1])
+	}
+	return derivedAggregate{name: match[1], fn: fn[1], column: fn[2]}, nil
+}
+
+// DerivedInputs returns the names of the sources a derived source config reads
+func DerivedInputs(cfg config.SourceConfig) []string {
+	inputs := []string{}
+	if cfg.From != "" {
+		inputs = append(inputs, cfg.From)
+	}
+	inputs = append(inputs, cfg.Union...)
+	for _, j := range cfg.Join {
+		inputs = append(inputs, j.Source)
+	}
+	return inputs
+}
+
+// Name returns the source identifier
+func (s *DerivedSource) Name() string {
+	return s.name
+}
+
+// Fetch reads the inputs and computes the rows. If an input served stale
+// rows, the rows are computed from them and returned with its error (see
+// StaleError).
+func (s *DerivedSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	ctx, err := enterInputChain(ctx, "derived", s.name)
+	if err != nil {
+		return nil, err
+	}
+
+	var staleErr error
+	fetchInput := func(name string) ([]map[string]interface{}, error) {
+		rows, err := s.fetchInput(ctx, name)
+		if err != nil && IsStale(err) {
+			if staleErr == nil {
+				staleErr = err
+			}
+			return rows, nil
+		}
+		return rows, err
+	}
+
+	rows, err := fetchInput(s.from)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range s.union {
+		more, err := fetchInput(name)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, more...)
+	}
+
+	for _, j := range s.joins {
+		right, err := fetchInput(j.source)
+		if err != nil {
+			return nil, err
+		}
+		rows = j.apply(rows, right)
+	}
+
+	for _, row := range rows {
+		for _, f := range s.fields {
+			row[f.name] = f.expr.eval(row)
+		}
+	}
+
+	if len(s.groupBy) > 0 || len(s.aggregates) > 0 {
+		rows = s.group(rows)
+	}
+
+	if len(s.filters) > 0 {
+		matching := rows[:0]
+		for _, row := range rows {
+			if matchFilters(row, s.filters) {
+				matching = append(matching, row)
+			}
+		}
+		rows = matching
+	}
+	return rows, staleErr
+}
+
+// enterInputChain adds a source to the chain of sources being fetched in ctx,
+// failing if it is already being fetched (its inputs depend on it)
+func enterInputChain(ctx context.Context, kind, name string) (context.Context, error) {
+	chain, _ := ctx.Value(derivedChainKey{}).([]string)
+	if slices.Contains(chain, name) {
+		return nil, fmt.Errorf("%s source %q: inputs form a cycle: %s -> %s", kind, name, strings.Join(chain, " -> "), name)
+	}
+	return context.WithValue(ctx, derivedChainKey{}, append(slices.Clone(chain), name)), nil
+}
+
+// fetchInput fetches an input's rows as copies, which can be modified
+// without changing the input's (possibly cached) rows
+func (s *DerivedSource) fetchInput(ctx context.Context, name string) ([]map[string]interface{}, error) {
+	src, ok := s.lookup(name)
+	if !ok {
+		return nil, &ValidationError{Source: s.name, Field: "inputs", Reason: fmt.Sprintf("source %q not found", name)}
+	}
+	rows, err := src.Fetch(ctx)
+	if err != nil {
+		err = fmt.Errorf("derived source %q: input %q: %w", s.name, name, err)
+		if !IsStale(err) {
+			return nil, err
+		}
+	}
+	copies := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		copies[i] = make(map[string]interface{}, len(row))
+		for k, v := range row {
+			copies[i][k] = v
+		}
+	}
+	return copies, err
+}
+
+// apply joins rows with the rows of the joined source. A row matching several
+// joined rows appears once per match. Without a prefix, columns the row already
+// has are kept.
+func (j derivedJoin) apply(rows, right []map[string]interface{}) []map[string]interface{} {
+	index := make(map[string][]map[string]interface{})
+	for _, r := range right {
+		if key, ok := joinKey(r, j.rightKey); ok {
+			index[key] = append(index[key], r)
+		}
+	}
+
+	joined := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		var matches []map[string]interface{}
+		if key, ok := joinKey(row, j.leftKey); ok {
+			matches = index[key]
+		}
+		if len(matches) == 0 {
+			if j.left {
+				joined = append(joined, row)
+			}
+			continue
+		}
+		for i, match := range matches {
+			out := row
+			if i < len(matches)-1 {
+				out = make(map[string]interface{}, len(row)+len(match))
+				for k, v := range row {
+					out[k] = v
+				}
+			}
+			for k, v := range match {
+				name := j.prefix + k
+				if _, exists := out[name]; exists && j.prefix == "" {
+					continue
+				}
+				out[name] = v
+			}
+			joined = append(joined, out)
+		}
+	}
+	return joined
+}
+
+// joinKey returns the text of a row's key column; null keys never match
+func joinKey(row map[string]interface{}, column string) (string, bool) {
+	value, ok := row[column]
+	if !ok {
+		value, _ = navigateJSONPath(row, column)
+	}
+	if value == nil {
+		return "", false
+	}
+	return exprText(value), true
+}
+
+// group returns one row per distinct group_by value, in order of first
+// appearance, with the group_by columns and the aggregates
+func (s *DerivedSource) group(rows []map[string]interface{}) []map[string]interface{} {
+	var order []string
+	groups := make(map[string][]map[string]interface{})
+	for _, row := range rows {
+		parts := make([]string, len(s.groupBy))
+		for i, column := range s.groupBy {
+			if value := columnExpr(column).eval(row); value != nil {
+				parts[i] = exprText(value)
+			}
+		}
+		key := strings.Join(parts, "\x00")
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+	if len(s.groupBy) == 0 && len(order) == 0 {
+		// Aggregates without groups always have a row (e.g., count 0)
+		order = append(order, "")
+	}
+
+	result := make([]map[string]interface{}, 0, len(order))
+	for _, key := range order {
+		members := groups[key]
+		out := make(map[string]interface{}, len(s.groupBy)+len(s.aggregates))
+		for _, column := range s.groupBy {
+			out[column] = columnExpr(column).eval(members[0])
+		}
+		for _, a := range s.aggregates {
+			out[a.name] = a.compute(members)
+		}
+		result = append(result, out)
+	}
+	return result
+}
+
+// compute returns the aggregate over a group's rows. Null values are skipped,
+// as are values that aren't numbers for sum and avg.
+func (a derivedAggregate) compute(rows []map[string]interface{}) interface{} {
+	if a.fn == "count" && a.column == "" {
+		return len(rows)
+	}
+
+	count, sum := 0, 0.0
+	var extreme interface{}
+	for _, row := range rows {
+		value := columnExpr(a.column).eval(row)
+		if value == nil {
+			continue
+		}
+		switch a.fn {
+		case "count":
+			count++
+		case "sum", "avg":
+			if n, ok := toNumber(value); ok {
+				count++
+				sum += n
+			}
+		case "min":
+			if extreme == nil || compareValues(value, extreme) < 0 {
+				extreme = value
+			}
+		case "max":
+			if extreme == nil || compareValues(value, extreme) > 0 {
+				extreme = value
+			}
+		}
+	}
+
+	switch a.fn {
+	case "count":
+		return count
+	case "sum":
+		return sum
+	case "avg":
+		if count == 0 {
+			return nil
+		}
+		return sum / float64(count)
+	default:
+		return extreme
+	}
+}
+
+// Close is a no-op; the inputs belong to the lookup's owner
+func (s *DerivedSource) Close() error {
+	return nil
+}
+
+// checkSourceInputs verifies that the inputs of the derived and query sources
+// in sources exist and don't depend on themselves
+func checkSourceInputs(sources map[string]config.SourceConfig) error {
+	for _, name := range sortedSourceNames(sources) {
+		for _, input := range SourceInputs(sources[name]) {
+			if _, ok := sources[input]; !ok {
+				return &ValidationError{Source: name, Field: "inputs", Reason: fmt.Sprintf("source %q not found", input)}
+			}
+		}
+	}
+
+	// Depth-first search for cycles
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return &ValidationError{Source: name, Field: "inputs", Reason: "inputs form a cycle: " + strings.Join(append(path, name), " -> ")}
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		for _, input := range SourceInputs(sources[name]) {
+			if err := visit(input, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		return nil
+	}
+	for _, name := range sortedSourceNames(sources) {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedSourceNames returns the names of sources in order
+func sortedSourceNames(sources map[string]config.SourceConfig) []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,253 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// derivedInputs returns a lookup over sources with fixed rows
+func derivedInputs(inputs map[string][]map[string]interface{}) func(string) (Source, bool) {
+	return func(name string) (Source, bool) {
+		rows, ok := inputs[name]
+		if !ok {
+			return nil, false
+		}
+		return &mockSource{name: name, data: rows}, true
+	}
+}
+
+var derivedTestInputs = map[string][]map[string]interface{}{
+	"orders": {
+		{"id": 1, "customer_id": 10, "amount": 25.0, "status": "paid"},
+		{"id": 2, "customer_id": 20, "amount": 40.0, "status": "open"},
+		{"id": 3, "customer_id": 10, "amount": 15.5, "status": "paid"},
+		{"id": 4, "customer_id": 30, "amount": 8.0, "status": "paid"},
+	},
+	"archived": {
+		{"id": 0, "customer_id": 20, "amount": 12.0, "status": "paid"},
+	},
+	"customers": {
+		{"id": 10, "name": "Acme", "region": "eu"},
+		{"id": 20, "name": "Globex", "region": "us"},
+	},
+}
+
+// fetchDerived creates a derived source over derivedTestInputs and fetches its rows
+func fetchDerived(t *testing.T, cfg config.SourceConfig) []map[string]interface{} {
+	t.Helper()
+	src, err := NewDerivedSource("report", cfg, derivedInputs(derivedTestInputs))
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	return rows
+}
+
+func TestDerivedSource_Join(t *testing.T) {
+	// Inner join drops orders without a customer; the order keeps its own id
+	rows := fetchDerived(t, config.SourceConfig{
+		From: "orders",
+		Join: []config.JoinConfig{{Source: "customers", On: "customer_id = id"}},
+	})
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+	if rows[0]["id"] != 1 || rows[0]["name"] != "Acme" || rows[1]["name"] != "Globex" || rows[2]["id"] != 3 {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	// Left join keeps them; a prefix keeps both ids
+	rows = fetchDerived(t, config.SourceConfig{
+		From: "orders",
+		Join: []config.JoinConfig{{Source: "customers", On: "customer_id == id", Type: "left", Prefix: "customer_"}},
+	})
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4", len(rows))
+	}
+	if rows[0]["customer_id"] != 10 || rows[0]["customer_name"] != "Acme" || rows[0]["id"] != 1 {
+		t.Errorf("unexpected row: %v", rows[0])
+	}
+	if _, ok := rows[3]["customer_name"]; ok || rows[3]["id"] != 4 {
+		t.Errorf("unmatched row = %v", rows[3])
+	}
+
+	// A row matching several rows appears once per match
+	rows = fetchDerived(t, config.SourceConfig{
+		From: "customers",
+		Join: []config.JoinConfig{{Source: "orders", On: "id = customer_id", Prefix: "order_"}},
+	})
+	if len(rows) != 3 || rows[0]["order_id"] != 1 || rows[1]["order_id"] != 3 || rows[2]["order_id"] != 2 {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	// The inputs' rows are not modified
+	if _, ok := derivedTestInputs["customers"][0]["order_id"]; ok {
+		t.Error("join modified the input rows")
+	}
+}
+
+func TestDerivedSource_UnionFieldsFilter(t *testing.T) {
+	rows := fetchDerived(t, config.SourceConfig{
+		From:  "orders",
+		Union: []string{"archived"},
+		Fields: []string{
+			"total = round(amount * 1.2, 2)",
+			"label = upper(status) + \" #\" + id",
+			"large = amount >= 20 && status == \"paid\"",
+		},
+		Filter: []string{"status == paid"},
+	})
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4", len(rows))
+	}
+	if rows[0]["total"] != 30.0 || rows[0]["label"] != "PAID #1" || rows[0]["large"] != true {
+		t.Errorf("unexpected row: %v", rows[0])
+	}
+	if rows[1]["total"] != 18.6 || rows[1]["large"] != false {
+		t.Errorf("unexpected row: %v", rows[1])
+	}
+	if rows[3]["id"] != 0 {
+		t.Errorf("union rows should follow, got %v", rows[3])
+	}
+}
+
+func TestDerivedSource_Aggregate(t *testing.T) {
+	rows := fetchDerived(t, config.SourceConfig{
+		From:      "orders",
+		GroupBy:   []string{"status"},
+		Aggregate: []string{"orders = count", "revenue = sum(amount)", "average = avg(amount)", "largest = max(amount)", "first = min(id)"},
+		Filter:    []string{"orders > 0"},
+	})
+	if len(rows) != 2 {
+		t.Fatalf("got %d groups, want 2", len(rows))
+	}
+	paid := rows[0]
+	if paid["status"] != "paid" || paid["orders"] != 3 || paid["revenue"] != 48.5 || paid["largest"] != 25.0 || paid["first"] != 1 {
+		t.Errorf("unexpected group: %v", paid)
+	}
+	if avg, _ := paid["average"].(float64); avg < 16.16 || avg > 16.17 {
+		t.Errorf("average = %v", paid["average"])
+	}
+	if rows[1]["status"] != "open" || rows[1]["orders"] != 1 {
+		t.Errorf("unexpected group: %v", rows[1])
+	}
+
+	// Aggregates without group_by give one row, even without input rows
+	rows = fetchDerived(t, config.SourceConfig{
+		From:      "orders",
+		Filter:    []string{"count == 0"},
+		Aggregate: []string{"count = count", "average = avg(amount)"},
+	})
+	if len(rows) != 0 {
+		t.Errorf("filter should drop the total row, got %v", rows)
+	}
+	src, _ := NewDerivedSource("empty", config.SourceConfig{From: "none", Aggregate: []string{"count = count", "average = avg(amount)"}},
+		derivedInputs(map[string][]map[string]interface{}{"none": {}}))
+	rows, err := src.Fetch(context.Background())
+	if err != nil || len(rows) != 1 || rows[0]["count"] != 0 || rows[0]["average"] != nil {
+		t.Errorf("unexpected totals: %v, %v", rows, err)
+	}
+}
+
+func TestDerivedSource_Errors(t *testing.T) {
+	for _, cfg := range []config.SourceConfig{
+		{},
+		{From: "orders", Join: []config.JoinConfig{{On: "id"}}},
+		{From: "orders", Join: []config.JoinConfig{{Source: "customers", On: " = id"}}},
+		{From: "orders", Join: []config.JoinConfig{{Source: "customers", On: "id", Type: "outer"}}},
+		{From: "orders", Fields: []string{"total == amount"}},
+		{From: "orders", Fields: []string{"total = amount *"}},
+		{From: "orders", Fields: []string{"total = nope(amount)"}},
+		{From: "orders", Aggregate: []string{"total = median(amount)"}},
+		{From: "orders", Aggregate: []string{"total = sum"}},
+	} {
+		if _, err := NewDerivedSource("report", cfg, derivedInputs(derivedTestInputs)); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+
+	src, _ := NewDerivedSource("report", config.SourceConfig{From: "missing"}, derivedInputs(derivedTestInputs))
+	if _, err := src.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected missing input error, got %v", err)
+	}
+
+	// Derived sources reading each other fail instead of recursing forever
+	var a, b *DerivedSource
+	lookup := func(name string) (Source, bool) {
+		if name == "a" {
+			return a, true
+		}
+		return b, true
+	}
+	a, _ = NewDerivedSource("a", config.SourceConfig{From: "b"}, lookup)
+	b, _ = NewDerivedSource("b", config.SourceConfig{From: "a"}, lookup)
+	if _, err := a.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+}
+
+// staleSource serves fixed rows with a StaleError, like a cached source whose
+// upstream is down
+type staleSource struct {
+	mockSource
+}
+
+func (s *staleSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	return s.data, &StaleError{Source: s.name, Err: errors.New("upstream down")}
+}
+
+func TestDerivedSource_StaleInput(t *testing.T) {
+	lookup := func(name string) (Source, bool) {
+		if name == "customers" {
+			return &staleSource{mockSource{name: name, data: derivedTestInputs[name]}}, true
+		}
+		return derivedInputs(derivedTestInputs)(name)
+	}
+	src, err := NewDerivedSource("report", config.SourceConfig{
+		From: "orders",
+		Join: []config.JoinConfig{{Source: "customers", On: "customer_id = id", Prefix: "customer_"}},
+	}, lookup)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	// The rows are computed from the stale input, and come with its error
+	rows, err := src.Fetch(context.Background())
+	if !IsStale(err) || !strings.Contains(err.Error(), `input "customers"`) {
+		t.Errorf("expected a stale error for customers, got %v", err)
+	}
+	if len(rows) != 3 || rows[0]["customer_name"] != "Acme" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+}
+
+func TestCheckSourceInputs(t *testing.T) {
+	err := checkSourceInputs(map[string]config.SourceConfig{
+		"orders":  {Type: "json"},
+		"totals":  {Type: "derived", From: "orders"},
+		"summary": {Type: "derived", From: "totals", Join: []config.JoinConfig{{Source: "orders", On: "id"}}},
+		"report":  {Type: "query", Tables: []string{"summary"}, Query: "SELECT * FROM summary"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkSourceInputs(map[string]config.SourceConfig{"totals": {Type: "derived", From: "orders"}}); err == nil {
+		t.Error("expected error for missing input")
+	}
+	err = checkSourceInputs(map[string]config.SourceConfig{
+		"a": {Type: "derived", From: "b"},
+		"b": {Type: "derived", From: "c"},
+		"c": {Type: "derived", From: "a"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+}
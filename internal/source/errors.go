@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 )
 
 // SourceError wraps errors with source context
@@ -115,6 +116,30 @@ func (e *CircuitOpenError) Error() string {
 	return fmt.Sprintf("source %q: circuit breaker open, service temporarily unavailable", e.Source)
 }
 
+// StaleError is returned together with rows a source served although it
+// failed to fetch them, e.g., the expired rows of a cached source whose
+// upstream is down. Callers that can show degraded data use the rows (see
+// IsStale); others handle it like the error it wraps.
+type StaleError struct {
+	Source    string
+	Err       error
+	FetchedAt time.Time // When the rows were fetched (zero if unknown)
+}
+
+func (e *StaleError) Error() string {
+	return fmt.Sprintf("source %q: showing cached data: %v", e.Source, e.Err)
+}
+
+func (e *StaleError) Unwrap() error {
+	return e.Err
+}
+
+// IsStale reports whether err came with usable rows (see StaleError)
+func IsStale(err error) bool {
+	var stale *StaleError
+	return errors.As(err, &stale)
+}
+
 // NewSourceError creates a SourceError with retryable detection
 func NewSourceError(source, operation string, err error) *SourceError {
 	return &SourceError{
@@ -25,6 +25,8 @@ type ExecSource struct {
 	delimiter string            // for csv format, default ","
 	env       map[string]string // environment variables (already expanded)
 	timeout   time.Duration     // command timeout (default 30s)
+	limiter   *rateLimiter      // shared per command; nil if not rate limited
+	breaker   *CircuitBreaker   // stops running a failing command
 }
 
 // NewExecSource creates a new exec source (legacy constructor for backwards compatibility)
@@ -39,6 +41,7 @@ func NewExecSource(name, cmd, siteDir string) (*ExecSource, error) {
 		format:    "json",
 		delimiter: ",",
 		timeout:   30 * time.Second,
+		breaker:   newExecCircuitBreaker(name, config.SourceConfig{}),
 	}, nil
 }
 
@@ -74,6 +77,16 @@ func NewExecSourceWithConfig(name string, cfg config.SourceConfig, siteDir strin
 		env[k] = os.ExpandEnv(v)
 	}
 
+	// Rate limits are shared by the sources running the same program
+	program := cfg.Cmd
+	if parts := strings.Fields(cfg.Cmd); len(parts) > 0 {
+		program = parts[0]
+	}
+	limiter, err := sourceRateLimiter(name, "exec:"+program, cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ExecSource{
 		name:      name,
 		cmd:       cfg.Cmd,
@@ -82,16 +95,36 @@ func NewExecSourceWithConfig(name string, cfg config.SourceConfig, siteDir strin
 		delimiter: delimiter,
 		env:       env,
 		timeout:   timeout,
+		limiter:   limiter,
+		breaker:   newExecCircuitBreaker(name, cfg),
 	}, nil
 }
 
+// newExecCircuitBreaker returns the circuit breaker of an exec source, which
+// counts every failed run (shared with the same source on other pages)
+func newExecCircuitBreaker(name string, cfg config.SourceConfig) *CircuitBreaker {
+	cbConfig := sourceCircuitBreakerConfig(cfg)
+	cbConfig.IsFailure = isCommandFailure
+	return sharedCircuitBreaker(name, cfg, cbConfig)
+}
+
 // Name returns the source identifier
 func (s *ExecSource) Name() string {
 	return s.name
 }
 
+// CircuitBreaker returns the circuit breaker guarding the command's runs
+func (s *ExecSource) CircuitBreaker() *CircuitBreaker {
+	return s.breaker
+}
+
 // Fetch executes the command and parses output according to format
 func (s *ExecSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	return s.breaker.Execute(ctx, s.doFetch)
+}
+
+// doFetch runs the command
+func (s *ExecSource) doFetch(ctx context.Context) ([]map[string]interface{}, error) {
 	// Parse command - split by spaces (simple parsing)
 	parts := strings.Fields(s.cmd)
 	if len(parts) == 0 {
@@ -101,6 +134,10 @@ func (s *ExecSource) Fetch(ctx context.Context) ([]map[string]interface{}, error
 	cmdName := parts[0]
 	args := parts[1:]
 
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("exec source %q: %w", s.name, err)
+	}
+
 	// Create command with context and timeout
 	timeout := s.timeout
 	if timeout == 0 {
@@ -266,6 +303,13 @@ func (s *ExecSource) Close() error {
 // FetchWithArgs executes the command with custom argument values
 // The args map contains argument name -> value pairs that override the defaults
 func (s *ExecSource) FetchWithArgs(ctx context.Context, args map[string]string) ([]map[string]interface{}, error) {
+	return s.breaker.Execute(ctx, func(ctx context.Context) ([]map[string]interface{}, error) {
+		return s.doFetchWithArgs(ctx, args)
+	})
+}
+
+// doFetchWithArgs runs the command with the arguments
+func (s *ExecSource) doFetchWithArgs(ctx context.Context, args map[string]string) ([]map[string]interface{}, error) {
 	// Parse original command to get executable
 	parts := strings.Fields(s.cmd)
 	if len(parts) == 0 {
@@ -284,6 +328,10 @@ func (s *ExecSource) FetchWithArgs(ctx context.Context, args map[string]string)
 		newArgs = append(newArgs, "--"+name, value)
 	}
 
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("exec source %q: %w", s.name, err)
+	}
+
 	// Create command with context and timeout
 	cmdCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
@@ -193,6 +193,32 @@ exit 1
 	assert.Contains(t, err.Error(), "command failed")
 }
 
+func TestExecSourceCircuitBreaker(t *testing.T) {
+	tmpDir := t.TempDir()
+	scriptPath := filepath.Join(tmpDir, "fail.sh")
+	err := os.WriteFile(scriptPath, []byte("#!/bin/bash\nexit 1\n"), 0755)
+	require.NoError(t, err)
+
+	src, err := NewExecSourceWithConfig("test", config.SourceConfig{
+		Cmd:     "./fail.sh",
+		Circuit: &config.CircuitBreakerConfig{FailureThreshold: 2, Timeout: "1m"},
+	}, tmpDir)
+	require.NoError(t, err)
+	t.Cleanup(src.CircuitBreaker().Reset)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err = src.Fetch(ctx)
+		assert.Contains(t, err.Error(), "command failed")
+	}
+	assert.Equal(t, CircuitOpen, src.CircuitBreaker().State())
+
+	// The command is not run while the circuit is open
+	_, err = src.FetchWithArgs(ctx, map[string]string{"verbose": "true"})
+	var circuitErr *CircuitOpenError
+	assert.ErrorAs(t, err, &circuitErr)
+}
+
 func TestExecSourceClose(t *testing.T) {
 	src, err := NewExecSource("test", "echo hello", ".")
 	require.NoError(t, err)
@@ -0,0 +1,636 @@
+package source
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// expr is a compiled expression evaluated against a row. Expressions are
+// side-effect free and cannot call anything but the functions in exprFuncs.
+//
+// Syntax:
+//   - literals: 42, 1.5, 'text', "text", true, false, null
+//   - columns: owner, address.city (nested fields use dots)
+//   - arithmetic: + - * / % (+ joins text when a side isn't a number)
+//   - comparison: == != < <= > >= (numbers numerically, otherwise as text)
+//   - logic: && || ! (or and, or, not)
+//   - functions: see exprFuncs, e.g. round(points / 3, 1)
+//
+// Operations on missing values or values of the wrong type evaluate to null
+// instead of failing, like SQL.
+type expr interface {
+	eval(row map[string]interface{}) interface{}
+}
+
+// exprFunc is a function callable from expressions
+type exprFunc struct {
+	minArgs, maxArgs int // maxArgs -1 for variadic
+	call             func(args []interface{}) interface{}
+}
+
+// exprFuncs are the functions available to expressions
+var exprFuncs = map[string]exprFunc{
+	"upper":    {1, 1, func(a []interface{}) interface{} { return mapString(a[0], strings.ToUpper) }},
+	"lower":    {1, 1, func(a []interface{}) interface{} { return mapString(a[0], strings.ToLower) }},
+	"trim":     {1, 1, func(a []interface{}) interface{} { return mapString(a[0], strings.TrimSpace) }},
+	"len":      {1, 1, exprLen},
+	"concat":   {1, -1, exprConcat},
+	"contains": {2, 2, exprContains},
+	"coalesce": {1, -1, exprCoalesce},
+	"if":       {3, 3, exprIf},
+	"number":   {1, 1, func(a []interface{}) interface{} { return mapNumber(a[0], func(f float64) float64 { return f }) }},
+	"string":   {1, 1, func(a []interface{}) interface{} { return mapString(a[0], func(s string) string { return s }) }},
+	"abs":      {1, 1, func(a []interface{}) interface{} { return mapNumber(a[0], math.Abs) }},
+	"floor":    {1, 1, func(a []interface{}) interface{} { return mapNumber(a[0], math.Floor) }},
+	"ceil":     {1, 1, func(a []interface{}) interface{} { return mapNumber(a[0], math.Ceil) }},
+	"round":    {1, 2, exprRound},
+	"min":      {1, -1, func(a []interface{}) interface{} { return exprExtreme(a, -1) }},
+	"max":      {1, -1, func(a []interface{}) interface{} { return exprExtreme(a, 1) }},
+}
+
+// parseExpr compiles an expression
+func parseExpr(src string) (expr, error) {
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+	}
+	return e, nil
+}
+
+// Token kinds
+const (
+	tokEOF = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type exprToken struct {
+	kind int
+	text string // Operator, identifier, or unquoted string
+	num  float64
+	pos  int
+}
+
+// exprOperators are the operator tokens, longest first
+var exprOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "+", "-", "*", "/", "%", "!", "(", ")", ","}
+
+// lexExpr splits an expression into tokens
+func lexExpr(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			num, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", src[start:i], start+1)
+			}
+			tokens = append(tokens, exprToken{kind: tokNumber, num: num, text: src[start:i], pos: start})
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(src[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, exprToken{kind: tokString, text: src[i+1 : i+1+end], pos: i})
+			i += end + 2
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range exprOperators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at position %d", c, i+1)
+			}
+			tokens = append(tokens, exprToken{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, exprToken{kind: tokEOF, text: "end of expression", pos: len(src)}), nil
+}
+
+// exprParser is a recursive descent parser over tokens
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of ops (operators or keywords)
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp && tok.kind != tokIdent {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.next()
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		tok := p.peek()
+		return fmt.Errorf("expected %q at position %d, got %q", op, tok.pos+1, tok.text)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicExpr{or: true, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicExpr{left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseNot() (expr, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return compareExpr{op: op, left: left, right: right}, nil
+}
+
+func (p *exprParser) parseAdditive() (expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = arithExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseMultiplicative() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = arithExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return arithExpr{op: "-", left: literalExpr{0.0}, right: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return literalExpr{tok.num}, nil
+	case tokString:
+		return literalExpr{tok.text}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return literalExpr{true}, nil
+		case "false":
+			return literalExpr{false}, nil
+		case "null":
+			return literalExpr{nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(tok)
+		}
+		return columnExpr(tok.text), nil
+	case tokOp:
+		if tok.text == "(" {
+			e, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expect(")")
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+}
+
+// parseCall parses the arguments of a function call after "("
+func (p *exprParser) parseCall(name exprToken) (expr, error) {
+	fn, ok := exprFuncs[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos+1)
+	}
+
+	var args []expr
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments for %s: got %d", name.text, len(args))
+	}
+	return callExpr{fn: fn, args: args}, nil
+}
+
+// literalExpr is a constant
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(map[string]interface{}) interface{} {
+	return e.value
+}
+
+// columnExpr reads a column, or a nested field using dots
+type columnExpr string
+
+func (e columnExpr) eval(row map[string]interface{}) interface{} {
+	if value, ok := row[string(e)]; ok {
+		return value
+	}
+	value, _ := navigateJSONPath(row, string(e))
+	return value
+}
+
+// logicExpr is && or ||
+type logicExpr struct {
+	or          bool
+	left, right expr
+}
+
+func (e logicExpr) eval(row map[string]interface{}) interface{} {
+	if truthy(e.left.eval(row)) == e.or {
+		return e.or
+	}
+	return truthy(e.right.eval(row))
+}
+
+// notExpr negates its operand
+type notExpr struct {
+	operand expr
+}
+
+func (e notExpr) eval(row map[string]interface{}) interface{} {
+	return !truthy(e.operand.eval(row))
+}
+
+// compareExpr compares two values like filters do. null only equals null.
+type compareExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e compareExpr) eval(row map[string]interface{}) interface{} {
+	a, b := e.left.eval(row), e.right.eval(row)
+	if a == nil || b == nil {
+		switch e.op {
+		case "==":
+			return a == nil && b == nil
+		case "!=":
+			return a != nil || b != nil
+		default:
+			return nil
+		}
+	}
+
+	c := compareValues(a, b)
+	switch e.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default: // >=
+		return c >= 0
+	}
+}
+
+// arithExpr is + - * / or %
+type arithExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e arithExpr) eval(row map[string]interface{}) interface{} {
+	a, b := e.left.eval(row), e.right.eval(row)
+	if a == nil || b == nil {
+		return nil
+	}
+	x, okX := toNumber(a)
+	y, okY := toNumber(b)
+	if !okX || !okY {
+		if e.op == "+" {
+			return exprText(a) + exprText(b)
+		}
+		return nil
+	}
+
+	switch e.op {
+	case "+":
+		return x + y
+	case "-":
+		return x - y
+	case "*":
+		return x * y
+	case "/":
+		if y == 0 {
+			return nil
+		}
+		return x / y
+	default: // %
+		if y == 0 {
+			return nil
+		}
+		return math.Mod(x, y)
+	}
+}
+
+// callExpr calls a function with its evaluated arguments
+type callExpr struct {
+	fn   exprFunc
+	args []expr
+}
+
+func (e callExpr) eval(row map[string]interface{}) interface{} {
+	values := make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		values[i] = arg.eval(row)
+	}
+	return e.fn.call(values)
+}
+
+// toNumber converts numbers and numeric text (as read from CSV) to float64
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// exprText returns the text form of a value
+func exprText(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// compareValues orders two non-nil values: numerically if both are numbers,
+// otherwise by their text
+func compareValues(a, b interface{}) int {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+	return strings.Compare(exprText(a), exprText(b))
+}
+
+// truthy reports whether a value counts as true: non-zero numbers, true, and
+// text other than "", "0" and "false"
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return b
+	case string:
+		if parsed, err := strconv.ParseBool(b); err == nil {
+			return parsed
+		}
+		return b != ""
+	}
+	if n, ok := toNumber(v); ok {
+		return n != 0
+	}
+	return true
+}
+
+func mapString(v interface{}, fn func(string) string) interface{} {
+	if v == nil {
+		return nil
+	}
+	return fn(exprText(v))
+}
+
+func mapNumber(v interface{}, fn func(float64) float64) interface{} {
+	n, ok := toNumber(v)
+	if !ok {
+		return nil
+	}
+	return fn(n)
+}
+
+func exprLen(args []interface{}) interface{} {
+	switch v := args[0].(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return float64(len(v))
+	case map[string]interface{}:
+		return float64(len(v))
+	default:
+		return float64(len([]rune(exprText(v))))
+	}
+}
+
+func exprConcat(args []interface{}) interface{} {
+	var b strings.Builder
+	for _, arg := range args {
+		if arg != nil {
+			b.WriteString(exprText(arg))
+		}
+	}
+	return b.String()
+}
+
+func exprContains(args []interface{}) interface{} {
+	if args[0] == nil || args[1] == nil {
+		return nil
+	}
+	return strings.Contains(exprText(args[0]), exprText(args[1]))
+}
+
+func exprCoalesce(args []interface{}) interface{} {
+	for _, arg := range args {
+		if arg != nil && arg != "" {
+			return arg
+		}
+	}
+	return nil
+}
+
+func exprIf(args []interface{}) interface{} {
+	if truthy(args[0]) {
+		return args[1]
+	}
+	return args[2]
+}
+
+func exprRound(args []interface{}) interface{} {
+	n, ok := toNumber(args[0])
+	if !ok {
+		return nil
+	}
+	places := 0.0
+	if len(args) > 1 {
+		if places, ok = toNumber(args[1]); !ok {
+			return nil
+		}
+	}
+	scale := math.Pow(10, math.Trunc(places))
+	return math.Round(n*scale) / scale
+}
+
+// exprExtreme returns the smallest (sign -1) or largest (sign 1) non-null argument
+func exprExtreme(args []interface{}, sign int) interface{} {
+	var best interface{}
+	for _, arg := range args {
+		if arg != nil && (best == nil || compareValues(arg, best)*sign > 0) {
+			best = arg
+		}
+	}
+	return best
+}
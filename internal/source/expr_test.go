@@ -0,0 +1,56 @@
+package source
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExpr(t *testing.T) {
+	row := map[string]interface{}{
+		"name":   " Ada ",
+		"qty":    3,
+		"price":  "2.5",
+		"active": true,
+		"note":   nil,
+		"meta":   map[string]interface{}{"team": "ops"},
+	}
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"qty * price", 7.5},
+		{"1 + 2 * 3 - -1", 8.0},
+		{"(1 + 2) * 3 % 4", 1.0},
+		{"qty / 0", nil},
+		{"trim(name) + \"!\"", "Ada!"},
+		{"upper(meta.team)", "OPS"},
+		{"len(trim(name))", 3.0},
+		{"concat(qty, 'x', note)", "3x"},
+		{"coalesce(note, 'none')", "none"},
+		{"if(qty > 2, 'many', 'few')", "many"},
+		{"round(10 / 3, 2)", 3.33},
+		{"max(qty, price, 1)", 3},
+		{"qty >= 3 and not active", false},
+		{"active || missing", true},
+		{"note == null", true},
+		{"note != 0", true},
+		{"contains(name, 'da')", true},
+		{"price == 2.5", true},
+	}
+	for _, tt := range tests {
+		e, err := parseExpr(tt.expr)
+		if err != nil {
+			t.Errorf("parseExpr(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := e.eval(row); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "1 +", "(qty", "qty qty", "upper()", "nope(qty)", "'open", "qty # 2"} {
+		if _, err := parseExpr(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
@@ -5,27 +5,39 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
 )
 
-// JSONFileSource reads data from a JSON file
+// JSONFileSource reads data from a JSON file.
+// It implements WritableSource when readonly is false (see file_write.go).
 type JSONFileSource struct {
 	name     string
 	filePath string
 	siteDir  string
+	readonly bool
 }
 
-// NewJSONFileSource creates a new JSON file source
+// NewJSONFileSource creates a new read-only JSON file source
 func NewJSONFileSource(name, file, siteDir string) (*JSONFileSource, error) {
-	if file == "" {
+	return NewJSONFileSourceWithConfig(name, config.SourceConfig{File: file}, siteDir)
+}
+
+// NewJSONFileSourceWithConfig creates a new JSON file source; writes are enabled
+// with readonly: false
+func NewJSONFileSourceWithConfig(name string, cfg config.SourceConfig, siteDir string) (*JSONFileSource, error) {
+	if cfg.File == "" {
 		return nil, fmt.Errorf("json source %q: file is required", name)
 	}
 	return &JSONFileSource{
 		name:     name,
-		filePath: file,
+		filePath: cfg.File,
 		siteDir:  siteDir,
+		readonly: cfg.IsReadonly(),
 	}, nil
 }
 
@@ -43,7 +55,15 @@ func (s *JSONFileSource) Fetch(ctx context.Context) ([]map[string]interface{}, e
 		return nil, fmt.Errorf("json source %q: failed to read file: %w", s.name, err)
 	}
 
-	return s.parseJSON(data)
+	rows, err := s.parseJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if !s.readonly {
+		// Writes need an ID for every row
+		addMissingIDs(rows)
+	}
+	return rows, nil
 }
 
 // parseJSON handles both array and object JSON
@@ -115,30 +135,39 @@ func (s *JSONFileSource) resolvePath(path string) string {
 	return filepath.Join(s.siteDir, path)
 }
 
-// CSVFileSource reads data from a CSV file
+// CSVFileSource reads data from a CSV file.
+// It implements WritableSource when readonly is false (see file_write.go).
 type CSVFileSource struct {
-	name     string
-	filePath string
-	siteDir  string
+	name      string
+	filePath  string
+	siteDir   string
 	hasHeader bool
+	readonly  bool
 }
 
-// NewCSVFileSource creates a new CSV file source
+// NewCSVFileSource creates a new read-only CSV file source
 func NewCSVFileSource(name, file, siteDir string, options map[string]string) (*CSVFileSource, error) {
-	if file == "" {
+	return NewCSVFileSourceWithConfig(name, config.SourceConfig{File: file, Options: options}, siteDir)
+}
+
+// NewCSVFileSourceWithConfig creates a new CSV file source; writes are enabled
+// with readonly: false
+func NewCSVFileSourceWithConfig(name string, cfg config.SourceConfig, siteDir string) (*CSVFileSource, error) {
+	if cfg.File == "" {
 		return nil, fmt.Errorf("csv source %q: file is required", name)
 	}
 
 	hasHeader := true
-	if options != nil && options["header"] == "false" {
+	if cfg.Options != nil && cfg.Options["header"] == "false" {
 		hasHeader = false
 	}
 
 	return &CSVFileSource{
 		name:      name,
-		filePath:  file,
+		filePath:  cfg.File,
 		siteDir:   siteDir,
 		hasHeader: hasHeader,
+		readonly:  cfg.IsReadonly(),
 	}, nil
 }
 
@@ -157,44 +186,16 @@ func (s *CSVFileSource) Fetch(ctx context.Context) ([]map[string]interface{}, er
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+	headers, records, err := s.parseRecords(file)
 	if err != nil {
 		return nil, fmt.Errorf("csv source %q: failed to read CSV: %w", s.name, err)
 	}
 
-	if len(records) == 0 {
-		return []map[string]interface{}{}, nil
+	results := csvRows(headers, records)
+	if !s.readonly {
+		// Writes need an ID for every row
+		addMissingIDs(results)
 	}
-
-	var headers []string
-	var dataRows [][]string
-
-	if s.hasHeader {
-		headers = records[0]
-		dataRows = records[1:]
-	} else {
-		// Generate column names: col1, col2, col3, etc.
-		if len(records) > 0 {
-			headers = make([]string, len(records[0]))
-			for i := range headers {
-				headers[i] = fmt.Sprintf("col%d", i+1)
-			}
-		}
-		dataRows = records
-	}
-
-	results := make([]map[string]interface{}, 0, len(dataRows))
-	for _, row := range dataRows {
-		rowMap := make(map[string]interface{})
-		for i, value := range row {
-			if i < len(headers) {
-				rowMap[headers[i]] = value
-			}
-		}
-		results = append(results, rowMap)
-	}
-
 	return results, nil
 }
 
@@ -210,3 +211,39 @@ func (s *CSVFileSource) resolvePath(path string) string {
 	}
 	return filepath.Join(s.siteDir, path)
 }
+
+// parseRecords reads the header (or generated col1, col2, ... names) and records
+func (s *CSVFileSource) parseRecords(r io.Reader) ([]string, [][]string, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+
+	if s.hasHeader {
+		return records[0], records[1:], nil
+	}
+	// Generate column names: col1, col2, col3, etc.
+	headers := make([]string, len(records[0]))
+	for i := range headers {
+		headers[i] = fmt.Sprintf("col%d", i+1)
+	}
+	return headers, records, nil
+}
+
+// csvRows converts records to rows keyed by column name
+func csvRows(headers []string, records [][]string) []map[string]interface{} {
+	results := make([]map[string]interface{}, 0, len(records))
+	for _, row := range records {
+		rowMap := make(map[string]interface{})
+		for i, value := range row {
+			if i < len(headers) {
+				rowMap[headers[i]] = value
+			}
+		}
+		results = append(results, rowMap)
+	}
+	return results
+}
@@ -0,0 +1,129 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/livetemplate/tinkerdown/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// DocumentFileSource reads rows from a YAML or TOML file. result_path selects
+// the list of rows inside the document (e.g., "servers" or "inventory.hosts");
+// without it, a top-level list gives one row per item and a top-level mapping
+// gives a single row.
+type DocumentFileSource struct {
+	name       string
+	format     string // "yaml" or "toml"
+	filePath   string
+	siteDir    string
+	resultPath string
+}
+
+// NewDocumentFileSource creates a YAML or TOML file source; cfg.Type selects the format
+func NewDocumentFileSource(name string, cfg config.SourceConfig, siteDir string) (*DocumentFileSource, error) {
+	if cfg.Type != "yaml" && cfg.Type != "toml" {
+		return nil, &ValidationError{Source: name, Field: "type", Reason: fmt.Sprintf("unsupported document type %q (expected yaml or toml)", cfg.Type)}
+	}
+	if cfg.File == "" {
+		return nil, fmt.Errorf("%s source %q: file is required", cfg.Type, name)
+	}
+	return &DocumentFileSource{
+		name:       name,
+		format:     cfg.Type,
+		filePath:   cfg.File,
+		siteDir:    siteDir,
+		resultPath: cfg.ResultPath,
+	}, nil
+}
+
+// Name returns the source identifier
+func (s *DocumentFileSource) Name() string {
+	return s.name
+}
+
+// Fetch reads the file and extracts its rows
+func (s *DocumentFileSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	path := s.resolvePath(s.filePath)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s source %q: failed to read file: %w", s.format, s.name, err)
+	}
+
+	docs, err := s.decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s source %q: invalid %s: %w", s.format, s.name, strings.ToUpper(s.format), err)
+	}
+
+	results := []map[string]interface{}{}
+	for _, doc := range docs {
+		rows, err := s.extractRows(doc)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rows...)
+	}
+	return results, nil
+}
+
+// decode parses the file into documents. A YAML file may hold several
+// documents separated by "---"; their rows are concatenated.
+func (s *DocumentFileSource) decode(data []byte) ([]interface{}, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	if s.format == "toml" {
+		var doc map[string]interface{}
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return []interface{}{doc}, nil
+	}
+
+	var docs []interface{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// extractRows navigates to resultPath (if specified) and converts the result to rows
+func (s *DocumentFileSource) extractRows(doc interface{}) ([]map[string]interface{}, error) {
+	result, err := navigateJSONPath(doc, s.resultPath)
+	if err != nil {
+		return nil, &ValidationError{Source: s.name, Field: "result_path", Reason: err.Error()}
+	}
+	return convertToMapSlice(s.name, result)
+}
+
+// Close is a no-op for file sources
+func (s *DocumentFileSource) Close() error {
+	return nil
+}
+
+// resolvePath makes a path absolute relative to siteDir
+func (s *DocumentFileSource) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.siteDir, path)
+}
@@ -0,0 +1,136 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+func TestDocumentFileSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		typ        string
+		content    string
+		resultPath string
+		wantNames  []string
+	}{
+		{
+			name:      "yaml list",
+			typ:       "yaml",
+			content:   "- name: web-1\n  cpus: 4\n- name: web-2\n  cpus: 8\n",
+			wantNames: []string{"web-1", "web-2"},
+		},
+		{
+			name:       "yaml result_path",
+			typ:        "yaml",
+			content:    "inventory:\n  region: eu\n  hosts:\n    - name: db-1\n    - name: db-2\n",
+			resultPath: "inventory.hosts",
+			wantNames:  []string{"db-1", "db-2"},
+		},
+		{
+			name:       "yaml documents",
+			typ:        "yaml",
+			content:    "hosts:\n  - name: a\n---\nhosts:\n  - name: b\n",
+			resultPath: "hosts",
+			wantNames:  []string{"a", "b"},
+		},
+		{
+			name:      "yaml mapping",
+			typ:       "yaml",
+			content:   "name: solo\n",
+			wantNames: []string{"solo"},
+		},
+		{
+			name:       "toml array of tables",
+			typ:        "toml",
+			content:    "title = \"fleet\"\n\n[[servers]]\nname = \"alpha\"\nport = 8080\n\n[[servers]]\nname = \"beta\"\nport = 8081\n",
+			resultPath: "servers",
+			wantNames:  []string{"alpha", "beta"},
+		},
+		{
+			name:       "toml nested table",
+			typ:        "toml",
+			content:    "[cluster]\n[[cluster.nodes]]\nname = \"n1\"\n",
+			resultPath: "cluster.nodes",
+			wantNames:  []string{"n1"},
+		},
+		{
+			name:    "empty",
+			typ:     "yaml",
+			content: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			file := "data." + tt.typ
+			os.WriteFile(filepath.Join(tmpDir, file), []byte(tt.content), 0644)
+
+			src, err := NewDocumentFileSource("data", config.SourceConfig{Type: tt.typ, File: file, ResultPath: tt.resultPath}, tmpDir)
+			if err != nil {
+				t.Fatalf("failed to create source: %v", err)
+			}
+			rows, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("fetch failed: %v", err)
+			}
+			if len(rows) != len(tt.wantNames) {
+				t.Fatalf("got %d rows, want %d: %v", len(rows), len(tt.wantNames), rows)
+			}
+			for i, name := range tt.wantNames {
+				if rows[i]["name"] != name {
+					t.Errorf("row %d name = %v, want %q", i, rows[i]["name"], name)
+				}
+			}
+		})
+	}
+}
+
+func TestDocumentFileSource_Types(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "servers.toml"), []byte("[[servers]]\nport = 8080\nenabled = true\nweight = 0.5\n"), 0644)
+
+	src, _ := NewDocumentFileSource("servers", config.SourceConfig{Type: "toml", File: "servers.toml", ResultPath: "servers"}, tmpDir)
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if rows[0]["port"] != int64(8080) || rows[0]["enabled"] != true || rows[0]["weight"] != 0.5 {
+		t.Errorf("unexpected row: %#v", rows[0])
+	}
+}
+
+func TestDocumentFileSource_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "bad.toml"), []byte("name = \n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "list.yaml"), []byte("items:\n  - 1\n  - 2\n"), 0644)
+
+	tests := []struct {
+		name string
+		cfg  config.SourceConfig
+	}{
+		{"invalid toml", config.SourceConfig{Type: "toml", File: "bad.toml"}},
+		{"missing path", config.SourceConfig{Type: "yaml", File: "list.yaml", ResultPath: "hosts"}},
+		{"not objects", config.SourceConfig{Type: "yaml", File: "list.yaml", ResultPath: "items"}},
+		{"missing file", config.SourceConfig{Type: "yaml", File: "nope.yaml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := NewDocumentFileSource("data", tt.cfg, tmpDir)
+			if err != nil {
+				t.Fatalf("failed to create source: %v", err)
+			}
+			if _, err := src.Fetch(context.Background()); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if _, err := NewDocumentFileSource("data", config.SourceConfig{Type: "yaml"}, tmpDir); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
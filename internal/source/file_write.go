@@ -0,0 +1,600 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Writes to JSON and CSV files rewrite the whole file atomically (temp file plus
+// rename) under a file lock. Rows are identified by their "id" field; rows without
+// one get an ID derived from their content (see assignRowIDs), so files without
+// an id column stay editable.
+
+// assignRowIDs returns the ID of each row: its "id" field, or a hash of its content
+// for rows without one. Repeated content gets a numeric suffix.
+func assignRowIDs(rows []map[string]interface{}) []string {
+	ids := make([]string, len(rows))
+	seen := make(map[string]int)
+	for i, row := range rows {
+		if id, ok := getID(row); ok {
+			ids[i] = fmt.Sprintf("%v", id)
+			continue
+		}
+		content, _ := json.Marshal(row)
+		id := generateContentID(string(content))
+		seen[id]++
+		if n := seen[id]; n > 1 {
+			id = fmt.Sprintf("%s-%d", id, n)
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+// addMissingIDs sets the content-derived ID of rows without an "id" field
+func addMissingIDs(rows []map[string]interface{}) {
+	for i, id := range assignRowIDs(rows) {
+		if _, ok := getID(rows[i]); !ok {
+			rows[i]["id"] = id
+		}
+	}
+}
+
+// findRow returns the index of the row with the ID in data
+func findRow(ids []string, data map[string]interface{}) (int, error) {
+	id, ok := getID(data)
+	if !ok {
+		return -1, fmt.Errorf("'id' field is required")
+	}
+	want := fmt.Sprintf("%v", id)
+	for i, rowID := range ids {
+		if rowID == want {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no record found with id: %v", id)
+}
+
+// nextID returns an ID for a new row: one more than the largest ID if all IDs
+// are integers, a random ID otherwise
+func nextID(ids []string) string {
+	next := 1
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			return generateID()
+		}
+		next = max(next, n+1)
+	}
+	return strconv.Itoa(next)
+}
+
+// writeFields returns the form fields to store, without internal fields and the ID
+func writeFields(data map[string]interface{}) map[string]interface{} {
+	fields := filterDataFields(data)
+	for _, key := range []string{"id", "Id", "ID"} {
+		delete(fields, key)
+	}
+	return fields
+}
+
+// toggleColumn returns the column of a toggle action (default: "done")
+func toggleColumn(data map[string]interface{}) string {
+	if col, ok := data["column"].(string); ok && col != "" {
+		return col
+	}
+	return "done"
+}
+
+// orderedObject is a JSON object that keeps its key order and the exact text of
+// its values
+type orderedObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// decodeOrderedObject reads an object from dec
+func decodeOrderedObject(dec *json.Decoder) (*orderedObject, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected an object, got %v", tok)
+	}
+
+	obj := &orderedObject{values: make(map[string]json.RawMessage)}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if _, dup := obj.values[key]; !dup {
+			obj.keys = append(obj.keys, key)
+		}
+		obj.values[key] = value
+	}
+	if _, err := dec.Token(); err != nil { // Closing brace
+		return nil, err
+	}
+	return obj, nil
+}
+
+// set stores a value, converting form strings to the JSON type of sample (the
+// key's current value, or the same key in another row)
+func (o *orderedObject) set(key string, value interface{}, sample json.RawMessage) error {
+	raw, err := marshalJSON(jsonFormValue(value, sample))
+	if err != nil {
+		return err
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = raw
+	return nil
+}
+
+// toMap decodes the object as Fetch returns it
+func (o *orderedObject) toMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(o.keys))
+	for _, key := range o.keys {
+		var v interface{}
+		json.Unmarshal(o.values[key], &v)
+		m[key] = v
+	}
+	return m
+}
+
+// MarshalJSON writes the object with its keys in order
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := marshalJSON(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalJSON is json.Marshal without HTML escaping, so text like "<b>" is
+// written as is
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// jsonFormValue converts a form string to the JSON type of sample: numbers stay
+// numbers and booleans stay booleans
+func jsonFormValue(value interface{}, sample json.RawMessage) interface{} {
+	str, ok := value.(string)
+	if !ok || len(sample) == 0 {
+		return value
+	}
+	switch sample[0] {
+	case 't', 'f':
+		return formBool(str)
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		if _, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err == nil {
+			return json.Number(strings.TrimSpace(str))
+		}
+	}
+	return value
+}
+
+// jsonDocument is a JSON data file parsed for writing
+type jsonDocument struct {
+	layout  string         // "array", "data" (an object with a "data" array), or "ndjson"
+	wrapper *orderedObject // For "data": the top-level object
+	rows    []*orderedObject
+	indent  string // Indentation of the file; empty if compact
+	crlf    bool   // Lines end with \r\n
+}
+
+// parseJSONDocument parses a JSON data file in one of the layouts Fetch accepts.
+// Files holding a single object cannot be written as rows.
+func parseJSONDocument(data []byte) (*jsonDocument, error) {
+	doc := &jsonDocument{indent: detectIndent(data), crlf: bytes.Contains(data, []byte("\r\n"))}
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case len(trimmed) == 0:
+		doc.layout = "array"
+		doc.indent = "  "
+		return doc, nil
+
+	case trimmed[0] == '[':
+		doc.layout = "array"
+		rows, err := decodeObjectArray(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		doc.rows = rows
+		return doc, nil
+	}
+
+	// An object: a "data" wrapper, a single object, or the first line of NDJSON
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	first, err := decodeOrderedObject(dec)
+	if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		doc.layout = "ndjson"
+		doc.rows = []*orderedObject{first}
+		for dec.More() {
+			obj, err := decodeOrderedObject(dec)
+			if err != nil {
+				return nil, err
+			}
+			doc.rows = append(doc.rows, obj)
+		}
+		return doc, nil
+	}
+
+	if raw, ok := first.values["data"]; ok && bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		rows, err := decodeObjectArray(raw)
+		if err == nil {
+			doc.layout = "data"
+			doc.wrapper = first
+			doc.rows = rows
+			return doc, nil
+		}
+	}
+	return nil, fmt.Errorf("file holds a single object; writes need an array of objects")
+}
+
+// decodeObjectArray decodes an array of objects, keeping key order
+func decodeObjectArray(data []byte) ([]*orderedObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil { // Opening bracket
+		return nil, err
+	}
+	var rows []*orderedObject
+	for dec.More() {
+		obj, err := decodeOrderedObject(dec)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, obj)
+	}
+	return rows, nil
+}
+
+// detectIndent returns the indentation of the first indented line, or "" for a
+// file without one
+func detectIndent(data []byte) string {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " \t")
+		if len(trimmed) > 0 && len(trimmed) < len(line) {
+			return string(line[:len(line)-len(trimmed)])
+		}
+	}
+	return ""
+}
+
+// maps returns the rows as Fetch returns them
+func (d *jsonDocument) maps() []map[string]interface{} {
+	rows := make([]map[string]interface{}, len(d.rows))
+	for i, obj := range d.rows {
+		rows[i] = obj.toMap()
+	}
+	return rows
+}
+
+// sample returns the current value of key in the first row that has it
+func (d *jsonDocument) sample(key string) json.RawMessage {
+	for _, obj := range d.rows {
+		if raw, ok := obj.values[key]; ok {
+			return raw
+		}
+	}
+	return nil
+}
+
+// encode writes the document in its original layout and indentation
+func (d *jsonDocument) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	switch d.layout {
+	case "ndjson":
+		for _, obj := range d.rows {
+			line, _ := obj.MarshalJSON()
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+	default:
+		rows := d.rows
+		if rows == nil {
+			rows = []*orderedObject{}
+		}
+		rowsJSON, err := marshalJSON(rows)
+		if err != nil {
+			return nil, err
+		}
+		doc := rowsJSON
+		if d.layout == "data" {
+			d.wrapper.values["data"] = rowsJSON
+			doc, _ = d.wrapper.MarshalJSON()
+		}
+		if d.indent == "" {
+			buf.Write(doc)
+		} else if err := json.Indent(&buf, doc, "", d.indent); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+	}
+
+	out := buf.Bytes()
+	if d.crlf {
+		out = bytes.ReplaceAll(out, []byte("\n"), []byte("\r\n"))
+	}
+	return out, nil
+}
+
+// IsReadonly returns whether the source is read-only
+func (s *JSONFileSource) IsReadonly() bool {
+	return s.readonly
+}
+
+// WriteItem adds, updates, deletes or toggles a row of the file. Key order and
+// the layout of the file are preserved.
+func (s *JSONFileSource) WriteItem(ctx context.Context, action string, data map[string]interface{}) error {
+	if s.readonly {
+		return fmt.Errorf("json source %q is read-only", s.name)
+	}
+
+	path := s.resolvePath(s.filePath)
+	lock, err := lockFile(path)
+	if err != nil {
+		return fmt.Errorf("json source %q: %w", s.name, err)
+	}
+	defer lock.unlock()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("json source %q: failed to read file: %w", s.name, err)
+	}
+	doc, err := parseJSONDocument(content)
+	if err != nil {
+		return fmt.Errorf("json source %q: %w", s.name, err)
+	}
+	ids := assignRowIDs(doc.maps())
+
+	switch action {
+	case "add":
+		obj := &orderedObject{values: make(map[string]json.RawMessage)}
+		fields := writeFields(data)
+		if id, ok := getID(data); ok {
+			fields["id"] = id
+		} else if doc.sample("id") != nil {
+			fields["id"] = nextID(ids)
+		}
+		// Known keys in the order of the existing rows, then new keys
+		var order []string
+		if len(doc.rows) > 0 {
+			order = append(order, doc.rows[0].keys...)
+		}
+		order = append(order, sortedKeys(fields)...)
+		for _, key := range order {
+			value, ok := fields[key]
+			if _, done := obj.values[key]; !ok || done {
+				continue
+			}
+			if err := obj.set(key, value, doc.sample(key)); err != nil {
+				return fmt.Errorf("json source %q: %w", s.name, err)
+			}
+		}
+		doc.rows = append(doc.rows, obj)
+
+	case "update":
+		i, err := findRow(ids, data)
+		if err != nil {
+			return fmt.Errorf("json source %q: %w", s.name, err)
+		}
+		fields := writeFields(data)
+		for _, key := range sortedKeys(fields) {
+			sample := doc.rows[i].values[key]
+			if sample == nil {
+				sample = doc.sample(key)
+			}
+			if err := doc.rows[i].set(key, fields[key], sample); err != nil {
+				return fmt.Errorf("json source %q: %w", s.name, err)
+			}
+		}
+
+	case "delete":
+		i, err := findRow(ids, data)
+		if err != nil {
+			return fmt.Errorf("json source %q: %w", s.name, err)
+		}
+		doc.rows = append(doc.rows[:i], doc.rows[i+1:]...)
+
+	case "toggle":
+		i, err := findRow(ids, data)
+		if err != nil {
+			return fmt.Errorf("json source %q: %w", s.name, err)
+		}
+		column := toggleColumn(data)
+		var current interface{}
+		json.Unmarshal(doc.rows[i].values[column], &current)
+		doc.rows[i].set(column, !formBool(current), nil)
+
+	default:
+		return fmt.Errorf("json source %q: unsupported action: %s", s.name, action)
+	}
+
+	out, err := doc.encode()
+	if err != nil {
+		return fmt.Errorf("json source %q: %w", s.name, err)
+	}
+	if err := writeFileAtomic(path, out, 0644); err != nil {
+		return fmt.Errorf("json source %q: %w", s.name, err)
+	}
+	return nil
+}
+
+// IsReadonly returns whether the source is read-only
+func (s *CSVFileSource) IsReadonly() bool {
+	return s.readonly
+}
+
+// WriteItem adds, updates, deletes or toggles a row of the file. Column order is
+// preserved; fields without a column are added as new columns at the end.
+func (s *CSVFileSource) WriteItem(ctx context.Context, action string, data map[string]interface{}) error {
+	if s.readonly {
+		return fmt.Errorf("csv source %q is read-only", s.name)
+	}
+
+	path := s.resolvePath(s.filePath)
+	lock, err := lockFile(path)
+	if err != nil {
+		return fmt.Errorf("csv source %q: %w", s.name, err)
+	}
+	defer lock.unlock()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("csv source %q: failed to read file: %w", s.name, err)
+	}
+	headers, records, err := s.parseRecords(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("csv source %q: failed to read CSV: %w", s.name, err)
+	}
+	ids := assignRowIDs(csvRows(headers, records))
+
+	// column returns the index of a column, adding it if needed
+	column := func(name string) int {
+		for i, h := range headers {
+			if h == name {
+				return i
+			}
+		}
+		headers = append(headers, name)
+		for i := range records {
+			records[i] = append(records[i], "")
+		}
+		return len(headers) - 1
+	}
+	set := func(record []string, fields map[string]interface{}) []string {
+		for _, key := range sortedKeys(fields) {
+			i := column(key)
+			for len(record) < len(headers) {
+				record = append(record, "")
+			}
+			record[i] = fmt.Sprintf("%v", fields[key])
+		}
+		return record
+	}
+
+	switch action {
+	case "add":
+		fields := writeFields(data)
+		if id, ok := getID(data); ok {
+			fields["id"] = id
+		} else if slices.Contains(headers, "id") {
+			fields["id"] = nextID(ids)
+		}
+		if !s.hasHeader {
+			for key := range fields {
+				if !slices.Contains(headers, key) {
+					return fmt.Errorf("csv source %q: unknown column %q (the file has no header)", s.name, key)
+				}
+			}
+		}
+		records = append(records, set(make([]string, len(headers)), fields))
+
+	case "update":
+		i, err := findRow(ids, data)
+		if err != nil {
+			return fmt.Errorf("csv source %q: %w", s.name, err)
+		}
+		records[i] = set(records[i], writeFields(data))
+
+	case "delete":
+		i, err := findRow(ids, data)
+		if err != nil {
+			return fmt.Errorf("csv source %q: %w", s.name, err)
+		}
+		records = append(records[:i], records[i+1:]...)
+
+	case "toggle":
+		i, err := findRow(ids, data)
+		if err != nil {
+			return fmt.Errorf("csv source %q: %w", s.name, err)
+		}
+		col := toggleColumn(data)
+		current := ""
+		if j := column(col); j < len(records[i]) {
+			current = records[i][j]
+		}
+		records[i] = set(records[i], map[string]interface{}{col: toggleCSVValue(current)})
+
+	default:
+		return fmt.Errorf("csv source %q: unsupported action: %s", s.name, action)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = bytes.Contains(content, []byte("\r\n"))
+	if s.hasHeader {
+		w.Write(headers)
+	}
+	for _, record := range records {
+		for len(record) < len(headers) {
+			record = append(record, "")
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("csv source %q: %w", s.name, err)
+	}
+
+	if err := writeFileAtomic(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("csv source %q: %w", s.name, err)
+	}
+	return nil
+}
+
+// toggleCSVValue flips a boolean cell, keeping its style (1/0, yes/no, true/false)
+func toggleCSVValue(current string) string {
+	switch strings.ToLower(strings.TrimSpace(current)) {
+	case "1":
+		return "0"
+	case "0":
+		return "1"
+	case "yes", "y":
+		return "no"
+	case "no", "n":
+		return "yes"
+	case "true", "on":
+		return "false"
+	default:
+		return "true"
+	}
+}
@@ -0,0 +1,206 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+func writableConfig(file string) config.SourceConfig {
+	readonly := false
+	return config.SourceConfig{File: file, Readonly: &readonly}
+}
+
+func TestJSONFileSource_WriteItem(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "tasks.json")
+	os.WriteFile(path, []byte(`[
+    {"id": 1, "title": "Write docs", "done": false, "estimate": 2, "note": "<b>keep</b>"},
+    {"id": 2, "title": "Ship", "done": true, "estimate": 1}
+]
+`), 0644)
+
+	src, err := NewJSONFileSourceWithConfig("tasks", writableConfig("tasks.json"), tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	ctx := context.Background()
+
+	steps := []struct {
+		action string
+		data   map[string]interface{}
+	}{
+		{"add", map[string]interface{}{"title": "Test", "estimate": "3", "done": "false", "_csrf": "x"}},
+		{"update", map[string]interface{}{"id": "1", "title": "Write more docs", "estimate": "5"}},
+		{"toggle", map[string]interface{}{"id": "1"}},
+		{"delete", map[string]interface{}{"id": "2"}},
+	}
+	for _, step := range steps {
+		if err := src.WriteItem(ctx, step.action, step.data); err != nil {
+			t.Fatalf("%s failed: %v", step.action, err)
+		}
+	}
+
+	content, _ := os.ReadFile(path)
+	want := `[
+    {
+        "id": 1,
+        "title": "Write more docs",
+        "done": true,
+        "estimate": 5,
+        "note": "<b>keep</b>"
+    },
+    {
+        "id": 3,
+        "title": "Test",
+        "done": false,
+        "estimate": 3
+    }
+]
+`
+	if string(content) != want {
+		t.Errorf("file content:\n%s\nwant:\n%s", content, want)
+	}
+
+	if err := src.WriteItem(ctx, "update", map[string]interface{}{"id": "9", "title": "x"}); err == nil {
+		t.Error("expected error for unknown id")
+	}
+
+	readonly, _ := NewJSONFileSource("tasks", "tasks.json", tmpDir)
+	if err := readonly.WriteItem(ctx, "add", map[string]interface{}{"title": "x"}); err == nil {
+		t.Error("expected error for read-only source")
+	}
+}
+
+func TestJSONFileSource_WriteLayouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			"data wrapper",
+			`{"version": 2, "data": [{"name": "a"}]}`,
+			`{"version":2,"data":[{"name":"a"},{"name":"b"}]}` + "\n",
+		},
+		{
+			"ndjson",
+			"{\"name\": \"a\"}\n{\"name\": \"c\"}\n",
+			"{\"name\":\"a\"}\n{\"name\":\"c\"}\n{\"name\":\"b\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			path := filepath.Join(tmpDir, "data.json")
+			os.WriteFile(path, []byte(tt.content), 0644)
+
+			src, _ := NewJSONFileSourceWithConfig("data", writableConfig("data.json"), tmpDir)
+			if err := src.WriteItem(context.Background(), "add", map[string]interface{}{"name": "b"}); err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+			content, _ := os.ReadFile(path)
+			if string(content) != tt.want {
+				t.Errorf("file content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONFileSource_ContentIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "links.json")
+	os.WriteFile(path, []byte(`[{"url": "a"}, {"url": "b"}, {"url": "a"}]`), 0644)
+
+	src, _ := NewJSONFileSourceWithConfig("links", writableConfig("links.json"), tmpDir)
+	ctx := context.Background()
+
+	rows, err := src.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if rows[0]["id"] == nil || rows[0]["id"] == rows[2]["id"] {
+		t.Fatalf("expected distinct IDs for duplicate rows, got %v", rows)
+	}
+
+	// Deleting by a content ID removes that row only, and no id is written to the file
+	if err := src.WriteItem(ctx, "delete", map[string]interface{}{"id": rows[2]["id"]}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != `[{"url":"a"},{"url":"b"}]`+"\n" {
+		t.Errorf("unexpected file content: %s", content)
+	}
+
+	// A read-only source returns the rows unchanged
+	readonly, _ := NewJSONFileSource("links", "links.json", tmpDir)
+	rows, _ = readonly.Fetch(ctx)
+	if _, ok := rows[0]["id"]; ok {
+		t.Errorf("expected no id for a read-only source, got %v", rows[0])
+	}
+}
+
+func TestCSVFileSource_WriteItem(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "people.csv")
+	os.WriteFile(path, []byte("name,id,active\r\nAda,7,yes\r\n\"Lovelace, A\",8,no\r\n"), 0644)
+
+	src, err := NewCSVFileSourceWithConfig("people", writableConfig("people.csv"), tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	ctx := context.Background()
+
+	steps := []struct {
+		action string
+		data   map[string]interface{}
+	}{
+		{"add", map[string]interface{}{"name": "Grace", "active": "yes", "team": "navy"}},
+		{"toggle", map[string]interface{}{"id": "7", "column": "active"}},
+		{"update", map[string]interface{}{"id": "8", "name": "Ada L."}},
+	}
+	for _, step := range steps {
+		if err := src.WriteItem(ctx, step.action, step.data); err != nil {
+			t.Fatalf("%s failed: %v", step.action, err)
+		}
+	}
+
+	content, _ := os.ReadFile(path)
+	want := "name,id,active,team\r\nAda,7,no,\r\nAda L.,8,no,\r\nGrace,9,yes,navy\r\n"
+	if string(content) != want {
+		t.Errorf("file content = %q, want %q", content, want)
+	}
+
+	if err := src.WriteItem(ctx, "delete", map[string]interface{}{"id": "9"}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	rows, err := src.Fetch(ctx)
+	if err != nil || len(rows) != 2 {
+		t.Errorf("unexpected rows after delete: %v (%v)", rows, err)
+	}
+}
+
+func TestCSVFileSource_ContentIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "items.csv")
+	os.WriteFile(path, []byte("item,qty\napple,1\npear,2\n"), 0644)
+
+	src, _ := NewCSVFileSourceWithConfig("items", writableConfig("items.csv"), tmpDir)
+	ctx := context.Background()
+
+	rows, _ := src.Fetch(ctx)
+	if err := src.WriteItem(ctx, "update", map[string]interface{}{"id": rows[1]["id"], "qty": "5"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if err := src.WriteItem(ctx, "add", map[string]interface{}{"item": "fig", "qty": "1"}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if want := "item,qty\napple,1\npear,5\nfig,1\n"; string(content) != want {
+		t.Errorf("file content = %q, want %q", content, want)
+	}
+}
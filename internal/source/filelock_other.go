@@ -0,0 +1,16 @@
+//go:build !unix
+
+package source
+
+// fileLock is a no-op on platforms without flock support
+type fileLock struct{}
+
+// lockFile returns a no-op lock; writes still go through an atomic rename
+func lockFile(path string) (*fileLock, error) {
+	return &fileLock{}, nil
+}
+
+// unlock releases the lock
+func (l *fileLock) unlock() error {
+	return nil
+}
@@ -0,0 +1,52 @@
+//go:build unix
+
+package source
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileLock is an exclusive advisory lock on a file
+type fileLock struct {
+	f *os.File
+}
+
+// lockFile acquires an exclusive advisory lock (flock) on path, blocking until
+// it is available. Because writes replace the file via rename, the lock is
+// re-acquired if the path no longer refers to the locked inode.
+func lockFile(path string) (*fileLock, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDONLY, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file for locking: %w", err)
+		}
+
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock file: %w", err)
+		}
+
+		// Another writer may have renamed a new file into place while we waited
+		locked, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to stat locked file: %w", err)
+		}
+		current, err := os.Stat(path)
+		if err == nil && os.SameFile(locked, current) {
+			return &fileLock{f: f}, nil
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat file: %w", err)
+		}
+	}
+}
+
+// unlock releases the lock
+func (l *fileLock) unlock() error {
+	// Closing the descriptor releases the flock
+	return l.f.Close()
+}
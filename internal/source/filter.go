@@ -0,0 +1,110 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// filterPredicate is a condition on a row field, written as "field op value"
+// (e.g., "level == error" or "status >= 500")
+type filterPredicate struct {
+	field   string
+	op      string // ==, !=, <, <=, >, >=, contains, or matches
+	value   string
+	pattern *regexp.Regexp // For matches
+}
+
+var filterExprPattern = regexp.MustCompile(`^\s*([\w.-]+)\s*(==|!=|<=|>=|<|>|\s(?:contains|matches)\s)\s*(.*?)\s*$`)
+
+// parseFilters parses filter expressions. Values may be in single or double quotes.
+func parseFilters(name string, exprs []string) ([]filterPredicate, error) {
+	predicates := make([]filterPredicate, 0, len(exprs))
+	for _, expr := range exprs {
+		match := filterExprPattern.FindStringSubmatch(expr)
+		if match == nil {
+			return nil, &ValidationError{Source: name, Field: "filter", Reason: fmt.Sprintf("invalid filter %q (expected \"field op value\" with op ==, !=, <, <=, >, >=, contains, or matches)", expr)}
+		}
+
+		p := filterPredicate{field: match[1], op: strings.TrimSpace(match[2]), value: unquoteFilterValue(match[3])}
+		if p.op == "matches" {
+			pattern, err := regexp.Compile(p.value)
+			if err != nil {
+				return nil, &ValidationError{Source: name, Field: "filter", Reason: fmt.Sprintf("invalid pattern in %q: %v", expr, err)}
+			}
+			p.pattern = pattern
+		}
+		predicates = append(predicates, p)
+	}
+	return predicates, nil
+}
+
+// unquoteFilterValue removes matching quotes around a value
+func unquoteFilterValue(value string) string {
+	if len(value) >= 2 {
+		if first, last := value[0], value[len(value)-1]; first == last && (first == '"' || first == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// matchFilters reports whether a row satisfies all predicates
+func matchFilters(row map[string]interface{}, predicates []filterPredicate) bool {
+	for _, p := range predicates {
+		if !p.match(row) {
+			return false
+		}
+	}
+	return true
+}
+
+// match evaluates the predicate against a row. A missing or null field only
+// matches !=. Values are compared as numbers when both sides are numeric.
+func (p filterPredicate) match(row map[string]interface{}) bool {
+	value, ok := row[p.field]
+	if !ok {
+		value, _ = navigateJSONPath(row, p.field)
+	}
+	if value == nil {
+		return p.op == "!="
+	}
+	str := fmt.Sprintf("%v", value)
+
+	switch p.op {
+	case "contains":
+		return strings.Contains(str, p.value)
+	case "matches":
+		return p.pattern.MatchString(str)
+	}
+
+	cmp := strings.Compare(str, p.value)
+	if a, errA := strconv.ParseFloat(str, 64); errA == nil {
+		if b, errB := strconv.ParseFloat(p.value, 64); errB == nil {
+			switch {
+			case a < b:
+				cmp = -1
+			case a > b:
+				cmp = 1
+			default:
+				cmp = 0
+			}
+		}
+	}
+
+	switch p.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // >=
+		return cmp >= 0
+	}
+}
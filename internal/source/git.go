@@ -0,0 +1,509 @@
+package source
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// gitModes are the kinds of rows a git source can read
+var gitModes = []string{"log", "history", "blame", "tags", "branches"}
+
+// GitSource reads a local git repository, without running git or using the
+// network.
+//
+// Options:
+//   - mode: log (default), history, blame, tags or branches
+//   - ref: revision to read from for log, history and blame (default: HEAD)
+//   - paths: comma-separated paths; log only lists commits changing files under them
+//   - since, until: date range (2006-01-02, RFC 3339, or a relative age like 30d, 2w, 12h)
+//   - limit: maximum number of rows
+//
+// The repository is the one containing path (default: the site directory).
+// history and blame read file (default: the current page). Relative paths are
+// resolved against the site directory.
+type GitSource struct {
+	name     string
+	repoPath string
+	file     string // Absolute; for history and blame
+	mode     string
+	ref      string
+	paths    []string // Absolute
+	since    string
+	until    string
+	filters  []filterPredicate
+	limit    int
+}
+
+// NewGitSource creates a new git repository source
+func NewGitSource(name string, cfg config.SourceConfig, siteDir, currentFile string) (*GitSource, error) {
+	s := &GitSource{
+		name:     name,
+		repoPath: resolveSitePath(siteDir, cmp.Or(cfg.Path, ".")),
+		mode:     cmp.Or(cfg.Options["mode"], "log"),
+		ref:      cmp.Or(cfg.Options["ref"], "HEAD"),
+		since:    cfg.Options["since"],
+		until:    cfg.Options["until"],
+	}
+	if !slices.Contains(gitModes, s.mode) {
+		return nil, &ValidationError{Source: name, Field: "options.mode", Reason: fmt.Sprintf("unknown mode %q (expected %s)", s.mode, strings.Join(gitModes, ", "))}
+	}
+
+	if s.mode == "history" || s.mode == "blame" {
+		switch {
+		case cfg.File != "":
+			s.file = resolveSitePath(siteDir, cfg.File)
+		case currentFile != "":
+			s.file = currentFile
+		default:
+			return nil, &ValidationError{Source: name, Field: "file", Reason: fmt.Sprintf("file is required for mode %q", s.mode)}
+		}
+	}
+	for _, path := range strings.Split(cfg.Options["paths"], ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			s.paths = append(s.paths, resolveSitePath(siteDir, path))
+		}
+	}
+
+	now := time.Now()
+	for field, value := range map[string]string{"since": s.since, "until": s.until} {
+		if _, err := parseGitTime(value, now, false); err != nil {
+			return nil, &ValidationError{Source: name, Field: "options." + field, Reason: err.Error()}
+		}
+	}
+	if v := cfg.Options["limit"]; v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return nil, &ValidationError{Source: name, Field: "options.limit", Reason: fmt.Sprintf("must be a positive number, got %q", v)}
+		}
+		s.limit = limit
+	}
+
+	filters, err := parseFilters(name, cfg.Filter)
+	if err != nil {
+		return nil, err
+	}
+	s.filters = filters
+	return s, nil
+}
+
+// Name returns the source identifier
+func (s *GitSource) Name() string {
+	return s.name
+}
+
+// Fetch opens the repository and reads the rows of the configured mode
+func (s *GitSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	repo, err := git.PlainOpenWithOptions(s.repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("git source %q: failed to open repository at %s: %w", s.name, s.repoPath, err)
+	}
+
+	now := time.Now()
+	since, _ := parseGitTime(s.since, now, false)
+	until, _ := parseGitTime(s.until, now, true)
+	q := &gitQuery{source: s, repo: repo, since: since, until: until, rows: []map[string]interface{}{}}
+
+	switch s.mode {
+	case "history":
+		err = q.log(ctx, s.file)
+	case "blame":
+		err = q.blame(ctx)
+	case "tags":
+		err = q.tags(ctx)
+	case "branches":
+		err = q.branches(ctx)
+	default:
+		err = q.log(ctx, "")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("git source %q: %w", s.name, err)
+	}
+	return q.rows, nil
+}
+
+// Close is a no-op for git sources
+func (s *GitSource) Close() error {
+	return nil
+}
+
+// gitQuery collects the rows of one Fetch
+type gitQuery struct {
+	source *GitSource
+	repo   *git.Repository
+	since  time.Time // Zero for no bound
+	until  time.Time // Zero for no bound
+	rows   []map[string]interface{}
+}
+
+// inRange reports whether t is within the since/until range
+func (q *gitQuery) inRange(t time.Time) bool {
+	return (q.since.IsZero() || !t.Before(q.since)) && (q.until.IsZero() || !t.After(q.until))
+}
+
+// add keeps row if it matches the filters, and reports whether the limit is reached
+func (q *gitQuery) add(row map[string]interface{}) (full bool) {
+	if matchFilters(row, q.source.filters) {
+		q.rows = append(q.rows, row)
+	}
+	return q.source.limit > 0 && len(q.rows) >= q.source.limit
+}
+
+// relPath returns a path relative to the repository root, with forward slashes
+func (q *gitQuery) relPath(path string) (string, error) {
+	wt, err := q.repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("repository has no working tree to resolve %s: %w", path, err)
+	}
+	root := wt.Filesystem.Root()
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the repository at %s", path, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// resolveRef returns the commit of the configured revision
+func (q *gitQuery) resolveRef() (*object.Commit, error) {
+	hash, err := q.repo.ResolveRevision(plumbing.Revision(q.source.ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %q: %w", q.source.ref, err)
+	}
+	return q.repo.CommitObject(*hash)
+}
+
+// log lists commits, newest first. With file set (history mode), it lists the
+// commits that changed the file, with their line counts for it.
+func (q *gitQuery) log(ctx context.Context, file string) error {
+	var prefixes []string
+	if file != "" {
+		rel, err := q.relPath(file)
+		if err != nil {
+			return err
+		}
+		prefixes = []string{rel}
+	} else {
+		for _, path := range q.source.paths {
+			rel, err := q.relPath(path)
+			if err != nil {
+				return err
+			}
+			prefixes = append(prefixes, rel)
+		}
+	}
+
+	head, err := q.resolveRef()
+	if err != nil {
+		return err
+	}
+	commits, err := q.repo.Log(&git.LogOptions{From: head.Hash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return err
+	}
+	defer commits.Close()
+
+	err = commits.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !q.inRange(c.Author.When) {
+			return nil
+		}
+
+		changes, err := commitChanges(ctx, c)
+		if err != nil {
+			return fmt.Errorf("failed to diff commit %s: %w", c.Hash, err)
+		}
+		files := make([]interface{}, 0, len(changes))
+		var matched *object.Change
+		for _, change := range changes {
+			name := changeName(change)
+			files = append(files, name)
+			if matched == nil && matchesPathPrefix(name, prefixes) {
+				matched = change
+			}
+		}
+		if len(prefixes) > 0 && matched == nil {
+			return nil
+		}
+
+		row := commitRow(c)
+		row["files"] = files
+		row["files_changed"] = len(files)
+		if file != "" {
+			additions, deletions, err := changeLines(ctx, matched)
+			if err != nil {
+				return fmt.Errorf("failed to diff commit %s: %w", c.Hash, err)
+			}
+			row["additions"] = additions
+			row["deletions"] = deletions
+		}
+		if q.add(row) {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if errors.Is(err, storer.ErrStop) {
+		return nil
+	}
+	return err
+}
+
+// blame lists the lines of the file with the commit that last changed each one
+func (q *gitQuery) blame(ctx context.Context) error {
+	rel, err := q.relPath(q.source.file)
+	if err != nil {
+		return err
+	}
+	head, err := q.resolveRef()
+	if err != nil {
+		return err
+	}
+	result, err := git.Blame(head, rel)
+	if err != nil {
+		return fmt.Errorf("failed to blame %s: %w", rel, err)
+	}
+
+	for i, line := range result.Lines {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !q.inRange(line.Date) {
+			continue
+		}
+		row := map[string]interface{}{
+			"id":         i + 1,
+			"line":       i + 1,
+			"text":       line.Text,
+			"hash":       line.Hash.String(),
+			"short_hash": shortHash(line.Hash),
+			"author":     line.AuthorName,
+			"email":      line.Author,
+			"date":       line.Date,
+		}
+		if q.add(row) {
+			break
+		}
+	}
+	return nil
+}
+
+// tags lists the tags, newest first. Annotated tags use their own date,
+// tagger and message; lightweight tags use those of the tagged commit.
+func (q *gitQuery) tags(ctx context.Context) error {
+	refs, err := q.repo.Tags()
+	if err != nil {
+		return err
+	}
+	var rows []map[string]interface{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var tag *object.Tag
+		commitHash := ref.Hash()
+		if t, err := q.repo.TagObject(ref.Hash()); err == nil {
+			tag, commitHash = t, t.Target
+		}
+		c, err := q.repo.CommitObject(commitHash)
+		if err != nil {
+			// Tags of trees or blobs have no commit
+			return nil
+		}
+
+		name := ref.Name().Short()
+		row := map[string]interface{}{
+			"id":         name,
+			"name":       name,
+			"hash":       c.Hash.String(),
+			"short_hash": shortHash(c.Hash),
+			"annotated":  tag != nil,
+		}
+		if tag != nil {
+			row["author"] = tag.Tagger.Name
+			row["email"] = tag.Tagger.Email
+			row["date"] = tag.Tagger.When
+			row["message"] = strings.TrimSpace(tag.Message)
+		} else {
+			row["author"] = c.Author.Name
+			row["email"] = c.Author.Email
+			row["date"] = c.Author.When
+			row["message"] = strings.TrimSpace(c.Message)
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	q.addNewestFirst(rows)
+	return nil
+}
+
+// branches lists the local branches, most recently committed first
+func (q *gitQuery) branches(ctx context.Context) error {
+	current := ""
+	if head, err := q.repo.Head(); err == nil && head.Name().IsBranch() {
+		current = head.Name().Short()
+	}
+
+	refs, err := q.repo.Branches()
+	if err != nil {
+		return err
+	}
+	var rows []map[string]interface{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c, err := q.repo.CommitObject(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("failed to read branch %s: %w", ref.Name().Short(), err)
+		}
+		row := commitRow(c)
+		name := ref.Name().Short()
+		row["id"] = name
+		row["name"] = name
+		row["current"] = name == current
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	q.addNewestFirst(rows)
+	return nil
+}
+
+// addNewestFirst sorts rows by date, newest first, and adds those in range
+func (q *gitQuery) addNewestFirst(rows []map[string]interface{}) {
+	slices.SortStableFunc(rows, func(a, b map[string]interface{}) int {
+		return b["date"].(time.Time).Compare(a["date"].(time.Time))
+	})
+	for _, row := range rows {
+		if q.inRange(row["date"].(time.Time)) && q.add(row) {
+			return
+		}
+	}
+}
+
+// commitRow returns the fields of a commit
+func commitRow(c *object.Commit) map[string]interface{} {
+	message := strings.TrimSpace(c.Message)
+	subject, body, _ := strings.Cut(message, "\n")
+	return map[string]interface{}{
+		"id":         c.Hash.String(),
+		"hash":       c.Hash.String(),
+		"short_hash": shortHash(c.Hash),
+		"author":     c.Author.Name,
+		"email":      c.Author.Email,
+		"date":       c.Author.When,
+		"subject":    strings.TrimSpace(subject),
+		"body":       strings.TrimSpace(body),
+		"message":    message,
+		"merge":      c.NumParents() > 1,
+	}
+}
+
+// commitChanges returns the files a commit changed compared to its first parent
+func commitChanges(ctx context.Context, c *object.Commit) (object.Changes, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	return object.DiffTreeContext(ctx, parentTree, tree)
+}
+
+// changeName returns the path of a changed file (the old path if it was deleted)
+func changeName(change *object.Change) string {
+	if change.To.Name != "" {
+		return change.To.Name
+	}
+	return change.From.Name
+}
+
+// changeLines returns the number of lines a change added and deleted
+func changeLines(ctx context.Context, change *object.Change) (additions, deletions int, err error) {
+	patch, err := change.PatchContext(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, stat := range patch.Stats() {
+		additions += stat.Addition
+		deletions += stat.Deletion
+	}
+	return additions, deletions, nil
+}
+
+// matchesPathPrefix reports whether path is one of prefixes or inside one of them
+func matchesPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix == "." || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// shortHash abbreviates a commit hash like git log --oneline
+func shortHash(hash plumbing.Hash) string {
+	return hash.String()[:7]
+}
+
+// parseGitTime parses a since/until value: a date (2006-01-02), an RFC 3339
+// time, or an age before now such as 30d, 2w or 12h. A date used as an upper
+// bound (endOfDay) includes the whole day. Empty values return the zero time.
+func parseGitTime(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			if count, err := strconv.Atoi(n); err == nil && count >= 0 {
+				return now.Add(-time.Duration(count) * unit), nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (expected 2006-01-02, RFC 3339, or an age like 30d)", value)
+}
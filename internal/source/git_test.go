@@ -0,0 +1,249 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// initGitRepo creates a repository with four commits a day apart (from
+// 2026-03-01), an annotated tag v1.0 on the second, a lightweight tag v1.1
+// on the fourth, and a branch "draft" at the third.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	commits := []struct {
+		author  string
+		file    string
+		content string
+		message string
+	}{
+		{"Ada", "README.md", "# Ops\n", "Initial commit"},
+		{"Ada", "runbooks/deploy.md", "1. Build\n2. Ship\n", "Add deploy runbook\n\nCovers the release train."},
+		{"Grace", "runbooks/deploy.md", "1. Build\n2. Test\n3. Ship\n", "Test before shipping"},
+		{"Grace", "notes.txt", "todo\n", "Add notes"},
+	}
+	var hashes []plumbing.Hash
+	for i, c := range commits {
+		path := filepath.Join(dir, c.file)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(c.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add(c.file); err != nil {
+			t.Fatal(err)
+		}
+		sig := &object.Signature{Name: c.author, Email: c.author + "@example.com", When: day.AddDate(0, 0, i)}
+		hash, err := wt.Commit(c.message, &git.CommitOptions{Author: sig, Committer: sig})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	tagger := &object.Signature{Name: "Ada", Email: "Ada@example.com", When: day.AddDate(0, 0, 5)}
+	if _, err := repo.CreateTag("v1.0", hashes[1], &git.CreateTagOptions{Tagger: tagger, Message: "First release"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTag("v1.1", hashes[3], nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("draft"), hashes[2])); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// fetchGit creates a git source for the repository and fetches its rows
+func fetchGit(t *testing.T, dir string, cfg config.SourceConfig) []map[string]interface{} {
+	t.Helper()
+	src, err := NewGitSource("git", cfg, dir, "")
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	return rows
+}
+
+func TestGitSource_Log(t *testing.T) {
+	dir := initGitRepo(t)
+
+	rows := fetchGit(t, dir, config.SourceConfig{})
+	if len(rows) != 4 {
+		t.Fatalf("got %d commits, want 4", len(rows))
+	}
+	if rows[0]["subject"] != "Add notes" || rows[3]["subject"] != "Initial commit" {
+		t.Errorf("commits not newest first: %v, %v", rows[0]["subject"], rows[3]["subject"])
+	}
+	deploy := rows[2]
+	if deploy["author"] != "Ada" || deploy["email"] != "Ada@example.com" || deploy["body"] != "Covers the release train." {
+		t.Errorf("unexpected commit: %#v", deploy)
+	}
+	if date, ok := deploy["date"].(time.Time); !ok || !date.Equal(time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("date = %#v, want 2026-03-02", deploy["date"])
+	}
+	if files, ok := deploy["files"].([]interface{}); !ok || len(files) != 1 || files[0] != "runbooks/deploy.md" || deploy["files_changed"] != 1 {
+		t.Errorf("files = %#v", deploy["files"])
+	}
+	if id, _ := deploy["id"].(string); len(id) != 40 || deploy["short_hash"] != id[:7] {
+		t.Errorf("id = %v, short_hash = %v", deploy["id"], deploy["short_hash"])
+	}
+
+	// Paths, dates, filters and limit narrow the log
+	tests := []struct {
+		name        string
+		options     map[string]string
+		filter      []string
+		wantSubject []string
+	}{
+		{"paths", map[string]string{"paths": "runbooks"}, nil, []string{"Test before shipping", "Add deploy runbook"}},
+		{"since", map[string]string{"since": "2026-03-03"}, nil, []string{"Add notes", "Test before shipping"}},
+		{"until", map[string]string{"until": "2026-03-02"}, nil, []string{"Add deploy runbook", "Initial commit"}},
+		{"filter", nil, []string{"author == Grace"}, []string{"Add notes", "Test before shipping"}},
+		{"limit", map[string]string{"limit": "1", "ref": "draft"}, nil, []string{"Test before shipping"}},
+		{"relative", map[string]string{"since": "1d"}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := fetchGit(t, dir, config.SourceConfig{Options: tt.options, Filter: tt.filter})
+			if len(rows) != len(tt.wantSubject) {
+				t.Fatalf("got %d commits, want %d", len(rows), len(tt.wantSubject))
+			}
+			for i, subject := range tt.wantSubject {
+				if rows[i]["subject"] != subject {
+					t.Errorf("commit %d = %v, want %q", i, rows[i]["subject"], subject)
+				}
+			}
+		})
+	}
+}
+
+func TestGitSource_HistoryAndBlame(t *testing.T) {
+	dir := initGitRepo(t)
+
+	// The site directory is inside the repository; file is relative to it
+	site := filepath.Join(dir, "runbooks")
+	src, err := NewGitSource("history", config.SourceConfig{File: "deploy.md", Options: map[string]string{"mode": "history"}}, site, "")
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["subject"] != "Test before shipping" || rows[1]["additions"] != 2 || rows[1]["deletions"] != 0 {
+		t.Errorf("unexpected history: %v", rows)
+	}
+
+	// Blame defaults to the current page
+	src, err = NewGitSource("blame", config.SourceConfig{Options: map[string]string{"mode": "blame"}}, site, filepath.Join(site, "deploy.md"))
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	rows, err = src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d lines, want 3", len(rows))
+	}
+	if rows[0]["text"] != "1. Build" || rows[0]["author"] != "Ada" || rows[1]["text"] != "2. Test" || rows[1]["author"] != "Grace" || rows[2]["line"] != 3 {
+		t.Errorf("unexpected blame: %v", rows)
+	}
+
+	if _, err := NewGitSource("blame", config.SourceConfig{Options: map[string]string{"mode": "blame"}}, site, ""); err == nil {
+		t.Error("expected error for blame without file")
+	}
+	src, _ = NewGitSource("blame", config.SourceConfig{File: "../../elsewhere.md", Options: map[string]string{"mode": "blame"}}, site, "")
+	if _, err := src.Fetch(context.Background()); err == nil {
+		t.Error("expected error for file outside the repository")
+	}
+}
+
+func TestGitSource_Refs(t *testing.T) {
+	dir := initGitRepo(t)
+
+	tags := fetchGit(t, dir, config.SourceConfig{Options: map[string]string{"mode": "tags"}})
+	if len(tags) != 2 {
+		t.Fatalf("got %d tags, want 2", len(tags))
+	}
+	// v1.0 is tagged on 2026-03-06, after v1.1's commit on 2026-03-04
+	if tags[0]["name"] != "v1.0" || tags[0]["annotated"] != true || tags[0]["message"] != "First release" {
+		t.Errorf("unexpected tag: %#v", tags[0])
+	}
+	if tags[1]["name"] != "v1.1" || tags[1]["annotated"] != false || tags[1]["message"] != "Add notes" {
+		t.Errorf("unexpected tag: %#v", tags[1])
+	}
+
+	branches := fetchGit(t, dir, config.SourceConfig{Options: map[string]string{"mode": "branches"}})
+	if len(branches) != 2 {
+		t.Fatalf("got %d branches, want 2", len(branches))
+	}
+	if branches[0]["name"] != "master" || branches[0]["current"] != true || branches[1]["name"] != "draft" || branches[1]["current"] != false {
+		t.Errorf("unexpected branches: %v", branches)
+	}
+	if branches[1]["subject"] != "Test before shipping" {
+		t.Errorf("draft subject = %v", branches[1]["subject"])
+	}
+}
+
+func TestGitSource_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for _, options := range []map[string]string{
+		{"mode": "diff"},
+		{"since": "last tuesday"},
+		{"limit": "-1"},
+	} {
+		if _, err := NewGitSource("git", config.SourceConfig{Options: options}, dir, ""); err == nil {
+			t.Errorf("expected error for options %v", options)
+		}
+	}
+
+	src, _ := NewGitSource("git", config.SourceConfig{}, dir, "")
+	if _, err := src.Fetch(context.Background()); err == nil {
+		t.Error("expected error outside a repository")
+	}
+}
+
+func TestParseGitTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		endOfDay bool
+		want     time.Time
+	}{
+		{"", false, time.Time{}},
+		{"2026-03-01T09:30:00Z", false, time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)},
+		{"30d", false, now.AddDate(0, 0, -30)},
+		{"2w", false, now.AddDate(0, 0, -14)},
+		{"12h", false, now.Add(-12 * time.Hour)},
+		{"2026-03-01", false, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)},
+		{"2026-03-01", true, time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond)},
+	}
+	for _, tt := range tests {
+		got, err := parseGitTime(tt.value, now, tt.endOfDay)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseGitTime(%q, %v) = %v, %v; want %v", tt.value, tt.endOfDay, got, err, tt.want)
+		}
+	}
+}
@@ -12,24 +12,38 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/livetemplate/tinkerdown/internal/config"
 )
 
-// GraphQLSource fetches data from a GraphQL API endpoint
+// GraphQLSource fetches data from a GraphQL API endpoint.
+// It implements WritableSource by mapping actions to mutations (see mutations).
 type GraphQLSource struct {
-	name           string
-	url            string
-	queryFile      string
-	variables      map[string]interface{}
-	resultPath     string
-	headers        map[string]string
-	client         *http.Client
-	retryConfig    RetryConfig
-	circuitBreaker *CircuitBreaker
-	siteDir        string
+	name            string
+	url             string
+	queryFile       string
+	variables       map[string]interface{}
+	resultPath      string
+	pagination      *config.PaginationConfig
+	pageInfoPath    string // Dot-path to the connection's pageInfo, for Relay pagination
+	mutations       map[string]config.GraphQLMutation
+	readonly        bool
+	subscriptionURL string
+	timeout         time.Duration
+	headers         map[string]string
+	client          *http.Client
+	retryConfig     RetryConfig
+	circuitBreaker  *CircuitBreaker
+	limiter         *rateLimiter // Shared per host; nil if not rate limited
+	siteDir         string
+	flightKey       string // Identifies the configuration, to coalesce fetches (see graphqlFlights)
 }
 
+// graphqlFlights coalesces concurrent fetches of GraphQL sources with the same
+// configuration, e.g., created by several connections to the same page
+var graphqlFlights flightGroup
+
 // NewGraphQLSource creates a new GraphQL API source
 func NewGraphQLSource(name string, cfg config.SourceConfig, siteDir string) (*GraphQLSource, error) {
 	if cfg.From == "" {
@@ -83,9 +97,36 @@ func NewGraphQLSource(name string, cfg config.SourceConfig, siteDir string) (*Gr
 		}
 	}
 
+	pageInfoPath, err := relayPageInfoPath(name, cfg.Pagination, cfg.ResultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Subscription settings
+	stream, err := streamSettings(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	wsURL := subscriptionURL(url)
+	if stream.URL != "" {
+		wsURL = os.ExpandEnv(stream.URL)
+	}
+
+	// Mutations by lowercase action name
+	mutations := make(map[string]config.GraphQLMutation, len(cfg.Mutations))
+	for action, mutation := range cfg.Mutations {
+		mutations[strings.ToLower(action)] = mutation
+	}
+
 	// Get timeout from config or default
 	timeout := cfg.GetTimeout()
 
+	// Build HTTP client with auth and TLS settings
+	client, err := newHTTPClient(name, cfg.Auth, siteDir, timeout)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build retry config
 	retryConfig := RetryConfig{
 		MaxRetries: cfg.GetRetryMaxRetries(),
@@ -95,23 +136,34 @@ func NewGraphQLSource(name string, cfg config.SourceConfig, siteDir string) (*Gr
 		EnableLog:  true,
 	}
 
-	// Create circuit breaker
-	cbConfig := DefaultCircuitBreakerConfig()
-	circuitBreaker := NewCircuitBreaker(name, cbConfig)
+	// Create circuit breaker (shared with the same source on other pages)
+	cbConfig := sourceCircuitBreakerConfig(cfg)
+	circuitBreaker := sharedCircuitBreaker(name, cfg, cbConfig)
+
+	limiter, err := sourceRateLimiter(name, urlHost(url), cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	return &GraphQLSource{
-		name:           name,
-		url:            url,
-		queryFile:      cfg.QueryFile,
-		variables:      variables,
-		resultPath:     cfg.ResultPath,
-		headers:        headers,
-		retryConfig:    retryConfig,
-		circuitBreaker: circuitBreaker,
-		siteDir:        siteDir,
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		name:            name,
+		url:             url,
+		queryFile:       cfg.QueryFile,
+		variables:       variables,
+		resultPath:      cfg.ResultPath,
+		pagination:      cfg.Pagination,
+		pageInfoPath:    pageInfoPath,
+		mutations:       mutations,
+		readonly:        cfg.IsReadonly(),
+		subscriptionURL: wsURL,
+		timeout:         timeout,
+		headers:         headers,
+		retryConfig:     retryConfig,
+		circuitBreaker:  circuitBreaker,
+		limiter:         limiter,
+		siteDir:         siteDir,
+		client:          client,
+		flightKey:       flightKey(name, cfg),
 	}, nil
 }
 
@@ -120,46 +172,118 @@ func (s *GraphQLSource) Name() string {
 	return s.name
 }
 
+// CircuitBreaker returns the circuit breaker guarding the source's requests
+func (s *GraphQLSource) CircuitBreaker() *CircuitBreaker {
+	return s.circuitBreaker
+}
+
 // Close is a no-op for GraphQL sources
 func (s *GraphQLSource) Close() error {
 	return nil
 }
 
-// Fetch makes a GraphQL request and parses the response
+// Fetch makes a GraphQL request and parses the response.
+// Concurrent fetches of sources with the same configuration share a request.
 func (s *GraphQLSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
-	// Use circuit breaker + retry
-	return s.circuitBreaker.Execute(ctx, func(ctx context.Context) ([]map[string]interface{}, error) {
-		return WithRetry(ctx, s.name, s.retryConfig, func(ctx context.Context) ([]map[string]interface{}, error) {
-			return s.doFetch(ctx)
+	return graphqlFlights.Do(ctx, s.flightKey, func() ([]map[string]interface{}, error) {
+		// Use circuit breaker + retry
+		return s.circuitBreaker.Execute(ctx, func(ctx context.Context) ([]map[string]interface{}, error) {
+			return WithRetry(ctx, s.name, s.retryConfig, func(ctx context.Context) ([]map[string]interface{}, error) {
+				return s.doFetch(ctx)
+			})
+		})
+	})
+}
+
+// FetchConditional fetches data like Fetch, revalidating a cached response with its validators.
+// Concurrent fetches with the same configuration and validators share a request.
+func (s *GraphQLSource) FetchConditional(ctx context.Context, etag, lastModified string) ([]map[string]interface{}, HTTPCacheInfo, bool, error) {
+	key := conditionalFlightKey(s.flightKey, etag, lastModified)
+	return graphqlFlights.DoConditional(ctx, key, func() ([]map[string]interface{}, HTTPCacheInfo, bool, error) {
+		var info HTTPCacheInfo
+		var notModified bool
+		data, err := s.circuitBreaker.Execute(ctx, func(ctx context.Context) ([]map[string]interface{}, error) {
+			return WithRetry(ctx, s.name, s.retryConfig, func(ctx context.Context) ([]map[string]interface{}, error) {
+				var rows []map[string]interface{}
+				var err error
+				rows, info, notModified, err = s.doFetchConditional(ctx, etag, lastModified)
+				return rows, err
+			})
 		})
+		return data, info, notModified, err
 	})
 }
 
 // doFetch performs the actual GraphQL request
 func (s *GraphQLSource) doFetch(ctx context.Context) ([]map[string]interface{}, error) {
-	// Read query from file
-	queryPath := filepath.Join(s.siteDir, s.queryFile)
-	queryBytes, err := os.ReadFile(queryPath)
+	data, _, _, err := s.doFetchConditional(ctx, "", "")
+	return data, err
+}
+
+// doFetchConditional performs the GraphQL query with optional cache validators,
+// following pages if Relay pagination is configured
+func (s *GraphQLSource) doFetchConditional(ctx context.Context, etag, lastModified string) ([]map[string]interface{}, HTTPCacheInfo, bool, error) {
+	document, err := s.readDocument(s.queryFile)
 	if err != nil {
-		return nil, &SourceError{
+		return nil, HTTPCacheInfo{}, false, err
+	}
+	operationName := queryOperationName(document)
+
+	// Subscriptions deliver rows through Subscribe
+	if _, ok := subscriptionOperation(document); ok {
+		return []map[string]interface{}{}, HTTPCacheInfo{}, false, nil
+	}
+
+	if s.pagination != nil {
+		data, err := s.fetchAllPages(ctx, document, operationName)
+		return data, HTTPCacheInfo{}, false, err
+	}
+
+	result, info, notModified, err := s.execute(ctx, document, operationName, s.variables, etag, lastModified)
+	if err != nil || notModified {
+		return nil, info, notModified, err
+	}
+
+	// Extract data using result_path
+	if result == nil {
+		return []map[string]interface{}{}, info, false, nil
+	}
+
+	data, err := extractPath(result, s.resultPath)
+	return data, info, false, err
+}
+
+// readDocument reads a .graphql file relative to the site directory
+func (s *GraphQLSource) readDocument(file string) (string, error) {
+	documentBytes, err := os.ReadFile(filepath.Join(s.siteDir, file))
+	if err != nil {
+		return "", &SourceError{
 			Source:    s.name,
 			Operation: "read query file",
 			Err:       err,
 			Retryable: false,
 		}
 	}
+	return string(documentBytes), nil
+}
 
+// execute sends a GraphQL request and returns the response's data object.
+// operationName selects the operation in documents that define several.
+func (s *GraphQLSource) execute(ctx context.Context, document, operationName string, variables map[string]interface{}, etag, lastModified string) (map[string]interface{}, HTTPCacheInfo, bool, error) {
 	// Build request body
 	body := map[string]interface{}{
-		"query": string(queryBytes),
+		"query": document,
+	}
+	if operationName != "" {
+		body["operationName"] = operationName
 	}
-	if len(s.variables) > 0 {
-		body["variables"] = s.variables
+	if len(variables) > 0 {
+		body["variables"] = variables
 	}
 
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
-		return nil, &SourceError{
+		return nil, HTTPCacheInfo{}, false, &SourceError{
 			Source:    s.name,
 			Operation: "marshal request",
 			Err:       err,
@@ -170,7 +294,7 @@ func (s *GraphQLSource) doFetch(ctx context.Context) ([]map[string]interface{},
 	// Create request with context
 	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(bodyBytes))
 	if err != nil {
-		return nil, &SourceError{
+		return nil, HTTPCacheInfo{}, false, &SourceError{
 			Source:    s.name,
 			Operation: "create request",
 			Err:       err,
@@ -184,18 +308,28 @@ func (s *GraphQLSource) doFetch(ctx context.Context) ([]map[string]interface{},
 	for key, value := range s.headers {
 		req.Header.Set(key, value)
 	}
+	setConditionalHeaders(req, etag, lastModified)
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, HTTPCacheInfo{}, false, NewSourceError(s.name, "rate limit", err)
+	}
 
 	// Execute request
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, NewSourceError(s.name, "request", err)
+		return nil, HTTPCacheInfo{}, false, NewSourceError(s.name, "request", err)
 	}
 	defer resp.Body.Close()
 
+	info := parseHTTPCacheInfo(resp.Header)
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, info, true, nil
+	}
+
 	// Check HTTP status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return nil, &HTTPError{
+		return nil, HTTPCacheInfo{}, false, &HTTPError{
 			Source:     s.name,
 			StatusCode: resp.StatusCode,
 			Status:     resp.Status,
@@ -207,7 +341,7 @@ func (s *GraphQLSource) doFetch(ctx context.Context) ([]map[string]interface{},
 	const maxResponseSize = 10 * 1024 * 1024 // 10MB
 	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
 	if err != nil {
-		return nil, &SourceError{
+		return nil, HTTPCacheInfo{}, false, &SourceError{
 			Source:    s.name,
 			Operation: "read response",
 			Err:       err,
@@ -225,7 +359,7 @@ func (s *GraphQLSource) doFetch(ctx context.Context) ([]map[string]interface{},
 	}
 
 	if err := json.Unmarshal(respBody, &gqlResp); err != nil {
-		return nil, &ValidationError{
+		return nil, HTTPCacheInfo{}, false, &ValidationError{
 			Source: s.name,
 			Reason: "could not parse response as JSON",
 		}
@@ -238,23 +372,20 @@ func (s *GraphQLSource) doFetch(ctx context.Context) ([]map[string]interface{},
 		for _, p := range gqlResp.Errors[0].Path {
 			pathStrings = append(pathStrings, fmt.Sprint(p))
 		}
-		return nil, &GraphQLError{
+		return nil, HTTPCacheInfo{}, false, &GraphQLError{
 			Source:  s.name,
 			Message: gqlResp.Errors[0].Message,
 			Path:    pathStrings,
 		}
 	}
 
-	// Extract data using result_path
-	if gqlResp.Data == nil {
-		return []map[string]interface{}{}, nil
-	}
-
-	return extractPath(gqlResp.Data, s.resultPath)
+	return gqlResp.Data, info, false, nil
 }
 
 // extractPath extracts an array from nested data using dot-notation path.
 // Example: "repository.issues.nodes" extracts data["repository"]["issues"]["nodes"]
+// Keys after an array are read from each element, so "repository.issues.edges.node"
+// returns the node of every edge.
 func extractPath(data map[string]interface{}, path string) ([]map[string]interface{}, error) {
 	if path == "" {
 		return nil, fmt.Errorf("result_path is required")
@@ -271,6 +402,16 @@ func extractPath(data map[string]interface{}, path string) ([]map[string]interfa
 			if !ok {
 				return nil, fmt.Errorf("path '%s' not found at '%s'", path, part)
 			}
+		case []interface{}:
+			values := make([]interface{}, 0, len(v))
+			for _, item := range v {
+				if m, ok := item.(map[string]interface{}); ok {
+					if value, ok := m[part]; ok {
+						values = append(values, value)
+					}
+				}
+			}
+			current = values
 		default:
 			return nil, fmt.Errorf("path '%s' cannot traverse non-object at '%s'", path, part)
 		}
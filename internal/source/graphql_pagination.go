@@ -0,0 +1,114 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// paginationRelay follows a GraphQL connection's pageInfo.endCursor
+const paginationRelay = "relay"
+
+// relayPageInfoPath validates a GraphQL pagination block and returns the dot-path
+// to the connection's pageInfo. Unless cursor_path is set, the connection is the
+// parent of the "edges.node", "edges" or "nodes" suffix of result_path.
+func relayPageInfoPath(name string, p *config.PaginationConfig, resultPath string) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+	if p.Type != paginationRelay {
+		return "", &ValidationError{Source: name, Field: "pagination.type", Reason: fmt.Sprintf("unknown pagination type %q (graphql sources support relay)", p.Type)}
+	}
+	if p.CursorPath != "" {
+		return p.CursorPath, nil
+	}
+
+	for _, suffix := range []string{".edges.node", ".edges", ".nodes"} {
+		if connection, ok := strings.CutSuffix(resultPath, suffix); ok && connection != "" {
+			return connection + ".pageInfo", nil
+		}
+	}
+	return "", &ValidationError{
+		Source: name,
+		Field:  "pagination.cursor_path",
+		Reason: "cursor_path is required when result_path does not end in edges.node, edges, or nodes",
+	}
+}
+
+// fetchAllPages runs the query until the connection's pageInfo reports no next page,
+// or a max_pages/max_rows cap is reached. After each page, the cursor variable is
+// set to pageInfo.endCursor.
+func (s *GraphQLSource) fetchAllPages(ctx context.Context, document, operationName string) ([]map[string]interface{}, error) {
+	p := s.pagination
+	maxPages := p.GetMaxPages()
+	maxRows := p.GetMaxRows()
+
+	cursorVar := p.CursorParam
+	if cursorVar == "" {
+		cursorVar = "after"
+	}
+
+	variables := make(map[string]interface{}, len(s.variables)+2)
+	for k, v := range s.variables {
+		variables[k] = v
+	}
+	if p.PerPage > 0 {
+		perPageVar := p.PerPageParam
+		if perPageVar == "" {
+			perPageVar = "first"
+		}
+		variables[perPageVar] = p.PerPage
+	}
+
+	results := []map[string]interface{}{}
+	seen := make(map[string]bool)
+	for pages := 0; ; pages++ {
+		if pages == maxPages {
+			log.Printf("[source/%s] Stopped pagination after %d pages (max_pages)", s.name, maxPages)
+			break
+		}
+
+		data, _, _, err := s.execute(ctx, document, operationName, variables, "", "")
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			break
+		}
+		rows, err := extractPath(data, s.resultPath)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, rows...)
+		if len(results) >= maxRows {
+			if len(results) > maxRows {
+				log.Printf("[source/%s] Truncated paginated results to %d rows (max_rows)", s.name, maxRows)
+				results = results[:maxRows]
+			}
+			break
+		}
+
+		pageInfoValue, err := navigateJSONPath(data, s.pageInfoPath)
+		if err != nil {
+			return nil, &SourceError{Source: s.name, Operation: "paginate", Err: err, Retryable: false}
+		}
+		pageInfo, ok := pageInfoValue.(map[string]interface{})
+		if !ok {
+			return nil, &SourceError{Source: s.name, Operation: "paginate", Err: fmt.Errorf("pageInfo at '%s' is not an object", s.pageInfoPath), Retryable: false}
+		}
+
+		hasNextPage, _ := pageInfo["hasNextPage"].(bool)
+		endCursor, _ := pageInfo["endCursor"].(string)
+		if !hasNextPage || endCursor == "" || seen[endCursor] {
+			break
+		}
+		seen[endCursor] = true
+		variables[cursorVar] = endCursor
+	}
+
+	return results, nil
+}
@@ -0,0 +1,203 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// graphqlTransportWS is the WebSocket subprotocol of graphql-ws
+// (https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md)
+const graphqlTransportWS = "graphql-transport-ws"
+
+// graphqlWSMessage is a graphql-transport-ws protocol message
+type graphqlWSMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// subscriptionOperation returns the operation a fetch would run if it is a
+// subscription. The first operation that is not a mutation decides.
+func subscriptionOperation(document string) (graphqlOperation, bool) {
+	for _, op := range parseGraphQLOperations(document) {
+		if op.kind == "mutation" {
+			continue
+		}
+		return op, op.kind == "subscription"
+	}
+	return graphqlOperation{}, false
+}
+
+// subscriptionURL returns the WebSocket URL for subscriptions
+func subscriptionURL(from string) string {
+	switch {
+	case strings.HasPrefix(from, "https://"):
+		return "wss://" + strings.TrimPrefix(from, "https://")
+	case strings.HasPrefix(from, "http://"):
+		return "ws://" + strings.TrimPrefix(from, "http://")
+	default:
+		return from
+	}
+}
+
+// Subscribe starts the subscription if the query file defines one, returning nil
+// otherwise. Each next message is emitted as an event; the connection is
+// re-established with backoff until ctx is done or the server completes the
+// subscription.
+func (s *GraphQLSource) Subscribe(ctx context.Context) <-chan Event {
+	document, err := s.readDocument(s.queryFile)
+	if err != nil {
+		log.Printf("[source/%s] Cannot subscribe: %v", s.name, err)
+		return nil
+	}
+	op, ok := subscriptionOperation(document)
+	if !ok {
+		return nil
+	}
+
+	events := make(chan Event)
+	go runStream(ctx, s.name, s.retryConfig, events, func(ctx context.Context, emit func(Event)) (bool, bool, error) {
+		return s.subscribeOnce(ctx, document, op.name, emit)
+	})
+	return events
+}
+
+// subscribeOnce runs one subscription connection. acknowledged is true if the
+// server accepted the connection; completed is true if the server ended the
+// subscription with a complete message.
+func (s *GraphQLSource) subscribeOnce(ctx context.Context, document, operationName string, emit func(Event)) (acknowledged, completed bool, err error) {
+	header, err := streamHeader(s.url, s.headers, s.client)
+	if err != nil {
+		return false, false, err
+	}
+
+	dialer := websocketDialer(s.client, s.timeout, graphqlTransportWS)
+	conn, _, err := dialer.DialContext(ctx, s.subscriptionURL, header)
+	if err != nil {
+		return false, false, NewSourceError(s.name, "subscribe", err)
+	}
+	defer conn.Close()
+	defer closeOnDone(ctx, conn)()
+
+	// Servers that authenticate in-band read credentials from the init payload
+	initPayload := make(map[string]string, len(header))
+	for key := range header {
+		initPayload[key] = header.Get(key)
+	}
+	if err := writeGraphQLWS(conn, "", "connection_init", initPayload); err != nil {
+		return false, false, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(s.timeout))
+	var ack graphqlWSMessage
+	if err := conn.ReadJSON(&ack); err != nil {
+		return false, false, fmt.Errorf("waiting for connection_ack: %w", err)
+	}
+	if ack.Type != "connection_ack" {
+		return false, false, fmt.Errorf("expected connection_ack, got %q", ack.Type)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	subscribe := map[string]interface{}{"query": document}
+	if operationName != "" {
+		subscribe["operationName"] = operationName
+	}
+	if len(s.variables) > 0 {
+		subscribe["variables"] = s.variables
+	}
+	const subscriptionID = "1"
+	if err := writeGraphQLWS(conn, subscriptionID, "subscribe", subscribe); err != nil {
+		return true, false, err
+	}
+
+	for {
+		var msg graphqlWSMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return true, false, err
+		}
+
+		switch msg.Type {
+		case "ping":
+			if err := writeGraphQLWS(conn, "", "pong", nil); err != nil {
+				return true, false, err
+			}
+		case "next":
+			if msg.ID != subscriptionID {
+				continue
+			}
+			emit(s.eventFromPayload(msg.Payload))
+		case "error":
+			var errs []struct {
+				Message string `json:"message"`
+			}
+			json.Unmarshal(msg.Payload, &errs)
+			gqlErr := &GraphQLError{Source: s.name, Message: "subscription rejected"}
+			if len(errs) > 0 {
+				gqlErr.Message = errs[0].Message
+			}
+			// A rejected subscription is retried with growing delays
+			return false, false, gqlErr
+		case "complete":
+			if msg.ID == subscriptionID {
+				return true, true, nil
+			}
+		}
+	}
+}
+
+// writeGraphQLWS sends a protocol message
+func writeGraphQLWS(conn *websocket.Conn, id, msgType string, payload interface{}) error {
+	msg := graphqlWSMessage{ID: id, Type: msgType}
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		msg.Payload = raw
+	}
+	return conn.WriteJSON(msg)
+}
+
+// eventFromPayload converts the payload of a next message to an event
+func (s *GraphQLSource) eventFromPayload(payload json.RawMessage) Event {
+	var result struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return Event{Err: &ValidationError{Source: s.name, Reason: "could not parse subscription event as JSON"}}
+	}
+	if len(result.Errors) > 0 {
+		return Event{Err: &GraphQLError{Source: s.name, Message: result.Errors[0].Message}}
+	}
+
+	rows, err := extractEventRows(result.Data, s.resultPath)
+	if err != nil {
+		return Event{Err: &ValidationError{Source: s.name, Reason: err.Error()}}
+	}
+	return Event{Rows: rows}
+}
+
+// extractEventRows reads the rows of a subscription event: the array at path, or
+// the object at path as a single row
+func extractEventRows(data map[string]interface{}, path string) ([]map[string]interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+	value, err := navigateJSONPath(data, path)
+	if err != nil {
+		return nil, err
+	}
+	if row, ok := value.(map[string]interface{}); ok {
+		return []map[string]interface{}{row}, nil
+	}
+	return extractPath(data, path)
+}
@@ -0,0 +1,324 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// subscriptionServer is an in-process graphql-transport-ws server. After a client
+// subscribes, it pings the client and then sends each event as a next message.
+type subscriptionServer struct {
+	*httptest.Server
+	events      []interface{}
+	complete    bool // Send complete after the events
+	initPayload chan map[string]string
+	subscribe   chan map[string]interface{}
+}
+
+func newSubscriptionServer(t *testing.T, events []interface{}, complete bool) *subscriptionServer {
+	srv := &subscriptionServer{
+		events:      events,
+		complete:    complete,
+		initPayload: make(chan map[string]string, 10),
+		subscribe:   make(chan map[string]interface{}, 10),
+	}
+	upgrader := websocket.Upgrader{Subprotocols: []string{graphqlTransportWS}}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		if conn.Subprotocol() != graphqlTransportWS {
+			t.Errorf("subprotocol = %q, want %q", conn.Subprotocol(), graphqlTransportWS)
+		}
+
+		var init struct {
+			Type    string            `json:"type"`
+			Payload map[string]string `json:"payload"`
+		}
+		if err := conn.ReadJSON(&init); err != nil || init.Type != "connection_init" {
+			t.Errorf("expected connection_init, got %+v (%v)", init, err)
+			return
+		}
+		srv.initPayload <- init.Payload
+		conn.WriteJSON(map[string]string{"type": "connection_ack"})
+
+		var sub struct {
+			ID      string                 `json:"id"`
+			Type    string                 `json:"type"`
+			Payload map[string]interface{} `json:"payload"`
+		}
+		if err := conn.ReadJSON(&sub); err != nil || sub.Type != "subscribe" {
+			t.Errorf("expected subscribe, got %+v (%v)", sub, err)
+			return
+		}
+		srv.subscribe <- sub.Payload
+
+		conn.WriteJSON(map[string]string{"type": "ping"})
+		var pong map[string]interface{}
+		if err := conn.ReadJSON(&pong); err != nil || pong["type"] != "pong" {
+			t.Errorf("expected pong, got %v (%v)", pong, err)
+			return
+		}
+
+		for _, event := range srv.events {
+			conn.WriteJSON(map[string]interface{}{"id": sub.ID, "type": "next", "payload": map[string]interface{}{"data": event}})
+		}
+		if srv.complete {
+			conn.WriteJSON(map[string]string{"id": sub.ID, "type": "complete"})
+		}
+		// Hold the connection until the client closes it
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	return srv
+}
+
+func newSubscriptionSource(t *testing.T, url string) *GraphQLSource {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "deploys.graphql"), []byte(`subscription OnDeploy($env: String!) {
+  deploymentUpdated(env: $env) { id status }
+}
+
+mutation Rollback($id: ID!) { rollback(id: $id) { id } }
+`), 0644)
+
+	src, err := NewGraphQLSource("deploys", config.SourceConfig{
+		Type:       "graphql",
+		From:       url,
+		QueryFile:  "deploys.graphql",
+		ResultPath: "deploymentUpdated",
+		Variables:  map[string]interface{}{"env": "prod"},
+		Headers:    map[string]string{"X-Team": "infra"},
+	}, tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	return src
+}
+
+// nextRows waits for the next event with rows
+func nextRows(t *testing.T, events <-chan Event) []map[string]interface{} {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			if ev.Err == nil {
+				return ev.Rows
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for event")
+		}
+	}
+}
+
+func TestGraphQLSource_Subscription(t *testing.T) {
+	server := newSubscriptionServer(t, []interface{}{
+		map[string]interface{}{"deploymentUpdated": map[string]interface{}{"id": "1", "status": "started"}},
+		map[string]interface{}{"deploymentUpdated": []interface{}{
+			map[string]interface{}{"id": "2", "status": "started"},
+			map[string]interface{}{"id": "3", "status": "started"},
+		}},
+	}, false)
+	defer server.Close()
+
+	src := newSubscriptionSource(t, server.URL)
+
+	if rows, err := src.Fetch(context.Background()); err != nil || len(rows) != 0 {
+		t.Fatalf("expected no rows from fetch, got %v (%v)", rows, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := src.Subscribe(ctx)
+	if events == nil {
+		t.Fatal("expected a stream for a subscription")
+	}
+
+	// An object at result_path is one row; an array is several
+	if rows := nextRows(t, events); len(rows) != 1 || rows[0]["status"] != "started" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	if rows := nextRows(t, events); len(rows) != 2 || rows[1]["id"] != "3" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	init := <-server.initPayload
+	if init["X-Team"] != "infra" {
+		t.Errorf("init payload = %v, want X-Team header", init)
+	}
+	sub := <-server.subscribe
+	if sub["operationName"] != "OnDeploy" || !strings.HasPrefix(sub["query"].(string), "subscription OnDeploy") {
+		t.Errorf("unexpected subscribe payload: %v", sub)
+	}
+	if vars, _ := sub["variables"].(map[string]interface{}); vars["env"] != "prod" {
+		t.Errorf("variables = %v, want env=prod", sub["variables"])
+	}
+
+	// Cancelling closes the stream
+	cancel()
+	for range events {
+	}
+}
+
+func TestGraphQLSource_SubscriptionWithSchema(t *testing.T) {
+	server := newSubscriptionServer(t, []interface{}{
+		map[string]interface{}{"deploymentUpdated": map[string]interface{}{"id": "7", "status": "started"}},
+	}, false)
+	defer server.Close()
+
+	// A schema wraps the source, which must keep both writes and the stream
+	src, err := WithTransform(newSubscriptionSource(t, server.URL), config.SourceConfig{
+		Schema: map[string]config.FieldSchema{"id": {Type: "int"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := src.(WritableSource); !ok {
+		t.Error("expected a writable source")
+	}
+	ss, ok := src.(StreamingSource)
+	if !ok {
+		t.Fatal("expected a streaming source")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := ss.Subscribe(ctx)
+	if events == nil {
+		t.Fatal("expected a stream for a subscription")
+	}
+	if rows := nextRows(t, events); len(rows) != 1 || rows[0]["id"] != 7 {
+		t.Errorf("expected the event rows coerced by the schema, got %v", rows)
+	}
+}
+
+func TestGraphQLSource_SubscriptionComplete(t *testing.T) {
+	server := newSubscriptionServer(t, []interface{}{
+		map[string]interface{}{"deploymentUpdated": []interface{}{map[string]interface{}{"id": "1"}}},
+	}, true)
+	defer server.Close()
+
+	src := newSubscriptionSource(t, server.URL)
+	events := src.Subscribe(context.Background())
+
+	nextRows(t, events)
+	select {
+	case ev, ok := <-events:
+		if ok {
+			t.Errorf("expected the stream to end, got %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not closed after complete")
+	}
+}
+
+func TestGraphQLSource_SubscriptionReconnect(t *testing.T) {
+	// The first connection is refused; the client retries with backoff
+	var attempts atomic.Int32
+	upstream := newSubscriptionServer(t, []interface{}{
+		map[string]interface{}{"deploymentUpdated": map[string]interface{}{"id": "1"}},
+	}, false)
+	defer upstream.Close()
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		upstream.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+
+	src := newSubscriptionSource(t, flaky.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := src.Subscribe(ctx)
+
+	select {
+	case ev := <-events:
+		if ev.Err == nil {
+			t.Errorf("expected an error event for the refused connection, got %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for error event")
+	}
+	if rows := nextRows(t, events); len(rows) != 1 {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	if n := attempts.Load(); n < 2 {
+		t.Errorf("expected a reconnect, got %d attempts", n)
+	}
+}
+
+func TestGraphQLSource_SubscribeQueryIsNil(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "q.graphql"), []byte("query { users { id } }"), 0644)
+	src, err := NewGraphQLSource("test", config.SourceConfig{Type: "graphql", From: "http://127.0.0.1:1", QueryFile: "q.graphql", ResultPath: "users"}, tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	if events := src.Subscribe(context.Background()); events != nil {
+		t.Error("expected no stream for a query")
+	}
+
+	if _, err := NewGraphQLSource("test", config.SourceConfig{
+		Type: "graphql", From: "http://example.com", QueryFile: "q.graphql", ResultPath: "users",
+		Stream: &config.StreamConfig{Mode: "merge"},
+	}, tmpDir); err == nil {
+		t.Error("expected error for unknown stream mode")
+	}
+}
+
+func TestSubscriptionOperation(t *testing.T) {
+	tests := []struct {
+		document string
+		want     bool
+	}{
+		{"subscription OnDeploy { deploymentUpdated { id } }", true},
+		{"mutation Undo { undo }\nsubscription { ticks }", true},
+		// Fields named like operation types are not operations
+		{"{ subscription { plan } }", false},
+		{"query Account { subscription { plan } }", false},
+		{"query { query { subscription { id } } }", false},
+		{"# subscription OnDeploy { x }\nquery { users(filter: \"subscription {\") { id } }", false},
+	}
+	for _, tt := range tests {
+		if _, got := subscriptionOperation(tt.document); got != tt.want {
+			t.Errorf("subscriptionOperation(%q) = %v, want %v", tt.document, got, tt.want)
+		}
+	}
+}
+
+func TestSubscriptionURL(t *testing.T) {
+	tests := map[string]string{
+		"https://api.example.com/graphql": "wss://api.example.com/graphql",
+		"http://localhost:4000/graphql":   "ws://localhost:4000/graphql",
+		"wss://api.example.com/graphql":   "wss://api.example.com/graphql",
+	}
+	for in, want := range tests {
+		if got := subscriptionURL(in); got != want {
+			t.Errorf("subscriptionURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -4,10 +4,13 @@ package source
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/livetemplate/tinkerdown/internal/config"
@@ -320,3 +323,261 @@ func TestGraphQLSource_FetchQueryFileNotFound(t *testing.T) {
 		t.Error("expected error for missing query file")
 	}
 }
+
+func TestExtractPath_Edges(t *testing.T) {
+	data := map[string]interface{}{
+		"repository": map[string]interface{}{
+			"issues": map[string]interface{}{
+				"edges": []interface{}{
+					map[string]interface{}{"cursor": "a", "node": map[string]interface{}{"title": "Bug"}},
+					map[string]interface{}{"cursor": "b", "node": map[string]interface{}{"title": "Feature"}},
+				},
+			},
+		},
+	}
+
+	result, err := extractPath(data, "repository.issues.edges.node")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 2 || result[1]["title"] != "Feature" {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
+
+func TestGraphQLSource_RelayPagination(t *testing.T) {
+	var afters []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		afters = append(afters, req.Variables["after"])
+		if req.Variables["first"] != float64(2) {
+			t.Errorf("expected first=2, got %v", req.Variables["first"])
+		}
+
+		page := map[string]interface{}{
+			"edges":    []interface{}{map[string]interface{}{"node": map[string]interface{}{"n": 1}}, map[string]interface{}{"node": map[string]interface{}{"n": 2}}},
+			"pageInfo": map[string]interface{}{"hasNextPage": true, "endCursor": "c2"},
+		}
+		if req.Variables["after"] == "c2" {
+			page = map[string]interface{}{
+				"edges":    []interface{}{map[string]interface{}{"node": map[string]interface{}{"n": 3}}},
+				"pageInfo": map[string]interface{}{"hasNextPage": false, "endCursor": "c3"},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"repository": map[string]interface{}{"issues": page}},
+		})
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "issues.graphql"), []byte(`query Issues($first: Int, $after: String) {
+  repository { issues(first: $first, after: $after) { edges { node { n } } pageInfo { hasNextPage endCursor } } }
+}`), 0644)
+
+	src, err := NewGraphQLSource("test", config.SourceConfig{
+		Type:       "graphql",
+		From:       server.URL,
+		QueryFile:  "issues.graphql",
+		ResultPath: "repository.issues.edges.node",
+		Pagination: &config.PaginationConfig{Type: "relay", PerPage: 2},
+	}, tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	result, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(result) != 3 || result[2]["n"] != float64(3) {
+		t.Errorf("unexpected result: %v", result)
+	}
+	if len(afters) != 2 || afters[0] != nil || afters[1] != "c2" {
+		t.Errorf("unexpected cursors: %v", afters)
+	}
+}
+
+func TestGraphQLSource_PaginationValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		pagination config.PaginationConfig
+		resultPath string
+		wantErr    bool
+	}{
+		{"nodes", config.PaginationConfig{Type: "relay"}, "viewer.repos.nodes", false},
+		{"cursor path", config.PaginationConfig{Type: "relay", CursorPath: "search.pageInfo"}, "search.results", false},
+		{"no connection", config.PaginationConfig{Type: "relay"}, "search.results", true},
+		{"rest type", config.PaginationConfig{Type: "page"}, "viewer.repos.nodes", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGraphQLSource("test", config.SourceConfig{
+				Type:       "graphql",
+				From:       "http://example.com/graphql",
+				QueryFile:  "q.graphql",
+				ResultPath: tt.resultPath,
+				Pagination: &tt.pagination,
+			}, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseGraphQLOperations(t *testing.T) {
+	document := `
+# query Commented { x }
+query Users($first: Int = 10, $team: ID!) @cached(ttl: 60) {
+  users(first: $first, where: {note: "mutation Fake { x }"}) {
+    query
+    mutation { id }
+  }
+}
+
+fragment UserFields on User { id subscription { plan } }
+
+mutation AddUser($input: UserInput = {name: "a)b", tags: ["{"]}) {
+  addUser(input: $input) { ...UserFields }
+}
+
+subscription {
+  ticks(format: """ block "{" string """)
+}
+`
+	got := parseGraphQLOperations(document)
+	want := []graphqlOperation{
+		{kind: "query", name: "Users", variables: map[string]graphqlVariable{
+			"first": {typ: "Int", hasDefault: true},
+			"team":  {typ: "ID!"},
+		}},
+		{kind: "mutation", name: "AddUser", variables: map[string]graphqlVariable{
+			"input": {typ: "UserInput", hasDefault: true},
+		}},
+		{kind: "subscription", variables: map[string]graphqlVariable{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGraphQLOperations() = %+v, want %+v", got, want)
+	}
+
+	// A selection set without a header is a query
+	if got := parseGraphQLOperations("{ query { id } }"); len(got) != 1 || got[0].kind != "query" || got[0].name != "" {
+		t.Errorf("shorthand query = %+v", got)
+	}
+}
+
+func TestGraphQLSource_WriteItem(t *testing.T) {
+	type request struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	var got request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = request{}
+		json.NewDecoder(r.Body).Decode(&got)
+		if strings.Contains(got.Query, "Forbidden") && got.OperationName == "DeleteTask" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []interface{}{map[string]interface{}{"message": "not allowed"}}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"tasks": []interface{}{}}})
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "tasks.graphql"), []byte(`query Tasks { tasks { id title } }
+
+# Forbidden for guests
+mutation AddTask($title: String!, $priority: Int, $done: Boolean) {
+  addTask(title: $title, priority: $priority, done: $done) { id }
+}
+
+mutation DeleteTask($id: ID!) {
+  deleteTask(id: $id) { id }
+}
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "update.graphql"), []byte(`mutation UpdateTask($input: UpdateTaskInput!, $priority: Int!) {
+  updateTask(input: $input, priority: $priority) { id }
+}`), 0644)
+
+	readonly := false
+	src, err := NewGraphQLSource("test", config.SourceConfig{
+		Type:       "graphql",
+		From:       server.URL,
+		QueryFile:  "tasks.graphql",
+		ResultPath: "tasks",
+		Readonly:   &readonly,
+		Mutations: map[string]config.GraphQLMutation{
+			"add":    {Operation: "AddTask"},
+			"delete": {Operation: "DeleteTask"},
+			"update": {
+				File: "update.graphql",
+				Variables: map[string]interface{}{
+					"input":    map[string]interface{}{"id": "{id}", "title": "Task: {title}"},
+					"priority": "{priority}",
+				},
+			},
+		},
+	}, tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	ctx := context.Background()
+
+	// Fetch selects the query in a document with several operations
+	if _, err := src.Fetch(ctx); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if got.OperationName != "Tasks" {
+		t.Errorf("fetch operationName = %q, want Tasks", got.OperationName)
+	}
+
+	if err := src.WriteItem(ctx, "add", map[string]interface{}{"title": "Write docs", "priority": "3", "done": "on", "extra": "x"}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	wantVars := map[string]interface{}{"title": "Write docs", "priority": float64(3), "done": true}
+	if got.OperationName != "AddTask" || !reflect.DeepEqual(got.Variables, wantVars) {
+		t.Errorf("add request = %s %v, want AddTask %v", got.OperationName, got.Variables, wantVars)
+	}
+
+	if err := src.WriteItem(ctx, "add", map[string]interface{}{"title": "No priority", "priority": ""}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if v, ok := got.Variables["priority"]; !ok || v != nil {
+		t.Errorf("expected null priority, got %v (present: %v)", v, ok)
+	}
+
+	if err := src.WriteItem(ctx, "update", map[string]interface{}{"Id": "7", "title": "Ship", "priority": "1"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	wantVars = map[string]interface{}{"input": map[string]interface{}{"id": "7", "title": "Task: Ship"}, "priority": float64(1)}
+	if got.OperationName != "UpdateTask" || !reflect.DeepEqual(got.Variables, wantVars) {
+		t.Errorf("update request = %s %v, want UpdateTask %v", got.OperationName, got.Variables, wantVars)
+	}
+
+	var gqlErr *GraphQLError
+	if err := src.WriteItem(ctx, "delete", map[string]interface{}{"id": "7"}); !errors.As(err, &gqlErr) {
+		t.Errorf("expected GraphQL error, got %v", err)
+	}
+	if err := src.WriteItem(ctx, "delete", map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "'id' field is required") {
+		t.Errorf("expected missing id error, got %v", err)
+	}
+	if err := src.WriteItem(ctx, "add", map[string]interface{}{"title": "x", "priority": "high"}); err == nil || !strings.Contains(err.Error(), "must be an integer") {
+		t.Errorf("expected integer error, got %v", err)
+	}
+	if err := src.WriteItem(ctx, "toggle", map[string]interface{}{"id": "7"}); err == nil {
+		t.Error("expected error for action without mutation")
+	}
+
+	readonlySrc, _ := NewGraphQLSource("test", config.SourceConfig{Type: "graphql", From: server.URL, QueryFile: "tasks.graphql", ResultPath: "tasks"}, tmpDir)
+	if !readonlySrc.IsReadonly() {
+		t.Error("expected graphql source to be read-only by default")
+	}
+	if err := readonlySrc.WriteItem(ctx, "add", map[string]interface{}{"title": "x"}); err == nil {
+		t.Error("expected error writing to read-only source")
+	}
+}
@@ -0,0 +1,329 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// graphqlOperation is an operation defined in a GraphQL document
+type graphqlOperation struct {
+	kind      string                     // query, mutation, or subscription
+	name      string                     // Empty for anonymous operations
+	variables map[string]graphqlVariable // Declared variables by name
+}
+
+// graphqlVariable is a declared operation variable
+type graphqlVariable struct {
+	typ        string // Type as written, e.g., "Int!" or "[ID!]"
+	hasDefault bool
+}
+
+// required reports whether the variable must be provided
+func (v graphqlVariable) required() bool {
+	return strings.HasSuffix(v.typ, "!") && !v.hasDefault
+}
+
+var (
+	graphqlOperationPattern = regexp.MustCompile(`^(query|mutation|subscription)\b\s*(\w*)\s*(?:\(([^)]*)\))?`)
+	graphqlVariablePattern  = regexp.MustCompile(`\$(\w+)\s*:\s*([\w\[\]!]+)(\s*=)?`)
+)
+
+// parseGraphQLOperations lists the operations defined in a document with their
+// declared variables. Only the headers of top-level definitions are parsed, so
+// fields named like operation types (e.g., { subscription { plan } }) are not
+// taken for operations; a selection set without a header is a query. The
+// document itself is sent to the server as is.
+func parseGraphQLOperations(document string) []graphqlOperation {
+	var ops []graphqlOperation
+	for _, header := range graphqlDefinitionHeaders(document) {
+		if header == "" {
+			// Query shorthand
+			ops = append(ops, graphqlOperation{kind: "query", variables: make(map[string]graphqlVariable)})
+			continue
+		}
+		match := graphqlOperationPattern.FindStringSubmatch(header)
+		if match == nil {
+			// e.g., a fragment
+			continue
+		}
+		op := graphqlOperation{kind: match[1], name: match[2], variables: make(map[string]graphqlVariable)}
+		for _, v := range graphqlVariablePattern.FindAllStringSubmatch(match[3], -1) {
+			op.variables[v[1]] = graphqlVariable{typ: v[2], hasDefault: v[3] != ""}
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// graphqlDefinitionHeaders returns the text before the selection set of each
+// top-level definition of a document (e.g., "query Users($first: Int)"),
+// without comments and with the contents of strings removed
+func graphqlDefinitionHeaders(document string) []string {
+	var headers []string
+	var header strings.Builder
+	depth, parens := 0, 0
+	for i := 0; i < len(document); i++ {
+		c := document[i]
+		switch {
+		case c == '#':
+			// Comment to the end of the line
+			for i+1 < len(document) && document[i+1] != '\n' {
+				i++
+			}
+			continue
+		case c == '"':
+			// String or block string; only its quotes are kept
+			end := `"`
+			if strings.HasPrefix(document[i:], `"""`) {
+				end = `"""`
+			}
+			for i += len(end); i < len(document) && !strings.HasPrefix(document[i:], end); i++ {
+				if document[i] == '\\' && end == `"` {
+					i++
+				}
+			}
+			i += len(end) - 1
+			if depth == 0 {
+				header.WriteString(`""`)
+			}
+			continue
+		case c == '(' && depth == 0:
+			parens++
+		case c == ')' && depth == 0:
+			parens--
+		case c == '{' && parens <= 0:
+			if depth == 0 {
+				headers = append(headers, strings.TrimSpace(header.String()))
+				header.Reset()
+			}
+			depth++
+			continue
+		case c == '}' && parens <= 0:
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+		if depth == 0 {
+			header.WriteByte(c)
+		}
+	}
+	return headers
+}
+
+// queryOperationName returns the operation to run for fetches: the first query of
+// a document that defines several operations, or "" if there is only one
+func queryOperationName(document string) string {
+	ops := parseGraphQLOperations(document)
+	if len(ops) <= 1 {
+		return ""
+	}
+	for _, op := range ops {
+		if op.kind == "query" {
+			return op.name
+		}
+	}
+	return ""
+}
+
+// findMutation returns the named mutation, or the only mutation if name is empty
+func findMutation(document, name string) (graphqlOperation, error) {
+	var mutations []graphqlOperation
+	for _, op := range parseGraphQLOperations(document) {
+		if op.kind != "mutation" {
+			continue
+		}
+		if name != "" && op.name == name {
+			return op, nil
+		}
+		mutations = append(mutations, op)
+	}
+
+	switch {
+	case name != "":
+		return graphqlOperation{}, fmt.Errorf("mutation %q not found", name)
+	case len(mutations) == 0:
+		return graphqlOperation{}, fmt.Errorf("no mutation found")
+	case len(mutations) > 1:
+		return graphqlOperation{}, fmt.Errorf("file defines %d mutations, set operation to choose one", len(mutations))
+	}
+	return mutations[0], nil
+}
+
+// IsReadonly returns whether the source is read-only
+func (s *GraphQLSource) IsReadonly() bool {
+	return s.readonly
+}
+
+// WriteItem runs the mutation configured for an action, with variables bound from
+// the form data. Supported actions are the keys of the mutations block.
+func (s *GraphQLSource) WriteItem(ctx context.Context, action string, data map[string]interface{}) error {
+	if s.readonly {
+		return fmt.Errorf("graphql source %q is read-only", s.name)
+	}
+
+	mutation, ok := s.mutations[action]
+	if !ok {
+		return fmt.Errorf("graphql source %q: no mutation configured for action %q", s.name, action)
+	}
+
+	file := mutation.File
+	if file == "" {
+		file = s.queryFile
+	}
+	document, err := s.readDocument(file)
+	if err != nil {
+		return err
+	}
+
+	op, err := findMutation(document, mutation.Operation)
+	if err != nil {
+		return fmt.Errorf("graphql source %q: %s: %s: %w", s.name, action, file, err)
+	}
+
+	variables, err := bindMutationVariables(op, mutation.Variables, data)
+	if err != nil {
+		return fmt.Errorf("graphql source %q: %s: %w", s.name, action, err)
+	}
+
+	// Mutations are not retried: they are not idempotent
+	_, _, _, err = s.execute(ctx, document, op.name, variables, "", "")
+	return err
+}
+
+// bindMutationVariables builds the variables of a mutation. Configured variables
+// are resolved against the form data; otherwise each declared variable is taken
+// from the form field of the same name. Form strings are converted to the
+// declared scalar type.
+func bindMutationVariables(op graphqlOperation, configured map[string]interface{}, data map[string]interface{}) (map[string]interface{}, error) {
+	variables := make(map[string]interface{})
+
+	if len(configured) > 0 {
+		for name, value := range configured {
+			resolved, err := resolveMutationVariable(name, value, op.variables[name].typ, data)
+			if err != nil {
+				return nil, err
+			}
+			variables[name] = resolved
+		}
+		return variables, nil
+	}
+
+	for name, decl := range op.variables {
+		value, ok := data[name]
+		if name == "id" {
+			value, ok = getID(data)
+		}
+		if !ok {
+			if decl.required() {
+				return nil, fmt.Errorf("'%s' field is required", name)
+			}
+			continue
+		}
+		coerced, err := coerceGraphQLValue(name, value, decl.typ)
+		if err != nil {
+			return nil, err
+		}
+		variables[name] = coerced
+	}
+	return variables, nil
+}
+
+// resolveMutationVariable fills {field} placeholders in a configured variable value.
+// A string that is exactly "{field}" takes the field's value (converted to typ);
+// other strings are interpolated. Objects and lists are resolved recursively.
+func resolveMutationVariable(name string, value interface{}, typ string, data map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if match := urlPlaceholderPattern.FindStringSubmatch(v); match != nil && match[0] == v {
+			field, ok := lookupFormField(data, match[1])
+			if !ok {
+				return nil, fmt.Errorf("'%s' field is required", match[1])
+			}
+			return coerceGraphQLValue(name, field, typ)
+		}
+		var missing string
+		filled := urlPlaceholderPattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			field, ok := lookupFormField(data, placeholder[1:len(placeholder)-1])
+			if !ok {
+				missing = placeholder[1 : len(placeholder)-1]
+				return placeholder
+			}
+			return fmt.Sprintf("%v", field)
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("'%s' field is required", missing)
+		}
+		return filled, nil
+
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			r, err := resolveMutationVariable(name+"."+key, item, "", data)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = r
+		}
+		return resolved, nil
+
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := resolveMutationVariable(name, item, "", data)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+		return resolved, nil
+
+	default:
+		return value, nil
+	}
+}
+
+// lookupFormField returns a form field, accepting any casing of "id"
+func lookupFormField(data map[string]interface{}, field string) (interface{}, bool) {
+	if field == "id" {
+		return getID(data)
+	}
+	value, ok := data[field]
+	return value, ok
+}
+
+// coerceGraphQLValue converts a form string to the declared scalar type.
+// Empty strings become null for nullable non-String types.
+func coerceGraphQLValue(name string, value interface{}, typ string) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok || typ == "" {
+		return value, nil
+	}
+
+	base := strings.TrimSuffix(typ, "!")
+	if str == "" && base == typ && base != "String" && base != "ID" {
+		return nil, nil
+	}
+
+	switch base {
+	case "Int":
+		n, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil {
+			return nil, fmt.Errorf("'%s' must be an integer", name)
+		}
+		return n, nil
+	case "Float":
+		f, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' must be a number", name)
+		}
+		return f, nil
+	case "Boolean":
+		return formBool(str), nil
+	default:
+		return str, nil
+	}
+}
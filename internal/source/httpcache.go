@@ -0,0 +1,83 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPCacheInfo holds the caching metadata of an HTTP response
+type HTTPCacheInfo struct {
+	ETag         string        // ETag response header
+	LastModified string        // Last-Modified response header
+	MaxAge       time.Duration // Freshness lifetime from Cache-Control (s-maxage, max-age), minus Age
+	HasMaxAge    bool          // True if Cache-Control specified a lifetime (no-cache counts as 0)
+	NoStore      bool          // Cache-Control: no-store
+}
+
+// ConditionalSource is implemented by HTTP sources that support conditional requests.
+// CachedSource uses it to revalidate expired entries instead of refetching them.
+type ConditionalSource interface {
+	Source
+
+	// FetchConditional fetches data, sending If-None-Match/If-Modified-Since for
+	// the given validators (if any). notModified is true if the upstream answered
+	// 304 Not Modified, in which case data is nil and the cached data is current.
+	FetchConditional(ctx context.Context, etag, lastModified string) (data []map[string]interface{}, info HTTPCacheInfo, notModified bool, err error)
+}
+
+// setConditionalHeaders adds validators of a cached response to a request
+func setConditionalHeaders(req *http.Request, etag, lastModified string) {
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+}
+
+// parseHTTPCacheInfo reads validators and freshness lifetime from response headers
+func parseHTTPCacheInfo(header http.Header) HTTPCacheInfo {
+	info := HTTPCacheInfo{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+
+	maxAge, sharedMaxAge := -1, -1
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		value = strings.Trim(value, `"`)
+		switch strings.ToLower(name) {
+		case "no-store":
+			info.NoStore = true
+		case "no-cache":
+			maxAge, sharedMaxAge = 0, 0
+		case "max-age":
+			if n, err := strconv.Atoi(value); err == nil && maxAge != 0 {
+				maxAge = n
+			}
+		case "s-maxage":
+			// Tinkerdown serves cached data to every viewer, like a shared cache
+			if n, err := strconv.Atoi(value); err == nil && sharedMaxAge != 0 {
+				sharedMaxAge = n
+			}
+		}
+	}
+
+	lifetime := maxAge
+	if sharedMaxAge >= 0 {
+		lifetime = sharedMaxAge
+	}
+	if lifetime >= 0 {
+		// Age is how long the response already spent in upstream caches
+		if age, err := strconv.Atoi(header.Get("Age")); err == nil && age > 0 {
+			lifetime = max(lifetime-age, 0)
+		}
+		info.MaxAge = time.Duration(lifetime) * time.Second
+		info.HasMaxAge = true
+	}
+
+	return info
+}
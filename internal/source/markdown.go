@@ -13,6 +13,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
 )
 
 // ConflictError is returned when a write operation detects concurrent modification
@@ -34,10 +36,17 @@ type MarkdownSource struct {
 	readonly    bool
 	siteDir     string
 	currentFile string // the markdown file being served (for same-file anchors)
+	cascade     bool   // toggling a nested task also toggles its descendants
+	inlineMeta  bool   // parse inline metadata (due:, @, #, !, key::) in list items
+
+	// Schema fields, written as inline fields to items that don't have them yet
+	fields map[string]bool
 
 	// Concurrency control
-	mu       sync.RWMutex
-	lastMtime time.Time // mtime of file when last read
+	mu             sync.RWMutex
+	lastMtime      time.Time // mtime of file when last read
+	lastSection    string    // section content when last read (merge base for writes)
+	hasLastSection bool      // whether lastSection holds a read section
 }
 
 // NewMarkdownSource creates a new markdown source
@@ -65,6 +74,25 @@ func NewMarkdownSource(name, file, anchor, siteDir, currentFile string, readonly
 	}, nil
 }
 
+// NewMarkdownSourceWithConfig creates a markdown source from a full SourceConfig.
+// Supported options: cascade ("true" to toggle nested tasks with their parent),
+// inline_metadata ("true" to read and write fields like due:, @name, #tag in list items)
+func NewMarkdownSourceWithConfig(name string, cfg config.SourceConfig, siteDir, currentFile string) (*MarkdownSource, error) {
+	src, err := NewMarkdownSource(name, cfg.File, cfg.Anchor, siteDir, currentFile, cfg.IsReadonly())
+	if err != nil {
+		return nil, err
+	}
+	src.cascade = cfg.Options["cascade"] == "true"
+	src.inlineMeta = cfg.Options["inline_metadata"] == "true"
+	if len(cfg.Schema) > 0 {
+		src.fields = make(map[string]bool, len(cfg.Schema))
+		for field := range cfg.Schema {
+			src.fields[field] = true
+		}
+	}
+	return src, nil
+}
+
 // Name returns the source identifier
 func (s *MarkdownSource) Name() string {
 	return s.name
@@ -85,9 +113,10 @@ func (s *MarkdownSource) Fetch(ctx context.Context) ([]map[string]interface{}, e
 		return nil, fmt.Errorf("markdown source %q: failed to read file: %w", s.name, err)
 	}
 
-	// Store mtime for conflict detection on writes
+	// Store mtime and section content for conflict detection and merging on writes
 	s.mu.Lock()
 	s.lastMtime = info.ModTime()
+	s.lastSection, s.hasLastSection = s.sectionContent(string(content))
 	s.mu.Unlock()
 
 	return s.parseSection(string(content))
@@ -204,8 +233,10 @@ func (s *MarkdownSource) detectAndParse(content string) ([]map[string]interface{
 }
 
 // parseTaskList parses - [ ] item <!-- id:xxx --> format
+// Indented items become children of the item above them (see nestListRows).
 func (s *MarkdownSource) parseTaskList(lines []string) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
+	var indents []int
 
 	taskPattern := regexp.MustCompile(`^\s*-\s+\[([ xX])\]\s+(.+?)(?:\s*<!--\s*id:(\w+)\s*-->)?$`)
 
@@ -224,19 +255,27 @@ func (s *MarkdownSource) parseTaskList(lines []string) ([]map[string]interface{}
 			id = generateContentID(text)
 		}
 
-		results = append(results, map[string]interface{}{
+		row := map[string]interface{}{
 			"id":   id,
 			"text": text,
 			"done": done,
-		})
+		}
+		if s.inlineMeta {
+			applyInlineMetadata(row)
+		}
+		results = append(results, row)
+		indents = append(indents, indentWidth(line))
 	}
 
+	nestListRows(results, indents)
 	return results, nil
 }
 
 // parseBulletList parses - item <!-- id:xxx --> format
+// Indented items become children of the item above them (see nestListRows).
 func (s *MarkdownSource) parseBulletList(lines []string) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
+	var indents []int
 
 	bulletPattern := regexp.MustCompile(`^\s*-\s+(.+?)(?:\s*<!--\s*id:(\w+)\s*-->)?$`)
 
@@ -259,12 +298,18 @@ func (s *MarkdownSource) parseBulletList(lines []string) ([]map[string]interface
 			id = generateContentID(text)
 		}
 
-		results = append(results, map[string]interface{}{
+		row := map[string]interface{}{
 			"id":   id,
 			"text": text,
-		})
+		}
+		if s.inlineMeta {
+			applyInlineMetadata(row)
+		}
+		results = append(results, row)
+		indents = append(indents, indentWidth(line))
 	}
 
+	nestListRows(results, indents)
 	return results, nil
 }
 
@@ -380,8 +425,10 @@ func (s *MarkdownSource) GetAnchor() string {
 }
 
 // WriteItem adds, updates, or deletes an item in the markdown source
-// Supported actions: add, toggle, delete, update
-// Returns ConflictError if the file was modified externally since last read
+// Supported actions: add, toggle, delete, update, and for nested lists move, indent, outdent
+// If the file was modified externally since last read, the change is applied to
+// the last-read section and three-way merged with the current section.
+// Returns ConflictError only if the external edits overlap with this change.
 func (s *MarkdownSource) WriteItem(ctx context.Context, action string, data map[string]interface{}) error {
 	if s.readonly {
 		return fmt.Errorf("markdown source %q is read-only", s.name)
@@ -389,6 +436,13 @@ func (s *MarkdownSource) WriteItem(ctx context.Context, action string, data map[
 
 	path := s.resolvePath()
 
+	// Serialize writers (other sources or processes) for the read-modify-write cycle
+	lock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
 	// Check current mtime before reading
 	info, err := os.Stat(path)
 	if err != nil {
@@ -396,24 +450,11 @@ func (s *MarkdownSource) WriteItem(ctx context.Context, action string, data map[
 	}
 	currentMtime := info.ModTime()
 
-	// Check for conflict (file modified since last read)
 	s.mu.RLock()
 	lastMtime := s.lastMtime
+	baseSection, hasBase := s.lastSection, s.hasLastSection
 	s.mu.RUnlock()
 
-	if !lastMtime.IsZero() && !currentMtime.Equal(lastMtime) {
-		// File was modified externally - create conflict copy
-		conflictPath, err := s.createConflictCopy(path)
-		if err != nil {
-			return fmt.Errorf("failed to create conflict copy: %w", err)
-		}
-		return &ConflictError{
-			OriginalPath: path,
-			ConflictPath: conflictPath,
-			Message:      fmt.Sprintf("file was modified externally; your changes saved to %s", conflictPath),
-		}
-	}
-
 	// Read current content
 	contentBytes, err := os.ReadFile(path)
 	if err != nil {
@@ -422,63 +463,95 @@ func (s *MarkdownSource) WriteItem(ctx context.Context, action string, data map[
 	content := string(contentBytes)
 
 	// Find section boundaries
-	sectionStart, sectionEnd, headerLevel, err := s.findSectionBoundaries(content)
+	sectionStart, sectionEnd, _, err := s.findSectionBoundaries(content)
 	if err != nil {
 		return err
 	}
 
 	sectionContent := content[sectionStart:sectionEnd]
 
-	// Detect format
-	format := s.detectFormat(sectionContent)
-
-	// Perform action
+	// File modified externally since last read: apply the change to what the
+	// user saw, then merge it with the section as it is now
+	modified := !lastMtime.IsZero() && !currentMtime.Equal(lastMtime)
 	var newSectionContent string
-	switch action {
-	case "add":
-		newSectionContent, err = s.addItem(sectionContent, format, data)
-	case "toggle":
-		newSectionContent, err = s.toggleItem(sectionContent, format, data)
-	case "delete":
-		newSectionContent, err = s.deleteItem(sectionContent, format, data)
-	case "update":
-		newSectionContent, err = s.updateItem(sectionContent, format, data)
-	default:
-		return fmt.Errorf("unknown action: %s", action)
-	}
+	if modified && hasBase && baseSection != sectionContent {
+		ours, err := s.applyAction(baseSection, action, data)
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		return err
+		merged, conflict := mergeLines(
+			strings.Split(baseSection, "\n"),
+			strings.Split(ours, "\n"),
+			strings.Split(sectionContent, "\n"),
+		)
+		newSectionContent = strings.Join(merged, "\n")
+
+		if conflict {
+			conflictContent := content[:sectionStart] + newSectionContent + content[sectionEnd:]
+			conflictPath, err := s.createConflictCopy(path, []byte(conflictContent))
+			if err != nil {
+				return fmt.Errorf("failed to create conflict copy: %w", err)
+			}
+			return &ConflictError{
+				OriginalPath: path,
+				ConflictPath: conflictPath,
+				Message:      fmt.Sprintf("file was modified externally with overlapping changes; your changes saved to %s", conflictPath),
+			}
+		}
+	} else {
+		newSectionContent, err = s.applyAction(sectionContent, action, data)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Reconstruct file content
 	newContent := content[:sectionStart] + newSectionContent + content[sectionEnd:]
 
 	// Write back to file
-	if err := os.WriteFile(path, []byte(newContent), 0644); err != nil {
+	if err := writeFileAtomic(path, []byte(newContent), 0644); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	// Update stored mtime after successful write
+	// Update stored mtime and merge base after successful write
 	if newInfo, err := os.Stat(path); err == nil {
 		s.mu.Lock()
 		s.lastMtime = newInfo.ModTime()
+		s.lastSection, s.hasLastSection = newSectionContent, true
 		s.mu.Unlock()
 	}
 
-	_ = headerLevel // Used in section boundary detection
 	return nil
 }
 
-// createConflictCopy creates a conflict copy of the current pending changes
-// Returns the path to the conflict file
-func (s *MarkdownSource) createConflictCopy(originalPath string) (string, error) {
-	// Read the current file content (what the user was trying to modify)
-	content, err := os.ReadFile(originalPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read original file: %w", err)
+// applyAction performs a write action on section content and returns the new content
+func (s *MarkdownSource) applyAction(sectionContent, action string, data map[string]interface{}) (string, error) {
+	format := s.detectFormat(sectionContent)
+
+	switch action {
+	case "add":
+		return s.addItem(sectionContent, format, data)
+	case "toggle":
+		return s.toggleItem(sectionContent, format, data)
+	case "delete":
+		return s.deleteItem(sectionContent, format, data)
+	case "update":
+		return s.updateItem(sectionContent, format, data)
+	case "move":
+		return s.moveItem(sectionContent, format, data)
+	case "indent":
+		return s.indentItem(sectionContent, format, data)
+	case "outdent":
+		return s.outdentItem(sectionContent, format, data)
+	default:
+		return "", fmt.Errorf("unknown action: %s", action)
 	}
+}
 
+// createConflictCopy writes content that could not be merged next to the original
+// file so a human can reconcile it. Returns the path to the conflict file
+func (s *MarkdownSource) createConflictCopy(originalPath string, content []byte) (string, error) {
 	// Generate conflict filename: file.conflict-{timestamp}.md
 	ext := filepath.Ext(originalPath)
 	base := strings.TrimSuffix(originalPath, ext)
@@ -486,13 +559,22 @@ func (s *MarkdownSource) createConflictCopy(originalPath string) (string, error)
 	conflictPath := fmt.Sprintf("%s.conflict-%s%s", base, timestamp, ext)
 
 	// Write conflict copy
-	if err := os.WriteFile(conflictPath, content, 0644); err != nil {
+	if err := writeFileAtomic(conflictPath, content, 0644); err != nil {
 		return "", fmt.Errorf("failed to write conflict file: %w", err)
 	}
 
 	return conflictPath, nil
 }
 
+// sectionContent returns the content of the source's section, if present
+func (s *MarkdownSource) sectionContent(content string) (string, bool) {
+	start, end, _, err := s.findSectionBoundaries(content)
+	if err != nil {
+		return "", false
+	}
+	return content[start:end], true
+}
+
 // findSectionBoundaries finds where the section starts and ends
 func (s *MarkdownSource) findSectionBoundaries(content string) (start, end, headerLevel int, err error) {
 	anchorName := strings.TrimPrefix(s.anchor, "#")
@@ -548,7 +630,7 @@ func (s *MarkdownSource) addItem(sectionContent, format string, data map[string]
 	var newLine string
 	switch format {
 	case "task":
-		text, _ := data["text"].(string)
+		text := s.itemText(data)
 		done, _ := data["done"].(bool)
 		checkbox := "[ ]"
 		if done {
@@ -557,7 +639,7 @@ func (s *MarkdownSource) addItem(sectionContent, format string, data map[string]
 		newLine = fmt.Sprintf("- %s %s <!-- id:%s -->", checkbox, text, id)
 
 	case "bullet":
-		text, _ := data["text"].(string)
+		text := s.itemText(data)
 		newLine = fmt.Sprintf("- %s <!-- id:%s -->", text, id)
 
 	case "table":
@@ -580,6 +662,11 @@ func (s *MarkdownSource) addItem(sectionContent, format string, data map[string]
 		return "", fmt.Errorf("cannot add item: unknown format")
 	}
 
+	// Add as a sub-item of an existing list item
+	if parentID, _ := data["parent_id"].(string); parentID != "" && format != "table" {
+		return s.insertChild(sectionContent, format, parentID, newLine)
+	}
+
 	// Append to the end of section (before trailing newlines)
 	trimmed := strings.TrimRight(sectionContent, "\n")
 	return trimmed + "\n" + newLine + "\n", nil
@@ -600,12 +687,12 @@ func (s *MarkdownSource) toggleItem(sectionContent, format string, data map[stri
 	lines := strings.Split(sectionContent, "\n")
 	taskPattern := regexp.MustCompile(`^\s*-\s+\[([ xX])\]\s+(.+?)(?:\s*<!--\s*id:(\w+)\s*-->)?$`)
 
-	found := false
+	found := -1
 	for i, line := range lines {
 		// First try explicit ID comment
 		if strings.Contains(line, "<!-- id:"+id+" -->") {
 			lines[i] = s.toggleCheckbox(line)
-			found = true
+			found = i
 			break
 		}
 
@@ -618,17 +705,25 @@ func (s *MarkdownSource) toggleItem(sectionContent, format string, data map[stri
 				text := strings.TrimSpace(matches[2])
 				if generateContentID(text) == id {
 					lines[i] = s.toggleCheckbox(line)
-					found = true
+					found = i
 					break
 				}
 			}
 		}
 	}
 
-	if !found {
+	if found < 0 {
 		return "", fmt.Errorf("item with id %q not found", id)
 	}
 
+	// Cascade the new state to nested sub-tasks
+	if s.cascade {
+		done := isChecked(lines[found])
+		for n := found + 1; n < subtreeEnd(lines, found); n++ {
+			lines[n] = setCheckbox(lines[n], done)
+		}
+	}
+
 	return strings.Join(lines, "\n"), nil
 }
 
@@ -652,35 +747,31 @@ func (s *MarkdownSource) deleteItem(sectionContent, format string, data map[stri
 	}
 
 	lines := strings.Split(sectionContent, "\n")
-	var newLines []string
-	found := false
-
-	for _, line := range lines {
-		// First try explicit ID comment
-		if strings.Contains(line, "<!-- id:"+id+" -->") {
-			found = true
-			continue // Skip this line (delete it)
-		}
-
-		// Check for content-based ID match
-		if !found {
-			itemID := s.extractItemID(line, format)
-			if itemID == id {
-				found = true
-				continue // Skip this line (delete it)
-			}
-		}
-
-		newLines = append(newLines, line)
+	idx := s.findItemLine(lines, format, id)
+	if idx < 0 {
+		return "", fmt.Errorf("item with id %q not found", id)
 	}
 
-	if !found {
-		return "", fmt.Errorf("item with id %q not found", id)
+	// List items are deleted together with their nested sub-items
+	end := idx + 1
+	if format != "table" {
+		end = subtreeEnd(lines, idx)
 	}
 
+	newLines := append(lines[:idx:idx], lines[end:]...)
 	return strings.Join(newLines, "\n"), nil
 }
 
+// findItemLine returns the index of the line holding the item with the given ID, or -1
+func (s *MarkdownSource) findItemLine(lines []string, format, id string) int {
+	for i, line := range lines {
+		if strings.Contains(line, "<!-- id:"+id+" -->") || s.extractItemID(line, format) == id {
+			return i
+		}
+	}
+	return -1
+}
+
 // extractItemID extracts the ID from a line, using content-based ID if no explicit ID
 func (s *MarkdownSource) extractItemID(line, format string) string {
 	switch format {
@@ -738,10 +829,14 @@ func (s *MarkdownSource) updateItem(sectionContent, format string, data map[stri
 		}
 		found = true
 
+		if s.inlineMeta && format != "table" {
+			lines[i] = s.updateListLineMetadata(line, format, data)
+		}
+
 		switch format {
 		case "task":
 			// Update text and/or done state
-			if text, ok := data["text"].(string); ok {
+			if text, ok := data["text"].(string); ok && !s.inlineMeta {
 				if hasExplicitID {
 					// Replace text between checkbox and ID comment
 					taskPattern := regexp.MustCompile(`^(\s*-\s+\[[ xX]\]\s+)(.+?)(\s*<!--\s*id:\w+\s*-->)`)
@@ -763,7 +858,7 @@ func (s *MarkdownSource) updateItem(sectionContent, format string, data map[stri
 
 		case "bullet":
 			// Update text
-			if text, ok := data["text"].(string); ok {
+			if text, ok := data["text"].(string); ok && !s.inlineMeta {
 				if hasExplicitID {
 					bulletPattern := regexp.MustCompile(`^(\s*-\s+)(.+?)(\s*<!--\s*id:\w+\s*-->)`)
 					lines[i] = bulletPattern.ReplaceAllString(line, "${1}"+text+"${3}")
@@ -802,6 +897,29 @@ func (s *MarkdownSource) updateItem(sectionContent, format string, data map[stri
 	return strings.Join(lines, "\n"), nil
 }
 
+// itemText returns the text for a new list item, including inline metadata fields
+func (s *MarkdownSource) itemText(data map[string]interface{}) string {
+	text, _ := data["text"].(string)
+	if !s.inlineMeta {
+		return text
+	}
+	return rewriteInlineMetadata("", data, s.fields)
+}
+
+// updateListLineMetadata rewrites the text of a list item line with new text and
+// inline field values from data, leaving the checkbox and ID comment intact
+func (s *MarkdownSource) updateListLineMetadata(line, format string, data map[string]interface{}) string {
+	pattern := regexp.MustCompile(`^(\s*-\s+)(.+?)(\s*<!--\s*id:\w+\s*-->)?$`)
+	if format == "task" {
+		pattern = regexp.MustCompile(`^(\s*-\s+\[[ xX]\]\s+)(.+?)(\s*<!--\s*id:\w+\s*-->)?$`)
+	}
+	matches := pattern.FindStringSubmatch(line)
+	if matches == nil {
+		return line
+	}
+	return matches[1] + rewriteInlineMetadata(matches[2], data, s.fields) + matches[3]
+}
+
 // extractTableHeaders extracts column headers from a table section
 func (s *MarkdownSource) extractTableHeaders(content string) []string {
 	lines := strings.Split(content, "\n")
@@ -0,0 +1,301 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Inline metadata lets list items carry structured fields in common plain-text
+// conventions (enabled with the inline_metadata option):
+//
+//	due:2026-01-05   key:value field            -> "due"
+//	[due:: 2026-01]  Dataview field (or parens) -> "due"
+//	owner:: Alice    Dataview field to EOL      -> "owner"
+//	#ops             tag                        -> "tags" (list)
+//	@alice           mention                    -> "assignee" (first), "mentions" (all)
+//	!high            priority                   -> "priority"
+//
+// The "text" column holds the item text with these tokens removed.
+
+// inlineFieldKind identifies the syntax a field was written in
+type inlineFieldKind int
+
+const (
+	fieldKeyValue inlineFieldKind = iota // key:value
+	fieldBracket                         // [key:: value] or (key:: value)
+	fieldTrailing                        // key:: value (to end of line)
+	fieldTag                             // #tag
+	fieldMention                         // @name
+	fieldPriority                        // !level
+)
+
+// inlineField is a metadata token found in item text
+type inlineField struct {
+	kind       inlineFieldKind
+	key        string // column name
+	value      string
+	start, end int  // byte span of the token in the item text
+	open       byte // opening bracket for fieldBracket
+}
+
+var (
+	bracketFieldPattern  = regexp.MustCompile(`[\[(]([A-Za-z][\w-]*)::\s*([^\])]*?)\s*[\])]`)
+	trailingFieldPattern = regexp.MustCompile(`(?:^|\s)(([A-Za-z][\w-]*)::\s*(.*?))\s*$`)
+	keyValuePattern      = regexp.MustCompile(`(?:^|\s)(([A-Za-z][\w-]*):([^\s:/]\S*))`)
+	tagPattern           = regexp.MustCompile(`(?:^|\s)(#([\w/-]+))`)
+	mentionPattern       = regexp.MustCompile(`(?:^|\s)(@(\w(?:[\w.-]*\w)?))`)
+	priorityPattern      = regexp.MustCompile(`(?:^|\s)(!(\w+))`)
+	allDigitsPattern     = regexp.MustCompile(`^\d+$`)
+)
+
+// reservedItemKeys are row columns that are never read from or written as inline fields
+var reservedItemKeys = map[string]bool{
+	"id": true, "Id": true, "ID": true, "text": true, "done": true, "parent_id": true, "depth": true, "children": true, "mentions": true,
+}
+
+// findInlineFields returns the metadata tokens in item text, ordered by position
+func findInlineFields(text string) []inlineField {
+	var fields []inlineField
+	overlaps := func(start, end int) bool {
+		for _, f := range fields {
+			if start < f.end && end > f.start {
+				return true
+			}
+		}
+		return false
+	}
+	add := func(f inlineField) {
+		if !overlaps(f.start, f.end) {
+			fields = append(fields, f)
+		}
+	}
+
+	for _, m := range bracketFieldPattern.FindAllStringSubmatchIndex(text, -1) {
+		add(inlineField{kind: fieldBracket, key: text[m[2]:m[3]], value: text[m[4]:m[5]], start: m[0], end: m[1], open: text[m[0]]})
+	}
+	if m := trailingFieldPattern.FindStringSubmatchIndex(text); m != nil {
+		add(inlineField{kind: fieldTrailing, key: text[m[4]:m[5]], value: text[m[6]:m[7]], start: m[2], end: m[3]})
+	}
+	for _, m := range keyValuePattern.FindAllStringSubmatchIndex(text, -1) {
+		add(inlineField{kind: fieldKeyValue, key: text[m[4]:m[5]], value: text[m[6]:m[7]], start: m[2], end: m[3]})
+	}
+	for _, m := range tagPattern.FindAllStringSubmatchIndex(text, -1) {
+		// Numeric-only tags are issue references (#123), not tags
+		if value := text[m[4]:m[5]]; !allDigitsPattern.MatchString(value) {
+			add(inlineField{kind: fieldTag, key: "tags", value: value, start: m[2], end: m[3]})
+		}
+	}
+	for _, m := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		add(inlineField{kind: fieldMention, key: "mentions", value: text[m[4]:m[5]], start: m[2], end: m[3]})
+	}
+	for _, m := range priorityPattern.FindAllStringSubmatchIndex(text, -1) {
+		add(inlineField{kind: fieldPriority, key: "priority", value: text[m[4]:m[5]], start: m[2], end: m[3]})
+	}
+
+	// Reserved keys stay part of the text
+	kept := fields[:0]
+	for _, f := range fields {
+		if f.kind == fieldTag || f.kind == fieldMention || !reservedItemKeys[f.key] {
+			kept = append(kept, f)
+		}
+	}
+	fields = kept
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].start < fields[j].start })
+
+	// The first mention is the assignee
+	for i := range fields {
+		if fields[i].kind == fieldMention {
+			fields[i].key = "assignee"
+			break
+		}
+	}
+	return fields
+}
+
+// applyInlineMetadata moves inline fields in row["text"] into row columns
+func applyInlineMetadata(row map[string]interface{}) {
+	text, _ := row["text"].(string)
+	fields := findInlineFields(text)
+	if len(fields) == 0 {
+		return
+	}
+
+	var plain strings.Builder
+	pos := 0
+	for _, f := range fields {
+		plain.WriteString(text[pos:f.start])
+		plain.WriteString(" ")
+		pos = f.end
+
+		switch f.kind {
+		case fieldTag:
+			tags, _ := row["tags"].([]string)
+			row["tags"] = append(tags, f.value)
+		case fieldMention:
+			mentions, _ := row["mentions"].([]string)
+			row["mentions"] = append(mentions, f.value)
+			if f.key == "assignee" {
+				row["assignee"] = f.value
+			}
+		default:
+			// First occurrence wins for repeated keys
+			if _, exists := row[f.key]; !exists {
+				row[f.key] = f.value
+			}
+		}
+	}
+	plain.WriteString(text[pos:])
+
+	row["text"] = strings.Join(strings.Fields(plain.String()), " ")
+}
+
+// newInlineKeys are the fields appended to items that don't have them yet,
+// besides the fields declared in the source's schema
+var newInlineKeys = map[string]bool{"tags": true, "assignee": true, "priority": true, "due": true}
+
+// rewriteInlineMetadata updates item text with new field values from data,
+// keeping each field in the syntax it was written in. Known fields (see
+// newInlineKeys) and declared fields not present yet are appended; other keys
+// of data (e.g., form inputs) are ignored. If data contains "text", the
+// human-readable text is replaced and the existing fields are kept after it.
+func rewriteInlineMetadata(text string, data map[string]interface{}, declared map[string]bool) string {
+	fields := findInlineFields(text)
+
+	var tokens []string // field tokens, in order, after applying updates
+	var body strings.Builder
+	handled := make(map[string]bool)
+	trailing := "" // trailing Dataview field, which must stay last
+	pos := 0
+	for _, f := range fields {
+		body.WriteString(text[pos:f.start])
+		pos = f.end
+
+		token := text[f.start:f.end]
+		if value, ok := data[f.key]; ok && !reservedItemKeys[f.key] {
+			if handled[f.key] {
+				token = "" // Repeated tag or field: the first occurrence holds the new value
+			} else {
+				token = formatInlineField(f, value)
+				handled[f.key] = true
+			}
+		}
+		body.WriteString(token)
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+		if f.kind == fieldTrailing {
+			trailing = token
+		}
+	}
+	body.WriteString(text[pos:])
+
+	result := strings.Join(strings.Fields(body.String()), " ")
+	if newText, ok := data["text"].(string); ok {
+		result = strings.Join(append([]string{strings.TrimSpace(newText)}, tokens...), " ")
+	}
+
+	// Append fields that are new to this item
+	var added []string
+	for _, key := range sortedKeys(data) {
+		if handled[key] || reservedItemKeys[key] || !(newInlineKeys[key] || declared[key]) {
+			continue
+		}
+		if token := formatInlineField(inlineField{kind: newFieldKind(key), key: key}, data[key]); token != "" {
+			added = append(added, token)
+		}
+	}
+	if len(added) == 0 {
+		return result
+	}
+	if trailing != "" && strings.HasSuffix(result, trailing) {
+		head := strings.TrimSpace(strings.TrimSuffix(result, trailing))
+		return strings.TrimSpace(head + " " + strings.Join(added, " ") + " " + trailing)
+	}
+	return strings.TrimSpace(result + " " + strings.Join(added, " "))
+}
+
+// newFieldKind returns the syntax used for a field that is not in the text yet
+func newFieldKind(key string) inlineFieldKind {
+	switch key {
+	case "tags":
+		return fieldTag
+	case "assignee":
+		return fieldMention
+	case "priority":
+		return fieldPriority
+	default:
+		return fieldKeyValue
+	}
+}
+
+// formatInlineField renders a field with a new value in the field's syntax.
+// Returns "" for empty values, which removes the field.
+func formatInlineField(f inlineField, value interface{}) string {
+	if f.kind == fieldTag {
+		var tags []string
+		for _, tag := range inlineListValue(value) {
+			tags = append(tags, "#"+strings.TrimPrefix(tag, "#"))
+		}
+		return strings.Join(tags, " ")
+	}
+
+	v := strings.TrimSpace(fmt.Sprintf("%v", value))
+	if value == nil || v == "" {
+		return ""
+	}
+
+	switch f.kind {
+	case fieldMention:
+		return "@" + strings.TrimPrefix(v, "@")
+	case fieldPriority:
+		return "!" + strings.TrimPrefix(v, "!")
+	case fieldBracket:
+		closing := "]"
+		if f.open == '(' {
+			closing = ")"
+		}
+		return string(f.open) + f.key + ":: " + v + closing
+	case fieldTrailing:
+		return f.key + ":: " + v
+	default:
+		// key:value cannot hold whitespace; fall back to the bracketed form
+		if strings.ContainsAny(v, " \t") {
+			return "[" + f.key + ":: " + v + "]"
+		}
+		return f.key + ":" + v
+	}
+}
+
+// inlineListValue converts a tags value from a row or form ([]string, []interface{},
+// or a comma/space separated string) to a list
+func inlineListValue(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			items = append(items, fmt.Sprintf("%v", item))
+		}
+		return items
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	case nil:
+		return nil
+	default:
+		return []string{fmt.Sprintf("%v", v)}
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
 )
 
 func TestNewMarkdownSource(t *testing.T) {
@@ -1203,21 +1207,21 @@ func TestWriteItemConflictDetection(t *testing.T) {
 		t.Fatalf("Fetch() error = %v", err)
 	}
 
-	// Simulate external modification by writing to the file directly
+	// Simulate external modification of the same item by writing to the file directly
 	// Wait a bit to ensure different mtime (some filesystems have 1-second resolution)
 	time.Sleep(100 * time.Millisecond)
 	externalContent := `# Tasks {#tasks}
 
-- [ ] Task 1 <!-- id:t1 -->
-- [ ] External task <!-- id:ext1 -->
+- [ ] Task 1 renamed externally <!-- id:t1 -->
 `
 	if err := os.WriteFile(mdPath, []byte(externalContent), 0644); err != nil {
 		t.Fatalf("Failed to write external modification: %v", err)
 	}
 
-	// Try to write - should detect conflict
-	err = src.WriteItem(context.Background(), "add", map[string]interface{}{
-		"text": "My new task",
+	// Try to update the same item - should detect conflict
+	err = src.WriteItem(context.Background(), "update", map[string]interface{}{
+		"id":   "t1",
+		"text": "Task 1 renamed in app",
 	})
 
 	if err == nil {
@@ -1239,10 +1243,137 @@ func TestWriteItemConflictDetection(t *testing.T) {
 		t.Errorf("conflict file should exist: %s", conflictErr.ConflictPath)
 	}
 
+	// Original file must be left untouched
+	content, _ := os.ReadFile(mdPath)
+	if string(content) != externalContent {
+		t.Errorf("original file should be unchanged on conflict, got:\n%s", content)
+	}
+
 	// Clean up conflict file
 	os.Remove(conflictErr.ConflictPath)
 }
 
+func TestWriteItemMergesConcurrentEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		external string
+		action   string
+		data     map[string]interface{}
+		want     []string
+	}{
+		{
+			name: "both sides append",
+			external: `# Tasks {#tasks}
+
+- [ ] Task 1 <!-- id:t1 -->
+- [ ] Task 2 <!-- id:t2 -->
+- [ ] External task <!-- id:ext1 -->
+`,
+			action: "add",
+			data:   map[string]interface{}{"text": "My new task"},
+			want:   []string{"Task 1", "Task 2", "External task", "My new task"},
+		},
+		{
+			name: "different items edited",
+			external: `# Tasks {#tasks}
+
+- [ ] Task 1 edited externally <!-- id:t1 -->
+- [ ] Task 2 <!-- id:t2 -->
+`,
+			action: "toggle",
+			data:   map[string]interface{}{"id": "t2"},
+			want:   []string{"Task 1 edited externally", "Task 2"},
+		},
+		{
+			name: "external delete with local add",
+			external: `# Tasks {#tasks}
+
+- [ ] Task 2 <!-- id:t2 -->
+`,
+			action: "add",
+			data:   map[string]interface{}{"text": "My new task"},
+			want:   []string{"Task 2", "My new task"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			mdContent := `# Tasks {#tasks}
+
+- [ ] Task 1 <!-- id:t1 -->
+- [ ] Task 2 <!-- id:t2 -->
+`
+			mdPath := filepath.Join(tmpDir, "test.md")
+			if err := os.WriteFile(mdPath, []byte(mdContent), 0644); err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+
+			src, err := NewMarkdownSource("tasks", "test.md", "#tasks", tmpDir, filepath.Join(tmpDir, "index.md"), false)
+			if err != nil {
+				t.Fatalf("NewMarkdownSource() error = %v", err)
+			}
+
+			if _, err := src.Fetch(context.Background()); err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			if err := os.WriteFile(mdPath, []byte(tt.external), 0644); err != nil {
+				t.Fatalf("Failed to write external modification: %v", err)
+			}
+
+			if err := src.WriteItem(context.Background(), tt.action, tt.data); err != nil {
+				t.Fatalf("WriteItem(%s) error = %v", tt.action, err)
+			}
+
+			results, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			var texts []string
+			for _, r := range results {
+				texts = append(texts, r["text"].(string))
+			}
+			if strings.Join(texts, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("texts = %v, want %v", texts, tt.want)
+			}
+
+			// No conflict copy should have been written
+			conflicts, _ := filepath.Glob(filepath.Join(tmpDir, "*.conflict-*"))
+			if len(conflicts) != 0 {
+				t.Errorf("unexpected conflict files: %v", conflicts)
+			}
+		})
+	}
+
+	// The toggle must have been applied on top of the external edit
+	t.Run("toggle applied", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		mdPath := filepath.Join(tmpDir, "test.md")
+		os.WriteFile(mdPath, []byte("# Tasks {#tasks}\n\n- [ ] Task 1 <!-- id:t1 -->\n- [ ] Task 2 <!-- id:t2 -->\n"), 0644)
+
+		src, _ := NewMarkdownSource("tasks", "test.md", "#tasks", tmpDir, filepath.Join(tmpDir, "index.md"), false)
+		src.Fetch(context.Background())
+
+		time.Sleep(100 * time.Millisecond)
+		os.WriteFile(mdPath, []byte("# Tasks {#tasks}\n\n- [ ] Task 1 edited <!-- id:t1 -->\n- [ ] Task 2 <!-- id:t2 -->\n"), 0644)
+
+		if err := src.WriteItem(context.Background(), "toggle", map[string]interface{}{"id": "t2"}); err != nil {
+			t.Fatalf("WriteItem(toggle) error = %v", err)
+		}
+
+		content, _ := os.ReadFile(mdPath)
+		if !strings.Contains(string(content), "- [x] Task 2 <!-- id:t2 -->") {
+			t.Errorf("toggle should be applied, got:\n%s", content)
+		}
+		if !strings.Contains(string(content), "Task 1 edited") {
+			t.Errorf("external edit should be preserved, got:\n%s", content)
+		}
+	})
+}
+
 func TestWriteItemNoConflictWithoutPriorFetch(t *testing.T) {
 	tmpDir := t.TempDir()
 	mdContent := `# Tasks {#tasks}
@@ -1278,7 +1409,7 @@ func TestWriteItemNoConflictWithoutPriorFetch(t *testing.T) {
 	}
 }
 
-func TestConflictCopyContainsOriginalContent(t *testing.T) {
+func TestConflictCopyContainsBothChanges(t *testing.T) {
 	tmpDir := t.TempDir()
 	mdContent := `# Tasks {#tasks}
 
@@ -1304,16 +1435,16 @@ func TestConflictCopyContainsOriginalContent(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 	externalContent := `# Tasks {#tasks}
 
-- [ ] Original task <!-- id:t1 -->
-- [ ] External modification <!-- id:ext1 -->
+- [ ] External modification <!-- id:t1 -->
 `
 	if err := os.WriteFile(mdPath, []byte(externalContent), 0644); err != nil {
 		t.Fatalf("Failed to write external modification: %v", err)
 	}
 
-	// Try to write - should create conflict copy
-	err = src.WriteItem(context.Background(), "add", map[string]interface{}{
-		"text": "My task",
+	// Try to update the same line - should create conflict copy
+	err = src.WriteItem(context.Background(), "update", map[string]interface{}{
+		"id":   "t1",
+		"text": "My modification",
 	})
 
 	conflictErr, ok := err.(*ConflictError)
@@ -1321,20 +1452,57 @@ func TestConflictCopyContainsOriginalContent(t *testing.T) {
 		t.Fatalf("expected *ConflictError, got %T: %v", err, err)
 	}
 
-	// Read conflict file and verify it contains the external modification
+	// Read conflict file and verify it contains both sides with markers
 	conflictContent, err := os.ReadFile(conflictErr.ConflictPath)
 	if err != nil {
 		t.Fatalf("Failed to read conflict file: %v", err)
 	}
 
-	if !strings.Contains(string(conflictContent), "External modification") {
-		t.Error("conflict file should contain the external modification")
+	for _, want := range []string{"<<<<<<< ours", "My modification", "=======", "External modification", ">>>>>>> theirs"} {
+		if !strings.Contains(string(conflictContent), want) {
+			t.Errorf("conflict file should contain %q, got:\n%s", want, conflictContent)
+		}
 	}
 
 	// Clean up
 	os.Remove(conflictErr.ConflictPath)
 }
 
+func TestWriteItemAtomicPreservesMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdPath := filepath.Join(tmpDir, "test.md")
+	if err := os.WriteFile(mdPath, []byte("# Tasks {#tasks}\n\n- [ ] Task 1 <!-- id:t1 -->\n"), 0600); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+
+	src, err := NewMarkdownSource("tasks", "test.md", "#tasks", tmpDir, filepath.Join(tmpDir, "index.md"), false)
+	if err != nil {
+		t.Fatalf("NewMarkdownSource() error = %v", err)
+	}
+
+	if err := src.WriteItem(context.Background(), "toggle", map[string]interface{}{"id": "t1"}); err != nil {
+		t.Fatalf("WriteItem(toggle) error = %v", err)
+	}
+
+	info, err := os.Stat(mdPath)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// No temp files should be left behind
+	entries, _ := os.ReadDir(tmpDir)
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("expected only test.md in dir, got %v", names)
+	}
+}
+
 func TestSlugify(t *testing.T) {
 	tests := []struct {
 		input string
@@ -1485,3 +1653,482 @@ func TestTextBasedAnchorMatching(t *testing.T) {
 		}
 	})
 }
+
+// ============ Nested Lists ============
+
+func TestMarkdownSourceParseNestedTaskList(t *testing.T) {
+	content := `# Runbook {#steps}
+
+- [ ] Prepare <!-- id:p1 -->
+  - [x] Drain node <!-- id:c1 -->
+  - [ ] Snapshot disk <!-- id:c2 -->
+    - [ ] Verify snapshot <!-- id:g1 -->
+- [ ] Deploy <!-- id:p2 -->
+`
+	src := &MarkdownSource{anchor: "#steps"}
+	results, err := src.parseSection(content)
+	if err != nil {
+		t.Fatalf("parseSection() error = %v", err)
+	}
+
+	if len(results) != 5 {
+		t.Fatalf("expected 5 rows (flat view), got %d", len(results))
+	}
+
+	want := []struct {
+		id       string
+		parentID string
+		depth    int
+	}{
+		{"p1", "", 0},
+		{"c1", "p1", 1},
+		{"c2", "p1", 1},
+		{"g1", "c2", 2},
+		{"p2", "", 0},
+	}
+	for i, w := range want {
+		row := results[i]
+		if row["id"] != w.id {
+			t.Errorf("row %d id = %v, want %s", i, row["id"], w.id)
+		}
+		if row["parent_id"] != w.parentID {
+			t.Errorf("row %s parent_id = %v, want %q", w.id, row["parent_id"], w.parentID)
+		}
+		if row["depth"] != w.depth {
+			t.Errorf("row %s depth = %v, want %d", w.id, row["depth"], w.depth)
+		}
+		// The tree view is built from the final rows, not by the source
+		if _, ok := row["children"]; ok {
+			t.Errorf("row %s should not have children: %v", w.id, row)
+		}
+	}
+}
+
+func TestMarkdownSourceFlatListHasNoHierarchyFields(t *testing.T) {
+	content := `# Tasks {#tasks}
+
+- [ ] Task 1
+- [ ] Task 2
+`
+	src := &MarkdownSource{anchor: "#tasks"}
+	results, err := src.parseSection(content)
+	if err != nil {
+		t.Fatalf("parseSection() error = %v", err)
+	}
+
+	for _, row := range results {
+		for _, key := range []string{"parent_id", "depth", "children"} {
+			if _, ok := row[key]; ok {
+				t.Errorf("flat list row should not have %q: %v", key, row)
+			}
+		}
+	}
+}
+
+func TestMarkdownSourceParseNestedBulletList(t *testing.T) {
+	content := `# Notes {#notes}
+
+- Topic <!-- id:t1 -->
+	- Detail <!-- id:d1 -->
+- Other topic <!-- id:t2 -->
+`
+	src := &MarkdownSource{anchor: "#notes"}
+	results, err := src.parseSection(content)
+	if err != nil {
+		t.Fatalf("parseSection() error = %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(results))
+	}
+	if results[1]["parent_id"] != "t1" || results[1]["depth"] != 1 {
+		t.Errorf("tab-indented bullet should be a child of t1, got %v", results[1])
+	}
+}
+
+func TestWriteItemNested(t *testing.T) {
+	initial := `# Runbook {#steps}
+
+- [ ] Prepare <!-- id:p1 -->
+  - [ ] Drain node <!-- id:c1 -->
+  - [ ] Snapshot disk <!-- id:c2 -->
+- [ ] Deploy <!-- id:p2 -->
+- [ ] Verify <!-- id:p3 -->
+
+# Notes
+`
+	tests := []struct {
+		name    string
+		cascade bool
+		action  string
+		data    map[string]interface{}
+		want    string
+	}{
+		{
+			name:   "add as child",
+			action: "add",
+			data:   map[string]interface{}{"text": "Rollback plan", "parent_id": "p2"},
+			want: `- [ ] Prepare <!-- id:p1 -->
+  - [ ] Drain node <!-- id:c1 -->
+  - [ ] Snapshot disk <!-- id:c2 -->
+- [ ] Deploy <!-- id:p2 -->
+  - [ ] Rollback plan <!-- id:NEW -->
+- [ ] Verify <!-- id:p3 -->`,
+		},
+		{
+			name:   "add as last child keeps sibling indentation",
+			action: "add",
+			data:   map[string]interface{}{"text": "Cordon", "parent_id": "p1"},
+			want: `- [ ] Prepare <!-- id:p1 -->
+  - [ ] Drain node <!-- id:c1 -->
+  - [ ] Snapshot disk <!-- id:c2 -->
+  - [ ] Cordon <!-- id:NEW -->
+- [ ] Deploy <!-- id:p2 -->
+- [ ] Verify <!-- id:p3 -->`,
+		},
+		{
+			name:   "toggle without cascade",
+			action: "toggle",
+			data:   map[string]interface{}{"id": "p1"},
+			want: `- [x] Prepare <!-- id:p1 -->
+  - [ ] Drain node <!-- id:c1 -->
+  - [ ] Snapshot disk <!-- id:c2 -->
+- [ ] Deploy <!-- id:p2 -->
+- [ ] Verify <!-- id:p3 -->`,
+		},
+		{
+			name:    "toggle with cascade",
+			cascade: true,
+			action:  "toggle",
+			data:    map[string]interface{}{"id": "p1"},
+			want: `- [x] Prepare <!-- id:p1 -->
+  - [x] Drain node <!-- id:c1 -->
+  - [x] Snapshot disk <!-- id:c2 -->
+- [ ] Deploy <!-- id:p2 -->
+- [ ] Verify <!-- id:p3 -->`,
+		},
+		{
+			name:   "delete removes subtree",
+			action: "delete",
+			data:   map[string]interface{}{"id": "p1"},
+			want: `- [ ] Deploy <!-- id:p2 -->
+- [ ] Verify <!-- id:p3 -->`,
+		},
+		{
+			name:   "move subtree under another item",
+			action: "move",
+			data:   map[string]interface{}{"id": "p1", "parent_id": "p3"},
+			want: `- [ ] Deploy <!-- id:p2 -->
+- [ ] Verify <!-- id:p3 -->
+  - [ ] Prepare <!-- id:p1 -->
+    - [ ] Drain node <!-- id:c1 -->
+    - [ ] Snapshot disk <!-- id:c2 -->`,
+		},
+		{
+			name:   "move child to top level",
+			action: "move",
+			data:   map[string]interface{}{"id": "c1", "parent_id": ""},
+			want: `- [ ] Prepare <!-- id:p1 -->
+  - [ ] Snapshot disk <!-- id:c2 -->
+- [ ] Deploy <!-- id:p2 -->
+- [ ] Verify <!-- id:p3 -->
+- [ ] Drain node <!-- id:c1 -->`,
+		},
+		{
+			name:   "indent under previous sibling",
+			action: "indent",
+			data:   map[string]interface{}{"id": "p2"},
+			want: `- [ ] Prepare <!-- id:p1 -->
+  - [ ] Drain node <!-- id:c1 -->
+  - [ ] Snapshot disk <!-- id:c2 -->
+  - [ ] Deploy <!-- id:p2 -->
+- [ ] Verify <!-- id:p3 -->`,
+		},
+		{
+			name:   "outdent to after parent",
+			action: "outdent",
+			data:   map[string]interface{}{"id": "c1"},
+			want: `- [ ] Prepare <!-- id:p1 -->
+  - [ ] Snapshot disk <!-- id:c2 -->
+- [ ] Drain node <!-- id:c1 -->
+- [ ] Deploy <!-- id:p2 -->
+- [ ] Verify <!-- id:p3 -->`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			mdPath := filepath.Join(tmpDir, "test.md")
+			if err := os.WriteFile(mdPath, []byte(initial), 0644); err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+
+			src, err := NewMarkdownSource("steps", "test.md", "#steps", tmpDir, filepath.Join(tmpDir, "index.md"), false)
+			if err != nil {
+				t.Fatalf("NewMarkdownSource() error = %v", err)
+			}
+			src.cascade = tt.cascade
+
+			if err := src.WriteItem(context.Background(), tt.action, tt.data); err != nil {
+				t.Fatalf("WriteItem(%s) error = %v", tt.action, err)
+			}
+
+			content, _ := os.ReadFile(mdPath)
+			section, _ := src.sectionContent(string(content))
+			got := regexp.MustCompile(`id:[0-9a-f]{8} `).ReplaceAllString(strings.TrimSpace(section), "id:NEW ")
+			if got != tt.want {
+				t.Errorf("section after %s =\n%s\nwant:\n%s", tt.action, got, tt.want)
+			}
+			if !strings.Contains(string(content), "# Notes") {
+				t.Error("content after the section should be preserved")
+			}
+		})
+	}
+}
+
+func TestWriteItemNestedErrors(t *testing.T) {
+	initial := `# Runbook {#steps}
+
+- [ ] Prepare <!-- id:p1 -->
+  - [ ] Drain node <!-- id:c1 -->
+- [ ] Deploy <!-- id:p2 -->
+`
+	tests := []struct {
+		name   string
+		action string
+		data   map[string]interface{}
+	}{
+		{"move under own descendant", "move", map[string]interface{}{"id": "p1", "parent_id": "c1"}},
+		{"indent first item", "indent", map[string]interface{}{"id": "p1"}},
+		{"indent first child", "indent", map[string]interface{}{"id": "c1"}},
+		{"outdent top-level item", "outdent", map[string]interface{}{"id": "p2"}},
+		{"add under missing parent", "add", map[string]interface{}{"text": "x", "parent_id": "missing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			mdPath := filepath.Join(tmpDir, "test.md")
+			if err := os.WriteFile(mdPath, []byte(initial), 0644); err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+
+			src, err := NewMarkdownSource("steps", "test.md", "#steps", tmpDir, filepath.Join(tmpDir, "index.md"), false)
+			if err != nil {
+				t.Fatalf("NewMarkdownSource() error = %v", err)
+			}
+
+			if err := src.WriteItem(context.Background(), tt.action, tt.data); err == nil {
+				t.Errorf("WriteItem(%s) expected error", tt.action)
+			}
+
+			content, _ := os.ReadFile(mdPath)
+			if string(content) != initial {
+				t.Errorf("file should be unchanged after error, got:\n%s", content)
+			}
+		})
+	}
+}
+
+// ============ Inline Metadata ============
+
+func TestApplyInlineMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want map[string]interface{}
+	}{
+		{
+			name: "no metadata",
+			text: "Buy milk",
+			want: map[string]interface{}{"text": "Buy milk"},
+		},
+		{
+			name: "all conventions",
+			text: "Renew cert due:2026-01-05 @alice #ops #security !high",
+			want: map[string]interface{}{
+				"text":     "Renew cert",
+				"due":      "2026-01-05",
+				"assignee": "alice",
+				"mentions": []string{"alice"},
+				"tags":     []string{"ops", "security"},
+				"priority": "high",
+			},
+		},
+		{
+			name: "dataview fields",
+			text: "Write report [due:: next friday] (effort:: 3) owner:: Bob Smith",
+			want: map[string]interface{}{
+				"text":   "Write report",
+				"due":    "next friday",
+				"effort": "3",
+				"owner":  "Bob Smith",
+			},
+		},
+		{
+			name: "ignores urls, issue refs and emails",
+			text: "See https://example.com/x for #123 mail bob@example.com",
+			want: map[string]interface{}{"text": "See https://example.com/x for #123 mail bob@example.com"},
+		},
+		{
+			name: "multiple mentions",
+			text: "Pair with @bob and @carol",
+			want: map[string]interface{}{
+				"text":     "Pair with and",
+				"assignee": "bob",
+				"mentions": []string{"bob", "carol"},
+			},
+		},
+		{
+			name: "reserved keys stay in text",
+			text: "Mark done:yes",
+			want: map[string]interface{}{"text": "Mark done:yes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := map[string]interface{}{"text": tt.text}
+			applyInlineMetadata(row)
+			if !reflect.DeepEqual(row, tt.want) {
+				t.Errorf("row = %#v, want %#v", row, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteInlineMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		data map[string]interface{}
+		want string
+	}{
+		{
+			name: "update key:value in place",
+			text: "Renew cert due:2026-01-05 @alice",
+			data: map[string]interface{}{"due": "2026-02-01"},
+			want: "Renew cert due:2026-02-01 @alice",
+		},
+		{
+			name: "update text keeps fields",
+			text: "Renew cert due:2026-01-05 #ops",
+			data: map[string]interface{}{"text": "Renew TLS certificate"},
+			want: "Renew TLS certificate due:2026-01-05 #ops",
+		},
+		{
+			name: "replace tags",
+			text: "Deploy #ops #old",
+			data: map[string]interface{}{"tags": "ops, release"},
+			want: "Deploy #ops #release",
+		},
+		{
+			name: "reassign and reprioritize",
+			text: "Deploy @alice !low",
+			data: map[string]interface{}{"assignee": "bob", "priority": "high"},
+			want: "Deploy @bob !high",
+		},
+		{
+			name: "keep dataview syntax",
+			text: "Report [due:: friday] (effort:: 3)",
+			data: map[string]interface{}{"due": "monday", "effort": 5},
+			want: "Report [due:: monday] (effort:: 5)",
+		},
+		{
+			name: "remove field with empty value",
+			text: "Deploy due:2026-01-05 !high",
+			data: map[string]interface{}{"due": ""},
+			want: "Deploy !high",
+		},
+		{
+			name: "append new fields",
+			text: "Deploy",
+			data: map[string]interface{}{"due": "2026-01-05", "assignee": "alice", "status": "in progress"},
+			want: "Deploy @alice due:2026-01-05 [status:: in progress]",
+		},
+		{
+			name: "undeclared keys are not written",
+			text: "Deploy",
+			data: map[string]interface{}{"column": "doing", "comment": "x", "priority": "low"},
+			want: "Deploy !low",
+		},
+		{
+			name: "new fields go before trailing dataview field",
+			text: "Deploy owner:: Bob Smith",
+			data: map[string]interface{}{"priority": "high"},
+			want: "Deploy !high owner:: Bob Smith",
+		},
+		{
+			name: "reserved keys are not written",
+			text: "Deploy",
+			data: map[string]interface{}{"id": "abc", "ID": "abc", "Id": "abc", "done": true, "depth": 1},
+			want: "Deploy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteInlineMetadata(tt.text, tt.data, map[string]bool{"status": true}); got != tt.want {
+				t.Errorf("rewriteInlineMetadata() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownSourceInlineMetadataRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdContent := `# Tasks {#tasks}
+
+- [ ] Renew cert due:2026-01-05 @alice #ops <!-- id:t1 -->
+- [x] Rotate keys !high <!-- id:t2 -->
+`
+	mdPath := filepath.Join(tmpDir, "test.md")
+	if err := os.WriteFile(mdPath, []byte(mdContent), 0644); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+
+	readonly := false
+	src, err := NewMarkdownSourceWithConfig("tasks", config.SourceConfig{
+		Type:     "markdown",
+		File:     "test.md",
+		Anchor:   "#tasks",
+		Readonly: &readonly,
+		Options:  map[string]string{"inline_metadata": "true"},
+	}, tmpDir, filepath.Join(tmpDir, "index.md"))
+	if err != nil {
+		t.Fatalf("NewMarkdownSourceWithConfig() error = %v", err)
+	}
+
+	results, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if results[0]["text"] != "Renew cert" || results[0]["due"] != "2026-01-05" || results[0]["assignee"] != "alice" {
+		t.Errorf("unexpected first row: %v", results[0])
+	}
+	if results[1]["priority"] != "high" || results[1]["done"] != true {
+		t.Errorf("unexpected second row: %v", results[1])
+	}
+
+	if err := src.WriteItem(context.Background(), "update", map[string]interface{}{
+		"id":  "t1",
+		"due": "2026-02-01",
+	}); err != nil {
+		t.Fatalf("WriteItem(update) error = %v", err)
+	}
+	if err := src.WriteItem(context.Background(), "add", map[string]interface{}{
+		"text":     "Audit access",
+		"priority": "low",
+	}); err != nil {
+		t.Fatalf("WriteItem(add) error = %v", err)
+	}
+
+	content, _ := os.ReadFile(mdPath)
+	if !strings.Contains(string(content), "- [ ] Renew cert due:2026-02-01 @alice #ops <!-- id:t1 -->") {
+		t.Errorf("update should rewrite the field in place, got:\n%s", content)
+	}
+	if !regexp.MustCompile(`- \[ \] Audit access !low <!-- id:\w+ -->`).Match(content) {
+		t.Errorf("add should write fields inline, got:\n%s", content)
+	}
+}
@@ -0,0 +1,290 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Nested task and bullet lists are represented as parent/child rows.
+// Indentation determines nesting: an item indented further than the item
+// above it is that item's child.
+
+// defaultListIndent is the indentation used for sub-items when a parent has none yet
+const defaultListIndent = 2
+
+var checkboxPattern = regexp.MustCompile(`^(\s*-\s+\[)([ xX])(\])`)
+
+// nestListRows links list rows into a hierarchy using their indentation.
+// When any item is nested, every row gets parent_id ("" for top-level items)
+// and depth (0 for top-level items). The rows stay a flat list; the tree view
+// is built from them once they are coerced and transformed.
+// Flat lists are left unchanged.
+func nestListRows(rows []map[string]interface{}, indents []int) {
+	nested := false
+	for _, indent := range indents {
+		if indent > indents[0] {
+			nested = true
+			break
+		}
+	}
+	if !nested {
+		return
+	}
+
+	// stack holds the indices of the current chain of ancestors
+	var stack []int
+	for i, row := range rows {
+		for len(stack) > 0 && indents[stack[len(stack)-1]] >= indents[i] {
+			stack = stack[:len(stack)-1]
+		}
+
+		row["parent_id"] = ""
+		row["depth"] = len(stack)
+		if len(stack) > 0 {
+			row["parent_id"] = rows[stack[len(stack)-1]]["id"]
+		}
+
+		stack = append(stack, i)
+	}
+}
+
+// indentWidth returns the indentation of a line in columns (tabs count to the next multiple of 4)
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// subtreeEnd returns the index just past the last line nested under the item at idx
+func subtreeEnd(lines []string, idx int) int {
+	indent := indentWidth(lines[idx])
+	end := idx + 1
+	for n := idx + 1; n < len(lines); n++ {
+		if strings.TrimSpace(lines[n]) == "" {
+			continue
+		}
+		if indentWidth(lines[n]) <= indent {
+			break
+		}
+		end = n + 1
+	}
+	return end
+}
+
+// childIndent returns the indentation for a new sub-item of the item at idx,
+// matching existing sub-items if there are any
+func childIndent(lines []string, idx int) int {
+	if end := subtreeEnd(lines, idx); end > idx+1 {
+		for n := idx + 1; n < end; n++ {
+			if strings.TrimSpace(lines[n]) != "" {
+				return indentWidth(lines[n])
+			}
+		}
+	}
+	return indentWidth(lines[idx]) + defaultListIndent
+}
+
+// reindent shifts a block of lines so that its first line has the given indentation,
+// preserving the relative indentation of the lines below it
+func reindent(block []string, indent int) []string {
+	delta := indent - indentWidth(block[0])
+	result := make([]string, len(block))
+	for i, line := range block {
+		if strings.TrimSpace(line) == "" {
+			result[i] = line
+			continue
+		}
+		width := max(indentWidth(line)+delta, 0)
+		result[i] = strings.Repeat(" ", width) + strings.TrimLeft(line, " \t")
+	}
+	return result
+}
+
+// isChecked reports whether a task line's checkbox is checked
+func isChecked(line string) bool {
+	matches := checkboxPattern.FindStringSubmatch(line)
+	return matches != nil && matches[2] != " "
+}
+
+// setCheckbox sets the checkbox state of a task line; other lines are returned unchanged
+func setCheckbox(line string, done bool) string {
+	mark := " "
+	if done {
+		mark = "x"
+	}
+	return checkboxPattern.ReplaceAllString(line, "${1}"+mark+"${3}")
+}
+
+// insertChild adds newLine as the last sub-item of the item with parentID
+func (s *MarkdownSource) insertChild(sectionContent, format, parentID, newLine string) (string, error) {
+	lines := strings.Split(sectionContent, "\n")
+	idx := s.findItemLine(lines, format, parentID)
+	if idx < 0 {
+		return "", fmt.Errorf("parent item with id %q not found", parentID)
+	}
+
+	end := subtreeEnd(lines, idx)
+	child := strings.Repeat(" ", childIndent(lines, idx)) + newLine
+
+	newLines := append(lines[:end:end], child)
+	newLines = append(newLines, lines[end:]...)
+	return strings.Join(newLines, "\n"), nil
+}
+
+// moveItem moves an item and its sub-items to become the last sub-item of parent_id,
+// or a top-level item at the end of the list when parent_id is empty
+func (s *MarkdownSource) moveItem(sectionContent, format string, data map[string]interface{}) (string, error) {
+	if format != "task" && format != "bullet" {
+		return "", fmt.Errorf("move action only supported for task and bullet lists")
+	}
+
+	id, _ := data["id"].(string)
+	if id == "" {
+		return "", fmt.Errorf("move action requires 'id' field")
+	}
+	parentID, _ := data["parent_id"].(string)
+
+	lines := strings.Split(sectionContent, "\n")
+	idx := s.findItemLine(lines, format, id)
+	if idx < 0 {
+		return "", fmt.Errorf("item with id %q not found", id)
+	}
+	end := subtreeEnd(lines, idx)
+	block := lines[idx:end]
+
+	if parentID == "" {
+		// Top level: after the last list item in the section
+		last := idx
+		for n := range lines {
+			if s.extractItemID(lines[n], format) != "" {
+				last = n
+			}
+		}
+		block = reindent(block, s.topLevelIndent(lines, format))
+		return spliceBlock(lines, idx, end, subtreeEnd(lines, last), block), nil
+	}
+
+	parentIdx := s.findItemLine(lines, format, parentID)
+	if parentIdx < 0 {
+		return "", fmt.Errorf("parent item with id %q not found", parentID)
+	}
+	if parentIdx >= idx && parentIdx < end {
+		return "", fmt.Errorf("cannot move item %q under itself", id)
+	}
+
+	return spliceBlock(lines, idx, end, subtreeEnd(lines, parentIdx), reindent(block, childIndent(lines, parentIdx))), nil
+}
+
+// indentItem makes an item the last sub-item of its previous sibling
+func (s *MarkdownSource) indentItem(sectionContent, format string, data map[string]interface{}) (string, error) {
+	if format != "task" && format != "bullet" {
+		return "", fmt.Errorf("indent action only supported for task and bullet lists")
+	}
+
+	id, _ := data["id"].(string)
+	if id == "" {
+		return "", fmt.Errorf("indent action requires 'id' field")
+	}
+
+	lines := strings.Split(sectionContent, "\n")
+	idx := s.findItemLine(lines, format, id)
+	if idx < 0 {
+		return "", fmt.Errorf("item with id %q not found", id)
+	}
+
+	// Previous sibling: nearest item above at the same indentation, before reaching a parent
+	indent := indentWidth(lines[idx])
+	sibling := -1
+	for n := idx - 1; n >= 0; n-- {
+		if s.extractItemID(lines[n], format) == "" {
+			continue
+		}
+		if w := indentWidth(lines[n]); w == indent {
+			sibling = n
+			break
+		} else if w < indent {
+			break
+		}
+	}
+	if sibling < 0 {
+		return "", fmt.Errorf("item %q has no previous sibling to indent under", id)
+	}
+
+	end := subtreeEnd(lines, idx)
+	block := reindent(lines[idx:end], childIndent(lines, sibling))
+	// The item already directly follows the sibling's subtree, so it stays in place
+	return spliceBlock(lines, idx, end, end, block), nil
+}
+
+// outdentItem makes an item a sibling of its parent, placed right after the parent's sub-items
+func (s *MarkdownSource) outdentItem(sectionContent, format string, data map[string]interface{}) (string, error) {
+	if format != "task" && format != "bullet" {
+		return "", fmt.Errorf("outdent action only supported for task and bullet lists")
+	}
+
+	id, _ := data["id"].(string)
+	if id == "" {
+		return "", fmt.Errorf("outdent action requires 'id' field")
+	}
+
+	lines := strings.Split(sectionContent, "\n")
+	idx := s.findItemLine(lines, format, id)
+	if idx < 0 {
+		return "", fmt.Errorf("item with id %q not found", id)
+	}
+
+	indent := indentWidth(lines[idx])
+	parent := -1
+	for n := idx - 1; n >= 0; n-- {
+		if s.extractItemID(lines[n], format) != "" && indentWidth(lines[n]) < indent {
+			parent = n
+			break
+		}
+	}
+	if parent < 0 {
+		return "", fmt.Errorf("item %q is already a top-level item", id)
+	}
+
+	end := subtreeEnd(lines, idx)
+	block := reindent(lines[idx:end], indentWidth(lines[parent]))
+	return spliceBlock(lines, idx, end, subtreeEnd(lines, parent), block), nil
+}
+
+// topLevelIndent returns the indentation of the first list item in the section
+func (s *MarkdownSource) topLevelIndent(lines []string, format string) int {
+	for _, line := range lines {
+		if s.extractItemID(line, format) != "" {
+			return indentWidth(line)
+		}
+	}
+	return 0
+}
+
+// spliceBlock removes lines[start:end] and inserts block before lines[insertAt].
+// An insertAt inside the removed range leaves the block in place.
+func spliceBlock(lines []string, start, end, insertAt int, block []string) string {
+	var result []string
+	for n := 0; n <= len(lines); n++ {
+		if n == insertAt {
+			result = append(result, block...)
+		}
+		if n == len(lines) {
+			break
+		}
+		if n >= start && n < end {
+			continue
+		}
+		result = append(result, lines[n])
+	}
+	return strings.Join(result, "\n")
+}
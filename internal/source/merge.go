@@ -0,0 +1,128 @@
+package source
+
+// mergeLines performs a line-level three-way merge of ours and theirs against
+// their common ancestor base (diff3 style).
+//
+// Hunks changed on only one side are taken from that side. Hunks changed
+// identically on both sides are taken once. Pure insertions at the same point
+// on both sides are kept in order (theirs, then ours), since list items
+// appended concurrently do not overlap. Hunks where both sides only replaced
+// lines one-for-one are merged line by line, so edits to adjacent list items do
+// not conflict. Any other hunk changed on both sides is a conflict: the merged
+// output contains git-style conflict markers and conflict is true.
+func mergeLines(base, ours, theirs []string) (merged []string, conflict bool) {
+	matchOurs := lcsMatches(base, ours)
+	matchTheirs := lcsMatches(base, theirs)
+
+	i, j, k := 0, 0, 0
+	for i < len(base) || j < len(ours) || k < len(theirs) {
+		// Stable line: unchanged on both sides
+		if i < len(base) && matchOurs[i] == j && matchTheirs[i] == k {
+			merged = append(merged, base[i])
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		// Find the next base line that is unchanged on both sides
+		next := i
+		for next < len(base) && (matchOurs[next] < 0 || matchTheirs[next] < 0) {
+			next++
+		}
+		endOurs, endTheirs := len(ours), len(theirs)
+		if next < len(base) {
+			endOurs, endTheirs = matchOurs[next], matchTheirs[next]
+		}
+
+		baseHunk := base[i:next]
+		oursHunk := ours[j:endOurs]
+		theirsHunk := theirs[k:endTheirs]
+
+		switch {
+		case equalLines(oursHunk, baseHunk):
+			merged = append(merged, theirsHunk...)
+		case equalLines(theirsHunk, baseHunk), equalLines(oursHunk, theirsHunk):
+			merged = append(merged, oursHunk...)
+		case len(baseHunk) == 0:
+			merged = append(merged, theirsHunk...)
+			merged = append(merged, oursHunk...)
+		case len(oursHunk) == len(baseHunk) && len(theirsHunk) == len(baseHunk):
+			for n := range baseHunk {
+				switch {
+				case oursHunk[n] == baseHunk[n], oursHunk[n] == theirsHunk[n]:
+					merged = append(merged, theirsHunk[n])
+				case theirsHunk[n] == baseHunk[n]:
+					merged = append(merged, oursHunk[n])
+				default:
+					conflict = true
+					merged = appendConflict(merged, oursHunk[n:n+1], theirsHunk[n:n+1])
+				}
+			}
+		default:
+			conflict = true
+			merged = appendConflict(merged, oursHunk, theirsHunk)
+		}
+
+		i, j, k = next, endOurs, endTheirs
+	}
+
+	return merged, conflict
+}
+
+// appendConflict appends both sides of a conflicting hunk with git-style markers
+func appendConflict(merged, ours, theirs []string) []string {
+	merged = append(merged, "<<<<<<< ours")
+	merged = append(merged, ours...)
+	merged = append(merged, "=======")
+	merged = append(merged, theirs...)
+	return append(merged, ">>>>>>> theirs")
+}
+
+// lcsMatches returns, for each line of a, the index of the matching line in b
+// according to a longest common subsequence, or -1 if the line was removed.
+func lcsMatches(a, b []string) []int {
+	// lengths[x][y] is the LCS length of a[x:] and b[y:]
+	lengths := make([][]int, len(a)+1)
+	for x := range lengths {
+		lengths[x] = make([]int, len(b)+1)
+	}
+	for x := len(a) - 1; x >= 0; x-- {
+		for y := len(b) - 1; y >= 0; y-- {
+			if a[x] == b[y] {
+				lengths[x][y] = lengths[x+1][y+1] + 1
+			} else {
+				lengths[x][y] = max(lengths[x+1][y], lengths[x][y+1])
+			}
+		}
+	}
+
+	matches := make([]int, len(a))
+	for x := range matches {
+		matches[x] = -1
+	}
+	x, y := 0, 0
+	for x < len(a) && y < len(b) {
+		switch {
+		case a[x] == b[y]:
+			matches[x] = y
+			x, y = x+1, y+1
+		case lengths[x+1][y] >= lengths[x][y+1]:
+			x++
+		default:
+			y++
+		}
+	}
+	return matches
+}
+
+// equalLines reports whether two line slices are identical
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
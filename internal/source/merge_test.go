@@ -0,0 +1,106 @@
+package source
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeLines(t *testing.T) {
+	tests := []struct {
+		name         string
+		base         string
+		ours         string
+		theirs       string
+		want         string
+		wantConflict bool
+	}{
+		{
+			name:   "no changes",
+			base:   "a\nb\nc",
+			ours:   "a\nb\nc",
+			theirs: "a\nb\nc",
+			want:   "a\nb\nc",
+		},
+		{
+			name:   "only ours changed",
+			base:   "a\nb\nc",
+			ours:   "a\nB\nc",
+			theirs: "a\nb\nc",
+			want:   "a\nB\nc",
+		},
+		{
+			name:   "only theirs changed",
+			base:   "a\nb\nc",
+			ours:   "a\nb\nc",
+			theirs: "a\nb\nC",
+			want:   "a\nb\nC",
+		},
+		{
+			name:   "non-overlapping changes",
+			base:   "a\nb\nc\nd",
+			ours:   "A\nb\nc\nd",
+			theirs: "a\nb\nc\nD",
+			want:   "A\nb\nc\nD",
+		},
+		{
+			name:   "adjacent line edits",
+			base:   "a\nb\nc",
+			ours:   "a\nB\nc",
+			theirs: "A\nb\nc",
+			want:   "A\nB\nc",
+		},
+		{
+			name:   "same change on both sides",
+			base:   "a\nb\nc",
+			ours:   "a\nX\nc",
+			theirs: "a\nX\nc",
+			want:   "a\nX\nc",
+		},
+		{
+			name:   "both append",
+			base:   "a\nb\n",
+			ours:   "a\nb\nours\n",
+			theirs: "a\nb\ntheirs\n",
+			want:   "a\nb\ntheirs\nours\n",
+		},
+		{
+			name:   "delete and insert elsewhere",
+			base:   "a\nb\nc",
+			ours:   "a\nb\nc\nd",
+			theirs: "b\nc",
+			want:   "b\nc\nd",
+		},
+		{
+			name:         "overlapping edits",
+			base:         "a\nb\nc",
+			ours:         "a\nours\nc",
+			theirs:       "a\ntheirs\nc",
+			want:         "a\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\nc",
+			wantConflict: true,
+		},
+		{
+			name:         "edit versus delete",
+			base:         "a\nb\nc",
+			ours:         "a\nB\nc",
+			theirs:       "a\nc",
+			want:         "a\n<<<<<<< ours\nB\n=======\n>>>>>>> theirs\nc",
+			wantConflict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflict := mergeLines(
+				strings.Split(tt.base, "\n"),
+				strings.Split(tt.ours, "\n"),
+				strings.Split(tt.theirs, "\n"),
+			)
+			if conflict != tt.wantConflict {
+				t.Errorf("conflict = %v, want %v", conflict, tt.wantConflict)
+			}
+			if got := strings.Join(merged, "\n"); got != tt.want {
+				t.Errorf("merged = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
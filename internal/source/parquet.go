@@ -0,0 +1,249 @@
+package source
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/apache/arrow-go/v18/parquet/schema"
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// parquetBatchSize is the number of rows decoded at a time
+const parquetBatchSize = 4096
+
+// ParquetSource reads rows from a local Parquet file.
+//
+// Only the needed columns are decoded (see columnarSettings.project), row
+// groups whose min/max statistics rule out a filter are skipped without being
+// read, and the remaining row groups are decoded in batches, so reading stops
+// early once options.limit rows match.
+type ParquetSource struct {
+	name     string
+	filePath string
+	siteDir  string
+	settings columnarSettings
+}
+
+// NewParquetSource creates a new Parquet file source
+func NewParquetSource(name string, cfg config.SourceConfig, siteDir string) (*ParquetSource, error) {
+	if cfg.File == "" {
+		return nil, fmt.Errorf("parquet source %q: file is required", name)
+	}
+	settings, err := newColumnarSettings(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &ParquetSource{
+		name:     name,
+		filePath: cfg.File,
+		siteDir:  siteDir,
+		settings: settings,
+	}, nil
+}
+
+// Name returns the source identifier
+func (s *ParquetSource) Name() string {
+	return s.name
+}
+
+// Fetch reads the matching row groups and converts their rows
+func (s *ParquetSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	path := s.resolvePath(s.filePath)
+
+	rdr, err := file.OpenParquetFile(path, false)
+	if err != nil {
+		return nil, fmt.Errorf("parquet source %q: failed to open file: %w", s.name, err)
+	}
+	defer rdr.Close()
+
+	fr, err := pqarrow.NewFileReader(rdr, pqarrow.ArrowReadProperties{BatchSize: parquetBatchSize}, memory.DefaultAllocator)
+	if err != nil {
+		return nil, fmt.Errorf("parquet source %q: invalid Parquet file: %w", s.name, err)
+	}
+
+	leaves, err := s.projectLeaves(fr.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	rowGroups := s.matchingRowGroups(rdr)
+
+	rows := []map[string]interface{}{}
+	if len(rowGroups) == 0 {
+		return rows, nil
+	}
+
+	rr, err := fr.GetRecordReader(ctx, leaves, rowGroups)
+	if err != nil {
+		return nil, fmt.Errorf("parquet source %q: failed to read file: %w", s.name, err)
+	}
+	defer rr.Release()
+
+	for !s.settings.full(rows) && rr.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rows = s.settings.appendRecordRows(rows, rr.RecordBatch(), nil)
+	}
+	if err := rr.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parquet source %q: failed to read file: %w", s.name, err)
+	}
+	return rows, nil
+}
+
+// projectLeaves returns the leaf columns of the projected fields (nil for all)
+func (s *ParquetSource) projectLeaves(manifest *pqarrow.SchemaManifest) ([]int, error) {
+	names := make([]string, len(manifest.Fields))
+	for i, field := range manifest.Fields {
+		names[i] = field.Field.Name
+	}
+
+	fields, err := s.settings.project(s.name, names)
+	if err != nil || fields == nil {
+		return nil, err
+	}
+
+	var leaves []int
+	var collect func(field *pqarrow.SchemaField)
+	collect = func(field *pqarrow.SchemaField) {
+		if field.IsLeaf() {
+			leaves = append(leaves, field.ColIndex)
+		}
+		for i := range field.Children {
+			collect(&field.Children[i])
+		}
+	}
+	for _, i := range fields {
+		collect(&manifest.Fields[i])
+	}
+	return leaves, nil
+}
+
+// matchingRowGroups returns the row groups that may contain matching rows:
+// a comparison filter (==, <, <=, >, >=) on a column rules out a row group
+// when the value falls outside the column's min/max statistics.
+func (s *ParquetSource) matchingRowGroups(rdr *file.Reader) []int {
+	meta := rdr.MetaData()
+	var groups []int
+	for g := 0; g < rdr.NumRowGroups(); g++ {
+		rowGroup := meta.RowGroup(g)
+		keep := true
+		for _, p := range s.settings.filters {
+			column := meta.Schema.ColumnIndexByName(p.field)
+			if column < 0 {
+				continue
+			}
+			chunk, err := rowGroup.ColumnChunk(column)
+			if err != nil {
+				continue
+			}
+			if lo, hi, ok := chunkRange(chunk); ok && !p.mayMatchRange(lo, hi) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// chunkRange returns a column chunk's min and max values, as float64 for
+// plain numeric columns or string for UTF-8 columns. Other types (dates,
+// timestamps, decimals, binary) are not compared.
+func chunkRange(chunk *metadata.ColumnChunkMetaData) (lo, hi interface{}, ok bool) {
+	if set, err := chunk.StatsSet(); err != nil || !set {
+		return nil, nil, false
+	}
+	stats, err := chunk.Statistics()
+	if err != nil || stats == nil || !stats.HasMinMax() {
+		return nil, nil, false
+	}
+
+	switch logical := stats.Descr().LogicalType().(type) {
+	case schema.NoLogicalType:
+	case schema.IntLogicalType:
+		if !logical.IsSigned() {
+			return nil, nil, false
+		}
+	case schema.StringLogicalType:
+		if st, ok := stats.(*metadata.ByteArrayStatistics); ok {
+			return string(st.Min()), string(st.Max()), true
+		}
+		return nil, nil, false
+	default:
+		return nil, nil, false
+	}
+
+	switch st := stats.(type) {
+	case *metadata.Int32Statistics:
+		return float64(st.Min()), float64(st.Max()), true
+	case *metadata.Int64Statistics:
+		return float64(st.Min()), float64(st.Max()), true
+	case *metadata.Float32Statistics:
+		return float64(st.Min()), float64(st.Max()), true
+	case *metadata.Float64Statistics:
+		return st.Min(), st.Max(), true
+	}
+	return nil, nil, false
+}
+
+// mayMatchRange reports whether a value between lo and hi could match the
+// predicate, comparing like match does: numbers numerically, and strings
+// (when the filter value is not a number) lexically.
+func (p filterPredicate) mayMatchRange(lo, hi interface{}) bool {
+	var cmpLo, cmpHi int // Sign of (lo - value) and (hi - value)
+	switch low := lo.(type) {
+	case float64:
+		v, err := strconv.ParseFloat(p.value, 64)
+		if err != nil {
+			return true
+		}
+		cmpLo, cmpHi = cmp.Compare(low, v), cmp.Compare(hi.(float64), v)
+	case string:
+		if _, err := strconv.ParseFloat(p.value, 64); err == nil {
+			// Numeric strings compare as numbers, which lexical bounds can't rule out
+			return true
+		}
+		cmpLo, cmpHi = cmp.Compare(low, p.value), cmp.Compare(hi.(string), p.value)
+	default:
+		return true
+	}
+
+	switch p.op {
+	case "==":
+		return cmpLo <= 0 && cmpHi >= 0
+	case "<":
+		return cmpLo < 0
+	case "<=":
+		return cmpLo <= 0
+	case ">":
+		return cmpHi > 0
+	case ">=":
+		return cmpHi >= 0
+	default:
+		return true
+	}
+}
+
+// Close is a no-op for file sources
+func (s *ParquetSource) Close() error {
+	return nil
+}
+
+// resolvePath makes a path absolute relative to siteDir
+func (s *ParquetSource) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.siteDir, path)
+}
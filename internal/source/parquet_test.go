@@ -0,0 +1,320 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// ordersSchema is the schema of the test order files
+var ordersSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+	{Name: "customer", Type: arrow.BinaryTypes.String},
+	{Name: "total", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	{Name: "placed", Type: &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}},
+	{Name: "tags", Type: arrow.ListOf(arrow.BinaryTypes.String)},
+}, nil)
+
+// ordersRecord builds n orders starting at id first. Every tenth order has no total.
+func ordersRecord(first, n int) arrow.RecordBatch {
+	b := array.NewRecordBuilder(memory.DefaultAllocator, ordersSchema)
+	defer b.Release()
+
+	placed := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for id := first; id < first+n; id++ {
+		b.Field(0).(*array.Int64Builder).Append(int64(id))
+		b.Field(1).(*array.StringBuilder).Append([]string{"acme", "globex", "initech"}[id%3])
+		if id%10 == 0 {
+			b.Field(2).AppendNull()
+		} else {
+			b.Field(2).(*array.Float64Builder).Append(float64(id) * 10)
+		}
+		b.Field(3).(*array.TimestampBuilder).Append(arrow.Timestamp(placed.AddDate(0, 0, id).UnixMilli()))
+		tags := b.Field(4).(*array.ListBuilder)
+		tags.Append(true)
+		tags.ValueBuilder().(*array.StringBuilder).Append("order")
+	}
+	return b.NewRecordBatch()
+}
+
+// writeOrdersParquet writes 100 orders in row groups of 25
+func writeOrdersParquet(t *testing.T, path string) {
+	t.Helper()
+	rec := ordersRecord(1, 100)
+	defer rec.Release()
+	table := array.NewTableFromRecords(ordersSchema, []arrow.RecordBatch{rec})
+	defer table.Release()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	props := parquet.NewWriterProperties(parquet.WithStats(true))
+	if err := pqarrow.WriteTable(table, f, 25, props, pqarrow.DefaultWriterProps()); err != nil {
+		t.Fatalf("failed to write parquet file: %v", err)
+	}
+}
+
+// writeOrdersArrow writes 100 orders as 4 record batches, in the IPC file or stream format
+func writeOrdersArrow(t *testing.T, path string, stream bool) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var w interface {
+		Write(arrow.RecordBatch) error
+		Close() error
+	}
+	if stream {
+		w = ipc.NewWriter(f, ipc.WithSchema(ordersSchema))
+	} else {
+		w, err = ipc.NewFileWriter(f, ipc.WithSchema(ordersSchema))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for first := 1; first <= 100; first += 25 {
+		rec := ordersRecord(first, 25)
+		err := w.Write(rec)
+		rec.Release()
+		if err != nil {
+			t.Fatalf("failed to write record batch: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParquetSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeOrdersParquet(t, filepath.Join(tmpDir, "orders.parquet"))
+	ctx := context.Background()
+
+	src, err := NewParquetSource("orders", config.SourceConfig{File: "orders.parquet"}, tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	rows, err := src.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(rows) != 100 {
+		t.Fatalf("got %d rows, want 100", len(rows))
+	}
+	first := rows[0]
+	if first["id"] != int64(1) || first["customer"] != "globex" || first["total"] != 10.0 {
+		t.Errorf("unexpected row: %#v", first)
+	}
+	if placed, ok := first["placed"].(time.Time); !ok || !placed.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("placed = %#v, want 2026-01-02", first["placed"])
+	}
+	if tags, ok := first["tags"].([]interface{}); !ok || len(tags) != 1 || tags[0] != "order" {
+		t.Errorf("tags = %#v, want [order]", first["tags"])
+	}
+	if rows[9]["total"] != nil {
+		t.Errorf("total = %#v, want nil", rows[9]["total"])
+	}
+}
+
+func TestParquetSource_Projection(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeOrdersParquet(t, filepath.Join(tmpDir, "orders.parquet"))
+
+	// The configured columns, the filter columns and the id are read
+	src, _ := NewParquetSource("orders", config.SourceConfig{
+		File:    "orders.parquet",
+		Columns: []string{"customer"},
+		Filter:  []string{"total > 500"},
+	}, tmpDir)
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(rows) != 45 {
+		t.Fatalf("got %d rows, want 45", len(rows))
+	}
+	if len(rows[0]) != 3 || rows[0]["id"] != int64(51) || rows[0]["customer"] != "acme" || rows[0]["total"] != 510.0 {
+		t.Errorf("unexpected row: %#v", rows[0])
+	}
+
+	src, _ = NewParquetSource("orders", config.SourceConfig{File: "orders.parquet", Columns: []string{"amount"}}, tmpDir)
+	if _, err := src.Fetch(context.Background()); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
+
+func TestParquetSource_Pushdown(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "orders.parquet")
+	writeOrdersParquet(t, path)
+
+	tests := []struct {
+		filter     string
+		wantGroups []int
+		wantRows   int
+	}{
+		{"id > 60", []int{2, 3}, 40},
+		{"id <= 25", []int{0}, 25},
+		{"id == 30", []int{1}, 1},
+		{"id != 30", []int{0, 1, 2, 3}, 99},
+		{"id > 100", nil, 0},
+		{"total >= 990", []int{3}, 1},
+		{"customer == zzz", nil, 0},
+		{"customer == acme", []int{0, 1, 2, 3}, 33},
+		{"customer contains glob", []int{0, 1, 2, 3}, 34},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			src, err := NewParquetSource("orders", config.SourceConfig{File: "orders.parquet", Filter: []string{tt.filter}}, tmpDir)
+			if err != nil {
+				t.Fatalf("failed to create source: %v", err)
+			}
+
+			rdr, err := file.OpenParquetFile(path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			groups := src.matchingRowGroups(rdr)
+			rdr.Close()
+			if len(groups) != len(tt.wantGroups) {
+				t.Fatalf("row groups = %v, want %v", groups, tt.wantGroups)
+			}
+			for i := range groups {
+				if groups[i] != tt.wantGroups[i] {
+					t.Fatalf("row groups = %v, want %v", groups, tt.wantGroups)
+				}
+			}
+
+			rows, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("fetch failed: %v", err)
+			}
+			if len(rows) != tt.wantRows {
+				t.Errorf("got %d rows, want %d", len(rows), tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestParquetSource_Limit(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeOrdersParquet(t, filepath.Join(tmpDir, "orders.parquet"))
+
+	src, _ := NewParquetSource("orders", config.SourceConfig{
+		File:    "orders.parquet",
+		Filter:  []string{"customer == initech"},
+		Options: map[string]string{"limit": "5"},
+	}, tmpDir)
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(rows) != 5 || rows[4]["id"] != int64(14) {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	if _, err := NewParquetSource("orders", config.SourceConfig{File: "orders.parquet", Options: map[string]string{"limit": "0"}}, tmpDir); err == nil {
+		t.Error("expected error for invalid limit")
+	}
+	if _, err := NewParquetSource("orders", config.SourceConfig{}, tmpDir); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestArrowSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeOrdersArrow(t, filepath.Join(tmpDir, "orders.arrow"), false)
+	writeOrdersArrow(t, filepath.Join(tmpDir, "orders.arrows"), true)
+	os.WriteFile(filepath.Join(tmpDir, "orders.csv"), []byte("id\n1\n"), 0644)
+
+	for _, file := range []string{"orders.arrow", "orders.arrows"} {
+		t.Run(file, func(t *testing.T) {
+			src, err := NewArrowSource("orders", config.SourceConfig{File: file}, tmpDir)
+			if err != nil {
+				t.Fatalf("failed to create source: %v", err)
+			}
+			rows, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("fetch failed: %v", err)
+			}
+			if len(rows) != 100 || rows[99]["id"] != int64(100) || rows[99]["total"] != nil {
+				t.Errorf("unexpected rows: %d, last %#v", len(rows), rows[len(rows)-1])
+			}
+
+			src, _ = NewArrowSource("orders", config.SourceConfig{
+				File:    file,
+				Columns: []string{"total"},
+				Filter:  []string{"customer == acme"},
+				Options: map[string]string{"limit": "2"},
+			}, tmpDir)
+			rows, err = src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("fetch failed: %v", err)
+			}
+			if len(rows) != 2 || len(rows[1]) != 3 || rows[1]["id"] != int64(6) || rows[1]["total"] != 60.0 {
+				t.Errorf("unexpected rows: %v", rows)
+			}
+		})
+	}
+
+	src, _ := NewArrowSource("orders", config.SourceConfig{File: "orders.csv"}, tmpDir)
+	if _, err := src.Fetch(context.Background()); err == nil {
+		t.Error("expected error for non-Arrow file")
+	}
+}
+
+func TestArrowValue(t *testing.T) {
+	mem := memory.DefaultAllocator
+	typ := arrow.StructOf(
+		arrow.Field{Name: "city", Type: arrow.BinaryTypes.String},
+		arrow.Field{Name: "zip", Type: arrow.PrimitiveTypes.Uint32, Nullable: true},
+	)
+	b := array.NewStructBuilder(mem, typ)
+	defer b.Release()
+	b.Append(true)
+	b.FieldBuilder(0).(*array.StringBuilder).Append("Oslo")
+	b.FieldBuilder(1).AppendNull()
+	arr := b.NewArray()
+	defer arr.Release()
+
+	got, ok := arrowValue(arr, 0).(map[string]interface{})
+	if !ok || len(got) != 2 || got["city"] != "Oslo" || got["zip"] != nil {
+		t.Errorf("struct = %#v", arrowValue(arr, 0))
+	}
+
+	db := array.NewDictionaryBuilder(mem, &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int8, ValueType: arrow.BinaryTypes.String}).(*array.BinaryDictionaryBuilder)
+	defer db.Release()
+	db.AppendString("open")
+	db.AppendString("closed")
+	db.AppendString("open")
+	dict := db.NewArray()
+	defer dict.Release()
+	if arrowValue(dict, 1) != "closed" || arrowValue(dict, 2) != "open" {
+		t.Errorf("dictionary values = %v, %v", arrowValue(dict, 1), arrowValue(dict, 2))
+	}
+
+	date := array.NewDate32Builder(mem)
+	defer date.Release()
+	date.Append(arrow.Date32FromTime(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)))
+	dates := date.NewArray()
+	defer dates.Release()
+	if d, ok := arrowValue(dates, 0).(time.Time); !ok || !d.Equal(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("date = %#v", arrowValue(dates, 0))
+	}
+}
@@ -76,9 +76,9 @@ func NewPostgresSourceWithConfig(name, query string, options map[string]string,
 		EnableLog:  true,
 	}
 
-	// Create circuit breaker
-	cbConfig := DefaultCircuitBreakerConfig()
-	circuitBreaker := NewCircuitBreaker(name, cbConfig)
+	// Create circuit breaker (shared with the same source on other pages)
+	cbConfig := sourceCircuitBreakerConfig(cfg)
+	circuitBreaker := sharedCircuitBreaker(name, cfg, cbConfig)
 
 	return &PostgresSource{
 		name:           name,
@@ -96,6 +96,11 @@ func (s *PostgresSource) Name() string {
 	return s.name
 }
 
+// CircuitBreaker returns the circuit breaker guarding the source's requests
+func (s *PostgresSource) CircuitBreaker() *CircuitBreaker {
+	return s.circuitBreaker
+}
+
 // Fetch executes the query and returns results with retry and circuit breaker
 func (s *PostgresSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
 	return s.circuitBreaker.Execute(ctx, func(ctx context.Context) ([]map[string]interface{}, error) {
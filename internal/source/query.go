@@ -0,0 +1,279 @@
+package source
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// QuerySource runs a SQL statement over the rows of other sources. Each input
+// is loaded into an in-memory SQLite database as a table named after the
+// source. The result is kept while all inputs are served from the same cache
+// entries (see CachedSource.FetchVersion); inputs without caching rerun the
+// query on every fetch.
+type QuerySource struct {
+	name   string
+	query  string
+	tables []string
+	lookup func(string) (Source, bool)
+
+	mu      sync.Mutex
+	key     string // Versions of the input rows the result was computed from
+	rows    []map[string]interface{}
+	columns map[string][]string // Last known columns of each table, for empty inputs
+}
+
+// NewQuerySource creates a query source. lookup finds the input sources by
+// name (e.g., Registry.Get); the query source does not close them.
+func NewQuerySource(name string, cfg config.SourceConfig, lookup func(string) (Source, bool)) (*QuerySource, error) {
+	if len(cfg.Tables) == 0 {
+		return nil, &ValidationError{Source: name, Field: "tables", Reason: "tables is required (the sources to query)"}
+	}
+	if strings.TrimSpace(cfg.Query) == "" {
+		return nil, &ValidationError{Source: name, Field: "query", Reason: "query is required"}
+	}
+	for i, table := range cfg.Tables {
+		if table == "" || slices.Contains(cfg.Tables[:i], table) {
+			return nil, &ValidationError{Source: name, Field: "tables", Reason: fmt.Sprintf("invalid or duplicate table %q", table)}
+		}
+	}
+
+	return &QuerySource{
+		name:    name,
+		query:   cfg.Query,
+		tables:  cfg.Tables,
+		lookup:  lookup,
+		columns: make(map[string][]string),
+	}, nil
+}
+
+// Name returns the source identifier
+func (s *QuerySource) Name() string {
+	return s.name
+}
+
+// Fetch reads the inputs and runs the query over them. If an input served
+// stale rows, the query runs over them and its rows are returned with the
+// input's error (see StaleError).
+func (s *QuerySource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	ctx, err := enterInputChain(ctx, "query", s.name)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := make([][]map[string]interface{}, len(s.tables))
+	versions := make([]string, len(s.tables))
+	var staleErr error
+	for i, table := range s.tables {
+		src, ok := s.lookup(table)
+		if !ok {
+			return nil, &ValidationError{Source: s.name, Field: "tables", Reason: fmt.Sprintf("source %q not found", table)}
+		}
+		if inputs[i], versions[i], err = fetchVersion(ctx, src); err != nil {
+			err = fmt.Errorf("query source %q: input %q: %w", s.name, table, err)
+			if !IsStale(err) {
+				return nil, err
+			}
+			if staleErr == nil {
+				staleErr = err
+			}
+		}
+	}
+
+	// Stale inputs are unversioned, so their results are not kept
+	key := inputsKey(versions)
+	s.mu.Lock()
+	if key != "" && key == s.key {
+		rows := s.rows
+		s.mu.Unlock()
+		return rows, nil
+	}
+	s.mu.Unlock()
+
+	rows, err := s.run(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.key, s.rows = key, rows
+	s.mu.Unlock()
+	return rows, staleErr
+}
+
+// run loads the inputs into a new in-memory database and runs the query
+func (s *QuerySource) run(ctx context.Context, inputs [][]map[string]interface{}) ([]map[string]interface{}, error) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("query source %q: failed to open database: %w", s.name, err)
+	}
+	defer db.Close()
+	// Each connection to :memory: has its own database
+	db.SetMaxOpenConns(1)
+
+	for i, table := range s.tables {
+		if err := s.load(ctx, db, table, inputs[i]); err != nil {
+			return nil, fmt.Errorf("query source %q: failed to load %q: %w", s.name, table, err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, s.query)
+	if err != nil {
+		return nil, fmt.Errorf("query source %q: query failed: %w", s.name, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[col] = values[i]
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query source %q: query failed: %w", s.name, err)
+	}
+	return results, nil
+}
+
+// load creates a table for an input with a column for every key of its rows
+func (s *QuerySource) load(ctx context.Context, db *sql.DB, table string, rows []map[string]interface{}) error {
+	columns := rowColumns(rows)
+	s.mu.Lock()
+	if len(columns) == 0 {
+		// Keep the columns of earlier rows so the query still runs
+		columns = s.columns[table]
+	} else {
+		s.columns[table] = columns
+	}
+	s.mu.Unlock()
+	if len(columns) == 0 {
+		columns = []string{"_"}
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(col)
+	}
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdentifier(table), strings.Join(quoted, ", "))); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdentifier(table), strings.Join(quoted, ", "), placeholders))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	values := make([]interface{}, len(columns))
+	for _, row := range rows {
+		for i, col := range columns {
+			values[i] = sqlValue(row[col])
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// rowColumns returns the keys of the rows, sorted
+func rowColumns(rows []map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var columns []string
+	for _, row := range rows {
+		for k := range row {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	slices.Sort(columns)
+	return columns
+}
+
+// sqlValue converts a row value for SQLite; nested values are stored as JSON
+// text, which SQLite's JSON functions can read
+func sqlValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, string, []byte, time.Time,
+		int, int8, int16, int32, int64, uint8, uint16, uint32, float32, float64:
+		return v
+	case uint, uint64:
+		return fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// quoteIdentifier quotes a table or column name for SQLite
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// fetchVersion fetches the rows of an input with their version (see
+// CachedSource.FetchVersion). The version is "" if the input isn't cached.
+func fetchVersion(ctx context.Context, src Source) ([]map[string]interface{}, string, error) {
+	if versioned, ok := src.(interface {
+		FetchVersion(context.Context) ([]map[string]interface{}, string, error)
+	}); ok {
+		return versioned.FetchVersion(ctx)
+	}
+	rows, err := src.Fetch(ctx)
+	return rows, "", err
+}
+
+// inputsKey returns a key identifying the rows of the inputs, or "" if an
+// input has no version
+func inputsKey(versions []string) string {
+	if slices.Contains(versions, "") {
+		return ""
+	}
+	return strings.Join(versions, "\n")
+}
+
+// Close is a no-op; the inputs belong to the lookup's owner
+func (s *QuerySource) Close() error {
+	return nil
+}
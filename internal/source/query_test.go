@@ -0,0 +1,131 @@
+package source
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/livetemplate/tinkerdown/internal/cache"
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+func TestQuerySource(t *testing.T) {
+	inputs := map[string]*mockSource{
+		"tickets": {name: "tickets", data: []map[string]interface{}{
+			{"id": 1, "owner": "ada", "priority": "high", "tags": []interface{}{"db"}},
+			{"id": 2, "owner": "grace", "priority": "low"},
+			{"id": 3, "owner": "ada", "priority": "low", "closed": true},
+			{"id": 4, "owner": nil, "priority": "high"},
+		}},
+		"roster": {name: "roster", data: []map[string]interface{}{
+			{"login": "ada", "team": "core", "rate": 1.5},
+			{"login": "grace", "team": "web", "rate": 2.0},
+		}},
+	}
+	// The result is kept while the inputs are served from their caches
+	c := cache.NewMemoryCache()
+	defer c.Stop()
+	cached := make(map[string]*CachedSource)
+	for name, input := range inputs {
+		cached[name] = NewCachedSource(input, c, config.SourceConfig{Cache: &config.CacheConfig{TTL: "1m"}})
+	}
+	lookup := func(name string) (Source, bool) {
+		src, ok := cached[name]
+		return src, ok
+	}
+
+	src, err := NewQuerySource("counts", config.SourceConfig{
+		Tables: []string{"tickets", "roster"},
+		Query: `SELECT r.team, t.owner, count(*) AS tickets, sum(t.priority = 'high') AS urgent,
+		               max(t.closed) AS closed, max(json_extract(t.tags, '$[0]')) AS tag
+		        FROM tickets t JOIN roster r ON r.login = t.owner
+		        GROUP BY r.team, t.owner ORDER BY tickets DESC`,
+	}, lookup)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	ada := rows[0]
+	if ada["team"] != "core" || ada["owner"] != "ada" || ada["tickets"] != int64(2) || ada["urgent"] != int64(1) || ada["closed"] != int64(1) || ada["tag"] != "db" {
+		t.Errorf("unexpected row: %#v", ada)
+	}
+	if rows[1]["owner"] != "grace" || rows[1]["closed"] != nil {
+		t.Errorf("unexpected row: %#v", rows[1])
+	}
+
+	// Unchanged inputs reuse the result
+	again, err := src.Fetch(context.Background())
+	if err != nil || len(again) != 2 || &again[0] != &rows[0] {
+		t.Errorf("expected the cached result, got %v, %v", again, err)
+	}
+
+	// Refetched inputs rerun the query
+	inputs["tickets"].data = append(inputs["tickets"].data, map[string]interface{}{"id": 5, "owner": "grace", "priority": "high"})
+	if again, _ := src.Fetch(context.Background()); len(again) != 2 || again[1]["tickets"] != int64(1) {
+		t.Errorf("expected the cached result before invalidation, got %v", again)
+	}
+	cached["tickets"].Invalidate()
+	rows, err = src.Fetch(context.Background())
+	if err != nil || len(rows) != 2 || rows[0]["tickets"] != int64(2) || rows[1]["tickets"] != int64(2) {
+		t.Errorf("unexpected rows after change: %v, %v", rows, err)
+	}
+
+	// Empty inputs keep their last columns
+	inputs["tickets"].data = nil
+	cached["tickets"].Invalidate()
+	rows, err = src.Fetch(context.Background())
+	if err != nil || len(rows) != 0 {
+		t.Errorf("expected no rows, got %v, %v", rows, err)
+	}
+}
+
+func TestQuerySource_StaleInput(t *testing.T) {
+	lookup := func(name string) (Source, bool) {
+		return &staleSource{mockSource{name: name, data: derivedTestInputs[name]}}, true
+	}
+	src, err := NewQuerySource("q", config.SourceConfig{Tables: []string{"orders"}, Query: "SELECT count(*) AS n FROM orders"}, lookup)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	rows, err := src.Fetch(context.Background())
+	if !IsStale(err) {
+		t.Errorf("expected a stale error, got %v", err)
+	}
+	if len(rows) != 1 || rows[0]["n"] != int64(4) {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+}
+
+func TestQuerySource_Errors(t *testing.T) {
+	lookup := derivedInputs(derivedTestInputs)
+	for _, cfg := range []config.SourceConfig{
+		{Query: "SELECT 1"},
+		{Tables: []string{"orders"}},
+		{Tables: []string{"orders", "orders"}, Query: "SELECT 1"},
+	} {
+		if _, err := NewQuerySource("q", cfg, lookup); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+
+	for query, want := range map[string]string{
+		"SELECT * FROM nope":         "no such table",
+		"SELECT missing FROM orders": "no such column",
+	} {
+		src, _ := NewQuerySource("q", config.SourceConfig{Tables: []string{"orders"}, Query: query}, lookup)
+		if _, err := src.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected %q error, got %v", query, want, err)
+		}
+	}
+
+	src, _ := NewQuerySource("q", config.SourceConfig{Tables: []string{"missing"}, Query: "SELECT 1"}, lookup)
+	if _, err := src.Fetch(context.Background()); err == nil {
+		t.Error("expected error for missing input")
+	}
+}
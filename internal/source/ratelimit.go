@@ -0,0 +1,120 @@
+package source
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// rateLimiter is a token bucket: it holds up to burst tokens, refilled at rate
+// tokens per second, and each request takes a token
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64 // Negative when requests are waiting for tokens
+	last   time.Time
+}
+
+// newRateLimiter creates a token bucket, full
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait takes a token, waiting until one is available or ctx is done.
+// Waiting requests get tokens in the order they asked. A nil limiter never waits.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens-- // Reserve a token, possibly one not refilled yet
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back to the requests still waiting
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// tighten lowers the limits of the bucket to rate and burst, where they are
+// stricter than its own. Returns whether a limit was lowered.
+func (l *rateLimiter) tighten(rate float64, burst int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rate >= l.rate && float64(burst) >= l.burst {
+		return false
+	}
+	l.rate = math.Min(l.rate, rate)
+	l.burst = math.Min(l.burst, float64(burst))
+	l.tokens = math.Min(l.tokens, l.burst)
+	return true
+}
+
+// rateLimiters are the token buckets shared by sources, by key
+var (
+	rateLimitersMu sync.Mutex
+	rateLimiters   = make(map[string]*rateLimiter)
+)
+
+// sourceRateLimiter returns the token bucket of the rate_limit of a source,
+// shared with the sources of the same key (host or command). If their limits
+// disagree, the strictest rate and burst apply to all of them, since the
+// limit is the API's. Returns nil if the source has no rate limit.
+func sourceRateLimiter(name, key string, cfg config.SourceConfig) (*rateLimiter, error) {
+	if cfg.RateLimit == nil {
+		return nil, nil
+	}
+	if cfg.RateLimit.Requests <= 0 {
+		return nil, &ValidationError{Source: name, Field: "rate_limit.requests", Reason: "requests must be greater than 0"}
+	}
+	rate := cfg.RateLimit.Requests / cfg.GetRateLimitPer().Seconds()
+	burst := cfg.GetRateLimitBurst()
+
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+	l, ok := rateLimiters[key]
+	if !ok {
+		l = newRateLimiter(rate, burst)
+		rateLimiters[key] = l
+		return l, nil
+	}
+	if l.tighten(rate, burst) {
+		log.Printf("[source/%s] rate_limit is stricter than other sources of %s; it applies to all of them", name, key)
+	}
+	return l, nil
+}
+
+// urlHost returns the host of a URL, for sharing rate limits (the URL itself if it doesn't parse)
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
@@ -0,0 +1,130 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	l := newRateLimiter(20, 2) // A token every 50ms
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("Wait() error: %v", err)
+		}
+	}
+	// The burst passes at once, the other two wait for refills
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Errorf("4 requests took %v, want about 100ms", elapsed)
+	}
+
+	var nilLimiter *rateLimiter
+	if err := nilLimiter.Wait(ctx); err != nil {
+		t.Errorf("nil limiter returned %v", err)
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	l := newRateLimiter(1, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("cancelled wait took %v", elapsed)
+	}
+
+	// The cancelled request gave its reservation back
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < -0.1 {
+		t.Errorf("tokens = %v, want the reservation returned", tokens)
+	}
+}
+
+func TestSourceRateLimiter(t *testing.T) {
+	cfg := config.SourceConfig{RateLimit: &config.RateLimitConfig{Requests: 60, Per: "1m"}}
+	a, err := sourceRateLimiter("a", "api.example.com", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := sourceRateLimiter("b", "api.example.com", cfg)
+	other, _ := sourceRateLimiter("c", "other.example.com", cfg)
+	if a != b || a == other {
+		t.Error("expected sources of the same host to share a limiter")
+	}
+	if a.rate != 1 || a.burst != 1 {
+		t.Errorf("rate = %v, burst = %v, want 1 and 1", a.rate, a.burst)
+	}
+
+	if l, err := sourceRateLimiter("d", "api.example.com", config.SourceConfig{}); l != nil || err != nil {
+		t.Errorf("expected no limiter without rate_limit, got %v, %v", l, err)
+	}
+	if _, err := sourceRateLimiter("e", "api.example.com", config.SourceConfig{RateLimit: &config.RateLimitConfig{}}); err == nil {
+		t.Error("expected error without requests")
+	}
+}
+
+func TestSourceRateLimiterStrictest(t *testing.T) {
+	loose := config.SourceConfig{RateLimit: &config.RateLimitConfig{Requests: 10, Per: "1s", Burst: 5}}
+	strict := config.SourceConfig{RateLimit: &config.RateLimitConfig{Requests: 2, Per: "1s", Burst: 1}}
+
+	a, _ := sourceRateLimiter("a", "strict.example.com", loose)
+	b, _ := sourceRateLimiter("b", "strict.example.com", strict)
+	c, _ := sourceRateLimiter("c", "strict.example.com", loose)
+	if a != b || a != c {
+		t.Fatal("expected sources of the same host to share a limiter, whatever their limits")
+	}
+	// The strictest limits apply, also to sources created later with looser ones
+	if a.rate != 2 || a.burst != 1 {
+		t.Errorf("rate = %v, burst = %v, want 2 and 1", a.rate, a.burst)
+	}
+	if a.tokens > 1 {
+		t.Errorf("tokens = %v, want at most the new burst", a.tokens)
+	}
+}
+
+func TestRestSource_RateLimit(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer server.Close()
+
+	src, err := NewRestSourceWithConfig("limited", config.SourceConfig{
+		From:      server.URL,
+		RateLimit: &config.RateLimitConfig{Requests: 1, Per: "1h"},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Fetch(context.Background()); err != nil {
+		t.Fatalf("first fetch failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := src.Fetch(ctx); err == nil {
+		t.Error("expected the second fetch to wait for the limiter until cancelled")
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected 1 request, got %d", got)
+	}
+}
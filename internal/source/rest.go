@@ -1,6 +1,7 @@
 package source
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -13,7 +14,8 @@ import (
 	"github.com/livetemplate/tinkerdown/internal/config"
 )
 
-// RestSource fetches data from a REST API endpoint
+// RestSource fetches data from a REST API endpoint.
+// It implements WritableSource by mapping actions to HTTP requests (see endpoints).
 type RestSource struct {
 	name           string
 	url            string
@@ -21,22 +23,34 @@ type RestSource struct {
 	headers        map[string]string
 	queryParams    map[string]string
 	resultPath     string
+	format         string // Response format; empty to detect from Content-Type
+	delimiter      rune   // Field delimiter for CSV responses
+	pagination     *config.PaginationConfig
+	endpoints      map[string]config.RestEndpoint
+	readonly       bool
 	client         *http.Client
 	retryConfig    RetryConfig
 	circuitBreaker *CircuitBreaker
+	limiter        *rateLimiter // Shared per host; nil if not rate limited
+	flightKey      string       // Identifies the configuration, to coalesce fetches (see restFlights)
 }
 
+// restFlights coalesces concurrent fetches of REST sources with the same
+// configuration, e.g., created by several connections to the same page
+var restFlights flightGroup
+
 // NewRestSource creates a new REST API source (legacy, uses URL directly)
 func NewRestSource(name, apiURL string, options map[string]string) (*RestSource, error) {
 	cfg := config.SourceConfig{
 		From:    apiURL,
 		Options: options,
 	}
-	return NewRestSourceWithConfig(name, cfg)
+	return NewRestSourceWithConfig(name, cfg, "")
 }
 
-// NewRestSourceWithConfig creates a new REST API source with full configuration
-func NewRestSourceWithConfig(name string, cfg config.SourceConfig) (*RestSource, error) {
+// NewRestSourceWithConfig creates a new REST API source with full configuration.
+// siteDir is used to resolve auth file paths (token_file, certificates).
+func NewRestSourceWithConfig(name string, cfg config.SourceConfig, siteDir string) (*RestSource, error) {
 	apiURL := cfg.From
 	if apiURL == "" {
 		return nil, &ValidationError{Source: name, Field: "from", Reason: "from is required"}
@@ -87,9 +101,35 @@ func NewRestSourceWithConfig(name string, cfg config.SourceConfig) (*RestSource,
 		queryParams[key] = os.ExpandEnv(value)
 	}
 
+	format := strings.ToLower(cfg.Format)
+	if _, ok := formatAccept[format]; format != "" && !ok {
+		return nil, &ValidationError{Source: name, Field: "format", Reason: fmt.Sprintf("unknown format %q (expected json, ndjson, csv, tsv, xml, or yaml)", cfg.Format)}
+	}
+	delimiter := ','
+	if cfg.Delimiter != "" {
+		delimiter = []rune(cfg.Delimiter)[0]
+	}
+
+	if err := validatePagination(name, cfg.Pagination); err != nil {
+		return nil, err
+	}
+
+	// Write endpoints, with env vars expanded in URLs
+	endpoints := make(map[string]config.RestEndpoint, len(cfg.Endpoints))
+	for action, endpoint := range cfg.Endpoints {
+		endpoint.URL = os.ExpandEnv(endpoint.URL)
+		endpoints[strings.ToLower(action)] = endpoint
+	}
+
 	// Get timeout from config or default
 	timeout := cfg.GetTimeout()
 
+	// Build HTTP client with auth and TLS settings
+	client, err := newHTTPClient(name, cfg.Auth, siteDir, timeout)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build retry config
 	retryConfig := RetryConfig{
 		MaxRetries: cfg.GetRetryMaxRetries(),
@@ -99,9 +139,14 @@ func NewRestSourceWithConfig(name string, cfg config.SourceConfig) (*RestSource,
 		EnableLog:  true,
 	}
 
-	// Create circuit breaker
-	cbConfig := DefaultCircuitBreakerConfig()
-	circuitBreaker := NewCircuitBreaker(name, cbConfig)
+	// Create circuit breaker (shared with the same source on other pages)
+	cbConfig := sourceCircuitBreakerConfig(cfg)
+	circuitBreaker := sharedCircuitBreaker(name, cfg, cbConfig)
+
+	limiter, err := sourceRateLimiter(name, urlHost(apiURL), cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	return &RestSource{
 		name:           name,
@@ -110,11 +155,16 @@ func NewRestSourceWithConfig(name string, cfg config.SourceConfig) (*RestSource,
 		headers:        headers,
 		queryParams:    queryParams,
 		resultPath:     cfg.ResultPath,
+		format:         format,
+		delimiter:      delimiter,
+		pagination:     cfg.Pagination,
+		endpoints:      endpoints,
+		readonly:       cfg.IsReadonly(),
 		retryConfig:    retryConfig,
 		circuitBreaker: circuitBreaker,
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		limiter:        limiter,
+		flightKey:      flightKey(name, cfg),
+		client:         client,
 	}, nil
 }
 
@@ -123,12 +173,20 @@ func (s *RestSource) Name() string {
 	return s.name
 }
 
-// Fetch makes an HTTP request and parses JSON response with retry and circuit breaker
+// CircuitBreaker returns the circuit breaker guarding the source's requests
+func (s *RestSource) CircuitBreaker() *CircuitBreaker {
+	return s.circuitBreaker
+}
+
+// Fetch makes an HTTP request and parses JSON response with retry and circuit breaker.
+// Concurrent fetches of sources with the same configuration share a request.
 func (s *RestSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
-	// Use circuit breaker + retry
-	return s.circuitBreaker.Execute(ctx, func(ctx context.Context) ([]map[string]interface{}, error) {
-		return WithRetry(ctx, s.name, s.retryConfig, func(ctx context.Context) ([]map[string]interface{}, error) {
-			return s.doFetch(ctx)
+	return restFlights.Do(ctx, s.flightKey, func() ([]map[string]interface{}, error) {
+		// Use circuit breaker + retry
+		return s.circuitBreaker.Execute(ctx, func(ctx context.Context) ([]map[string]interface{}, error) {
+			return WithRetry(ctx, s.name, s.retryConfig, func(ctx context.Context) ([]map[string]interface{}, error) {
+				return s.doFetch(ctx)
+			})
 		})
 	})
 }
@@ -156,12 +214,38 @@ func (s *RestSource) buildURLWithQueryParams() (string, error) {
 	return parsedURL.String(), nil
 }
 
-// doFetch performs the actual HTTP request
+// FetchConditional fetches data like Fetch, revalidating a cached response with
+// its validators. Paginated sources always refetch all pages. Concurrent
+// fetches with the same configuration and validators share a request.
+func (s *RestSource) FetchConditional(ctx context.Context, etag, lastModified string) ([]map[string]interface{}, HTTPCacheInfo, bool, error) {
+	key := conditionalFlightKey(s.flightKey, etag, lastModified)
+	return restFlights.DoConditional(ctx, key, func() ([]map[string]interface{}, HTTPCacheInfo, bool, error) {
+		var info HTTPCacheInfo
+		var notModified bool
+		data, err := s.circuitBreaker.Execute(ctx, func(ctx context.Context) ([]map[string]interface{}, error) {
+			return WithRetry(ctx, s.name, s.retryConfig, func(ctx context.Context) ([]map[string]interface{}, error) {
+				var rows []map[string]interface{}
+				var err error
+				rows, info, notModified, err = s.doFetchConditional(ctx, etag, lastModified)
+				return rows, err
+			})
+		})
+		return data, info, notModified, err
+	})
+}
+
+// doFetch performs the actual HTTP request, following pages if pagination is configured
 func (s *RestSource) doFetch(ctx context.Context) ([]map[string]interface{}, error) {
+	data, _, _, err := s.doFetchConditional(ctx, "", "")
+	return data, err
+}
+
+// doFetchConditional performs the HTTP request with optional cache validators
+func (s *RestSource) doFetchConditional(ctx context.Context, etag, lastModified string) ([]map[string]interface{}, HTTPCacheInfo, bool, error) {
 	// Build URL with merged query parameters
 	requestURL, err := s.buildURLWithQueryParams()
 	if err != nil {
-		return nil, &SourceError{
+		return nil, HTTPCacheInfo{}, false, &SourceError{
 			Source:    s.name,
 			Operation: "build URL",
 			Err:       err,
@@ -169,10 +253,37 @@ func (s *RestSource) doFetch(ctx context.Context) ([]map[string]interface{}, err
 		}
 	}
 
+	if s.pagination != nil {
+		data, err := s.fetchAllPages(ctx, requestURL)
+		return data, HTTPCacheInfo{}, false, err
+	}
+
+	body, header, notModified, err := s.fetchPageConditional(ctx, requestURL, etag, lastModified)
+	if err != nil {
+		return nil, HTTPCacheInfo{}, false, err
+	}
+	info := parseHTTPCacheInfo(header)
+	if notModified {
+		return nil, info, true, nil
+	}
+
+	data, err := s.parseResponse(body, header)
+	return data, info, false, err
+}
+
+// fetchPage requests a single URL and returns the response body and headers
+func (s *RestSource) fetchPage(ctx context.Context, requestURL string) ([]byte, http.Header, error) {
+	body, header, _, err := s.fetchPageConditional(ctx, requestURL, "", "")
+	return body, header, err
+}
+
+// fetchPageConditional requests a single URL, sending cache validators if given.
+// notModified is true if the server answered 304 Not Modified.
+func (s *RestSource) fetchPageConditional(ctx context.Context, requestURL, etag, lastModified string) ([]byte, http.Header, bool, error) {
 	// Create request with context
 	req, err := http.NewRequestWithContext(ctx, s.method, requestURL, nil)
 	if err != nil {
-		return nil, &SourceError{
+		return nil, nil, false, &SourceError{
 			Source:    s.name,
 			Operation: "create request",
 			Err:       err,
@@ -181,22 +292,35 @@ func (s *RestSource) doFetch(ctx context.Context) ([]map[string]interface{}, err
 	}
 
 	// Set headers
-	req.Header.Set("Accept", "application/json")
+	accept := "application/json"
+	if s.format != "" {
+		accept = formatAccept[s.format]
+	}
+	req.Header.Set("Accept", accept)
 	for key, value := range s.headers {
 		req.Header.Set(key, value)
 	}
+	setConditionalHeaders(req, etag, lastModified)
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, nil, false, NewSourceError(s.name, "rate limit", err)
+	}
 
 	// Execute request
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, NewSourceError(s.name, "request", err)
+		return nil, nil, false, NewSourceError(s.name, "request", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, resp.Header, true, nil
+	}
+
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return nil, &HTTPError{
+		return nil, nil, false, &HTTPError{
 			Source:     s.name,
 			StatusCode: resp.StatusCode,
 			Status:     resp.Status,
@@ -208,7 +332,7 @@ func (s *RestSource) doFetch(ctx context.Context) ([]map[string]interface{}, err
 	const maxResponseSize = 10 * 1024 * 1024 // 10MB
 	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
 	if err != nil {
-		return nil, &SourceError{
+		return nil, nil, false, &SourceError{
 			Source:    s.name,
 			Operation: "read response",
 			Err:       err,
@@ -216,8 +340,7 @@ func (s *RestSource) doFetch(ctx context.Context) ([]map[string]interface{}, err
 		}
 	}
 
-	// Parse JSON response
-	return s.parseJSON(body)
+	return body, resp.Header, false, nil
 }
 
 // navigateJSONPath extracts nested data using dot notation
@@ -246,37 +369,82 @@ func navigateJSONPath(data interface{}, path string) (interface{}, error) {
 	return current, nil
 }
 
-// parseJSON handles both array and object JSON responses
-// If resultPath is specified, navigates to that path to extract the array
-// Otherwise expects array at root, or wraps single object in array
-func (s *RestSource) parseJSON(data []byte) ([]map[string]interface{}, error) {
-	data = []byte(strings.TrimSpace(string(data)))
+// parseResponse decodes a response body and extracts its rows
+func (s *RestSource) parseResponse(body []byte, header http.Header) ([]map[string]interface{}, error) {
+	parsed, format, err := s.decodeBody(body, header)
+	if err != nil {
+		return nil, err
+	}
+	return s.extractRows(parsed, format)
+}
+
+// responseFormat returns the configured format, or detects it from Content-Type
+func (s *RestSource) responseFormat(header http.Header) string {
+	if s.format != "" {
+		return s.format
+	}
+	return formatFromContentType(header.Get("Content-Type"))
+}
+
+// decodeBody parses a response body into a generic structure (nil for an empty body).
+// Document formats (JSON, YAML, XML) decode to nested maps and slices; row formats
+// (NDJSON, CSV, TSV) decode to a slice of rows.
+func (s *RestSource) decodeBody(data []byte, header http.Header) (interface{}, string, error) {
+	format := s.responseFormat(header)
+	data = bytes.TrimSpace(data)
 
 	if len(data) == 0 {
-		return []map[string]interface{}{}, nil
+		return nil, format, nil
 	}
 
-	// Parse JSON into generic structure first
 	var parsed interface{}
-	if err := json.Unmarshal(data, &parsed); err != nil {
-		return nil, &ValidationError{Source: s.name, Reason: "could not parse response as JSON"}
+	var err error
+	switch format {
+	case formatNDJSON:
+		parsed, err = parseNDJSON(data)
+	case formatCSV:
+		parsed, err = parseDelimited(data, s.delimiter)
+	case formatTSV:
+		parsed, err = parseDelimited(data, '\t')
+	case formatXML:
+		parsed, err = parseXML(data)
+	case formatYAML:
+		parsed, err = parseYAML(data)
+	default:
+		err = json.Unmarshal(data, &parsed)
+	}
+	if err != nil {
+		return nil, format, &ValidationError{Source: s.name, Reason: fmt.Sprintf("could not parse response as %s: %v", strings.ToUpper(format), err)}
+	}
+	return parsed, format, nil
+}
+
+// extractRows navigates to resultPath (if specified) and converts the result to rows.
+// Row formats are already flat, so resultPath only applies to document formats.
+func (s *RestSource) extractRows(parsed interface{}, format string) ([]map[string]interface{}, error) {
+	if parsed == nil {
+		return []map[string]interface{}{}, nil
 	}
 
-	// Navigate to resultPath if specified
-	if s.resultPath != "" {
+	switch {
+	case format == formatNDJSON || format == formatCSV || format == formatTSV:
+		// Rows as decoded
+	case s.resultPath != "":
 		result, err := navigateJSONPath(parsed, s.resultPath)
 		if err != nil {
 			return nil, &ValidationError{Source: s.name, Reason: err.Error()}
 		}
 		parsed = result
+	case format == formatXML:
+		parsed = xmlDefaultRows(parsed)
 	}
 
 	// Convert to []map[string]interface{}
-	return s.convertToMapSlice(parsed)
+	return convertToMapSlice(s.name, parsed)
 }
 
 // convertToMapSlice converts an interface{} to []map[string]interface{}
-func (s *RestSource) convertToMapSlice(data interface{}) ([]map[string]interface{}, error) {
+func convertToMapSlice(name string, data interface{}) ([]map[string]interface{}, error) {
 	switch v := data.(type) {
 	case []interface{}:
 		results := make([]map[string]interface{}, 0, len(v))
@@ -284,7 +452,7 @@ func (s *RestSource) convertToMapSlice(data interface{}) ([]map[string]interface
 			itemMap, ok := item.(map[string]interface{})
 			if !ok {
 				return nil, &ValidationError{
-					Source: s.name,
+					Source: name,
 					Reason: fmt.Sprintf("expected array of objects, but element %d is %T", i, item),
 				}
 			}
@@ -300,7 +468,7 @@ func (s *RestSource) convertToMapSlice(data interface{}) ([]map[string]interface
 		return []map[string]interface{}{v}, nil
 
 	default:
-		return nil, &ValidationError{Source: s.name, Reason: fmt.Sprintf("expected array or object, got %T", data)}
+		return nil, &ValidationError{Source: name, Reason: fmt.Sprintf("expected array or object, got %T", data)}
 	}
 }
 
@@ -0,0 +1,248 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Response formats supported by REST sources
+const (
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+	formatTSV    = "tsv"
+	formatXML    = "xml"
+	formatYAML   = "yaml"
+)
+
+// formatAccept is the Accept header sent for an explicitly configured format
+var formatAccept = map[string]string{
+	formatJSON:   "application/json",
+	formatNDJSON: "application/x-ndjson, application/json;q=0.5",
+	formatCSV:    "text/csv",
+	formatTSV:    "text/tab-separated-values",
+	formatXML:    "application/xml, text/xml;q=0.9",
+	formatYAML:   "application/yaml, application/x-yaml;q=0.9, text/yaml;q=0.9",
+}
+
+// formatFromContentType detects the response format from a Content-Type header.
+// Unknown or missing types are treated as JSON.
+func formatFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	switch {
+	case mediaType == "application/x-ndjson", mediaType == "application/ndjson",
+		mediaType == "application/jsonl", mediaType == "application/x-jsonlines":
+		return formatNDJSON
+	case mediaType == "text/csv", mediaType == "application/csv":
+		return formatCSV
+	case mediaType == "text/tab-separated-values":
+		return formatTSV
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return formatXML
+	case mediaType == "application/yaml", mediaType == "application/x-yaml",
+		mediaType == "text/yaml", mediaType == "text/x-yaml", strings.HasSuffix(mediaType, "+yaml"):
+		return formatYAML
+	default:
+		return formatJSON
+	}
+}
+
+// parseNDJSON parses newline-delimited JSON objects
+func parseNDJSON(data []byte) ([]interface{}, error) {
+	var rows []interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var row interface{}
+		if err := json.Unmarshal(line, &row); err != nil {
+			return nil, fmt.Errorf("invalid JSON on line %d: %w", lineNum, err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// parseDelimited parses CSV/TSV with the first row as headers
+func parseDelimited(data []byte, comma rune) ([]interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+
+	headers, err := reader.Read()
+	if err == io.EOF {
+		return []interface{}{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read headers: %w", err)
+	}
+
+	rows := []interface{}{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		row := make(map[string]interface{}, len(headers))
+		for i, header := range headers {
+			if i < len(record) {
+				row[header] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseYAML parses a YAML document into generic maps and slices
+func parseYAML(data []byte) (interface{}, error) {
+	var parsed interface{}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// parseXML converts an XML document into generic maps, so result_path can
+// address elements by name:
+//
+//	<catalog><book id="1"><title>Go</title></book><book id="2">...</book></catalog>
+//
+// becomes {"catalog": {"book": [{"id": "1", "title": "Go"}, ...]}}. Attributes
+// and child elements become keys (namespaces are dropped), repeated elements
+// become lists, and elements with only text become strings. The text of an
+// element that also has attributes or children is stored under "text".
+func parseXML(data []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// Accept documents declaring non-UTF-8 charsets; bytes are passed through
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no root element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := parseXMLElement(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{start.Name.Local: value}, nil
+		}
+	}
+}
+
+// parseXMLElement reads an element's content up to its end tag
+func parseXMLElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	fields := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		fields[attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	hasChildren := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			hasChildren = true
+			child, err := parseXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			name := t.Name.Local
+			switch existing := fields[name].(type) {
+			case nil:
+				fields[name] = child
+			case []interface{}:
+				fields[name] = append(existing, child)
+			default:
+				fields[name] = []interface{}{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if !hasChildren && len(fields) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				if _, exists := fields["text"]; !exists {
+					fields["text"] = content
+				}
+			}
+			return fields, nil
+		}
+	}
+}
+
+// xmlDefaultRows picks rows from an XML document when no result_path is set:
+// the repeated child of the root element (e.g., <items><item/><item/></items>),
+// or the root element itself. A root whose only content is one child element
+// (<items><item/></items>) gives a one-row list, as it would with more items.
+func xmlDefaultRows(doc interface{}) interface{} {
+	root, ok := doc.(map[string]interface{})
+	if !ok || len(root) != 1 {
+		return doc
+	}
+	for _, value := range root {
+		children, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		var list interface{}
+		for _, child := range children {
+			if items, ok := child.([]interface{}); ok {
+				if list != nil {
+					return value // Several repeated elements: ambiguous
+				}
+				list = items
+			}
+		}
+		if list != nil {
+			return list
+		}
+		if len(children) == 1 {
+			for _, child := range children {
+				if item, ok := child.(map[string]interface{}); ok {
+					return []interface{}{item}
+				}
+			}
+		}
+		return value
+	}
+	return doc
+}
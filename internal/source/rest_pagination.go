@@ -0,0 +1,245 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// Pagination strategies for REST sources
+const (
+	paginationLink   = "link"   // Follow the Link header's rel="next" URL
+	paginationCursor = "cursor" // Pass a cursor read from the response body
+	paginationPage   = "page"   // Increment a page number query parameter
+)
+
+// validatePagination checks a pagination block at construction time
+func validatePagination(name string, p *config.PaginationConfig) error {
+	if p == nil {
+		return nil
+	}
+	switch p.Type {
+	case paginationLink, paginationPage:
+		return nil
+	case paginationCursor:
+		if p.CursorPath == "" {
+			return &ValidationError{Source: name, Field: "pagination.cursor_path", Reason: "cursor_path is required for cursor pagination"}
+		}
+		return nil
+	case "":
+		return &ValidationError{Source: name, Field: "pagination.type", Reason: "type is required (link, cursor, or page)"}
+	default:
+		return &ValidationError{Source: name, Field: "pagination.type", Reason: fmt.Sprintf("unknown pagination type %q (expected link, cursor, or page)", p.Type)}
+	}
+}
+
+// fetchAllPages requests pages until the API reports no more, or a max_pages/max_rows
+// cap is reached. The next page is determined from each raw response, before
+// result_path extraction, and the extracted rows of all pages are concatenated.
+func (s *RestSource) fetchAllPages(ctx context.Context, firstURL string) ([]map[string]interface{}, error) {
+	p := s.pagination
+	maxPages := p.GetMaxPages()
+	maxRows := p.GetMaxRows()
+
+	pageURL := firstURL
+	page := p.GetStartPage()
+	if p.Type == paginationPage {
+		var err error
+		if pageURL, err = s.pageNumberURL(firstURL, page); err != nil {
+			return nil, &SourceError{Source: s.name, Operation: "build URL", Err: err, Retryable: false}
+		}
+	}
+
+	results := []map[string]interface{}{}
+	visited := make(map[string]bool)
+	for pages := 0; pageURL != ""; pages++ {
+		if pages == maxPages {
+			log.Printf("[source/%s] Stopped pagination after %d pages (max_pages)", s.name, maxPages)
+			break
+		}
+		visited[pageURL] = true
+
+		body, header, err := s.fetchPage(ctx, pageURL)
+		if err != nil {
+			return nil, err
+		}
+		parsed, format, err := s.decodeBody(body, header)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := s.extractRows(parsed, format)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, rows...)
+		if len(results) >= maxRows {
+			if len(results) > maxRows {
+				log.Printf("[source/%s] Truncated paginated results to %d rows (max_rows)", s.name, maxRows)
+				results = results[:maxRows]
+			}
+			break
+		}
+
+		next, err := s.nextPageURL(pageURL, header, parsed, len(rows), &page)
+		if err != nil {
+			return nil, &SourceError{Source: s.name, Operation: "paginate", Err: err, Retryable: false}
+		}
+		if visited[next] {
+			break // The API pointed back at a page we already have
+		}
+		pageURL = next
+	}
+
+	return results, nil
+}
+
+// nextPageURL returns the URL of the page after currentURL, or "" if there is none
+func (s *RestSource) nextPageURL(currentURL string, header http.Header, parsed interface{}, rowCount int, page *int) (string, error) {
+	p := s.pagination
+
+	switch p.Type {
+	case paginationLink:
+		next := parseLinkHeader(header.Values("Link"))["next"]
+		if next == "" {
+			return "", nil
+		}
+		base, err := url.Parse(currentURL)
+		if err != nil {
+			return "", err
+		}
+		ref, err := url.Parse(next)
+		if err != nil {
+			return "", fmt.Errorf("invalid next link %q: %w", next, err)
+		}
+		target := base.ResolveReference(ref)
+		// The request carries the source's auth headers and query params; don't
+		// send them elsewhere, but keep the pages already read
+		if target.Scheme != base.Scheme || target.Host != base.Host {
+			log.Printf("[source/%s] Stopped pagination: next link %q points to another origin than %s://%s", s.name, next, base.Scheme, base.Host)
+			return "", nil
+		}
+		return target.String(), nil
+
+	case paginationCursor:
+		if rowCount == 0 || parsed == nil {
+			return "", nil
+		}
+		value, err := navigateJSONPath(parsed, p.CursorPath)
+		if err != nil {
+			return "", nil // No cursor means the last page
+		}
+		cursor := cursorString(value)
+		if cursor == "" {
+			return "", nil
+		}
+		return setQueryParam(currentURL, p.GetCursorParam(), cursor)
+
+	case paginationPage:
+		if rowCount == 0 || (p.PerPage > 0 && rowCount < p.PerPage) {
+			return "", nil
+		}
+		*page++
+		return s.pageNumberURL(currentURL, *page)
+	}
+
+	return "", nil
+}
+
+// pageNumberURL sets the page number (and page size, if configured) on a URL
+func (s *RestSource) pageNumberURL(rawURL string, page int) (string, error) {
+	p := s.pagination
+	u, err := setQueryParam(rawURL, p.GetPageParam(), strconv.Itoa(page))
+	if err != nil || p.PerPage <= 0 {
+		return u, err
+	}
+	return setQueryParam(u, p.GetPerPageParam(), strconv.Itoa(p.PerPage))
+}
+
+// setQueryParam returns rawURL with a query parameter set to value
+func setQueryParam(rawURL, key, value string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// cursorString formats a cursor value from a JSON response.
+// null, false and empty values mean there are no more pages.
+func cursorString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if !v {
+			return ""
+		}
+		return "true"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// parseLinkHeader parses RFC 8288 Link header values into a map of rel to URL.
+// e.g., `<https://api.example.com/items?page=2>; rel="next"` -> {"next": "https://..."}
+// Links are separated by commas outside <...> and quoted strings, so URLs and
+// parameters may contain commas.
+func parseLinkHeader(values []string) map[string]string {
+	links := make(map[string]string)
+	for _, value := range values {
+		for rest := value; ; {
+			start := strings.IndexByte(rest, '<')
+			if start < 0 {
+				break
+			}
+			end := strings.IndexByte(rest[start:], '>')
+			if end < 0 {
+				break
+			}
+			target := rest[start+1 : start+end]
+			rest = rest[start+end+1:]
+
+			// Parameters run up to the next comma outside a quoted string
+			params, next := rest, ""
+			inQuotes := false
+			for i := 0; i < len(rest); i++ {
+				if rest[i] == '"' {
+					inQuotes = !inQuotes
+				} else if rest[i] == ',' && !inQuotes {
+					params, next = rest[:i], rest[i+1:]
+					break
+				}
+			}
+			rest = next
+
+			for _, param := range strings.Split(params, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				// rel may hold several space-separated relation types
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(val), `"`)) {
+					rel = strings.ToLower(rel)
+					if _, exists := links[rel]; !exists {
+						links[rel] = target
+					}
+				}
+			}
+		}
+	}
+	return links
+}
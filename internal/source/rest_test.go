@@ -3,10 +3,14 @@ package source
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/livetemplate/tinkerdown/internal/config"
@@ -27,7 +31,7 @@ func TestRestSource_BasicFetch(t *testing.T) {
 		Type: "rest",
 		From: server.URL,
 	}
-	src, err := NewRestSourceWithConfig("test", cfg)
+	src, err := NewRestSourceWithConfig("test", cfg, "")
 	if err != nil {
 		t.Fatalf("Failed to create source: %v", err)
 	}
@@ -65,7 +69,7 @@ func TestRestSource_WithHeaders(t *testing.T) {
 			"X-Custom-Header": "custom-value",
 		},
 	}
-	src, err := NewRestSourceWithConfig("test", cfg)
+	src, err := NewRestSourceWithConfig("test", cfg, "")
 	if err != nil {
 		t.Fatalf("Failed to create source: %v", err)
 	}
@@ -103,7 +107,7 @@ func TestRestSource_HeadersEnvExpansion(t *testing.T) {
 			"Authorization": "Bearer ${TEST_API_TOKEN}",
 		},
 	}
-	src, err := NewRestSourceWithConfig("test", cfg)
+	src, err := NewRestSourceWithConfig("test", cfg, "")
 	if err != nil {
 		t.Fatalf("Failed to create source: %v", err)
 	}
@@ -138,7 +142,7 @@ func TestRestSource_WithQueryParams(t *testing.T) {
 			"status": "active",
 		},
 	}
-	src, err := NewRestSourceWithConfig("test", cfg)
+	src, err := NewRestSourceWithConfig("test", cfg, "")
 	if err != nil {
 		t.Fatalf("Failed to create source: %v", err)
 	}
@@ -176,7 +180,7 @@ func TestRestSource_QueryParamsMerge(t *testing.T) {
 			"new_param": "new_value",
 		},
 	}
-	src, err := NewRestSourceWithConfig("test", cfg)
+	src, err := NewRestSourceWithConfig("test", cfg, "")
 	if err != nil {
 		t.Fatalf("Failed to create source: %v", err)
 	}
@@ -213,7 +217,7 @@ func TestRestSource_ResultPath(t *testing.T) {
 		From:       server.URL,
 		ResultPath: "data.items",
 	}
-	src, err := NewRestSourceWithConfig("test", cfg)
+	src, err := NewRestSourceWithConfig("test", cfg, "")
 	if err != nil {
 		t.Fatalf("Failed to create source: %v", err)
 	}
@@ -247,7 +251,7 @@ func TestRestSource_ResultPathNotFound(t *testing.T) {
 		From:       server.URL,
 		ResultPath: "data.items", // Path doesn't exist
 	}
-	src, err := NewRestSourceWithConfig("test", cfg)
+	src, err := NewRestSourceWithConfig("test", cfg, "")
 	if err != nil {
 		t.Fatalf("Failed to create source: %v", err)
 	}
@@ -277,7 +281,7 @@ func TestRestSource_NoResultPath_RootArray(t *testing.T) {
 		From: server.URL,
 		// No ResultPath - should work with array at root
 	}
-	src, err := NewRestSourceWithConfig("test", cfg)
+	src, err := NewRestSourceWithConfig("test", cfg, "")
 	if err != nil {
 		t.Fatalf("Failed to create source: %v", err)
 	}
@@ -307,7 +311,7 @@ func TestRestSource_NoResultPath_SingleObject(t *testing.T) {
 		From: server.URL,
 		// No ResultPath - should wrap single object in array
 	}
-	src, err := NewRestSourceWithConfig("test", cfg)
+	src, err := NewRestSourceWithConfig("test", cfg, "")
 	if err != nil {
 		t.Fatalf("Failed to create source: %v", err)
 	}
@@ -339,7 +343,7 @@ func TestRestSource_String_MasksAuth(t *testing.T) {
 		},
 		ResultPath: "data.items",
 	}
-	src, err := NewRestSourceWithConfig("test", cfg)
+	src, err := NewRestSourceWithConfig("test", cfg, "")
 	if err != nil {
 		t.Fatalf("Failed to create source: %v", err)
 	}
@@ -378,7 +382,7 @@ func TestRestSource_MissingFrom(t *testing.T) {
 		Type: "rest",
 		// From is missing
 	}
-	_, err := NewRestSourceWithConfig("test", cfg)
+	_, err := NewRestSourceWithConfig("test", cfg, "")
 	if err == nil {
 		t.Fatal("Expected error for missing 'from', got nil")
 	}
@@ -472,3 +476,579 @@ func TestNavigateJSONPath(t *testing.T) {
 		})
 	}
 }
+
+// pagedItems returns items [start, start+n) with "id" fields
+func pagedItems(start, n int) []map[string]interface{} {
+	items := make([]map[string]interface{}, n)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": start + i}
+	}
+	return items
+}
+
+func TestRestSource_PaginationLinkHeader(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		w.Header().Set("Content-Type", "application/json")
+		switch page {
+		case "":
+			w.Header().Set("Link", `<`+server.URL+`/items?page=2>; rel="next", <`+server.URL+`/items?page=3>; rel="last"`)
+			json.NewEncoder(w).Encode(pagedItems(0, 2))
+		case "2":
+			w.Header().Set("Link", `</items?page=3>; rel="next"`) // relative link
+			json.NewEncoder(w).Encode(pagedItems(2, 2))
+		default:
+			json.NewEncoder(w).Encode(pagedItems(4, 1))
+		}
+	}))
+	defer server.Close()
+
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+		Type:       "rest",
+		From:       server.URL + "/items",
+		Pagination: &config.PaginationConfig{Type: "link"},
+	}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	results, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("Expected 5 results, got %d", len(results))
+	}
+	if results[4]["id"] != float64(4) {
+		t.Errorf("Expected last id 4, got %v", results[4]["id"])
+	}
+}
+
+func TestRestSource_PaginationLinkOtherOrigin(t *testing.T) {
+	var otherRequests atomic.Int32
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherRequests.Add(1)
+		json.NewEncoder(w).Encode(pagedItems(2, 2))
+	}))
+	defer other.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", `<`+other.URL+`/items?page=2>; rel="next"`)
+		json.NewEncoder(w).Encode(pagedItems(0, 2))
+	}))
+	defer server.Close()
+
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+		Type:       "rest",
+		From:       server.URL + "/items",
+		Headers:    map[string]string{"Authorization": "Bearer secret"},
+		Pagination: &config.PaginationConfig{Type: "link"},
+	}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	// Pagination stops at the link, keeping the rows already read
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("Expected the 2 rows of the first page, got %d", len(rows))
+	}
+	if n := otherRequests.Load(); n != 0 {
+		t.Errorf("Expected no requests to the other origin, got %d", n)
+	}
+}
+
+func TestRestSource_PaginationCursor(t *testing.T) {
+	var cursors []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("after")
+		cursors = append(cursors, cursor)
+
+		next := interface{}(nil)
+		start := 0
+		switch cursor {
+		case "":
+			next = "abc"
+		case "abc":
+			start, next = 2, "def"
+		case "def":
+			start = 4
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": pagedItems(start, 2),
+			"meta": map[string]interface{}{"next_cursor": next},
+		})
+	}))
+	defer server.Close()
+
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+		Type:       "rest",
+		From:       server.URL + "?status=open",
+		ResultPath: "data",
+		Pagination: &config.PaginationConfig{
+			Type:        "cursor",
+			CursorPath:  "meta.next_cursor",
+			CursorParam: "after",
+		},
+	}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	results, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(results) != 6 {
+		t.Errorf("Expected 6 results, got %d", len(results))
+	}
+	if strings.Join(cursors, ",") != ",abc,def" {
+		t.Errorf("Unexpected cursors requested: %q", cursors)
+	}
+}
+
+func TestRestSource_PaginationPageNumber(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "1":
+			json.NewEncoder(w).Encode(pagedItems(0, 3))
+		case "2":
+			json.NewEncoder(w).Encode(pagedItems(3, 3))
+		default:
+			json.NewEncoder(w).Encode(pagedItems(6, 1)) // Short page ends pagination
+		}
+	}))
+	defer server.Close()
+
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+		Type:       "rest",
+		From:       server.URL,
+		Pagination: &config.PaginationConfig{Type: "page", PerPage: 3},
+	}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	results, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(results) != 7 {
+		t.Errorf("Expected 7 results, got %d", len(results))
+	}
+	want := "page=1&per_page=3,page=2&per_page=3,page=3&per_page=3"
+	if got := strings.Join(requests, ","); got != want {
+		t.Errorf("Requests = %q, want %q", got, want)
+	}
+}
+
+func TestRestSource_PaginationCaps(t *testing.T) {
+	// Server that always has another page
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pagedItems(requests*10, 10))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		pagination   config.PaginationConfig
+		wantRows     int
+		wantRequests int
+	}{
+		{"max pages", config.PaginationConfig{Type: "page", MaxPages: 3}, 30, 3},
+		{"max rows", config.PaginationConfig{Type: "page", MaxRows: 25}, 25, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			pagination := tt.pagination
+			src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+				Type:       "rest",
+				From:       server.URL,
+				Pagination: &pagination,
+			}, "")
+			if err != nil {
+				t.Fatalf("Failed to create source: %v", err)
+			}
+
+			results, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch failed: %v", err)
+			}
+			if len(results) != tt.wantRows {
+				t.Errorf("Expected %d results, got %d", tt.wantRows, len(results))
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, requests)
+			}
+		})
+	}
+}
+
+func TestRestSource_PaginationValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		pagination config.PaginationConfig
+		wantErr    string
+	}{
+		{"missing type", config.PaginationConfig{}, "type is required"},
+		{"unknown type", config.PaginationConfig{Type: "offset"}, "unknown pagination type"},
+		{"cursor without path", config.PaginationConfig{Type: "cursor"}, "cursor_path is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pagination := tt.pagination
+			_, err := NewRestSourceWithConfig("test", config.SourceConfig{
+				Type:       "rest",
+				From:       "http://example.com",
+				Pagination: &pagination,
+			}, "")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	links := parseLinkHeader([]string{
+		`<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=9>; rel="last"`,
+		`<https://api.example.com/items?page=1>; rel="first prev"`,
+		`<https://api.example.com/items?fields=a,b&page=3>; title="a, b"; rel="self", <https://api.example.com/items?page=0>; rel=up`,
+	})
+
+	want := map[string]string{
+		"next":  "https://api.example.com/items?page=2",
+		"last":  "https://api.example.com/items?page=9",
+		"first": "https://api.example.com/items?page=1",
+		"prev":  "https://api.example.com/items?page=1",
+		"self":  "https://api.example.com/items?fields=a,b&page=3",
+		"up":    "https://api.example.com/items?page=0",
+	}
+	for rel, url := range want {
+		if links[rel] != url {
+			t.Errorf("links[%q] = %q, want %q", rel, links[rel], url)
+		}
+	}
+}
+
+func TestRestSource_WriteItem(t *testing.T) {
+	type request struct {
+		method, path, body string
+	}
+	var got request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = request{r.Method, r.URL.RequestURI(), string(body)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	readonly := false
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+		Type:     "rest",
+		From:     server.URL + "/items",
+		Readonly: &readonly,
+		Endpoints: map[string]config.RestEndpoint{
+			"update": {Method: "put"},
+			"toggle": {URL: "/items/{id}/complete", Method: "POST", Body: `{"complete": {{not (bool .done)}}, "by": {{json .user}}}`},
+		},
+	}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	tests := []struct {
+		action string
+		data   map[string]interface{}
+		want   request
+	}{
+		{"add", map[string]interface{}{"title": "Milk", "_csrf": "x"}, request{"POST", "/items", `{"title":"Milk"}`}},
+		{"update", map[string]interface{}{"id": "7", "title": "Eggs"}, request{"PUT", "/items/7", `{"title":"Eggs"}`}},
+		{"delete", map[string]interface{}{"id": "a b"}, request{"DELETE", "/items/a%20b", ""}},
+		{"toggle", map[string]interface{}{"id": "7", "done": "false", "user": "sam"}, request{"POST", "/items/7/complete", `{"complete": true, "by": "sam"}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			got = request{}
+			if err := src.WriteItem(context.Background(), tt.action, tt.data); err != nil {
+				t.Fatalf("WriteItem failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Request = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestSource_WriteItemQueryParams(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.RequestURI())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	readonly := false
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+		Type:        "rest",
+		From:        server.URL + "/items?tenant=acme",
+		QueryParams: map[string]string{"api_key": "secret"},
+		Readonly:    &readonly,
+		Endpoints: map[string]config.RestEndpoint{
+			"toggle": {URL: "/items/{id}/complete?tenant=other", Method: "POST"},
+		},
+	}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	ctx := context.Background()
+
+	// Writes send the query of the source URL and query_params, like reads;
+	// the endpoint's own parameters take precedence
+	for _, action := range []string{"add", "delete", "toggle"} {
+		if err := src.WriteItem(ctx, action, map[string]interface{}{"id": "7", "done": true}); err != nil {
+			t.Fatalf("%s failed: %v", action, err)
+		}
+	}
+	want := []string{
+		"POST /items?api_key=secret&tenant=acme",
+		"DELETE /items/7?api_key=secret&tenant=acme",
+		"POST /items/7/complete?api_key=secret&tenant=other",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+
+	// Endpoints on another origin don't get them
+	if u, err := src.endpointURL("https://elsewhere.example/{id}", map[string]interface{}{"id": "7"}); err != nil || u != "https://elsewhere.example/7" {
+		t.Errorf("endpointURL = %q, %v", u, err)
+	}
+}
+
+func TestRestSource_ToggleReadsDone(t *testing.T) {
+	var patched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/items/1":
+			w.Write([]byte(`{"id": 1, "title": "Milk", "done": true}`))
+		case r.Method == "GET" && r.URL.Path == "/items/2":
+			w.Write([]byte(`{"data": [{"id": 2, "done": false}]}`))
+		case r.Method == "GET":
+			w.Write([]byte(`{"id": 3}`))
+		case r.Method == "PATCH":
+			body, _ := io.ReadAll(r.Body)
+			patched = append(patched, r.URL.Path+" "+string(body))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	readonly := false
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+		Type:       "rest",
+		From:       server.URL + "/items",
+		ResultPath: "data",
+		Readonly:   &readonly,
+	}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+
+	for _, id := range []string{"1", "2"} {
+		if err := src.WriteItem(context.Background(), "toggle", map[string]interface{}{"id": id}); err != nil {
+			t.Fatalf("toggle %s failed: %v", id, err)
+		}
+	}
+	want := []string{`/items/1 {"done": false}`, `/items/2 {"done": true}`}
+	if !reflect.DeepEqual(patched, want) {
+		t.Errorf("PATCH requests = %q, want %q", patched, want)
+	}
+
+	err = src.WriteItem(context.Background(), "toggle", map[string]interface{}{"id": "3"})
+	if err == nil || !strings.Contains(err.Error(), "'done' field is required") {
+		t.Errorf("Expected missing done error, got %v", err)
+	}
+	if len(patched) != 2 {
+		t.Errorf("Expected no PATCH for an item without done, got %q", patched[2:])
+	}
+}
+
+func TestRestSource_WriteItemErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "item is locked", http.StatusConflict)
+	}))
+	defer server.Close()
+
+	readonly := false
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{Type: "rest", From: server.URL, Readonly: &readonly}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	ctx := context.Background()
+
+	err = src.WriteItem(ctx, "delete", map[string]interface{}{"id": "1"})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected HTTP 409 error, got %v", err)
+	}
+
+	if err := src.WriteItem(ctx, "delete", map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "'id' field is required") {
+		t.Errorf("Expected missing id error, got %v", err)
+	}
+	if err := src.WriteItem(ctx, "archive", map[string]interface{}{"id": "1"}); err == nil {
+		t.Error("Expected error for unknown action")
+	}
+
+	readonlySrc, _ := NewRestSourceWithConfig("test", config.SourceConfig{Type: "rest", From: server.URL}, "")
+	if !readonlySrc.IsReadonly() {
+		t.Error("Expected REST source to be read-only by default")
+	}
+	if err := readonlySrc.WriteItem(ctx, "add", map[string]interface{}{"title": "x"}); err == nil {
+		t.Error("Expected error writing to read-only source")
+	}
+}
+
+func TestRestSource_ResponseFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		format      string
+		delimiter   string
+		resultPath  string
+		want        []map[string]interface{}
+	}{
+		{
+			name:        "ndjson detected",
+			contentType: "application/x-ndjson",
+			body:        "{\"id\": 1, \"name\": \"a\"}\n\n{\"id\": 2, \"name\": \"b\"}\n",
+			want:        []map[string]interface{}{{"id": float64(1), "name": "a"}, {"id": float64(2), "name": "b"}},
+		},
+		{
+			name:        "csv detected",
+			contentType: "text/csv; charset=utf-8",
+			body:        "id,name\n1,a\n2,\"b, c\"\n",
+			want:        []map[string]interface{}{{"id": "1", "name": "a"}, {"id": "2", "name": "b, c"}},
+		},
+		{
+			name:        "tsv detected",
+			contentType: "text/tab-separated-values",
+			body:        "id\tname\n1\ta\n",
+			want:        []map[string]interface{}{{"id": "1", "name": "a"}},
+		},
+		{
+			name:        "csv with delimiter",
+			contentType: "text/plain",
+			format:      "csv",
+			delimiter:   ";",
+			body:        "id;name\n1;a\n",
+			want:        []map[string]interface{}{{"id": "1", "name": "a"}},
+		},
+		{
+			name:        "yaml with result path",
+			contentType: "application/yaml",
+			body:        "data:\n  items:\n    - id: 1\n      name: a\n",
+			resultPath:  "data.items",
+			want:        []map[string]interface{}{{"id": 1, "name": "a"}},
+		},
+		{
+			name:        "xml element path",
+			contentType: "application/xml",
+			body:        `<?xml version="1.0"?><catalog><book id="1"><title>Go</title><tag>a</tag><tag>b</tag></book><book id="2"><title lang="en">Rust</title></book></catalog>`,
+			resultPath:  "catalog.book",
+			want: []map[string]interface{}{
+				{"id": "1", "title": "Go", "tag": []interface{}{"a", "b"}},
+				{"id": "2", "title": map[string]interface{}{"lang": "en", "text": "Rust"}},
+			},
+		},
+		{
+			name:        "xml repeated child by default",
+			contentType: "application/atom+xml",
+			body:        `<items><item><id>1</id></item><item><id>2</id></item></items>`,
+			want:        []map[string]interface{}{{"id": "1"}, {"id": "2"}},
+		},
+		{
+			name:        "xml single repeated child by default",
+			contentType: "application/xml",
+			body:        `<items><item><id>1</id></item></items>`,
+			want:        []map[string]interface{}{{"id": "1"}},
+		},
+		{
+			name:        "unknown content type is json",
+			contentType: "text/plain",
+			body:        `[{"id": 1}]`,
+			want:        []map[string]interface{}{{"id": float64(1)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var accept string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accept = r.Header.Get("Accept")
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			src, err := NewRestSourceWithConfig("test", config.SourceConfig{
+				Type:       "rest",
+				From:       server.URL,
+				Format:     tt.format,
+				Delimiter:  tt.delimiter,
+				ResultPath: tt.resultPath,
+			}, "")
+			if err != nil {
+				t.Fatalf("Failed to create source: %v", err)
+			}
+
+			got, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch = %#v, want %#v", got, tt.want)
+			}
+			if tt.format != "" && accept != formatAccept[tt.format] {
+				t.Errorf("Accept = %q, want %q", accept, formatAccept[tt.format])
+			}
+		})
+	}
+}
+
+func TestRestSource_ResponseFormatErrors(t *testing.T) {
+	if _, err := NewRestSourceWithConfig("test", config.SourceConfig{Type: "rest", From: "http://example.com", Format: "protobuf"}, ""); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte("<items><item>"))
+	}))
+	defer server.Close()
+
+	src, err := NewRestSourceWithConfig("test", config.SourceConfig{Type: "rest", From: server.URL}, "")
+	if err != nil {
+		t.Fatalf("Failed to create source: %v", err)
+	}
+	src.retryConfig.MaxRetries = 0
+	if _, err := src.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "could not parse response as XML") {
+		t.Errorf("Expected XML parse error, got %v", err)
+	}
+}
@@ -0,0 +1,266 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// defaultRestEndpoints maps write actions to requests when no endpoint is configured.
+// URLs are relative to the source URL; "" means the source URL itself.
+var defaultRestEndpoints = map[string]config.RestEndpoint{
+	"add":    {Method: "POST", URL: ""},
+	"update": {Method: "PATCH", URL: "{id}"},
+	"delete": {Method: "DELETE", URL: "{id}"},
+	"toggle": {Method: "PATCH", URL: "{id}", Body: `{"done": {{not (bool .done)}}}`},
+}
+
+// urlPlaceholderPattern matches {field} placeholders in endpoint URLs
+var urlPlaceholderPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// restBodyFuncs are available in endpoint body templates
+var restBodyFuncs = template.FuncMap{
+	// json encodes a value as JSON (e.g., {"title": {{json .title}}})
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// bool interprets form values like "true", "on" and "1" as booleans
+	"bool": formBool,
+}
+
+// formBool interprets form values like "true", "on" and "1" as booleans
+func formBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		parsed, _ := strconv.ParseBool(b)
+		return parsed || b == "on"
+	case float64:
+		return b != 0
+	default:
+		return false
+	}
+}
+
+// IsReadonly returns whether the source is read-only
+func (s *RestSource) IsReadonly() bool {
+	return s.readonly
+}
+
+// WriteItem sends the HTTP request configured for an action.
+// Supported actions: add, update, delete, toggle
+func (s *RestSource) WriteItem(ctx context.Context, action string, data map[string]interface{}) error {
+	if s.readonly {
+		return fmt.Errorf("rest source %q is read-only", s.name)
+	}
+
+	// Configured endpoints override the defaults field by field
+	endpoint, configured := s.endpoints[action]
+	defaults, known := defaultRestEndpoints[action]
+	if !configured && !known {
+		return fmt.Errorf("rest source %q: unknown action %q", s.name, action)
+	}
+	if endpoint.Method == "" {
+		endpoint.Method = defaults.Method
+	}
+	if endpoint.Method == "" {
+		endpoint.Method = "POST"
+	}
+	if endpoint.URL == "" {
+		endpoint.URL = defaults.URL
+	}
+	if endpoint.Body == "" {
+		endpoint.Body = defaults.Body
+	}
+
+	requestURL, err := s.endpointURL(endpoint.URL, data)
+	if err != nil {
+		return fmt.Errorf("rest source %q: %s: %w", s.name, action, err)
+	}
+
+	// Toggle actions send only the id; read the done field the body flips from the item
+	if _, ok := data["done"]; !ok && action == "toggle" && strings.Contains(endpoint.Body, ".done") {
+		done, err := s.currentDone(ctx, data)
+		if err != nil {
+			return fmt.Errorf("rest source %q: %s: %w", s.name, action, err)
+		}
+		withDone := make(map[string]interface{}, len(data)+1)
+		for k, v := range data {
+			withDone[k] = v
+		}
+		withDone["done"] = done
+		data = withDone
+	}
+
+	body, err := s.endpointBody(action, endpoint.Body, data)
+	if err != nil {
+		return fmt.Errorf("rest source %q: %s: %w", s.name, action, err)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(endpoint.Method), requestURL, reader)
+	if err != nil {
+		return &SourceError{Source: s.name, Operation: action, Err: err, Retryable: false}
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		return NewSourceError(s.name, action, err)
+	}
+
+	// Writes are not retried: POST and PATCH are not idempotent
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return NewSourceError(s.name, action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &HTTPError{
+			Source:     s.name,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       strings.TrimSpace(string(respBody)),
+		}
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1024*1024))
+
+	return nil
+}
+
+// currentDone fetches the item at <from>/{id} and returns its done field. The
+// response may be the item itself, or rows (at result_path) that include it.
+func (s *RestSource) currentDone(ctx context.Context, data map[string]interface{}) (interface{}, error) {
+	itemURL, err := s.endpointURL("{id}", data)
+	if err != nil {
+		return nil, err
+	}
+	body, header, err := s.fetchPage(ctx, itemURL)
+	if err != nil {
+		return nil, err
+	}
+	parsed, format, err := s.decodeBody(body, header)
+	if err != nil {
+		return nil, err
+	}
+	if item, ok := parsed.(map[string]interface{}); ok {
+		if done, ok := item["done"]; ok {
+			return done, nil
+		}
+	}
+
+	rows, _ := s.extractRows(parsed, format)
+	id, _ := getID(data)
+	for _, row := range rows {
+		rowID, ok := getID(row)
+		if len(rows) > 1 && (!ok || fmt.Sprintf("%v", rowID) != fmt.Sprintf("%v", id)) {
+			continue
+		}
+		if done, ok := row["done"]; ok {
+			return done, nil
+		}
+	}
+	return nil, fmt.Errorf("'done' field is required (the item at %s has none)", itemURL)
+}
+
+// endpointURL resolves an endpoint URL against the source URL and fills {field}
+// placeholders with path-escaped values from data. Endpoints on the source's
+// origin get the query of the source URL and its query_params, like reads
+// (e.g., an api_key); parameters in the endpoint URL take precedence.
+func (s *RestSource) endpointURL(pattern string, data map[string]interface{}) (string, error) {
+	var missing string
+	filled := urlPlaceholderPattern.ReplaceAllStringFunc(pattern, func(match string) string {
+		field := match[1 : len(match)-1]
+		value, ok := data[field]
+		if field == "id" {
+			value, ok = getID(data)
+		}
+		if !ok || value == nil || fmt.Sprintf("%v", value) == "" {
+			missing = field
+			return match
+		}
+		return url.PathEscape(fmt.Sprintf("%v", value))
+	})
+	if missing != "" {
+		return "", fmt.Errorf("'%s' field is required", missing)
+	}
+
+	base, err := url.Parse(s.url)
+	if err != nil {
+		return "", err
+	}
+	// Treat the source URL as a collection, so "{id}" resolves to <from>/<id>
+	if !strings.HasSuffix(base.Path, "/") && filled != "" && !strings.HasPrefix(filled, "/") && !strings.Contains(filled, "://") {
+		base.Path += "/"
+	}
+	ref, err := url.Parse(filled)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint URL %q: %w", filled, err)
+	}
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != base.Scheme || resolved.Host != base.Host {
+		return resolved.String(), nil
+	}
+
+	query := base.Query()
+	for key, value := range s.queryParams {
+		query.Set(key, value)
+	}
+	for key, values := range resolved.Query() {
+		query[key] = values
+	}
+	resolved.RawQuery = query.Encode()
+	return resolved.String(), nil
+}
+
+// endpointBody builds the request body: the rendered body template, or the form data
+// as JSON. Delete requests without a body template have no body.
+func (s *RestSource) endpointBody(action, bodyTemplate string, data map[string]interface{}) ([]byte, error) {
+	if bodyTemplate != "" {
+		tmpl, err := template.New(action).Funcs(restBodyFuncs).Option("missingkey=zero").Parse(bodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render body: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	if action == "delete" {
+		return nil, nil
+	}
+
+	fields := filterDataFields(data)
+	if action != "add" {
+		// The id identifies the resource in the URL
+		delete(fields, "id")
+		delete(fields, "Id")
+		delete(fields, "ID")
+	}
+	return json.Marshal(fields)
+}
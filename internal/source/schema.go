@@ -0,0 +1,308 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// schemaTypes are the field types of a schema
+var schemaTypes = []string{"string", "int", "float", "bool", "date", "datetime", "enum", "json"}
+
+// refDeleteActions are what happens to the rows referencing a deleted row
+var refDeleteActions = []string{"restrict", "cascade", "nullify"}
+
+// Schema holds the declared types and rules of a source's fields. It coerces
+// the rows read from the source and validates the data of adds and updates.
+// A nil Schema leaves rows and data unchanged.
+type Schema struct {
+	fields []schemaField // In name order
+}
+
+// schemaField is a declared field
+type schemaField struct {
+	name    string
+	cfg     config.FieldSchema
+	pattern *regexp.Regexp
+}
+
+// NewSchema parses the schema of a source. Returns nil if no fields are declared.
+func NewSchema(name string, fields map[string]config.FieldSchema) (*Schema, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	slices.Sort(names)
+
+	s := &Schema{}
+	for _, field := range names {
+		cfg := fields[field]
+		if cfg.Type == "" {
+			cfg.Type = "string"
+		}
+		if !slices.Contains(schemaTypes, cfg.Type) {
+			return nil, &ValidationError{Source: name, Field: "schema." + field, Reason: fmt.Sprintf("unknown type %q (expected %s)", cfg.Type, strings.Join(schemaTypes, ", "))}
+		}
+		if cfg.Type == "enum" && len(cfg.Values) == 0 {
+			return nil, &ValidationError{Source: name, Field: "schema." + field, Reason: "enum fields need values"}
+		}
+		if cfg.Ref == "" && (cfg.Display != "" || cfg.OnDelete != "") {
+			return nil, &ValidationError{Source: name, Field: "schema." + field, Reason: "display and on_delete need ref"}
+		}
+		if cfg.OnDelete != "" && !slices.Contains(refDeleteActions, cfg.OnDelete) {
+			return nil, &ValidationError{Source: name, Field: "schema." + field, Reason: fmt.Sprintf("unknown on_delete %q (expected %s)", cfg.OnDelete, strings.Join(refDeleteActions, ", "))}
+		}
+		f := schemaField{name: field, cfg: cfg}
+		if cfg.Pattern != "" {
+			pattern, err := regexp.Compile(`^(?:` + cfg.Pattern + `)$`)
+			if err != nil {
+				return nil, &ValidationError{Source: name, Field: "schema." + field, Reason: fmt.Sprintf("invalid pattern: %v", err)}
+			}
+			f.pattern = pattern
+		}
+		s.fields = append(s.fields, f)
+	}
+	return s, nil
+}
+
+// Coerce returns copies of the rows with the values of declared fields
+// converted to their types: numbers, booleans, dates as "2006-01-02", times
+// as RFC 3339, and parsed JSON. Values that don't convert are kept as is.
+func (s *Schema) Coerce(rows []map[string]interface{}) []map[string]interface{} {
+	if s == nil {
+		return rows
+	}
+	coerced := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		out := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			out[k] = v
+		}
+		for _, f := range s.fields {
+			if v, ok := out[f.name]; ok && v != nil {
+				if converted, err := f.convert(v); err == nil {
+					out[f.name] = converted
+				}
+			}
+		}
+		coerced[i] = out
+	}
+	return coerced
+}
+
+// ApplyDefaults returns a copy of the data of an add, with the defaults of
+// the fields it leaves empty
+func (s *Schema) ApplyDefaults(data map[string]interface{}) map[string]interface{} {
+	if s == nil {
+		return data
+	}
+	out := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		out[k] = v
+	}
+	for _, f := range s.fields {
+		if f.cfg.Default != nil && isEmptyValue(out[f.name]) {
+			out[f.name] = f.cfg.Default
+		}
+	}
+	return out
+}
+
+// Validate checks the data of an add or update against the schema. rows are
+// the current rows, for unique fields; on update, the row with the data's id
+// is not compared. Returns the data with values converted to their types and
+// the error message of each invalid field (nil if all are valid).
+func (s *Schema) Validate(action string, data map[string]interface{}, rows []map[string]interface{}) (map[string]interface{}, map[string]string) {
+	if s == nil {
+		return data, nil
+	}
+	out := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		out[k] = v
+	}
+
+	errs := make(map[string]string)
+	for _, f := range s.fields {
+		v, present := data[f.name]
+		if isEmptyValue(v) {
+			if f.cfg.Type == "bool" && action == "add" {
+				// Unchecked checkboxes are not submitted
+				out[f.name] = false
+			} else if f.cfg.Required && (present || action == "add") {
+				errs[f.name] = f.Label() + " is required"
+			}
+			continue
+		}
+
+		converted, err := f.convert(v)
+		if err != nil {
+			errs[f.name] = f.Label() + " " + err.Error()
+			continue
+		}
+		if msg := f.check(converted); msg != "" {
+			errs[f.name] = f.Label() + " " + msg
+			continue
+		}
+		if f.cfg.Unique && f.taken(converted, data["id"], action, rows) {
+			errs[f.name] = f.Label() + " must be unique"
+			continue
+		}
+		if f.cfg.Type == "json" {
+			// Sources store the JSON text
+			continue
+		}
+		out[f.name] = converted
+	}
+
+	if len(errs) == 0 {
+		return out, nil
+	}
+	return out, errs
+}
+
+// Label returns the label of the field
+func (f schemaField) Label() string {
+	if f.cfg.Label != "" {
+		return f.cfg.Label
+	}
+	return SchemaLabel(f.name)
+}
+
+// SchemaLabel returns the default label of a field: its name with spaces for
+// underscores and the first letter in upper case
+func SchemaLabel(name string) string {
+	label := strings.ReplaceAll(name, "_", " ")
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// convert converts a non-empty value to the field's type
+func (f schemaField) convert(v interface{}) (interface{}, error) {
+	switch f.cfg.Type {
+	case "int":
+		n, ok := schemaNumber(v)
+		if !ok || n != math.Trunc(n) {
+			return nil, fmt.Errorf("must be a whole number")
+		}
+		return int(n), nil
+	case "float":
+		n, ok := schemaNumber(v)
+		if !ok {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return n, nil
+	case "bool":
+		b, ok := castValue(v, "bool").(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+	case "date":
+		t, ok := parseDateValue(v, "").(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("must be a date")
+		}
+		return t.Format("2006-01-02"), nil
+	case "datetime":
+		t, ok := parseDateValue(v, "").(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("must be a date and time")
+		}
+		return t.Format(time.RFC3339), nil
+	case "json":
+		text, ok := v.(string)
+		if !ok {
+			return v, nil
+		}
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(text), &parsed); err != nil {
+			return nil, fmt.Errorf("must be valid JSON")
+		}
+		return parsed, nil
+	case "enum":
+		text := exprText(v)
+		if !slices.Contains(f.cfg.Values, text) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(f.cfg.Values, ", "))
+		}
+		return text, nil
+	default:
+		return exprText(v), nil
+	}
+}
+
+// check applies min and max (the length of text) and pattern to a converted
+// value. Returns the reason it is invalid, or "".
+func (f schemaField) check(v interface{}) string {
+	size, unit := 0.0, ""
+	switch f.cfg.Type {
+	case "int":
+		size = float64(v.(int))
+	case "float":
+		size = v.(float64)
+	case "string", "enum":
+		size, unit = float64(utf8.RuneCountInString(v.(string))), " characters"
+	default:
+		return ""
+	}
+	if f.cfg.Min != nil && size < *f.cfg.Min {
+		return fmt.Sprintf("must be at least %s%s", exprText(*f.cfg.Min), unit)
+	}
+	if f.cfg.Max != nil && size > *f.cfg.Max {
+		return fmt.Sprintf("must be at most %s%s", exprText(*f.cfg.Max), unit)
+	}
+	if f.pattern != nil && !f.pattern.MatchString(exprText(v)) {
+		return "has an invalid format"
+	}
+	return ""
+}
+
+// taken reports whether another row has the value
+func (f schemaField) taken(v, id interface{}, action string, rows []map[string]interface{}) bool {
+	text := exprText(v)
+	for _, row := range rows {
+		if action == "update" && id != nil && exprText(row["id"]) == exprText(id) {
+			continue
+		}
+		other, ok := row[f.name]
+		if !ok || other == nil {
+			continue
+		}
+		if converted, err := f.convert(other); err == nil {
+			other = converted
+		}
+		if exprText(other) == text {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaNumber converts a number or numeric text
+func schemaNumber(v interface{}) (float64, bool) {
+	if n, ok := toNumber(v); ok {
+		return n, true
+	}
+	n, ok := castValue(v, "float").(float64)
+	return n, ok
+}
+
+// isEmptyValue reports whether a submitted value is missing: nil or blank text
+func isEmptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	s, ok := v.(string)
+	return ok && strings.TrimSpace(s) == ""
+}
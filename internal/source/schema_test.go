@@ -0,0 +1,155 @@
+package source
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+func testSchema(t *testing.T) *Schema {
+	t.Helper()
+	minLen, maxLen, maxAge := 2.0, 10.0, 150.0
+	schema, err := NewSchema("people", map[string]config.FieldSchema{
+		"name":    {Required: true, Unique: true, Min: &minLen, Max: &maxLen},
+		"age":     {Type: "int", Max: &maxAge},
+		"score":   {Type: "float"},
+		"active":  {Type: "bool"},
+		"born":    {Type: "date", Label: "Birthday"},
+		"seen_at": {Type: "datetime"},
+		"role":    {Type: "enum", Values: []string{"admin", "user"}, Default: "user"},
+		"meta":    {Type: "json"},
+		"code":    {Pattern: `[A-Z]{3}`},
+	})
+	if err != nil {
+		t.Fatalf("NewSchema failed: %v", err)
+	}
+	return schema
+}
+
+func TestSchema_Coerce(t *testing.T) {
+	schema := testSchema(t)
+	rows := []map[string]interface{}{
+		{"id": "1", "name": "Ada", "age": "36", "score": "9.5", "active": "yes", "born": "12/10/1815", "seen_at": "2026-03-01 10:30", "meta": `{"a": 1}`, "other": "x"},
+		{"id": "2", "name": "Alan", "age": "n/a", "score": 7.0, "active": true},
+	}
+	got := schema.Coerce(rows)
+	want := []map[string]interface{}{
+		{"id": "1", "name": "Ada", "age": 36, "score": 9.5, "active": true, "born": "1815-12-10", "seen_at": "2026-03-01T10:30:00Z", "meta": map[string]interface{}{"a": 1.0}, "other": "x"},
+		{"id": "2", "name": "Alan", "age": "n/a", "score": 7.0, "active": true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Coerce() = %v, want %v", got, want)
+	}
+	if rows[0]["age"] != "36" {
+		t.Error("Coerce() modified the input rows")
+	}
+
+	var nilSchema *Schema
+	if got := nilSchema.Coerce(rows); !reflect.DeepEqual(got, rows) {
+		t.Errorf("nil schema changed rows: %v", got)
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema := testSchema(t)
+	rows := []map[string]interface{}{
+		{"id": "1", "name": "Ada"},
+		{"id": "2", "name": "Alan"},
+	}
+
+	tests := []struct {
+		name     string
+		action   string
+		data     map[string]interface{}
+		wantData map[string]interface{}
+		wantErrs map[string]string
+	}{
+		{
+			name:     "valid add",
+			action:   "add",
+			data:     map[string]interface{}{"name": "Grace", "age": "45", "born": "1906-12-09", "role": "admin", "meta": `[1]`, "code": "ABC"},
+			wantData: map[string]interface{}{"name": "Grace", "age": 45, "active": false, "born": "1906-12-09", "role": "admin", "meta": `[1]`, "code": "ABC"},
+		},
+		{
+			name:   "invalid add",
+			action: "add",
+			data:   map[string]interface{}{"name": " ", "age": "4.5", "score": "high", "born": "soon", "role": "guest", "meta": "{", "code": "abc"},
+			wantErrs: map[string]string{
+				"name":  "Name is required",
+				"age":   "Age must be a whole number",
+				"score": "Score must be a number",
+				"born":  "Birthday must be a date",
+				"role":  "Role must be one of admin, user",
+				"meta":  "Meta must be valid JSON",
+				"code":  "Code has an invalid format",
+			},
+		},
+		{
+			name:     "limits",
+			action:   "add",
+			data:     map[string]interface{}{"name": "A", "age": "200"},
+			wantErrs: map[string]string{"name": "Name must be at least 2 characters", "age": "Age must be at most 150"},
+		},
+		{
+			name:     "unique",
+			action:   "add",
+			data:     map[string]interface{}{"name": "Ada"},
+			wantErrs: map[string]string{"name": "Name must be unique"},
+		},
+		{
+			name:     "update keeps own value",
+			action:   "update",
+			data:     map[string]interface{}{"id": "1", "name": "Ada"},
+			wantData: map[string]interface{}{"id": "1", "name": "Ada"},
+		},
+		{
+			name:     "update of other fields",
+			action:   "update",
+			data:     map[string]interface{}{"id": "2", "age": "41"},
+			wantData: map[string]interface{}{"id": "2", "age": 41},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, errs := schema.Validate(tt.action, tt.data, rows)
+			if !reflect.DeepEqual(errs, tt.wantErrs) {
+				t.Errorf("errors = %v, want %v", errs, tt.wantErrs)
+			}
+			if tt.wantErrs == nil && !reflect.DeepEqual(data, tt.wantData) {
+				t.Errorf("data = %v, want %v", data, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestSchema_ApplyDefaults(t *testing.T) {
+	schema := testSchema(t)
+	got := schema.ApplyDefaults(map[string]interface{}{"name": "Ada", "role": ""})
+	want := map[string]interface{}{"name": "Ada", "role": "user"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplyDefaults() = %v, want %v", got, want)
+	}
+}
+
+func TestNewSchema_Errors(t *testing.T) {
+	tests := map[string]config.FieldSchema{
+		"unknown type":   {Type: "money"},
+		"enum no values": {Type: "enum"},
+		"bad pattern":    {Pattern: "("},
+		"bad on_delete":  {Ref: "users", OnDelete: "drop"},
+		"ref options":    {Display: "name"},
+	}
+	for name, field := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewSchema("s", map[string]config.FieldSchema{"f": field}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if schema, err := NewSchema("s", nil); schema != nil || err != nil {
+		t.Errorf("NewSchema(nil) = %v, %v", schema, err)
+	}
+}
@@ -5,6 +5,10 @@ package source
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
 
 	"github.com/livetemplate/tinkerdown/internal/cache"
 	"github.com/livetemplate/tinkerdown/internal/config"
@@ -32,6 +36,7 @@ type WritableSource interface {
 
 	// WriteItem performs a write operation on the source.
 	// action is one of: "add", "toggle", "delete", "update"
+	// (markdown lists also support "move", "indent", "outdent")
 	// data contains the item data (e.g., form fields, id for delete)
 	WriteItem(ctx context.Context, action string, data map[string]interface{}) error
 
@@ -39,6 +44,25 @@ type WritableSource interface {
 	IsReadonly() bool
 }
 
+// Event is a change delivered by a StreamingSource
+type Event struct {
+	Rows    []map[string]interface{} // Rows carried by the event
+	Replace bool                     // Rows replace all previous rows instead of being appended
+	Err     error                    // Stream failure (e.g., disconnected); Rows is empty
+}
+
+// StreamingSource is implemented by push-based sources (server-sent events,
+// WebSockets, tailed files, GraphQL subscriptions). Fetch returns a snapshot if
+// the source has one; the rows of a stream arrive through Subscribe.
+type StreamingSource interface {
+	Source
+
+	// Subscribe starts the stream. The channel is closed when ctx is done or the
+	// stream ends. Returns nil if the source has nothing to stream (e.g., a
+	// GraphQL source whose query file defines a query).
+	Subscribe(ctx context.Context) <-chan Event
+}
+
 // SQLExecutor extends Source with ability to execute arbitrary SQL statements.
 // This is used by custom actions defined in frontmatter (action kind: "sql").
 type SQLExecutor interface {
@@ -64,10 +88,13 @@ func NewRegistry(cfg *config.Config, siteDir string) (*Registry, error) {
 // NewRegistryWithFile creates a source registry with knowledge of the current markdown file
 // This is needed for markdown sources that reference anchors in the current file
 func NewRegistryWithFile(cfg *config.Config, siteDir, currentFile string) (*Registry, error) {
-	memCache := cache.NewMemoryCache()
+	c, err := newCache(cfg.Cache, siteDir)
+	if err != nil {
+		return nil, err
+	}
 	r := &Registry{
 		sources: make(map[string]Source),
-		cache:   memCache,
+		cache:   c,
 		cfg:     cfg,
 	}
 
@@ -75,24 +102,25 @@ func NewRegistryWithFile(cfg *config.Config, siteDir, currentFile string) (*Regi
 		return r, nil
 	}
 
+	if err := checkSourceInputs(cfg.Sources); err != nil {
+		r.closeCache()
+		return nil, err
+	}
+
 	for name, srcCfg := range cfg.Sources {
-		src, err := createSource(name, srcCfg, siteDir, currentFile)
+		src, err := createSource(name, srcCfg, siteDir, currentFile, r.Get)
+		if err == nil {
+			// Transforms run before caching, so the cache holds the shaped rows
+			src, err = WithTransform(src, srcCfg)
+		}
 		if err != nil {
-			// Stop cache cleanup goroutine to avoid leak on initialization error
-			memCache.Stop()
+			// Stop the cache to avoid leaking it on initialization error
+			r.closeCache()
 			return nil, err
 		}
 
 		// Wrap with caching if enabled
-		if srcCfg.IsCacheEnabled() {
-			if ws, ok := src.(WritableSource); ok {
-				src = NewCachedWritableSource(ws, r.cache, srcCfg)
-			} else {
-				src = NewCachedSource(src, r.cache, srcCfg)
-			}
-		}
-
-		r.sources[name] = src
+		r.sources[name] = WithCache(src, r.cache, srcCfg)
 	}
 
 	return r, nil
@@ -106,10 +134,7 @@ func (r *Registry) Get(name string) (Source, bool) {
 
 // Close releases all sources and stops the cache
 func (r *Registry) Close() error {
-	// Stop the cache cleanup goroutine
-	if mc, ok := r.cache.(*cache.MemoryCache); ok {
-		mc.Stop()
-	}
+	r.closeCache()
 
 	for _, src := range r.sources {
 		if err := src.Close(); err != nil {
@@ -119,6 +144,81 @@ func (r *Registry) Close() error {
 	return nil
 }
 
+// newCache creates the cache backend configured for the site (memory by default)
+func newCache(cfg *config.CacheBackendConfig, siteDir string) (cache.Cache, error) {
+	if cfg == nil || cfg.Backend == "" || cfg.Backend == "memory" {
+		return cache.NewMemoryCache(), nil
+	}
+	if cfg.Backend != "disk" {
+		return nil, fmt.Errorf("cache: unknown backend %q (expected memory or disk)", cfg.Backend)
+	}
+	path := cfg.GetPath()
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(siteDir, path)
+	}
+	// Sites sharing a database file keep their entries apart
+	namespace, err := filepath.Abs(siteDir)
+	if err != nil {
+		namespace = siteDir
+	}
+	return cache.NewDiskCache(path, cache.DiskOptions{
+		MaxSize:    cfg.GetMaxSize(),
+		MaxEntries: cfg.MaxEntries,
+		MaxStale:   cfg.GetMaxStale(),
+		Namespace:  namespace + "|",
+	})
+}
+
+var (
+	sharedCachesMu sync.Mutex
+	sharedCaches   = make(map[string]cache.Cache)
+)
+
+// SharedCache returns the process-wide cache of a site, creating the backend
+// configured in cfg on first use. Pages share it, so cached rows outlive their
+// connections (and, with the disk backend, restarts).
+func SharedCache(siteDir string, cfg *config.CacheBackendConfig) (cache.Cache, error) {
+	cfgKey, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	key := siteDir + "|" + string(cfgKey)
+
+	sharedCachesMu.Lock()
+	defer sharedCachesMu.Unlock()
+	if c, ok := sharedCaches[key]; ok {
+		return c, nil
+	}
+	c, err := newCache(cfg, siteDir)
+	if err != nil {
+		return nil, err
+	}
+	sharedCaches[key] = c
+	return c, nil
+}
+
+// WithCache wraps a source whose config enables caching so it reads through c.
+// Writable sources stay writable; their writes invalidate the cached rows.
+func WithCache(src Source, c cache.Cache, cfg config.SourceConfig) Source {
+	if c == nil || !cfg.IsCacheEnabled() {
+		return src
+	}
+	if ws, ok := src.(WritableSource); ok {
+		return NewCachedWritableSource(ws, c, cfg)
+	}
+	return NewCachedSource(src, c, cfg)
+}
+
+// closeCache stops the memory cache's cleanup goroutine or closes the disk cache
+func (r *Registry) closeCache() {
+	switch c := r.cache.(type) {
+	case *cache.MemoryCache:
+		c.Stop()
+	case *cache.DiskCache:
+		c.Close()
+	}
+}
+
 // InvalidateCache invalidates the cache for a specific source
 func (r *Registry) InvalidateCache(name string) {
 	src, ok := r.sources[name]
@@ -139,8 +239,9 @@ func (r *Registry) InvalidateAllCaches() {
 	r.cache.InvalidateAll()
 }
 
-// createSource instantiates a source based on config type
-func createSource(name string, cfg config.SourceConfig, siteDir, currentFile string) (Source, error) {
+// createSource instantiates a source based on config type. lookup finds the
+// inputs of derived and query sources.
+func createSource(name string, cfg config.SourceConfig, siteDir, currentFile string, lookup func(string) (Source, bool)) (Source, error) {
 	switch cfg.Type {
 	case "exec":
 		if !config.IsExecAllowed() {
@@ -154,25 +255,63 @@ func createSource(name string, cfg config.SourceConfig, siteDir, currentFile str
 	case "pg":
 		return NewPostgresSourceWithConfig(name, cfg.Query, cfg.Options, cfg)
 	case "rest":
-		return NewRestSourceWithConfig(name, cfg)
+		return NewRestSourceWithConfig(name, cfg, siteDir)
 	case "json":
-		return NewJSONFileSource(name, cfg.File, siteDir)
+		return NewJSONFileSourceWithConfig(name, cfg, siteDir)
 	case "csv":
-		return NewCSVFileSource(name, cfg.File, siteDir, cfg.Options)
+		return NewCSVFileSourceWithConfig(name, cfg, siteDir)
+	case "yaml", "toml":
+		return NewDocumentFileSource(name, cfg, siteDir)
+	case "xlsx":
+		return NewXLSXFileSource(name, cfg, siteDir)
+	case "parquet":
+		return NewParquetSource(name, cfg, siteDir)
+	case "arrow":
+		return NewArrowSource(name, cfg, siteDir)
+	case "git":
+		return NewGitSource(name, cfg, siteDir, currentFile)
+	case "derived":
+		return NewDerivedSource(name, cfg, lookup)
+	case "query":
+		return NewQuerySource(name, cfg, lookup)
 	case "markdown":
-		// Use IsReadonly() which defaults to true if not specified
-		return NewMarkdownSource(name, cfg.File, cfg.Anchor, siteDir, currentFile, cfg.IsReadonly())
+		// Uses IsReadonly() which defaults to true if not specified
+		return NewMarkdownSourceWithConfig(name, cfg, siteDir, currentFile)
 	case "sqlite":
 		return NewSQLiteSource(name, cfg.DB, cfg.Table, siteDir, cfg.IsReadonly())
 	case "wasm":
-		return wasm.NewWasmSource(name, cfg.Path, siteDir, cfg.Options)
+		src, err := wasm.NewWasmSource(name, cfg.Path, siteDir, cfg.Options)
+		if err != nil {
+			return nil, err
+		}
+		return WithCircuitBreaker(src, cfg), nil
 	case "graphql":
 		return NewGraphQLSource(name, cfg, siteDir)
+	case "collection":
+		return NewCollectionSource(name, cfg, siteDir)
+	case "sse":
+		return NewSSESource(name, cfg, siteDir)
+	case "websocket":
+		return NewWebSocketSource(name, cfg, siteDir)
+	case "tail":
+		return NewTailSource(name, cfg, siteDir)
 	default:
 		return nil, &UnsupportedSourceError{Type: cfg.Type}
 	}
 }
 
+// SourceInputs returns the names of the sources a derived or query source reads
+func SourceInputs(cfg config.SourceConfig) []string {
+	switch cfg.Type {
+	case "derived":
+		return DerivedInputs(cfg)
+	case "query":
+		return cfg.Tables
+	default:
+		return nil
+	}
+}
+
 // UnsupportedSourceError is returned for unknown source types
 type UnsupportedSourceError struct {
 	Type string
@@ -0,0 +1,188 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// SSESource streams server-sent events from a URL. Each event's data becomes
+// rows (see messageRows); reconnects resume from the last event ID.
+type SSESource struct {
+	name        string
+	url         string
+	headers     map[string]string
+	eventType   string // Only events of this type are used; empty for all
+	client      *http.Client
+	retryConfig RetryConfig
+	lastEventID string // Sent as Last-Event-ID on reconnect
+}
+
+// NewSSESource creates a new server-sent events source
+func NewSSESource(name string, cfg config.SourceConfig, siteDir string) (*SSESource, error) {
+	if cfg.From == "" {
+		return nil, &ValidationError{Source: name, Field: "from", Reason: "from is required"}
+	}
+	if _, err := streamSettings(name, cfg); err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string)
+	for key, value := range cfg.Headers {
+		headers[key] = os.ExpandEnv(value)
+	}
+
+	// No client timeout: the response body stays open for the life of the stream
+	client, err := newHTTPClient(name, cfg.Auth, siteDir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SSESource{
+		name:      name,
+		url:       os.ExpandEnv(cfg.From),
+		headers:   headers,
+		eventType: cfg.Options["event"],
+		client:    client,
+		retryConfig: RetryConfig{
+			MaxRetries: cfg.GetRetryMaxRetries(),
+			BaseDelay:  cfg.GetRetryBaseDelay(),
+			MaxDelay:   cfg.GetRetryMaxDelay(),
+			Multiplier: 2.0,
+		},
+	}, nil
+}
+
+// Name returns the source identifier
+func (s *SSESource) Name() string {
+	return s.name
+}
+
+// Fetch returns no rows: the rows of an SSE source arrive through Subscribe
+func (s *SSESource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{}, nil
+}
+
+// Subscribe connects to the event stream, reconnecting with backoff until ctx is
+// done or the server answers 204 No Content
+func (s *SSESource) Subscribe(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go runStream(ctx, s.name, s.retryConfig, events, s.connect)
+	return events
+}
+
+// Close is a no-op for SSE sources
+func (s *SSESource) Close() error {
+	return nil
+}
+
+// connect runs one connection to the event stream
+func (s *SSESource) connect(ctx context.Context, emit func(Event)) (connected, done bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return false, false, err
+	}
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, false, NewSourceError(s.name, "connect", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		// The server asks the client to stop reconnecting
+		return false, true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return false, false, &HTTPError{Source: s.name, StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		return false, false, &ValidationError{Source: s.name, Reason: fmt.Sprintf("expected text/event-stream, got %q", resp.Header.Get("Content-Type"))}
+	}
+
+	err = readSSE(resp.Body, func(ev sseEvent) {
+		if ev.id != "" {
+			s.lastEventID = ev.id
+		}
+		if s.eventType != "" && ev.typ != s.eventType {
+			return
+		}
+		emit(Event{Rows: messageRows([]byte(ev.data))})
+	})
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return true, false, err
+}
+
+// sseEvent is a dispatched server-sent event
+type sseEvent struct {
+	typ  string // Event type; "message" if not set
+	id   string // Last event ID seen in the stream
+	data string
+}
+
+// readSSE parses a text/event-stream body, calling dispatch for each event with
+// data. Returns nil when the body ends.
+func readSSE(r io.Reader, dispatch func(sseEvent)) error {
+	reader := bufio.NewReader(r)
+
+	var data strings.Builder
+	var typ, id string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			// A blank line dispatches the event
+			if data.Len() > 0 {
+				if typ == "" {
+					typ = "message"
+				}
+				dispatch(sseEvent{typ: typ, id: id, data: strings.TrimSuffix(data.String(), "\n")})
+			}
+			data.Reset()
+			typ = ""
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // Comment (often a keep-alive)
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "event":
+			typ = value
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				id = value
+			}
+		}
+	}
+}
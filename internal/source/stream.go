@@ -0,0 +1,145 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// streamSettings validates the stream block of a source config and returns it
+// with defaults for a missing block
+func streamSettings(name string, cfg config.SourceConfig) (config.StreamConfig, error) {
+	var stream config.StreamConfig
+	if cfg.Stream != nil {
+		stream = *cfg.Stream
+	}
+	if mode := stream.GetMode(); mode != "append" && mode != "replace" {
+		return stream, &ValidationError{Source: name, Field: "stream.mode", Reason: fmt.Sprintf("unknown mode %q (expected append or replace)", mode)}
+	}
+	return stream, nil
+}
+
+// streamConnectFunc runs one connection of a stream, emitting its events.
+// connected is true if the stream was established before it failed; done is true
+// if the stream ended normally and should not be reconnected.
+type streamConnectFunc func(ctx context.Context, emit func(Event)) (connected, done bool, err error)
+
+// runStream runs connect until ctx is done or the stream ends, reconnecting with
+// the backoff of the retry config. Failures are emitted as error events. The
+// events channel is closed on return.
+func runStream(ctx context.Context, name string, retry RetryConfig, events chan<- Event, connect streamConnectFunc) {
+	defer close(events)
+
+	emit := func(ev Event) {
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+		}
+	}
+
+	delay := retry.BaseDelay
+	for {
+		connected, done, err := connect(ctx, emit)
+		if ctx.Err() != nil {
+			return
+		}
+		if done {
+			log.Printf("[source/%s] Stream ended", name)
+			return
+		}
+		if connected {
+			// The connection worked, so this is a new outage
+			delay = retry.BaseDelay
+		}
+
+		log.Printf("[source/%s] Stream disconnected: %v (reconnecting in %v)", name, err, delay)
+		emit(Event{Err: err})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(time.Duration(float64(delay)*retry.Multiplier), retry.MaxDelay)
+	}
+}
+
+// messageRows converts a stream message to rows: a JSON object is one row, a JSON
+// array of objects is several rows, and anything else is a row with a "data" field
+func messageRows(message []byte) []map[string]interface{} {
+	var parsed interface{}
+	if err := json.Unmarshal(message, &parsed); err == nil {
+		switch v := parsed.(type) {
+		case map[string]interface{}:
+			return []map[string]interface{}{v}
+		case []interface{}:
+			rows := make([]map[string]interface{}, 0, len(v))
+			for _, item := range v {
+				if row, ok := item.(map[string]interface{}); ok {
+					rows = append(rows, row)
+				}
+			}
+			if len(rows) == len(v) {
+				return rows
+			}
+		}
+	}
+	return []map[string]interface{}{{"data": strings.TrimRight(string(message), "\r\n")}}
+}
+
+// streamHeader returns the configured headers plus the credentials of the auth
+// block (if any), for requests the HTTP client does not send itself
+func streamHeader(rawURL string, headers map[string]string, client *http.Client) (http.Header, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if rt, ok := client.Transport.(*authRoundTripper); ok {
+		if err := rt.auth.authorize(req); err != nil {
+			return nil, err
+		}
+	}
+	return req.Header, nil
+}
+
+// websocketDialer creates a dialer using the TLS settings of an HTTP client's auth block
+func websocketDialer(client *http.Client, timeout time.Duration, subprotocols ...string) *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Subprotocols:     subprotocols,
+		HandshakeTimeout: timeout,
+		Proxy:            http.ProxyFromEnvironment,
+	}
+	transport := client.Transport
+	if rt, ok := transport.(*authRoundTripper); ok {
+		transport = rt.base
+	}
+	if t, ok := transport.(*http.Transport); ok {
+		dialer.TLSClientConfig = t.TLSClientConfig
+	}
+	return dialer
+}
+
+// closeOnDone closes conn when ctx is done, to unblock reads. Call the returned
+// function when the connection is no longer used.
+func closeOnDone(ctx context.Context, conn interface{ Close() error }) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,197 @@
+package source
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// StreamHub shares one upstream stream between subscribers (e.g., every page
+// showing a source). The upstream starts with the first subscriber and stops
+// when the last one leaves. Recent events are kept and replayed to new
+// subscribers, and each subscriber has a bounded queue: a subscriber that falls
+// behind loses events instead of holding up the others.
+type StreamHub struct {
+	name   string
+	create func() (StreamingSource, error)
+	replay int // Events kept for new subscribers
+	buffer int // Queue size per subscriber
+
+	mu           sync.Mutex
+	src          StreamingSource
+	notStreaming bool       // The source has nothing to stream
+	run          *streamRun // Running upstream; nil if stopped
+	history      []Event    // Last events, oldest first (errors excluded)
+	subscribers  map[*streamSubscriber]struct{}
+}
+
+// streamRun is one run of the upstream stream
+type streamRun struct {
+	cancel context.CancelFunc
+}
+
+// streamSubscriber is a subscriber's queue
+type streamSubscriber struct {
+	events  chan Event
+	dropped int
+}
+
+// NewStreamHub creates a hub for the stream of the source returned by create.
+// The source is created on first subscribe.
+func NewStreamHub(name string, create func() (StreamingSource, error), replay, buffer int) *StreamHub {
+	return &StreamHub{
+		name:        name,
+		create:      create,
+		replay:      replay,
+		buffer:      max(buffer, 1),
+		subscribers: make(map[*streamSubscriber]struct{}),
+	}
+}
+
+var (
+	sharedHubsMu sync.Mutex
+	sharedHubs   = make(map[string]*StreamHub)
+)
+
+// SharedStreamHub returns the process-wide hub for key, creating it with
+// NewStreamHub on first use. Pages subscribing with the same key share the
+// upstream connection.
+func SharedStreamHub(key, name string, create func() (StreamingSource, error), replay, buffer int) *StreamHub {
+	sharedHubsMu.Lock()
+	defer sharedHubsMu.Unlock()
+
+	if hub, ok := sharedHubs[key]; ok {
+		return hub
+	}
+	hub := NewStreamHub(name, create, replay, buffer)
+	sharedHubs[key] = hub
+	return hub
+}
+
+// Subscribe returns a channel of events, starting with the replayed history. The
+// channel is closed when ctx is done or the upstream stream ends. Returns nil if
+// the source has nothing to stream.
+func (h *StreamHub) Subscribe(ctx context.Context) (<-chan Event, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.run == nil {
+		if err := h.start(); err != nil {
+			return nil, err
+		}
+	}
+	if h.notStreaming {
+		return nil, nil
+	}
+
+	sub := &streamSubscriber{events: make(chan Event, h.buffer+len(h.history))}
+	for _, ev := range h.history {
+		sub.events <- ev
+	}
+	h.subscribers[sub] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		h.unsubscribe(sub)
+	}()
+	return sub.events, nil
+}
+
+// start creates the source if needed and starts the upstream stream.
+// Must be called with h.mu held.
+func (h *StreamHub) start() error {
+	if h.notStreaming {
+		return nil
+	}
+	if h.src == nil {
+		src, err := h.create()
+		if err != nil {
+			return err
+		}
+		h.src = src
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := h.src.Subscribe(ctx)
+	if events == nil {
+		cancel()
+		h.notStreaming = true
+		return nil
+	}
+
+	run := &streamRun{cancel: cancel}
+	h.run = run
+	go h.forward(run, events)
+	return nil
+}
+
+// forward delivers upstream events to the subscribers until the upstream closes
+func (h *StreamHub) forward(run *streamRun, events <-chan Event) {
+	for ev := range events {
+		h.publish(run, ev)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.run != run {
+		// Stopped because the last subscriber left
+		return
+	}
+	// The stream ended: close the subscribers, the next subscriber restarts it
+	h.run = nil
+	for sub := range h.subscribers {
+		close(sub.events)
+		delete(h.subscribers, sub)
+	}
+}
+
+// publish records an event and queues it for each subscriber
+func (h *StreamHub) publish(run *streamRun, ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.run != run {
+		return
+	}
+
+	if ev.Err == nil && h.replay > 0 {
+		if ev.Replace {
+			// Older events are superseded
+			h.history = h.history[:0]
+		}
+		h.history = append(h.history, ev)
+		if len(h.history) > h.replay {
+			h.history = append([]Event(nil), h.history[len(h.history)-h.replay:]...)
+		}
+	}
+
+	for sub := range h.subscribers {
+		select {
+		case sub.events <- ev:
+		default:
+			if sub.dropped == 0 {
+				log.Printf("[source/%s] Subscriber is falling behind, dropping events (buffer: %d)", h.name, h.buffer)
+			}
+			sub.dropped++
+		}
+	}
+}
+
+// unsubscribe removes a subscriber, stopping the upstream if it was the last one
+func (h *StreamHub) unsubscribe(sub *streamSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+	delete(h.subscribers, sub)
+	close(sub.events)
+	if sub.dropped > 0 {
+		log.Printf("[source/%s] Subscriber dropped %d events", h.name, sub.dropped)
+	}
+
+	if len(h.subscribers) == 0 && h.run != nil {
+		h.run.cancel()
+		h.run = nil
+	}
+}
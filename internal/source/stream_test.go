@@ -0,0 +1,296 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+func TestMessageRows(t *testing.T) {
+	tests := []struct {
+		message string
+		want    int
+		field   string
+	}{
+		{`{"id": 1}`, 1, "id"},
+		{`[{"id": 1}, {"id": 2}]`, 2, "id"},
+		{`[1, 2]`, 1, "data"},
+		{"plain text\n", 1, "data"},
+	}
+	for _, tt := range tests {
+		rows := messageRows([]byte(tt.message))
+		if len(rows) != tt.want {
+			t.Errorf("messageRows(%q) = %v, want %d rows", tt.message, rows, tt.want)
+			continue
+		}
+		if _, ok := rows[0][tt.field]; !ok {
+			t.Errorf("messageRows(%q) = %v, want field %q", tt.message, rows, tt.field)
+		}
+	}
+	if rows := messageRows([]byte("plain text\n")); rows[0]["data"] != "plain text" {
+		t.Errorf("expected trailing newline to be trimmed, got %q", rows[0]["data"])
+	}
+}
+
+func TestReadSSE(t *testing.T) {
+	body := ": keep-alive\n" +
+		"id: 1\n" +
+		"data: {\"n\": 1}\n\n" +
+		"event: status\r\n" +
+		"data: line one\r\n" +
+		"data:line two\r\n\r\n" +
+		"id: 3\n\n" + // No data: not dispatched
+		"data: unterminated"
+
+	var events []sseEvent
+	if err := readSSE(strings.NewReader(body), func(ev sseEvent) { events = append(events, ev) }); err != nil {
+		t.Fatalf("readSSE failed: %v", err)
+	}
+	want := []sseEvent{
+		{typ: "message", id: "1", data: `{"n": 1}`},
+		{typ: "status", id: "1", data: "line one\nline two"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+// streamRows waits for the next event with rows
+func streamRows(t *testing.T, events <-chan Event) []map[string]interface{} {
+	t.Helper()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			if ev.Err == nil {
+				return ev.Rows
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+}
+
+func TestSSESource(t *testing.T) {
+	lastEventIDs := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		if r.Header.Get("Last-Event-ID") == "2" {
+			// Done: stop reconnecting
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "id: 1\ndata: {\"status\": \"up\"}\n\n")
+		fmt.Fprint(w, "event: ping\ndata: ignored\n\n")
+		fmt.Fprint(w, "id: 2\ndata: [{\"status\": \"down\"}, {\"status\": \"up\"}]\n\n")
+	}))
+	defer server.Close()
+
+	src, err := NewSSESource("status", config.SourceConfig{
+		Type:    "sse",
+		From:    server.URL,
+		Options: map[string]string{"event": "message"},
+		Retry:   &config.RetryConfig{BaseDelay: "10ms"},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	events := src.Subscribe(context.Background())
+	if rows := streamRows(t, events); len(rows) != 1 || rows[0]["status"] != "up" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	if rows := streamRows(t, events); len(rows) != 2 {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	// The reconnect resumes after the last event and gets 204, which ends the stream
+	for ev := range events {
+		if ev.Err == nil {
+			t.Errorf("unexpected event: %+v", ev)
+		}
+	}
+	if first, second := <-lastEventIDs, <-lastEventIDs; first != "" || second != "2" {
+		t.Errorf("Last-Event-ID = %q, %q; want \"\", \"2\"", first, second)
+	}
+}
+
+func TestWebSocketSource(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, msg, err := conn.ReadMessage()
+		if err != nil || string(msg) != `{"subscribe": "trades"}` {
+			t.Errorf("expected subscribe message, got %q (%v)", msg, err)
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"price": 10}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`tick`))
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}))
+	defer server.Close()
+
+	src, err := NewWebSocketSource("trades", config.SourceConfig{
+		Type:    "websocket",
+		From:    "ws" + strings.TrimPrefix(server.URL, "http"),
+		Headers: map[string]string{"X-Token": "secret"},
+		Stream:  &config.StreamConfig{Send: `{"subscribe": "trades"}`},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	events := src.Subscribe(context.Background())
+	if rows := streamRows(t, events); rows[0]["price"] != float64(10) {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	if rows := streamRows(t, events); rows[0]["data"] != "tick" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	// A normal close ends the stream
+	for ev := range events {
+		t.Errorf("unexpected event after close: %+v", ev)
+	}
+}
+
+func TestTailSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "app.log")
+	os.WriteFile(path, []byte("one\ntwo\r\nthree\npartial"), 0644)
+
+	src, err := NewTailSource("log", config.SourceConfig{Type: "tail", File: "app.log", Stream: &config.StreamConfig{MaxRows: 2}}, tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	rows, err := src.Fetch(context.Background())
+	if err != nil || len(rows) != 2 || rows[0]["line"] != "two" || rows[1]["line"] != "three" {
+		t.Fatalf("unexpected fetch: %v (%v)", rows, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := src.Subscribe(ctx)
+
+	ev := <-events
+	if !ev.Replace || len(ev.Rows) != 2 {
+		t.Fatalf("expected initial replace event, got %+v", ev)
+	}
+
+	// The partial line is emitted once complete
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(" line\nfour\n")
+	f.Close()
+	if rows := streamRows(t, events); len(rows) != 2 || rows[0]["line"] != "partial line" || rows[1]["line"] != "four" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	// Truncation starts over
+	os.WriteFile(path, []byte("new\n"), 0644)
+	ev = <-events
+	if !ev.Replace || len(ev.Rows) != 1 || ev.Rows[0]["line"] != "new" {
+		t.Errorf("expected replace after truncation, got %+v", ev)
+	}
+
+	if _, err := NewTailSource("log", config.SourceConfig{Type: "tail"}, tmpDir); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+// fakeStream is a StreamingSource whose events are sent by the test
+type fakeStream struct {
+	subscribed chan context.Context
+	events     chan Event
+}
+
+func (s *fakeStream) Name() string { return "fake" }
+func (s *fakeStream) Close() error { return nil }
+
+func (s *fakeStream) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (s *fakeStream) Subscribe(ctx context.Context) <-chan Event {
+	s.events = make(chan Event)
+	s.subscribed <- ctx
+	return s.events
+}
+
+func TestStreamHub(t *testing.T) {
+	upstream := &fakeStream{subscribed: make(chan context.Context, 2)}
+	hub := NewStreamHub("fake", func() (StreamingSource, error) { return upstream, nil }, 2, 1)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	first, err := hub.Subscribe(ctx1)
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	upstreamCtx := <-upstream.subscribed
+
+	row := func(id int) Event { return Event{Rows: []map[string]interface{}{{"id": id}}} }
+	upstream.events <- row(1)
+	if ev := <-first; ev.Rows[0]["id"] != 1 {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+	upstream.events <- row(2)
+	upstream.events <- row(3) // Dropped: the buffer holds 1 event
+	upstream.events <- Event{Err: fmt.Errorf("disconnected")}
+
+	// A new subscriber shares the upstream and gets the last 2 events (not errors)
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	second, _ := hub.Subscribe(ctx2)
+	if ev := <-second; ev.Rows[0]["id"] != 2 {
+		t.Errorf("replayed %+v, want id 2", ev)
+	}
+	if ev := <-second; ev.Rows[0]["id"] != 3 {
+		t.Errorf("replayed %+v, want id 3", ev)
+	}
+	if ev := <-first; ev.Rows[0]["id"] != 2 {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+	select {
+	case ev := <-first:
+		t.Errorf("expected events to be dropped, got %+v", ev)
+	default:
+	}
+
+	// The upstream stops when the last subscriber leaves
+	cancel1()
+	cancel2()
+	select {
+	case <-upstreamCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream not stopped")
+	}
+	for range first {
+	}
+	for range second {
+	}
+}
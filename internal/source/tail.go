@@ -0,0 +1,262 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+const (
+	// tailPollInterval is how often a tailed file is checked for new lines
+	tailPollInterval = 250 * time.Millisecond
+
+	// tailInitialBytes caps how much of the end of a file is read for the initial rows
+	tailInitialBytes = 1 << 20
+)
+
+// TailSource streams lines appended to a local file, like tail -f. It follows the
+// file across rotation (a new file at the path, detected by its inode) and
+// truncation. Lines are parsed into rows (see lineParser) and filtered, and the
+// last max_rows matching rows are kept.
+type TailSource struct {
+	name        string
+	path        string
+	maxRows     int
+	parser      *lineParser
+	filters     []filterPredicate
+	retryConfig RetryConfig
+}
+
+// NewTailSource creates a new file tail source
+func NewTailSource(name string, cfg config.SourceConfig, siteDir string) (*TailSource, error) {
+	if cfg.File == "" {
+		return nil, &ValidationError{Source: name, Field: "file", Reason: "file is required"}
+	}
+	stream, err := streamSettings(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	parser, err := newLineParser(name, cfg.Format, cfg.Options["pattern"])
+	if err != nil {
+		return nil, err
+	}
+	filters, err := parseFilters(name, cfg.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TailSource{
+		name:    name,
+		path:    resolveSitePath(siteDir, os.ExpandEnv(cfg.File)),
+		maxRows: stream.GetMaxRows(),
+		parser:  parser,
+		filters: filters,
+		retryConfig: RetryConfig{
+			MaxRetries: cfg.GetRetryMaxRetries(),
+			BaseDelay:  cfg.GetRetryBaseDelay(),
+			MaxDelay:   cfg.GetRetryMaxDelay(),
+			Multiplier: 2.0,
+		},
+	}, nil
+}
+
+// Name returns the source identifier
+func (s *TailSource) Name() string {
+	return s.name
+}
+
+// Fetch returns the rows of the last lines of the file (up to stream.max_rows)
+func (s *TailSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("tail source %q: %w", s.name, err)
+	}
+	defer f.Close()
+
+	rows, _, err := s.readInitial(f)
+	if err != nil {
+		return nil, fmt.Errorf("tail source %q: %w", s.name, err)
+	}
+	return rows, nil
+}
+
+// Subscribe follows the file until ctx is done. The first event replaces the rows
+// with those of the last lines of the file; each later event appends the rows of
+// new lines. If the file is truncated, it is read again from the start.
+func (s *TailSource) Subscribe(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go runStream(ctx, s.name, s.retryConfig, events, s.follow)
+	return events
+}
+
+// Close is a no-op for tail sources
+func (s *TailSource) Close() error {
+	return nil
+}
+
+// follow tails the file until ctx is done or the file cannot be read
+func (s *TailSource) follow(ctx context.Context, emit func(Event)) (connected, done bool, err error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return false, false, err
+	}
+	defer func() { f.Close() }()
+
+	rows, offset, err := s.readInitial(f)
+	if err != nil {
+		return false, false, err
+	}
+	emit(Event{Rows: rows, Replace: true})
+
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return true, false, nil
+		case <-ticker.C:
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			return true, false, err
+		}
+
+		var lines []string
+		replace := false
+		if info.Size() < offset {
+			// Truncated in place (e.g., copytruncate): start over
+			offset = 0
+			replace = true
+		}
+		if info.Size() != offset {
+			if lines, offset, err = readLines(f, offset); err != nil {
+				return true, false, err
+			}
+		}
+
+		// Rotated: the path names a new file. Read the old file to its end, then
+		// continue with the new file from its start.
+		if next := s.openRotated(info); next != nil {
+			last, lastOffset, err := readLines(f, offset)
+			if err != nil {
+				next.Close()
+				return true, false, err
+			}
+			lines = append(lines, last...)
+			if rest := readRest(f, lastOffset); rest != "" {
+				lines = append(lines, rest)
+			}
+			f.Close()
+			f = next
+			log.Printf("[source/%s] File rotated, following the new file", s.name)
+
+			more, nextOffset, err := readLines(f, 0)
+			if err != nil {
+				return true, false, err
+			}
+			lines = append(lines, more...)
+			offset = nextOffset
+		}
+
+		if rows := s.parseLines(lines); len(rows) > 0 || replace {
+			emit(Event{Rows: rows, Replace: replace})
+		}
+	}
+}
+
+// openRotated opens the file at the path if it is no longer the open file
+// (compared by device and inode). Returns nil if the file was not rotated or the
+// new file does not exist yet.
+func (s *TailSource) openRotated(current os.FileInfo) *os.File {
+	info, err := os.Stat(s.path)
+	if err != nil || os.SameFile(info, current) {
+		return nil
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
+// readInitial reads the last lines of the file, returning the rows of the last
+// max_rows matching lines with the offset after the last complete line
+func (s *TailSource) readInitial(f *os.File) ([]map[string]interface{}, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := max(info.Size()-tailInitialBytes, 0)
+	lines, offset, err := readLines(f, start)
+	if err != nil {
+		return nil, 0, err
+	}
+	if start > 0 && len(lines) > 0 {
+		// The first line was cut by the start of the read, unless the read
+		// starts right after a newline
+		prev := make([]byte, 1)
+		if _, err := f.ReadAt(prev, start-1); err != nil || prev[0] != '\n' {
+			lines = lines[1:]
+		}
+	}
+	return s.parseLines(lines), offset, nil
+}
+
+// parseLines converts lines to rows, keeping the last max_rows rows that match
+// the filters
+func (s *TailSource) parseLines(lines []string) []map[string]interface{} {
+	rows := make([]map[string]interface{}, 0, len(lines))
+	for _, line := range lines {
+		if row := s.parser.parse(line); matchFilters(row, s.filters) {
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) > s.maxRows {
+		rows = rows[len(rows)-s.maxRows:]
+	}
+	return rows
+}
+
+// readLines reads the complete lines after offset, returning them with the offset
+// after the last one. A partial last line is left for the next read.
+func readLines(f *os.File, offset int64) ([]string, int64, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, offset, err
+	}
+
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return nil, offset, nil
+	}
+
+	lines := strings.Split(string(data[:end]), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, offset + int64(end) + 1, nil
+}
+
+// readRest returns the unterminated last line after offset, if any
+func readRest(f *os.File, offset int64) string {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return ""
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(data), "\r\n")
+}
@@ -0,0 +1,151 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Line formats of a tail source
+const (
+	lineFormatText   = "text"   // Row with a "line" field
+	lineFormatJSON   = "json"   // One JSON object per line
+	lineFormatLogfmt = "logfmt" // key=value pairs
+	lineFormatRegex  = "regex"  // Named groups of options.pattern
+)
+
+// lineParser converts log lines to rows. Lines that do not parse become rows with
+// a "line" field, so nothing is lost.
+type lineParser struct {
+	format  string
+	pattern *regexp.Regexp
+}
+
+// newLineParser validates the format and pattern of a tail source
+func newLineParser(name, format, pattern string) (*lineParser, error) {
+	format = strings.ToLower(format)
+	if format == "" {
+		format = lineFormatText
+		if pattern != "" {
+			format = lineFormatRegex
+		}
+	}
+
+	p := &lineParser{format: format}
+	switch format {
+	case lineFormatText, lineFormatJSON, lineFormatLogfmt:
+	case lineFormatRegex:
+		if pattern == "" {
+			return nil, &ValidationError{Source: name, Field: "options.pattern", Reason: "pattern is required for format regex"}
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, &ValidationError{Source: name, Field: "options.pattern", Reason: err.Error()}
+		}
+		named := false
+		for _, group := range re.SubexpNames() {
+			named = named || group != ""
+		}
+		if !named {
+			return nil, &ValidationError{Source: name, Field: "options.pattern", Reason: "pattern needs named groups, e.g. (?P<level>\\w+)"}
+		}
+		p.pattern = re
+	default:
+		return nil, &ValidationError{Source: name, Field: "format", Reason: fmt.Sprintf("unknown format %q (expected text, json, logfmt, or regex)", format)}
+	}
+	return p, nil
+}
+
+// parse converts a line to a row
+func (p *lineParser) parse(line string) map[string]interface{} {
+	switch p.format {
+	case lineFormatJSON:
+		var row map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &row); err == nil && row != nil {
+			return row
+		}
+	case lineFormatLogfmt:
+		if row, pairs := parseLogfmt(line); pairs > 0 {
+			return row
+		}
+	case lineFormatRegex:
+		if match := p.pattern.FindStringSubmatch(line); match != nil {
+			row := make(map[string]interface{})
+			for i, group := range p.pattern.SubexpNames() {
+				if group != "" {
+					row[group] = match[i]
+				}
+			}
+			return row
+		}
+	}
+	return map[string]interface{}{"line": line}
+}
+
+// parseLogfmt parses key=value pairs, returning the row and the number of pairs.
+// Values may be double-quoted with backslash escapes; a key without a value is true.
+func parseLogfmt(line string) (map[string]interface{}, int) {
+	row := make(map[string]interface{})
+	pairs := 0
+	i := 0
+	for i < len(line) {
+		// Skip spaces
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i == len(line) {
+			break
+		}
+
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		key := line[start:i]
+		if i == len(line) || line[i] != '=' {
+			if key != "" {
+				row[key] = true
+			}
+			continue
+		}
+		i++ // Skip '='
+
+		if i < len(line) && line[i] == '"' {
+			var value strings.Builder
+			i++
+			for i < len(line) && line[i] != '"' {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+					switch line[i] {
+					case 'n':
+						value.WriteByte('\n')
+					case 't':
+						value.WriteByte('\t')
+					default:
+						value.WriteByte(line[i])
+					}
+				} else {
+					value.WriteByte(line[i])
+				}
+				i++
+			}
+			i++ // Skip closing quote
+			if key != "" {
+				row[key] = value.String()
+				pairs++
+			}
+			continue
+		}
+
+		start = i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		if key != "" {
+			row[key] = line[start:i]
+			pairs++
+		}
+	}
+	return row, pairs
+}
@@ -0,0 +1,198 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+func TestLineParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		pattern string
+		line    string
+		want    map[string]interface{}
+	}{
+		{"text", "", "", "hello", map[string]interface{}{"line": "hello"}},
+		{"json", "json", "", `{"level": "error", "n": 2}`, map[string]interface{}{"level": "error", "n": float64(2)}},
+		{"json fallback", "json", "", "not json", map[string]interface{}{"line": "not json"}},
+		{"logfmt", "logfmt", "", `ts=1 level=warn msg="disk \"sda\" full" retry`, map[string]interface{}{"ts": "1", "level": "warn", "msg": `disk "sda" full`, "retry": true}},
+		{"logfmt fallback", "logfmt", "", "plain words", map[string]interface{}{"line": "plain words"}},
+		{"regex", "", `^(?P<ip>\S+) \S+ \S+ \[[^\]]+\] "(?P<method>\w+) (?P<path>\S+)[^"]*" (?P<status>\d+)`,
+			`10.0.0.1 - - [10/Oct/2026:13:55:36 +0000] "GET /api/users HTTP/1.1" 503 12`,
+			map[string]interface{}{"ip": "10.0.0.1", "method": "GET", "path": "/api/users", "status": "503"}},
+		{"regex fallback", "regex", `(?P<n>\d+)`, "none", map[string]interface{}{"line": "none"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newLineParser("log", tt.format, tt.pattern)
+			if err != nil {
+				t.Fatalf("newLineParser failed: %v", err)
+			}
+			if got := p.parse(tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse(%q) = %v, want %v", tt.line, got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []struct{ format, pattern string }{{"xml", ""}, {"regex", ""}, {"regex", `(\d+)`}, {"regex", `(?P<x>`}} {
+		if _, err := newLineParser("log", bad.format, bad.pattern); err == nil {
+			t.Errorf("expected error for format %q pattern %q", bad.format, bad.pattern)
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	row := map[string]interface{}{
+		"level":  "error",
+		"status": "503",
+		"msg":    "upstream timeout",
+		"http":   map[string]interface{}{"method": "POST"},
+		"user":   nil,
+	}
+	tests := map[string]bool{
+		"level == error":        true,
+		"level == 'warn'":       false,
+		`level != "warn"`:       true,
+		"status >= 500":         true,
+		"status < 60":           false, // Numeric, not string, comparison
+		"msg contains timeout":  true,
+		"msg matches ^up\\w+ t": true,
+		"http.method == POST":   true,
+		"missing == x":          false,
+		"missing != x":          true,
+		"user < x":              false, // Null, like missing
+		"user != x":             true,
+		"level > debug":         true,
+	}
+	for expr, want := range tests {
+		predicates, err := parseFilters("log", []string{expr})
+		if err != nil {
+			t.Errorf("parseFilters(%q) failed: %v", expr, err)
+			continue
+		}
+		if got := matchFilters(row, predicates); got != want {
+			t.Errorf("%q = %v, want %v", expr, got, want)
+		}
+	}
+
+	for _, bad := range []string{"level", "level = error", "msg matches (", "contains x"} {
+		if _, err := parseFilters("log", []string{bad}); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestTailSource_FormatAndFilter(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "app.log"), []byte(
+		"level=info msg=start\n"+
+			"level=error msg=\"db down\"\n"+
+			"level=info msg=retry\n"+
+			"level=error msg=\"db still down\"\n"+
+			"level=error msg=recovered\n"), 0644)
+
+	src, err := NewTailSource("errors", config.SourceConfig{
+		Type:   "tail",
+		File:   "app.log",
+		Format: "logfmt",
+		Filter: []string{"level == error"},
+		Stream: &config.StreamConfig{MaxRows: 2},
+	}, tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	// The window holds the last 2 matching rows
+	rows, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["msg"] != "db still down" || rows[1]["msg"] != "recovered" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+}
+
+func TestTailSource_InitialReadBoundary(t *testing.T) {
+	tmpDir := t.TempDir()
+	// "keep" starts the last tailInitialBytes of the file
+	padding := strings.Repeat("x", tailInitialBytes-len("keep\n")-1) + "\n"
+	tests := []struct {
+		name, prefix string
+		want         int
+	}{
+		{"read starts on a line", "cut\n", 1},
+		{"read starts inside a line", "k", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(filepath.Join(tmpDir, "app.log"), []byte(tt.prefix+"keep\n"+padding), 0644)
+			src, err := NewTailSource("log", config.SourceConfig{Type: "tail", File: "app.log", Filter: []string{"line == keep"}}, tmpDir)
+			if err != nil {
+				t.Fatalf("failed to create source: %v", err)
+			}
+			defer src.Close()
+
+			rows, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("fetch failed: %v", err)
+			}
+			if len(rows) != tt.want {
+				t.Errorf("expected %d rows, got %v", tt.want, rows)
+			}
+		})
+	}
+}
+
+func TestTailSource_Rotation(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "app.log")
+	os.WriteFile(path, []byte("one\n"), 0644)
+
+	src, err := NewTailSource("log", config.SourceConfig{Type: "tail", File: path}, "")
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := src.Subscribe(ctx)
+	if ev := <-events; len(ev.Rows) != 1 {
+		t.Fatalf("unexpected initial event: %+v", ev)
+	}
+
+	// Rotate: the old file gets a last line, then moves away and a new file appears
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("two\nunterminated")
+	f.Close()
+	os.Rename(path, path+".1")
+	os.WriteFile(path, []byte("three\n"), 0644)
+
+	var lines []interface{}
+	for len(lines) < 3 {
+		ev := <-events
+		if ev.Err != nil || ev.Replace {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+		for _, row := range ev.Rows {
+			lines = append(lines, row["line"])
+		}
+	}
+	if want := []interface{}{"two", "unterminated", "three"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %v, want %v", lines, want)
+	}
+
+	// The new file is followed
+	f, _ = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("four\n")
+	f.Close()
+	if rows := streamRows(t, events); rows[0]["line"] != "four" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+}
@@ -0,0 +1,567 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// transformCastTypes are the types columns can be cast to
+var transformCastTypes = []string{"string", "int", "float", "bool"}
+
+// transformDateLayouts are tried in order when parse_date has no layout
+var transformDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02", time.RFC1123Z, time.RFC1123, "01/02/2006"}
+
+// Transform is a pipeline of steps shaping the rows of a source (see
+// config.TransformStep). A nil Transform leaves rows unchanged.
+type Transform struct {
+	steps []transformStep
+}
+
+// transformStep is one step of a transform; it may modify the rows it is given
+type transformStep func(rows []map[string]interface{}) []map[string]interface{}
+
+// NewTransform parses the transform steps of a source. Returns nil if there are
+// no steps.
+func NewTransform(name string, steps []config.TransformStep) (*Transform, error) {
+	if len(steps) == 0 {
+		return nil, nil
+	}
+	t := &Transform{}
+	for i, step := range steps {
+		fn, err := parseTransformStep(name, step)
+		if err != nil {
+			return nil, &ValidationError{Source: name, Field: fmt.Sprintf("transform[%d]", i), Reason: err.Error()}
+		}
+		t.steps = append(t.steps, fn)
+	}
+	return t, nil
+}
+
+// Apply runs the steps over copies of the rows, so rows shared with a cache or
+// other pages are not modified
+func (t *Transform) Apply(rows []map[string]interface{}) []map[string]interface{} {
+	if t == nil {
+		return rows
+	}
+	copies := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		copies[i] = make(map[string]interface{}, len(row))
+		for k, v := range row {
+			copies[i][k] = v
+		}
+	}
+	for _, step := range t.steps {
+		copies = step(copies)
+	}
+	return copies
+}
+
+// parseTransformStep returns the function of a step with exactly one operation
+func parseTransformStep(name string, step config.TransformStep) (transformStep, error) {
+	var ops []string
+	var fn transformStep
+	var err error
+	if step.Filter != nil {
+		ops = append(ops, "filter")
+		fn, err = filterStep(name, step.Filter)
+	}
+	if step.Select != nil {
+		ops = append(ops, "select")
+		fn = selectStep(step.Select)
+	}
+	if step.Rename != nil {
+		ops = append(ops, "rename")
+		fn = renameStep(step.Rename)
+	}
+	if step.Sort != nil {
+		ops = append(ops, "sort")
+		fn, err = sortStep(step.Sort)
+	}
+	if step.Limit != nil {
+		ops = append(ops, "limit")
+		fn, err = limitStep(*step.Limit)
+	}
+	if step.Dedupe != nil {
+		ops = append(ops, "dedupe")
+		fn, err = dedupeStep(step.Dedupe)
+	}
+	if step.Flatten != nil {
+		ops = append(ops, "flatten")
+		fn, err = flattenStep(step.Flatten)
+	}
+	if step.ParseDate != nil {
+		ops = append(ops, "parse_date")
+		fn = parseDateStep(step.ParseDate)
+	}
+	if step.Cast != nil {
+		ops = append(ops, "cast")
+		fn, err = castStep(step.Cast)
+	}
+
+	switch {
+	case len(ops) == 0:
+		return nil, fmt.Errorf("empty step (expected filter, select, rename, sort, limit, dedupe, flatten, parse_date or cast)")
+	case len(ops) > 1:
+		return nil, fmt.Errorf("step has several operations (%s); use one step each", strings.Join(ops, ", "))
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", ops[0], err)
+	}
+	return fn, nil
+}
+
+// filterStep keeps the rows matching all predicates
+func filterStep(name string, exprs []string) (transformStep, error) {
+	predicates, err := parseFilters(name, exprs)
+	if err != nil {
+		return nil, err
+	}
+	return func(rows []map[string]interface{}) []map[string]interface{} {
+		matching := rows[:0]
+		for _, row := range rows {
+			if matchFilters(row, predicates) {
+				matching = append(matching, row)
+			}
+		}
+		return matching
+	}, nil
+}
+
+// selectStep keeps only the given columns; dotted paths select nested values
+func selectStep(columns []string) transformStep {
+	return func(rows []map[string]interface{}) []map[string]interface{} {
+		for i, row := range rows {
+			selected := make(map[string]interface{}, len(columns))
+			for _, column := range columns {
+				if v, ok := row[column]; ok {
+					selected[column] = v
+				} else if v, err := navigateJSONPath(row, column); err == nil {
+					selected[column] = v
+				}
+			}
+			rows[i] = selected
+		}
+		return rows
+	}
+}
+
+// renameStep renames columns; all columns are renamed at once, so names can be swapped
+func renameStep(names map[string]string) transformStep {
+	return func(rows []map[string]interface{}) []map[string]interface{} {
+		for _, row := range rows {
+			renamed := make(map[string]interface{}, len(names))
+			for from, to := range names {
+				if v, ok := row[from]; ok {
+					renamed[to] = v
+					delete(row, from)
+				}
+			}
+			for k, v := range renamed {
+				row[k] = v
+			}
+		}
+		return rows
+	}
+}
+
+// sortKey is a column rows are sorted by
+type sortKey struct {
+	column string
+	desc   bool
+}
+
+// sortStep sorts rows by columns, in order; null values sort last
+func sortStep(columns []string) (transformStep, error) {
+	var keys []sortKey
+	for _, column := range columns {
+		key := sortKey{column: strings.TrimSpace(column)}
+		if strings.HasPrefix(key.column, "-") {
+			key.column, key.desc = strings.TrimSpace(key.column[1:]), true
+		} else if col, dir, ok := strings.Cut(key.column, " "); ok {
+			switch strings.ToLower(strings.TrimSpace(dir)) {
+			case "asc":
+			case "desc":
+				key.desc = true
+			default:
+				return nil, fmt.Errorf("invalid sort %q (expected \"column\", \"-column\" or \"column desc\")", column)
+			}
+			key.column = col
+		}
+		if key.column == "" {
+			return nil, fmt.Errorf("invalid sort %q", column)
+		}
+		keys = append(keys, key)
+	}
+	return func(rows []map[string]interface{}) []map[string]interface{} {
+		slices.SortStableFunc(rows, func(a, b map[string]interface{}) int {
+			for _, key := range keys {
+				x, y := columnExpr(key.column).eval(a), columnExpr(key.column).eval(b)
+				var c int
+				switch {
+				case x == nil && y == nil:
+					continue
+				case x == nil:
+					return 1
+				case y == nil:
+					return -1
+				default:
+					c = compareValues(x, y)
+				}
+				if key.desc {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+			return 0
+		})
+		return rows
+	}, nil
+}
+
+// limitStep keeps the first n rows
+func limitStep(n int) (transformStep, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid limit %d", n)
+	}
+	return func(rows []map[string]interface{}) []map[string]interface{} {
+		if len(rows) > n {
+			return rows[:n]
+		}
+		return rows
+	}, nil
+}
+
+// dedupeStep keeps the first row per distinct value of the columns ("*" for the whole row)
+func dedupeStep(columns []string) (transformStep, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("columns are required (\"*\" for whole rows)")
+	}
+	whole := slices.Contains(columns, "*")
+	return func(rows []map[string]interface{}) []map[string]interface{} {
+		seen := make(map[string]bool)
+		unique := rows[:0]
+		for _, row := range rows {
+			var key string
+			if whole {
+				// Map keys are encoded in order
+				data, _ := json.Marshal(row)
+				key = string(data)
+			} else {
+				parts := make([]string, len(columns))
+				for i, column := range columns {
+					if v := columnExpr(column).eval(row); v != nil {
+						parts[i] = exprText(v)
+					}
+				}
+				key = strings.Join(parts, "\x00")
+			}
+			if !seen[key] {
+				seen[key] = true
+				unique = append(unique, row)
+			}
+		}
+		return unique
+	}, nil
+}
+
+// flattenStep replaces nested objects in the columns ("*" for all) with a
+// column per value, named with the path joined by "_"
+func flattenStep(columns []string) (transformStep, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("columns are required (\"*\" for all)")
+	}
+	all := slices.Contains(columns, "*")
+	return func(rows []map[string]interface{}) []map[string]interface{} {
+		for _, row := range rows {
+			for k, v := range row {
+				nested, ok := v.(map[string]interface{})
+				if !ok || !(all || slices.Contains(columns, k)) {
+					continue
+				}
+				delete(row, k)
+				flattenInto(row, k, nested)
+			}
+		}
+		return rows
+	}, nil
+}
+
+// flattenInto adds the values of a nested object to row
+func flattenInto(row map[string]interface{}, prefix string, nested map[string]interface{}) {
+	for k, v := range nested {
+		name := prefix + "_" + k
+		if m, ok := v.(map[string]interface{}); ok {
+			flattenInto(row, name, m)
+		} else {
+			row[name] = v
+		}
+	}
+}
+
+// parseDateStep parses columns as times with their layout: a Go layout, "unix",
+// "unix_ms", or "" to try common formats. Values that don't parse become null.
+func parseDateStep(layouts map[string]string) transformStep {
+	return func(rows []map[string]interface{}) []map[string]interface{} {
+		for _, row := range rows {
+			for column, layout := range layouts {
+				if v, ok := row[column]; ok && v != nil {
+					row[column] = parseDateValue(v, layout)
+				}
+			}
+		}
+		return rows
+	}
+}
+
+// parseDateValue parses a value as a time, or returns nil
+func parseDateValue(v interface{}, layout string) interface{} {
+	if t, ok := v.(time.Time); ok {
+		return t
+	}
+	switch layout {
+	case "unix", "unix_ms":
+		n, ok := toNumber(v)
+		if !ok {
+			return nil
+		}
+		if layout == "unix_ms" {
+			return time.UnixMilli(int64(n)).UTC()
+		}
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	case "":
+		text := strings.TrimSpace(exprText(v))
+		for _, l := range transformDateLayouts {
+			if t, err := time.Parse(l, text); err == nil {
+				return t
+			}
+		}
+		return nil
+	default:
+		t, err := time.Parse(layout, strings.TrimSpace(exprText(v)))
+		if err != nil {
+			return nil
+		}
+		return t
+	}
+}
+
+// castStep converts columns to string, int, float or bool. Values that can't
+// be converted become null.
+func castStep(types map[string]string) (transformStep, error) {
+	for column, typ := range types {
+		if !slices.Contains(transformCastTypes, typ) {
+			return nil, fmt.Errorf("unknown type %q for %s (expected %s)", typ, column, strings.Join(transformCastTypes, ", "))
+		}
+	}
+	return func(rows []map[string]interface{}) []map[string]interface{} {
+		for _, row := range rows {
+			for column, typ := range types {
+				if v, ok := row[column]; ok && v != nil {
+					row[column] = castValue(v, typ)
+				}
+			}
+		}
+		return rows
+	}, nil
+}
+
+// castValue converts a non-nil value to a type, or returns nil
+func castValue(v interface{}, typ string) interface{} {
+	switch typ {
+	case "string":
+		return exprText(v)
+	case "bool":
+		if b, ok := v.(bool); ok {
+			return b
+		}
+		if n, ok := toNumber(v); ok {
+			return n != 0
+		}
+		switch strings.ToLower(strings.TrimSpace(exprText(v))) {
+		case "true", "yes", "y", "on":
+			return true
+		case "false", "no", "n", "off", "":
+			return false
+		}
+		return nil
+	}
+
+	n, ok := toNumber(v)
+	if !ok {
+		// Allow thousands separators and surrounding spaces (e.g., " 1,200 ")
+		text := strings.ReplaceAll(strings.TrimSpace(exprText(v)), ",", "")
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil
+		}
+		n = f
+	}
+	if typ == "int" {
+		return int(n)
+	}
+	return n
+}
+
+// TransformSource applies a transform and a schema to the rows of a source
+type TransformSource struct {
+	inner     Source
+	transform *Transform
+	schema    *Schema
+}
+
+// WithTransform wraps src with the schema and transform of cfg. The schema
+// coerces the rows as the source stores them; the transform then shapes them.
+// Returns src unchanged if cfg has neither. Writable and streaming sources keep
+// those interfaces.
+func WithTransform(src Source, cfg config.SourceConfig) (Source, error) {
+	transform, err := NewTransform(src.Name(), cfg.Transform)
+	if err != nil {
+		return src, err
+	}
+	schema, err := NewSchema(src.Name(), cfg.Schema)
+	if err != nil || (transform == nil && schema == nil) {
+		return src, err
+	}
+	ts := &TransformSource{inner: src, transform: transform, schema: schema}
+	ws, writable := src.(WritableSource)
+	ss, streaming := src.(StreamingSource)
+	switch {
+	case writable && streaming:
+		// e.g. GraphQL sources, which may both mutate and subscribe
+		return &TransformWritableStreamingSource{
+			TransformWritableSource: &TransformWritableSource{TransformSource: ts, writable: ws},
+			streaming:               &TransformStreamingSource{TransformSource: ts, streaming: ss},
+		}, nil
+	case writable:
+		return &TransformWritableSource{TransformSource: ts, writable: ws}, nil
+	case streaming:
+		return &TransformStreamingSource{TransformSource: ts, streaming: ss}, nil
+	}
+	return ts, nil
+}
+
+// Name returns the source name
+func (s *TransformSource) Name() string {
+	return s.inner.Name()
+}
+
+// Fetch fetches the rows of the source, coerces and transforms them. Stale
+// rows are transformed too and returned with their error (see StaleError).
+func (s *TransformSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	rows, err := s.inner.Fetch(ctx)
+	if err != nil && !IsStale(err) {
+		return nil, err
+	}
+	return s.transform.Apply(s.schema.Coerce(rows)), err
+}
+
+// FetchConditional revalidates through the underlying source if it supports
+// conditional requests, so caching keeps its validators and Cache-Control TTL.
+// Only full responses are coerced and transformed; on 304 the cache reuses
+// rows it stored already transformed. Other sources are fetched like Fetch.
+func (s *TransformSource) FetchConditional(ctx context.Context, etag, lastModified string) ([]map[string]interface{}, HTTPCacheInfo, bool, error) {
+	conditional, ok := s.inner.(ConditionalSource)
+	if !ok {
+		rows, err := s.Fetch(ctx)
+		return rows, HTTPCacheInfo{}, false, err
+	}
+	rows, info, notModified, err := conditional.FetchConditional(ctx, etag, lastModified)
+	if err != nil || notModified {
+		return nil, info, notModified, err
+	}
+	return s.transform.Apply(s.schema.Coerce(rows)), info, false, nil
+}
+
+// FetchWithArgs runs the underlying exec source with args and coerces and
+// transforms its rows like Fetch
+func (s *TransformSource) FetchWithArgs(ctx context.Context, args map[string]string) ([]map[string]interface{}, error) {
+	execSrc, ok := s.inner.(*ExecSource)
+	if !ok {
+		return nil, fmt.Errorf("source %q does not take arguments", s.inner.Name())
+	}
+	rows, err := execSrc.FetchWithArgs(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.transform.Apply(s.schema.Coerce(rows)), nil
+}
+
+// Close closes the underlying source
+func (s *TransformSource) Close() error {
+	return s.inner.Close()
+}
+
+// GetInner returns the underlying source
+func (s *TransformSource) GetInner() Source {
+	return s.inner
+}
+
+// TransformWritableSource is a TransformSource over a WritableSource. Writes
+// go to the underlying source unchanged.
+type TransformWritableSource struct {
+	*TransformSource
+	writable WritableSource
+}
+
+// WriteItem performs a write on the underlying source
+func (s *TransformWritableSource) WriteItem(ctx context.Context, action string, data map[string]interface{}) error {
+	return s.writable.WriteItem(ctx, action, data)
+}
+
+// IsReadonly returns whether the underlying source is read-only
+func (s *TransformWritableSource) IsReadonly() bool {
+	return s.writable.IsReadonly()
+}
+
+// TransformStreamingSource is a TransformSource over a StreamingSource. The
+// rows of each event are coerced and transformed like fetched rows.
+type TransformStreamingSource struct {
+	*TransformSource
+	streaming StreamingSource
+}
+
+// Subscribe starts the stream of the underlying source
+func (s *TransformStreamingSource) Subscribe(ctx context.Context) <-chan Event {
+	events := s.streaming.Subscribe(ctx)
+	if events == nil {
+		return nil
+	}
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		for ev := range events {
+			if ev.Err == nil {
+				ev.Rows = s.transform.Apply(s.schema.Coerce(ev.Rows))
+			}
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// TransformWritableStreamingSource is a TransformWritableSource over a source
+// that also streams. Stream events are coerced and transformed like
+// TransformStreamingSource's.
+type TransformWritableStreamingSource struct {
+	*TransformWritableSource
+	streaming *TransformStreamingSource
+}
+
+// Subscribe starts the stream of the underlying source
+func (s *TransformWritableStreamingSource) Subscribe(ctx context.Context) <-chan Event {
+	return s.streaming.Subscribe(ctx)
+}
@@ -0,0 +1,182 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+func TestTransform(t *testing.T) {
+	rows := func() []map[string]interface{} {
+		return []map[string]interface{}{
+			{"id": "3", "first_name": "Ada", "price": "1,200", "status": "open", "due": "2026-03-01", "address": map[string]interface{}{"city": "Oslo", "geo": map[string]interface{}{"lat": 59.9}}},
+			{"id": "10", "first_name": "Grace", "price": "9.5", "status": "closed", "due": "03/02/2026"},
+			{"id": "9", "first_name": "Alan", "price": "n/a", "status": "open", "due": "soon"},
+			{"id": "9", "first_name": "Alan", "price": "n/a", "status": "open", "due": "soon"},
+		}
+	}
+	limit := 2
+	tests := []struct {
+		name  string
+		steps []config.TransformStep
+		want  []map[string]interface{}
+	}{
+		{
+			name:  "filter and select",
+			steps: []config.TransformStep{{Filter: []string{"status == open"}}, {Select: []string{"id", "address.city"}}},
+			want:  []map[string]interface{}{{"id": "3", "address.city": "Oslo"}, {"id": "9"}, {"id": "9"}},
+		},
+		{
+			name:  "rename swaps",
+			steps: []config.TransformStep{{Rename: map[string]string{"first_name": "id", "id": "first_name"}}, {Select: []string{"id"}}, {Limit: &limit}},
+			want:  []map[string]interface{}{{"id": "Ada"}, {"id": "Grace"}},
+		},
+		{
+			name:  "sort numeric text descending",
+			steps: []config.TransformStep{{Sort: []string{"-id", "first_name"}}, {Dedupe: []string{"id"}}, {Select: []string{"id"}}},
+			want:  []map[string]interface{}{{"id": "10"}, {"id": "9"}, {"id": "3"}},
+		},
+		{
+			name:  "dedupe whole rows",
+			steps: []config.TransformStep{{Dedupe: []string{"*"}}, {Sort: []string{"first_name desc"}}, {Select: []string{"first_name"}}},
+			want:  []map[string]interface{}{{"first_name": "Grace"}, {"first_name": "Alan"}, {"first_name": "Ada"}},
+		},
+		{
+			name:  "cast",
+			steps: []config.TransformStep{{Cast: map[string]string{"id": "int", "price": "float", "status": "bool"}}, {Select: []string{"id", "price"}}},
+			want:  []map[string]interface{}{{"id": 3, "price": 1200.0}, {"id": 10, "price": 9.5}, {"id": 9, "price": nil}, {"id": 9, "price": nil}},
+		},
+		{
+			name:  "flatten",
+			steps: []config.TransformStep{{Flatten: []string{"*"}}, {Select: []string{"address_city", "address_geo_lat"}}, {Limit: &limit}},
+			want:  []map[string]interface{}{{"address_city": "Oslo", "address_geo_lat": 59.9}, {}},
+		},
+		{
+			name:  "parse dates",
+			steps: []config.TransformStep{{ParseDate: map[string]string{"due": ""}}, {Select: []string{"due"}}, {Limit: &limit}},
+			want: []map[string]interface{}{
+				{"due": time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+				{"due": time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transform, err := NewTransform("test", tt.steps)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			input := rows()
+			got := transform.Apply(input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(input, rows()) {
+				t.Error("transform modified its input")
+			}
+		})
+	}
+}
+
+func TestTransform_Errors(t *testing.T) {
+	negative := -1
+	for _, step := range []config.TransformStep{
+		{},
+		{Select: []string{"id"}, Limit: &negative},
+		{Limit: &negative},
+		{Filter: []string{"status"}},
+		{Sort: []string{"id sideways"}},
+		{Dedupe: []string{}},
+		{Cast: map[string]string{"id": "decimal"}},
+	} {
+		if _, err := NewTransform("test", []config.TransformStep{step}); err == nil {
+			t.Errorf("expected error for %+v", step)
+		}
+	}
+
+	if transform, err := NewTransform("test", nil); transform != nil || err != nil {
+		t.Errorf("expected no transform, got %v, %v", transform, err)
+	}
+}
+
+func TestParseDateValue(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		layout string
+		want   interface{}
+	}{
+		{"2026-03-01T09:30:00Z", "", time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)},
+		{"01.03.2026", "02.01.2006", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{1772323200, "unix", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"1772323200500", "unix_ms", time.Date(2026, 3, 1, 0, 0, 0, 500e6, time.UTC)},
+		{"yesterday", "", nil},
+		{"abc", "unix", nil},
+	}
+	for _, tt := range tests {
+		if got := parseDateValue(tt.value, tt.layout); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseDateValue(%v, %q) = %v, want %v", tt.value, tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestWithTransform(t *testing.T) {
+	inner := &mockWritableSource{mockSource: mockSource{name: "items", data: []map[string]interface{}{{"id": 1, "secret": "x"}}}}
+	src, err := WithTransform(inner, config.SourceConfig{Transform: []config.TransformStep{{Select: []string{"id"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := src.Fetch(context.Background())
+	if err != nil || !reflect.DeepEqual(rows, []map[string]interface{}{{"id": 1}}) {
+		t.Errorf("unexpected rows: %v, %v", rows, err)
+	}
+
+	// Writable sources stay writable
+	ws, ok := src.(WritableSource)
+	if !ok {
+		t.Fatal("expected a writable source")
+	}
+	if err := ws.WriteItem(context.Background(), "add", map[string]interface{}{"id": 2}); err != nil || inner.WriteCount() != 1 {
+		t.Errorf("write not passed through: %v", err)
+	}
+
+	// Without a transform, the source is returned as is
+	if src, _ := WithTransform(inner, config.SourceConfig{}); src != Source(inner) {
+		t.Error("expected the source itself")
+	}
+}
+
+func TestWithTransformStreaming(t *testing.T) {
+	upstream := &fakeStream{subscribed: make(chan context.Context, 1)}
+	src, err := WithTransform(upstream, config.SourceConfig{Transform: []config.TransformStep{{Select: []string{"id"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, ok := src.(StreamingSource)
+	if !ok {
+		t.Fatal("expected a streaming source")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := ss.Subscribe(ctx)
+	<-upstream.subscribed
+
+	// The rows of each event are transformed; errors pass through
+	upstream.events <- Event{Rows: []map[string]interface{}{{"id": 1, "secret": "x"}}}
+	if ev := <-events; !reflect.DeepEqual(ev.Rows, []map[string]interface{}{{"id": 1}}) {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+	upstream.events <- Event{Err: errors.New("disconnected")}
+	if ev := <-events; ev.Err == nil {
+		t.Errorf("expected the error, got %+v", ev)
+	}
+
+	close(upstream.events)
+	if _, ok := <-events; ok {
+		t.Error("expected the stream to end")
+	}
+}
@@ -0,0 +1,118 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+)
+
+// WebSocketSource streams messages from a WebSocket URL. Each message becomes rows
+// (see messageRows).
+type WebSocketSource struct {
+	name        string
+	url         string
+	headers     map[string]string
+	send        string // Message sent after connecting; empty for none
+	client      *http.Client
+	timeout     time.Duration
+	retryConfig RetryConfig
+}
+
+// NewWebSocketSource creates a new WebSocket source
+func NewWebSocketSource(name string, cfg config.SourceConfig, siteDir string) (*WebSocketSource, error) {
+	if cfg.From == "" {
+		return nil, &ValidationError{Source: name, Field: "from", Reason: "from is required"}
+	}
+	stream, err := streamSettings(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string)
+	for key, value := range cfg.Headers {
+		headers[key] = os.ExpandEnv(value)
+	}
+
+	timeout := cfg.GetTimeout()
+	client, err := newHTTPClient(name, cfg.Auth, siteDir, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebSocketSource{
+		name:    name,
+		url:     os.ExpandEnv(cfg.From),
+		headers: headers,
+		send:    os.ExpandEnv(stream.Send),
+		client:  client,
+		timeout: timeout,
+		retryConfig: RetryConfig{
+			MaxRetries: cfg.GetRetryMaxRetries(),
+			BaseDelay:  cfg.GetRetryBaseDelay(),
+			MaxDelay:   cfg.GetRetryMaxDelay(),
+			Multiplier: 2.0,
+		},
+	}, nil
+}
+
+// Name returns the source identifier
+func (s *WebSocketSource) Name() string {
+	return s.name
+}
+
+// Fetch returns no rows: the rows of a WebSocket source arrive through Subscribe
+func (s *WebSocketSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{}, nil
+}
+
+// Subscribe connects to the WebSocket, reconnecting with backoff until ctx is done
+// or the server closes the connection normally
+func (s *WebSocketSource) Subscribe(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go runStream(ctx, s.name, s.retryConfig, events, s.connect)
+	return events
+}
+
+// Close is a no-op for WebSocket sources
+func (s *WebSocketSource) Close() error {
+	return nil
+}
+
+// connect runs one WebSocket connection
+func (s *WebSocketSource) connect(ctx context.Context, emit func(Event)) (connected, done bool, err error) {
+	header, err := streamHeader(s.url, s.headers, s.client)
+	if err != nil {
+		return false, false, err
+	}
+
+	conn, _, err := websocketDialer(s.client, s.timeout).DialContext(ctx, s.url, header)
+	if err != nil {
+		return false, false, NewSourceError(s.name, "connect", err)
+	}
+	defer conn.Close()
+	defer closeOnDone(ctx, conn)()
+
+	if s.send != "" {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(s.send)); err != nil {
+			return false, false, err
+		}
+	}
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) && closeErr.Code == websocket.CloseNormalClosure {
+				return true, true, nil
+			}
+			return true, false, err
+		}
+		emit(Event{Rows: messageRows(message)})
+	}
+}
@@ -0,0 +1,366 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+	"github.com/xuri/excelize/v2"
+)
+
+// headerScanRows is how many leading rows are scanned for a header row
+const headerScanRows = 10
+
+// XLSXFileSource reads rows from a sheet of an Excel workbook.
+//
+// Options:
+//   - sheet: sheet name (default: the first sheet)
+//   - header_row: 1-based row holding the column names (default: detected)
+//   - header: "false" if the sheet has no header row (columns are col1, col2, ...)
+//
+// Cells keep their types: numbers are float64, booleans are bool, and cells
+// with a date or time number format are time.Time.
+type XLSXFileSource struct {
+	name      string
+	filePath  string
+	siteDir   string
+	sheet     string
+	headerRow int // 1-based; 0 to detect
+	hasHeader bool
+}
+
+// NewXLSXFileSource creates a new XLSX file source
+func NewXLSXFileSource(name string, cfg config.SourceConfig, siteDir string) (*XLSXFileSource, error) {
+	if cfg.File == "" {
+		return nil, fmt.Errorf("xlsx source %q: file is required", name)
+	}
+
+	s := &XLSXFileSource{
+		name:      name,
+		filePath:  cfg.File,
+		siteDir:   siteDir,
+		sheet:     cfg.Options["sheet"],
+		hasHeader: cfg.Options["header"] != "false",
+	}
+	if v := cfg.Options["header_row"]; v != "" {
+		row, err := strconv.Atoi(v)
+		if err != nil || row < 1 {
+			return nil, &ValidationError{Source: name, Field: "options.header_row", Reason: fmt.Sprintf("must be a positive row number, got %q", v)}
+		}
+		s.headerRow = row
+	}
+	return s, nil
+}
+
+// Name returns the source identifier
+func (s *XLSXFileSource) Name() string {
+	return s.name
+}
+
+// Fetch reads the sheet and converts its rows
+func (s *XLSXFileSource) Fetch(ctx context.Context) ([]map[string]interface{}, error) {
+	path := s.resolvePath(s.filePath)
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx source %q: failed to open file: %w", s.name, err)
+	}
+	defer f.Close()
+
+	sheet, err := s.sheetName(f)
+	if err != nil {
+		return nil, err
+	}
+
+	cells, err := s.readCells(f, sheet)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx source %q: failed to read sheet %q: %w", s.name, sheet, err)
+	}
+
+	headers, start := s.headers(cells)
+	results := make([]map[string]interface{}, 0, len(cells))
+	for _, row := range cells[start:] {
+		if isEmptyRow(row) {
+			continue
+		}
+		rowMap := make(map[string]interface{}, len(headers))
+		for i, header := range headers {
+			var value interface{}
+			if i < len(row) {
+				value = row[i]
+			}
+			rowMap[header] = value
+		}
+		// Cells beyond the header get generated names
+		for i := len(headers); i < len(row); i++ {
+			if row[i] != nil {
+				rowMap[fmt.Sprintf("col%d", i+1)] = row[i]
+			}
+		}
+		results = append(results, rowMap)
+	}
+	return results, nil
+}
+
+// sheetName returns the configured sheet, or the first sheet
+func (s *XLSXFileSource) sheetName(f *excelize.File) (string, error) {
+	sheets := f.GetSheetList()
+	if s.sheet == "" {
+		if len(sheets) == 0 {
+			return "", fmt.Errorf("xlsx source %q: workbook has no sheets", s.name)
+		}
+		return sheets[0], nil
+	}
+	for _, sheet := range sheets {
+		if strings.EqualFold(sheet, s.sheet) {
+			return sheet, nil
+		}
+	}
+	return "", &ValidationError{
+		Source: s.name,
+		Field:  "options.sheet",
+		Reason: fmt.Sprintf("sheet %q not found (available: %s)", s.sheet, strings.Join(sheets, ", ")),
+	}
+}
+
+// readCells reads the sheet's cells as typed values (nil for empty cells)
+func (s *XLSXFileSource) readCells(f *excelize.File, sheet string) ([][]interface{}, error) {
+	rows, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, err
+	}
+
+	props, err := f.GetWorkbookProps()
+	if err != nil {
+		return nil, err
+	}
+	date1904 := props.Date1904 != nil && *props.Date1904
+
+	dateStyles := make(map[int]bool)
+	cells := make([][]interface{}, len(rows))
+	for r, row := range rows {
+		cells[r] = make([]interface{}, len(row))
+		for c, raw := range row {
+			if raw == "" {
+				continue
+			}
+			ref, err := excelize.CoordinatesToCellName(c+1, r+1)
+			if err != nil {
+				return nil, err
+			}
+			cells[r][c], err = cellValue(f, sheet, ref, raw, date1904, dateStyles)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return cells, nil
+}
+
+// cellValue converts a raw cell value using the cell's type and number format.
+// dateStyles caches whether a style ID has a date format.
+func cellValue(f *excelize.File, sheet, ref, raw string, date1904 bool, dateStyles map[int]bool) (interface{}, error) {
+	cellType, err := f.GetCellType(sheet, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cellType {
+	case excelize.CellTypeBool:
+		return raw == "1" || strings.EqualFold(raw, "true"), nil
+	case excelize.CellTypeDate:
+		// ISO 8601 date cells (t="d")
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, raw); err == nil {
+				return t, nil
+			}
+		}
+		return raw, nil
+	case excelize.CellTypeUnset, excelize.CellTypeNumber:
+		// Numbers, including the cached results of numeric formulas
+	default:
+		// Strings, string formula results and errors (e.g., #DIV/0!)
+		return raw, nil
+	}
+
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return raw, nil
+	}
+
+	styleID, err := f.GetCellStyle(sheet, ref)
+	if err != nil {
+		return nil, err
+	}
+	isDate, ok := dateStyles[styleID]
+	if !ok {
+		if style, err := f.GetStyle(styleID); err == nil {
+			custom := ""
+			if style.CustomNumFmt != nil {
+				custom = *style.CustomNumFmt
+			}
+			isDate = isDateNumFmt(style.NumFmt, custom)
+		}
+		dateStyles[styleID] = isDate
+	}
+	if isDate {
+		if t, err := excelize.ExcelDateToTime(number, date1904); err == nil {
+			return t, nil
+		}
+	}
+	return number, nil
+}
+
+// isDateNumFmt reports whether a number format displays a date or time: one of
+// the built-in date formats, or a custom format with date/time tokens outside
+// quoted text, escapes, colors and conditions.
+func isDateNumFmt(id int, custom string) bool {
+	if custom == "" {
+		return (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58)
+	}
+
+	for i := 0; i < len(custom); i++ {
+		switch ch := custom[i]; {
+		case ch == '"':
+			// Quoted text
+			if end := strings.IndexByte(custom[i+1:], '"'); end >= 0 {
+				i += end + 1
+			} else {
+				return false
+			}
+		case ch == '[':
+			// Elapsed time ([h]:mm) is a time; colors ([Red]) and conditions ([>100]) are skipped
+			end := strings.IndexByte(custom[i:], ']')
+			if end < 0 {
+				return false
+			}
+			if token := strings.ToLower(custom[i+1 : i+end]); token != "" && strings.Trim(token, "hms") == "" {
+				return true
+			}
+			i += end
+		case ch == '\\' || ch == '_' || ch == '*':
+			i++ // Skip the escaped, padded or repeated character
+		case ch == ';':
+			// Only the positive section decides
+			return false
+		case strings.IndexByte("dDmMyYhHsS", ch) >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+// headers returns the column names and the index of the first data row
+func (s *XLSXFileSource) headers(cells [][]interface{}) ([]string, int) {
+	width := 0
+	for _, row := range cells {
+		width = max(width, len(row))
+	}
+
+	headerIndex := -1
+	switch {
+	case !s.hasHeader:
+	case s.headerRow > len(cells):
+		// The header is below the data: no rows
+		return nil, len(cells)
+	case s.headerRow > 0:
+		headerIndex = s.headerRow - 1
+	default:
+		headerIndex = detectHeaderRow(cells)
+	}
+
+	if headerIndex < 0 {
+		headers := make([]string, width)
+		for i := range headers {
+			headers[i] = fmt.Sprintf("col%d", i+1)
+		}
+		return headers, 0
+	}
+
+	row := cells[headerIndex]
+	headers := make([]string, len(row))
+	seen := make(map[string]int)
+	for i, cell := range row {
+		name := strings.TrimSpace(formatHeaderCell(cell))
+		if name == "" {
+			name = fmt.Sprintf("col%d", i+1)
+		}
+		// Repeated names get a numeric suffix: amount, amount_2, ...
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+		headers[i] = name
+	}
+	return headers, headerIndex + 1
+}
+
+// detectHeaderRow finds the header among the leading rows: the first row with
+// the most filled cells, skipping titles and blank rows above the table. It
+// returns -1 if that row holds anything but text, as data rows do.
+func detectHeaderRow(cells [][]interface{}) int {
+	best, bestCount := -1, 0
+	for i := 0; i < len(cells) && i < headerScanRows; i++ {
+		count := 0
+		for _, cell := range cells[i] {
+			if cell != nil {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = i, count
+		}
+	}
+	if best < 0 {
+		return -1
+	}
+	for _, cell := range cells[best] {
+		if _, ok := cell.(string); cell != nil && !ok {
+			return -1
+		}
+	}
+	return best
+}
+
+// formatHeaderCell returns a header cell as text
+func formatHeaderCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format("2006-01-02")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// isEmptyRow reports whether a row has no values
+func isEmptyRow(row []interface{}) bool {
+	for _, cell := range row {
+		if cell != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Close is a no-op for file sources
+func (s *XLSXFileSource) Close() error {
+	return nil
+}
+
+// resolvePath makes a path absolute relative to siteDir
+func (s *XLSXFileSource) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.siteDir, path)
+}
@@ -0,0 +1,119 @@
+package source
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/livetemplate/tinkerdown/internal/config"
+	"github.com/xuri/excelize/v2"
+)
+
+// writeWorkbook creates a workbook with a titled expenses sheet and a second sheet
+func writeWorkbook(t *testing.T, path string) {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+
+	f.SetSheetName("Sheet1", "Expenses")
+	f.SetCellValue("Expenses", "A1", "Q3 expenses")
+	f.SetSheetRow("Expenses", "A3", &[]interface{}{"Date", "Item", "Amount", "Paid", "Code"})
+	f.SetSheetRow("Expenses", "A4", &[]interface{}{time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), "Laptop", 1299.5, true, "007"})
+	f.SetSheetRow("Expenses", "A5", &[]interface{}{time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC), "Desk", 300, false, "012"})
+	f.SetCellFormula("Expenses", "C6", "SUM(C4:C5)")
+	f.SetCellValue("Expenses", "C6", 1599.5)
+	f.SetCellValue("Expenses", "B6", "Total")
+
+	custom := "dd/mm/yyyy"
+	style, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &custom})
+	f.SetCellStyle("Expenses", "A4", "A5", style)
+
+	f.NewSheet("Rates")
+	f.SetSheetRow("Rates", "A1", &[]interface{}{"EUR", 1.1})
+	f.SetSheetRow("Rates", "A2", &[]interface{}{"GBP", 1.3})
+
+	if err := f.SaveAs(path); err != nil {
+		t.Fatalf("failed to save workbook: %v", err)
+	}
+}
+
+func TestXLSXFileSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeWorkbook(t, filepath.Join(tmpDir, "finance.xlsx"))
+	ctx := context.Background()
+
+	// The header row below the title is detected, and cells keep their types
+	src, err := NewXLSXFileSource("expenses", config.SourceConfig{File: "finance.xlsx"}, tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	rows, err := src.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3: %v", len(rows), rows)
+	}
+	first := rows[0]
+	if date, ok := first["Date"].(time.Time); !ok || !date.Equal(time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Date = %#v, want 2026-07-01", first["Date"])
+	}
+	if first["Item"] != "Laptop" || first["Amount"] != 1299.5 || first["Paid"] != true || first["Code"] != "007" {
+		t.Errorf("unexpected row: %#v", first)
+	}
+	if total := rows[2]; total["Amount"] != 1599.5 || total["Date"] != nil {
+		t.Errorf("unexpected total row: %#v", total)
+	}
+
+	// Sheet selection without a header row
+	src, _ = NewXLSXFileSource("rates", config.SourceConfig{File: "finance.xlsx", Options: map[string]string{"sheet": "rates"}}, tmpDir)
+	rows, err = src.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["col1"] != "EUR" || rows[0]["col2"] != 1.1 {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	// An explicit header row
+	src, _ = NewXLSXFileSource("rates", config.SourceConfig{File: "finance.xlsx", Options: map[string]string{"sheet": "Rates", "header_row": "1"}}, tmpDir)
+	rows, _ = src.Fetch(ctx)
+	if len(rows) != 1 || rows[0]["EUR"] != "GBP" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	src, _ = NewXLSXFileSource("missing", config.SourceConfig{File: "finance.xlsx", Options: map[string]string{"sheet": "Budget"}}, tmpDir)
+	if _, err := src.Fetch(ctx); err == nil {
+		t.Error("expected error for unknown sheet")
+	}
+	if _, err := NewXLSXFileSource("bad", config.SourceConfig{File: "finance.xlsx", Options: map[string]string{"header_row": "0"}}, tmpDir); err == nil {
+		t.Error("expected error for invalid header_row")
+	}
+}
+
+func TestIsDateNumFmt(t *testing.T) {
+	tests := []struct {
+		id     int
+		custom string
+		want   bool
+	}{
+		{0, "", false},
+		{2, "", false},
+		{14, "", true},
+		{22, "", true},
+		{164, "yyyy-mm-dd", true},
+		{164, "[$-409]mmm d, yyyy", true},
+		{164, "[h]:mm:ss", true},
+		{164, "#,##0.00", false},
+		{164, "[Red]#,##0", false},
+		{164, `0.0 "days"`, false},
+		{164, `#,##0\d`, false},
+		{164, "0.00E+00", false},
+	}
+	for _, tt := range tests {
+		if got := isDateNumFmt(tt.id, tt.custom); got != tt.want {
+			t.Errorf("isDateNumFmt(%d, %q) = %v, want %v", tt.id, tt.custom, got, tt.want)
+		}
+	}
+}
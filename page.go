@@ -14,19 +14,23 @@ var (
 	tableRegex          = regexp.MustCompile(`(?s)<table([^>]*lvt-source="[^"]+[^>]*)>(.*?)</table>`)
 	ulListRegex         = regexp.MustCompile(`(?s)<ul([^>]*lvt-source="[^"]+[^>]*)>(.*?)</ul>`)
 	olListRegex         = regexp.MustCompile(`(?s)<ol([^>]*lvt-source="[^"]+[^>]*)>(.*?)</ol>`)
+	formRegex           = regexp.MustCompile(`(?s)<form([^>]*lvt-source="[^"]+[^>]*)>(.*?)</form>`)
 	lvtSourceRegex      = regexp.MustCompile(`\s*lvt-source="[^"]*"`)
 	lvtColumnsRegex     = regexp.MustCompile(`\s*lvt-columns="[^"]*"`)
 	lvtActionsRegex     = regexp.MustCompile(`\s*lvt-actions="[^"]*"`)
 	lvtEmptyRegex       = regexp.MustCompile(`\s*lvt-empty="[^"]*"`)
 	lvtFieldRegex       = regexp.MustCompile(`\s*lvt-field="[^"]*"`)
 	lvtDatatableRegex   = regexp.MustCompile(`\s*lvt-datatable`)
+	lvtFieldsRegex      = regexp.MustCompile(`\s*lvt-fields="[^"]*"`)
 	columnsAttrRegex    = regexp.MustCompile(`lvt-columns="([^"]+)"`)
 	actionsAttrRegex    = regexp.MustCompile(`lvt-actions="([^"]+)"`)
 	emptyAttrRegex      = regexp.MustCompile(`lvt-empty="([^"]+)"`)
 	fieldAttrRegex      = regexp.MustCompile(`lvt-field="([^"]+)"`)
+	fieldsAttrRegex     = regexp.MustCompile(`lvt-fields="([^"]+)"`)
 	tableDetectRegex    = regexp.MustCompile(`(?i)<table[^>]*lvt-source=`)
 	selectDetectRegex   = regexp.MustCompile(`(?i)<select[^>]*lvt-source=`)
 	listDetectRegex     = regexp.MustCompile(`(?i)<(ul|ol)[^>]*lvt-source=`)
+	formDetectRegex     = regexp.MustCompile(`(?i)<form[^>]*lvt-source=`)
 )
 
 // ParseFile parses a markdown file and creates a Page.
@@ -200,11 +204,13 @@ func (p *Page) buildBlocks(codeBlocks []*CodeBlock, sourceFile string) error {
 			elementType := getLvtSourceElementType(cb.Content)
 			columns := getTableColumns(cb.Content)
 			actions := getTableActions(cb.Content)
+			fields := getFormFields(cb.Content)
 
-			// Apply smart template generation for tables/selects/lists with lvt-source
+			// Apply smart template generation for tables/selects/lists/forms with lvt-source
 			processedContent := autoGenerateTableTemplate(cb.Content)
 			processedContent = autoGenerateSelectTemplate(processedContent)
 			processedContent = autoGenerateListTemplate(processedContent)
+			processedContent = autoGenerateFormTemplate(processedContent)
 
 			if stateRef == "" && sourceName != "" {
 				// Create auto-generated server block for lvt-source
@@ -225,6 +231,10 @@ func (p *Page) buildBlocks(codeBlocks []*CodeBlock, sourceFile string) error {
 						metadata["lvt-actions"] = actions
 					}
 				}
+				if fields != "" {
+					// Order of the generated form's fields
+					metadata["lvt-fields"] = fields
+				}
 
 				// Create a marker ServerBlock that will be compiled
 				block := &ServerBlock{
@@ -332,7 +342,7 @@ func getLvtSource(content string) string {
 }
 
 // getLvtSourceElementType detects what kind of element has the lvt-source attribute
-// Returns "table", "select", "list", or "div" (default)
+// Returns "table", "select", "list", "form", or "div" (default)
 func getLvtSourceElementType(content string) string {
 	if tableDetectRegex.MatchString(content) {
 		return "table"
@@ -343,6 +353,9 @@ func getLvtSourceElementType(content string) string {
 	if listDetectRegex.MatchString(content) {
 		return "list"
 	}
+	if formDetectRegex.MatchString(content) {
+		return "form"
+	}
 	return "div"
 }
 
@@ -551,8 +564,10 @@ func generateSimpleTable(w *strings.Builder, columns, actions, emptyMessage stri
 	w.WriteString("  <tbody>\n")
 	w.WriteString("    {{range .Data}}\n    <tr>\n")
 	for _, col := range cols {
-		// Use titlecase field name for Go template access
-		w.WriteString(fmt.Sprintf("      <td>{{.%s}}</td>\n", titleCase(col.field)))
+		// Use titlecase field name for Go template access. Ref fields of a source's
+		// schema show the label of the referenced row, which rows hold as <field>_label.
+		field := titleCase(col.field)
+		w.WriteString(fmt.Sprintf("      <td>{{with .%s_label}}{{.}}{{else}}{{.%s}}{{end}}</td>\n", field, field))
 	}
 	if len(acts) > 0 {
 		w.WriteString("      <td>\n")
@@ -783,3 +798,66 @@ func validateAction(name string, action Action) error {
 	}
 	return nil
 }
+
+// getFormFields extracts lvt-fields from a form element
+// Returns a comma-separated list like "title,due,priority"
+func getFormFields(content string) string {
+	match := fieldsAttrRegex.FindStringSubmatch(content)
+	if match != nil && len(match) > 1 {
+		return match[1]
+	}
+	return ""
+}
+
+// autoGenerateFormTemplate transforms an empty <form lvt-source="..."> into a form
+// with an input for each field of the source's schema (see runtime.Field). Invalid
+// fields show the messages of .Errors after a submit.
+//
+// Attributes:
+//   - lvt-source="name" - Required, specifies the data source
+//   - lvt-fields="field,field2" - Fields to show first (optional, the others follow by name)
+//   - lvt-submit="action" - Action of the form (optional, defaults to "add")
+func autoGenerateFormTemplate(content string) string {
+	match := formRegex.FindStringSubmatch(content)
+	if match == nil {
+		return content
+	}
+
+	// Only empty forms are generated
+	if strings.TrimSpace(match[2]) != "" {
+		return content
+	}
+
+	attrs := lvtFieldsRegex.ReplaceAllString(match[1], "")
+	if !strings.Contains(attrs, "lvt-submit=") {
+		attrs += ` lvt-submit="add"`
+	}
+
+	var generated strings.Builder
+	generated.WriteString("<form")
+	generated.WriteString(attrs)
+	generated.WriteString(">\n")
+	generated.WriteString("  {{range .Fields}}{{$name := .Name}}\n")
+	generated.WriteString("  <label>{{.Label}}\n")
+	generated.WriteString("    {{if eq .Input \"select\"}}{{$default := .Default}}\n")
+	generated.WriteString("    <select name=\"{{.Name}}\"{{if .Required}} required{{end}}>\n")
+	generated.WriteString("      {{if not .Required}}<option value=\"\"></option>{{end}}\n")
+	generated.WriteString("      {{range .Options}}<option value=\"{{.Value}}\"{{if eq .Value $default}} selected{{end}}>{{.Label}}</option>{{end}}\n")
+	generated.WriteString("    </select>\n")
+	generated.WriteString("    {{else if eq .Input \"textarea\"}}\n")
+	generated.WriteString("    <textarea name=\"{{.Name}}\"{{if .Required}} required{{end}}>{{.Default}}</textarea>\n")
+	generated.WriteString("    {{else if eq .Input \"checkbox\"}}\n")
+	generated.WriteString("    <input type=\"checkbox\" name=\"{{.Name}}\" value=\"true\"{{if eq .Default \"true\"}} checked{{end}}>\n")
+	generated.WriteString("    {{else}}\n")
+	generated.WriteString("    <input type=\"{{.Input}}\" name=\"{{.Name}}\" value=\"{{.Default}}\"{{if .Required}} required{{end}}")
+	generated.WriteString("{{with .Min}} min=\"{{.}}\"{{end}}{{with .Max}} max=\"{{.}}\"{{end}}{{with .Step}} step=\"{{.}}\"{{end}}")
+	generated.WriteString("{{with .MinLen}} minlength=\"{{.}}\"{{end}}{{with .MaxLen}} maxlength=\"{{.}}\"{{end}}{{with .Pattern}} pattern=\"{{.}}\"{{end}}>\n")
+	generated.WriteString("    {{end}}\n")
+	generated.WriteString("  </label>\n")
+	generated.WriteString("  {{with $.Errors}}{{with index . $name}}<small class=\"field-error\">{{.}}</small>{{end}}{{end}}\n")
+	generated.WriteString("  {{end}}\n")
+	generated.WriteString("  <button type=\"submit\">Save</button>\n")
+	generated.WriteString("</form>")
+
+	return formRegex.ReplaceAllLiteralString(content, generated.String())
+}
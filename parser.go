@@ -19,25 +19,168 @@ import (
 
 // SourceConfig represents a data source configuration for lvt-source blocks.
 type SourceConfig struct {
-	Type        string            `yaml:"type"`                   // exec, pg, rest, csv, json, markdown, sqlite, wasm
-	Cmd         string            `yaml:"cmd,omitempty"`          // For exec type
-	Query       string            `yaml:"query,omitempty"`        // For pg type
-	From        string            `yaml:"from,omitempty"`         // For rest type: API endpoint URL
-	File        string            `yaml:"file,omitempty"`         // For csv/json/markdown types
-	Anchor      string            `yaml:"anchor,omitempty"`       // For markdown: section anchor (e.g., "#todos")
-	DB          string            `yaml:"db,omitempty"`           // For sqlite: database file path
-	Table       string            `yaml:"table,omitempty"`        // For sqlite: table name
-	Path        string            `yaml:"path,omitempty"`         // For wasm: path to .wasm file
-	Headers     map[string]string `yaml:"headers,omitempty"`      // For rest: HTTP headers (env vars expanded)
-	QueryParams map[string]string `yaml:"query_params,omitempty"` // For rest: URL query parameters
-	ResultPath  string            `yaml:"result_path,omitempty"`  // For rest: dot-path to extract array (e.g., "data.items")
-	Readonly    *bool             `yaml:"readonly,omitempty"`     // For markdown/sqlite: read-only mode (default: true)
-	Options     map[string]string `yaml:"options,omitempty"`
-	Manual      bool              `yaml:"manual,omitempty"`      // For exec: require Run button click
-	Format      string            `yaml:"format,omitempty"`      // For exec: output format (json, lines, csv)
-	Delimiter   string            `yaml:"delimiter,omitempty"`   // For exec CSV: field delimiter (default ",")
-	Env         map[string]string `yaml:"env,omitempty"`         // For exec: environment variables (env vars expanded)
-	Timeout     string            `yaml:"timeout,omitempty"`     // For exec/rest: timeout (e.g., "30s", "1m")
+	Type        string                     `yaml:"type"`                   // exec, pg, rest, csv, json, yaml, toml, xlsx, parquet, arrow, git, derived, query, markdown, sqlite, wasm, collection, sse, websocket, tail
+	Cmd         string                     `yaml:"cmd,omitempty"`          // For exec type
+	Query       string                     `yaml:"query,omitempty"`        // For pg type. For query: SQL over the tables
+	From        string                     `yaml:"from,omitempty"`         // For rest/sse/websocket types: endpoint URL. For derived: input source
+	File        string                     `yaml:"file,omitempty"`         // For csv/json/yaml/toml/xlsx/parquet/arrow/markdown/tail/git types
+	Anchor      string                     `yaml:"anchor,omitempty"`       // For markdown: section anchor (e.g., "#todos")
+	Glob        string                     `yaml:"glob,omitempty"`         // For collection: markdown files to include
+	DB          string                     `yaml:"db,omitempty"`           // For sqlite: database file path
+	Table       string                     `yaml:"table,omitempty"`        // For sqlite: table name
+	Path        string                     `yaml:"path,omitempty"`         // For wasm: path to .wasm file. For git: repository directory (default: site directory)
+	Headers     map[string]string          `yaml:"headers,omitempty"`      // For rest: HTTP headers (env vars expanded)
+	QueryParams map[string]string          `yaml:"query_params,omitempty"` // For rest: URL query parameters
+	QueryFile   string                     `yaml:"query_file,omitempty"`   // For graphql: path to .graphql file
+	Variables   map[string]interface{}     `yaml:"variables,omitempty"`    // For graphql: query variables
+	ResultPath  string                     `yaml:"result_path,omitempty"`  // For rest/yaml/toml: dot-path to extract array (e.g., "data.items")
+	Readonly    *bool                      `yaml:"readonly,omitempty"`     // For markdown/sqlite/json/csv: read-only mode (default: true)
+	Options     map[string]string          `yaml:"options,omitempty"`
+	Manual      bool                       `yaml:"manual,omitempty"`          // For exec: require Run button click
+	Format      string                     `yaml:"format,omitempty"`          // For exec: json, lines, csv. For rest: json, ndjson, csv, tsv, xml, yaml. For tail: text, json, logfmt, regex
+	Delimiter   string                     `yaml:"delimiter,omitempty"`       // For exec/rest CSV: field delimiter (default ",")
+	Env         map[string]string          `yaml:"env,omitempty"`             // For exec: environment variables (env vars expanded)
+	Timeout     string                     `yaml:"timeout,omitempty"`         // For exec/rest: timeout (e.g., "30s", "1m")
+	Retry       *RetryConfig               `yaml:"retry,omitempty"`           // Retry configuration
+	Stream      *StreamConfig              `yaml:"stream,omitempty"`          // For sse/websocket/tail: how incoming events update the rows
+	RateLimit   *RateLimitConfig           `yaml:"rate_limit,omitempty"`      // For rest/graphql/exec: limit the request rate
+	Circuit     *CircuitBreakerConfig      `yaml:"circuit_breaker,omitempty"` // For rest/graphql/pg/exec/wasm: when to stop calling a failing source
+	Cache       *CacheConfig               `yaml:"cache,omitempty"`           // Cache TTL and strategy
+	Pagination  *PaginationConfig          `yaml:"pagination,omitempty"`      // For rest/graphql: follow paged responses
+	Auth        *AuthConfig                `yaml:"auth,omitempty"`            // For rest/graphql: authentication and TLS settings
+	Endpoints   map[string]RestEndpoint    `yaml:"endpoints,omitempty"`       // For rest: HTTP request per write action
+	Mutations   map[string]GraphQLMutation `yaml:"mutations,omitempty"`       // For graphql: mutation per write action (add, update, delete, toggle)
+	Filter      []string                   `yaml:"filter,omitempty"`          // For tail/parquet/arrow/git/derived: row predicates that must all match (e.g., "level == error")
+	Columns     []string                   `yaml:"columns,omitempty"`         // For parquet/arrow: columns to read (default: lvt-columns, else all)
+	Union       []string                   `yaml:"union,omitempty"`           // For derived: sources whose rows are appended
+	Join        []JoinConfig               `yaml:"join,omitempty"`            // For derived: sources joined to the rows
+	Fields      []string                   `yaml:"fields,omitempty"`          // For derived: computed columns ("name = expression")
+	GroupBy     []string                   `yaml:"group_by,omitempty"`        // For derived: columns to group by
+	Aggregate   []string                   `yaml:"aggregate,omitempty"`       // For derived: aggregates per group ("open = count")
+	Tables      []string                   `yaml:"tables,omitempty"`          // For query: sources loaded as tables
+	Transform   []TransformStep            `yaml:"transform,omitempty"`       // Steps shaping the fetched rows, in order
+	Schema      map[string]FieldSchema     `yaml:"schema,omitempty"`          // Field types and validation rules
+}
+
+// JoinConfig joins the rows of a derived source with those of another source.
+type JoinConfig struct {
+	Source string `yaml:"source"`           // Source to join
+	On     string `yaml:"on"`               // Key columns: "owner = login", or "id" if named the same
+	Type   string `yaml:"type,omitempty"`   // inner (default) or left
+	Prefix string `yaml:"prefix,omitempty"` // Prefix for the joined columns
+}
+
+// RetryConfig configures retry behavior for a source.
+type RetryConfig struct {
+	MaxRetries int    `yaml:"max_retries,omitempty"` // Maximum retry attempts (default: 3)
+	BaseDelay  string `yaml:"base_delay,omitempty"`  // Initial delay (default: 100ms)
+	MaxDelay   string `yaml:"max_delay,omitempty"`   // Maximum delay (default: 5s)
+}
+
+// StreamConfig configures how a streaming source applies incoming events to its rows.
+type StreamConfig struct {
+	URL     string `yaml:"url,omitempty"`      // For graphql: subscription endpoint
+	Mode    string `yaml:"mode,omitempty"`     // append (default) or replace
+	MaxRows int    `yaml:"max_rows,omitempty"` // Rows kept in append mode (default: 1000)
+	Replay  *int   `yaml:"replay,omitempty"`   // Recent events replayed to a page when it connects (default: 100)
+	Buffer  int    `yaml:"buffer,omitempty"`   // Events queued per page (default: 256)
+	Send    string `yaml:"send,omitempty"`     // For websocket: message sent after connecting
+}
+
+// RateLimitConfig limits the requests of a source.
+type RateLimitConfig struct {
+	Requests float64 `yaml:"requests"`        // Requests allowed per interval
+	Per      string  `yaml:"per,omitempty"`   // Interval (default: 1s)
+	Burst    int     `yaml:"burst,omitempty"` // Requests allowed at once (default: 1)
+}
+
+// CircuitBreakerConfig configures when a source's circuit breaker opens and closes.
+type CircuitBreakerConfig struct {
+	FailureThreshold int    `yaml:"failure_threshold,omitempty"` // Failures that open the circuit (default: 5)
+	SuccessThreshold int    `yaml:"success_threshold,omitempty"` // Successes that close it (default: 2)
+	Timeout          string `yaml:"timeout,omitempty"`           // How long it stays open (default: 30s)
+	FailureWindow    string `yaml:"failure_window,omitempty"`    // Window for counting failures (default: 1m)
+}
+
+// CacheConfig configures caching for a source.
+type CacheConfig struct {
+	TTL                 string `yaml:"ttl,omitempty"`                   // e.g., "5m"; empty disables caching
+	Strategy            string `yaml:"strategy,omitempty"`              // simple (default) or stale-while-revalidate
+	RespectCacheControl bool   `yaml:"respect_cache_control,omitempty"` // For rest/graphql: TTL from Cache-Control
+}
+
+// PaginationConfig configures how a REST or GraphQL source follows paged responses.
+type PaginationConfig struct {
+	Type         string `yaml:"type"`                     // link, cursor or page (rest); relay (graphql)
+	CursorPath   string `yaml:"cursor_path,omitempty"`    // For cursor/relay: dot-path to the next cursor or pageInfo
+	CursorParam  string `yaml:"cursor_param,omitempty"`   // For cursor/relay: parameter carrying the cursor
+	PageParam    string `yaml:"page_param,omitempty"`     // For page: page number parameter
+	PerPageParam string `yaml:"per_page_param,omitempty"` // For page/relay: page size parameter
+	PerPage      int    `yaml:"per_page,omitempty"`       // For page/relay: page size to request
+	StartPage    *int   `yaml:"start_page,omitempty"`     // For page: first page number (default: 1)
+	MaxPages     int    `yaml:"max_pages,omitempty"`      // Maximum pages to fetch (default: 100)
+	MaxRows      int    `yaml:"max_rows,omitempty"`       // Maximum rows to return (default: 10000)
+}
+
+// AuthConfig configures authentication and TLS for HTTP sources (env vars expanded).
+type AuthConfig struct {
+	Type         string            `yaml:"type,omitempty"`          // basic, bearer or oauth2 (empty for TLS only)
+	Username     string            `yaml:"username,omitempty"`      // For basic
+	Password     string            `yaml:"password,omitempty"`      // For basic
+	Token        string            `yaml:"token,omitempty"`         // For bearer
+	TokenFile    string            `yaml:"token_file,omitempty"`    // For bearer: file containing the token
+	TokenURL     string            `yaml:"token_url,omitempty"`     // For oauth2: token endpoint
+	ClientID     string            `yaml:"client_id,omitempty"`     // For oauth2
+	ClientSecret string            `yaml:"client_secret,omitempty"` // For oauth2
+	Scopes       []string          `yaml:"scopes,omitempty"`        // For oauth2
+	Params       map[string]string `yaml:"params,omitempty"`        // For oauth2: extra token request parameters
+	ClientAuth   string            `yaml:"client_auth,omitempty"`   // For oauth2: header (default) or params
+	CACert       string            `yaml:"ca_cert,omitempty"`       // PEM bundle of additional CAs
+	ClientCert   string            `yaml:"client_cert,omitempty"`   // PEM client certificate for mTLS
+	ClientKey    string            `yaml:"client_key,omitempty"`    // PEM client private key for mTLS
+}
+
+// RestEndpoint configures the HTTP request a writable REST source makes for an action.
+type RestEndpoint struct {
+	Method string `yaml:"method,omitempty"` // Default: POST for add, PATCH for update/toggle, DELETE for delete
+	URL    string `yaml:"url,omitempty"`    // {field} is replaced from form data
+	Body   string `yaml:"body,omitempty"`   // Go template for the body (default: form data as JSON)
+}
+
+// GraphQLMutation configures the mutation a writable GraphQL source runs for an action.
+type GraphQLMutation struct {
+	File      string                 `yaml:"file,omitempty"`      // .graphql file with the mutation. Default: query_file
+	Operation string                 `yaml:"operation,omitempty"` // Operation name; required if the file has several mutations
+	Variables map[string]interface{} `yaml:"variables,omitempty"` // "{field}" is replaced from form data. Default: form fields named like the declared variables
+}
+
+// FieldSchema declares the type and validation rules of a source field.
+type FieldSchema struct {
+	Type     string      `yaml:"type,omitempty"`      // string, int, float, bool, date, datetime, enum, json
+	Label    string      `yaml:"label,omitempty"`     // Label for forms and messages
+	Required bool        `yaml:"required,omitempty"`  // Adds need a non-empty value
+	Unique   bool        `yaml:"unique,omitempty"`    // No two rows may have the same value
+	Min      *float64    `yaml:"min,omitempty"`       // Smallest number, or fewest characters
+	Max      *float64    `yaml:"max,omitempty"`       // Largest number, or most characters
+	Pattern  string      `yaml:"pattern,omitempty"`   // Regular expression values must match
+	Values   []string    `yaml:"values,omitempty"`    // For enum: allowed values
+	Default  interface{} `yaml:"default,omitempty"`   // Value for adds without one
+	Ref      string      `yaml:"ref,omitempty"`       // Source whose row ids the field holds
+	Display  string      `yaml:"display,omitempty"`   // For ref: column shown for an id
+	OnDelete string      `yaml:"on_delete,omitempty"` // For ref: restrict, cascade or nullify
+}
+
+// TransformStep is one step of a source's transform pipeline (one field set).
+type TransformStep struct {
+	Filter    []string          `yaml:"filter,omitempty"`     // Rows matching all predicates
+	Select    []string          `yaml:"select,omitempty"`     // Columns to keep
+	Rename    map[string]string `yaml:"rename,omitempty"`     // old: new
+	Sort      []string          `yaml:"sort,omitempty"`       // Columns; "-column" sorts descending
+	Limit     *int              `yaml:"limit,omitempty"`      // First n rows
+	Dedupe    []string          `yaml:"dedupe,omitempty"`     // Columns identifying duplicates ("*" for whole rows)
+	Flatten   []string          `yaml:"flatten,omitempty"`    // Nested objects to flatten ("*" for all)
+	ParseDate map[string]string `yaml:"parse_date,omitempty"` // column: layout
+	Cast      map[string]string `yaml:"cast,omitempty"`       // column: string, int, float or bool
 }
 
 // StylingConfig represents styling/theme configuration.
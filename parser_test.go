@@ -342,6 +342,31 @@ sources:
   api_data:
     type: rest
     from: https://api.example.com/data
+    pagination:
+      type: link
+    auth:
+      type: bearer
+      token: ${API_TOKEN}
+    cache:
+      ttl: 5m
+    rate_limit:
+      requests: 10
+    circuit_breaker:
+      failure_threshold: 3
+    endpoints:
+      add:
+        url: /items
+    timeout: 5s
+    retry:
+      max_retries: 1
+  events:
+    type: sse
+    from: https://api.example.com/events
+    stream:
+      mode: replace
+      max_rows: 50
+      replay: 0
+      send: subscribe
   db_users:
     type: pg
     query: "SELECT * FROM users"
@@ -364,8 +389,8 @@ sources:
 		t.Errorf("Title = %q, want %q", fm.Title, "Source Test")
 	}
 
-	if len(fm.Sources) != 5 {
-		t.Fatalf("got %d sources, want 5", len(fm.Sources))
+	if len(fm.Sources) != 6 {
+		t.Fatalf("got %d sources, want 6", len(fm.Sources))
 	}
 
 	// Check JSON source
@@ -391,6 +416,20 @@ sources:
 	if restSrc.From != "https://api.example.com/data" {
 		t.Errorf("api_data.From = %q, want %q", restSrc.From, "https://api.example.com/data")
 	}
+	if restSrc.Pagination == nil || restSrc.Pagination.Type != "link" || restSrc.Auth == nil || restSrc.Auth.Token != "${API_TOKEN}" ||
+		restSrc.Cache == nil || restSrc.Cache.TTL != "5m" || restSrc.RateLimit == nil || restSrc.RateLimit.Requests != 10 ||
+		restSrc.Circuit == nil || restSrc.Circuit.FailureThreshold != 3 || restSrc.Endpoints["add"].URL != "/items" {
+		t.Errorf("api_data settings not parsed: %+v", restSrc)
+	}
+	if restSrc.Timeout != "5s" || restSrc.Retry == nil || restSrc.Retry.MaxRetries != 1 {
+		t.Errorf("api_data timeout and retry not parsed: %+v", restSrc)
+	}
+
+	// Check streaming source
+	eventsSrc := fm.Sources["events"]
+	if s := eventsSrc.Stream; s == nil || s.Mode != "replace" || s.MaxRows != 50 || s.Replay == nil || *s.Replay != 0 || s.Send != "subscribe" {
+		t.Errorf("events stream settings not parsed: %+v", eventsSrc.Stream)
+	}
 
 	// Check PostgreSQL source
 	pgSrc, ok := fm.Sources["db_users"]